@@ -0,0 +1,254 @@
+// Package client is a Go client for the taskmanager HTTP API (/api/v1), so
+// Go consumers don't have to hand-roll requests:
+//
+//	tasks, err := client.NewTaskClient("https://tasks.example.com")
+//	tasks.APIKey = os.Getenv("TASKMANAGER_API_KEY")
+//	t, err := tasks.Create(ctx, client.CreateTask{Title: "Write the report"})
+//	for t, err := range tasks.All(ctx, client.ListOptions{Assignees: []string{"sam"}}) { ... }
+//
+// Every call takes a context. Idempotent requests (GET, PUT, DELETE) are
+// retried on connection errors, 429 and 502-504 (see RetryPolicy).
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UserAgent is sent with every request.
+const UserAgent = "taskmanager-go-client"
+
+// ErrNotFound is matched (errors.Is) by the *Error of a 404 response.
+var ErrNotFound = errors.New("not found")
+
+// Error is a non-2xx answer of the API, decoded from its
+// application/problem+json body.
+type Error struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	Type       string       `json:"type"`
+	Title      string       `json:"title"`
+	Detail     string       `json:"detail"`
+	Instance   string       `json:"instance"`
+	Errors     []FieldError `json:"errors"`
+	// RequestID is the id the request was logged under; quote it when
+	// reporting a problem.
+	RequestID string `json:"request_id"`
+	// IncidentID is set on 5xx responses, the id the error was reported
+	// under.
+	IncidentID string `json:"incident_id"`
+}
+
+// FieldError is a validation error of one request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	s := fmt.Sprintf("taskmanager: %d %s", e.StatusCode, msg)
+	if e.RequestID != "" {
+		s += " (request " + e.RequestID + ")"
+	}
+	return s
+}
+
+func (e *Error) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// RetryPolicy bounds the retries of idempotent requests. The zero value
+// makes 3 attempts, waiting 200ms and then 400ms; a Retry-After header
+// (seconds or an HTTP date) overrides the wait.
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt; 1 disables retries.
+	MaxAttempts int
+	// Backoff is the wait after the first failure; it doubles per attempt
+	// up to MaxBackoff (5s by default), with up to 20% jitter.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// MaxRetryAfter is the longest Retry-After honoured (1m by default); a
+	// response asking for a longer wait is returned as the error instead.
+	MaxRetryAfter time.Duration
+}
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts > 0 {
+		return p.MaxAttempts
+	}
+	return 3
+}
+
+// wait returns how long to wait before the next attempt, or false when the
+// server asked for a longer wait than MaxRetryAfter.
+func (p RetryPolicy) wait(attempt int, resp *http.Response) (time.Duration, bool) {
+	if d, ok := retryAfter(resp); ok {
+		limit := p.MaxRetryAfter
+		if limit <= 0 {
+			limit = time.Minute
+		}
+		return d, d <= limit
+	}
+	d, limit := p.Backoff, p.MaxBackoff
+	if d <= 0 {
+		d = 200 * time.Millisecond
+	}
+	if limit <= 0 {
+		limit = 5 * time.Second
+	}
+	for range attempt - 1 {
+		d *= 2
+	}
+	d = min(d, limit)
+	return d + rand.N(d/5+1), true
+}
+
+// retryAfter reads the Retry-After header of resp, in seconds or as an
+// HTTP date; a date in the past means no wait.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// TaskClient calls the task endpoints of one taskmanager deployment. Set its
+// fields before the first call; it is safe for concurrent use afterwards.
+type TaskClient struct {
+	// APIKey is sent in the X-API-Key header when set.
+	APIKey string
+	// HTTPClient sends the requests; http.DefaultClient when nil.
+	HTTPClient *http.Client
+	Retry      RetryPolicy
+
+	base *url.URL
+}
+
+// NewTaskClient creates a client for the service at baseURL, including its
+// server.base_path if any, e.g. "https://example.com/taskmanager".
+func NewTaskClient(baseURL string) (*TaskClient, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: expected http(s)://host[/path]", baseURL)
+	}
+	return &TaskClient{base: u}, nil
+}
+
+// do sends method path?query with body (JSON-encoded when not nil) and
+// decodes a 2xx JSON answer into out (when not nil). It returns the final
+// response, its body already consumed.
+func (c *TaskClient) do(ctx context.Context, method, path string, query url.Values, body, out any) (*http.Response, error) {
+	u := *c.base
+	u.Path += "/api/v1" + path
+	u.RawQuery = query.Encode()
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	attempts := 1
+	if method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete {
+		attempts = c.Retry.attempts()
+	}
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", UserAgent)
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.APIKey != "" {
+			req.Header.Set("X-API-Key", c.APIKey)
+		}
+		resp, err := hc.Do(req)
+		wait, ok := c.Retry.wait(attempt, resp)
+		if err == nil && (!retryable(resp.StatusCode) || !ok) || attempt >= attempts || ctx.Err() != nil {
+			if err != nil {
+				return nil, err
+			}
+			return resp, decode(resp, out)
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// decode reads resp into out, or into an *Error for a non-2xx status.
+func decode(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		e := &Error{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(b, e)
+		if e.RequestID == "" {
+			e.RequestID = resp.Header.Get("X-Request-Id")
+		}
+		return e
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("decode %s response: %w", resp.Request.URL.Path, err)
+	}
+	return nil
+}
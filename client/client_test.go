@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/handler"
+	"taskmanager/internal/middleware"
+	"taskmanager/internal/problem"
+	"taskmanager/internal/repositories"
+	"taskmanager/internal/service"
+)
+
+// server serves the task routes of the real handler, on the in-memory
+// repository, behind an API key.
+func server(t *testing.T, bare bool) *TaskClient {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := handler.NewTaskHandler(service.NewTaskService(repositories.NewMemoryTaskRepository()))
+	h.SetBareListResponses(bare)
+	r := gin.New()
+	api := r.Group("/base/api/v1", middleware.APIKeyAuth([]string{"secret"}, nil))
+	api.POST("/tasks", h.CreateTask)
+	api.GET("/tasks", h.ListTasks)
+	api.GET("/tasks/:id", h.GetTask)
+	api.PUT("/tasks/:id", h.UpdateTask)
+	api.DELETE("/tasks/:id", h.DeleteTask)
+	api.POST("/tasks/:id/complete", h.CompleteTask)
+	api.POST("/tasks/:id/reopen", h.ReopenTask)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	c, err := NewTaskClient(srv.URL + "/base/")
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	c.APIKey = "secret"
+	return c
+}
+
+func TestTaskClient_CRUD(t *testing.T) {
+	c := server(t, false)
+	ctx := context.Background()
+
+	created, err := c.Create(ctx, CreateTask{Title: "write report", Assignee: Ptr("sam"), EstimateMinutes: Ptr[int64](90)})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.ID == "" || created.Key != "TM-1" || *created.Assignee != "sam" || *created.EstimateMinutes != 90 || created.DueDate != nil {
+		t.Fatalf("unexpected task %+v", created)
+	}
+	got, err := c.Get(ctx, created.ID)
+	if err != nil || got.Title != "write report" {
+		t.Fatalf("get: %+v %v", got, err)
+	}
+	updated, err := c.Update(ctx, created.ID, UpdateTask{Title: Ptr("write the report")})
+	if err != nil || updated.Title != "write the report" || *updated.Assignee != "sam" {
+		t.Fatalf("update: %+v %v", updated, err)
+	}
+	done, err := c.Complete(ctx, created.ID)
+	if err != nil || !done.Completed || done.CompletedAt == nil {
+		t.Fatalf("complete: %+v %v", done, err)
+	}
+	if reopened, err := c.Reopen(ctx, created.ID); err != nil || reopened.Completed {
+		t.Fatalf("reopen: %+v %v", reopened, err)
+	}
+	if err := c.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_, err = c.Get(ctx, created.ID)
+	var apiErr *Error
+	if !errors.Is(err, ErrNotFound) || !errors.As(err, &apiErr) || apiErr.Detail != "task not found" {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	_, err = c.Create(ctx, CreateTask{})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || len(apiErr.Errors) == 0 || apiErr.Errors[0].Field != "title" {
+		t.Fatalf("expected the validation errors, got %#v", err)
+	}
+
+	c.APIKey = "wrong"
+	if _, err := c.Get(ctx, created.ID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %v", err)
+	}
+}
+
+func TestTaskClient_ListAndAll(t *testing.T) {
+	for _, bare := range []bool{false, true} {
+		c := server(t, bare)
+		ctx := context.Background()
+		for _, title := range []string{"a", "b", "c", "d", "e"} {
+			if _, err := c.Create(ctx, CreateTask{Title: title, Assignee: Ptr("sam")}); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+		}
+		if _, err := c.Create(ctx, CreateTask{Title: "other"}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		page, err := c.List(ctx, ListOptions{Assignees: []string{"sam"}, Limit: 2, Offset: 2})
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		if len(page.Items) != 2 || page.Limit != 2 || page.Offset != 2 || page.Total == nil || *page.Total != 5 {
+			t.Fatalf("bare=%v: unexpected page %+v", bare, page)
+		}
+
+		var titles []string
+		for task, err := range c.All(ctx, ListOptions{Assignees: []string{"sam"}, Limit: 2, Sort: "created_at asc"}) {
+			if err != nil {
+				t.Fatalf("all: %v", err)
+			}
+			titles = append(titles, task.Title)
+		}
+		if len(titles) != 5 || titles[0] != "a" || titles[4] != "e" {
+			t.Fatalf("bare=%v: expected every task of sam in order, got %v", bare, titles)
+		}
+	}
+}
+
+func TestTaskClient_Retries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var calls atomic.Int32
+	r := gin.New()
+	r.GET("/api/v1/tasks/:id", func(c *gin.Context) {
+		if c.Param("id") == "down" {
+			problem.Abort(c, http.StatusBadGateway, "upstream down")
+			return
+		}
+		if c.Param("id") == "slow" {
+			calls.Add(1)
+			c.Header("Retry-After", "3600")
+			problem.Abort(c, http.StatusServiceUnavailable, "read-only mode")
+			return
+		}
+		if calls.Add(1) < 3 {
+			c.Header("Retry-After", "0")
+			problem.Abort(c, http.StatusServiceUnavailable, "read-only mode")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id"), "title": "ok"})
+	})
+	r.POST("/api/v1/tasks", func(c *gin.Context) {
+		calls.Add(1)
+		problem.Abort(c, http.StatusServiceUnavailable, "read-only mode")
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	c, _ := NewTaskClient(srv.URL)
+	c.Retry = RetryPolicy{Backoff: time.Millisecond}
+
+	if task, err := c.Get(context.Background(), "x"); err != nil || task.Title != "ok" || calls.Load() != 3 {
+		t.Fatalf("expected success on the third attempt, got %+v %v after %d calls", task, err, calls.Load())
+	}
+
+	calls.Store(0)
+	var apiErr *Error
+	if _, err := c.Create(context.Background(), CreateTask{Title: "x"}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Fatalf("expected a create not to be retried, got %v after %d calls", err, calls.Load())
+	}
+
+	// an hour's Retry-After is handed back rather than slept through
+	calls.Store(0)
+	if _, err := c.Get(context.Background(), "slow"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Fatalf("expected a long Retry-After returned at once, got %v after %d calls", err, calls.Load())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c.Retry = RetryPolicy{Backoff: time.Hour}
+	if _, err := c.Get(ctx, "down"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the context to end the retries, got %v", err)
+	}
+}
+
+func TestRetryPolicy_RetryAfter(t *testing.T) {
+	resp := func(v string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": {v}}}
+	}
+	var p RetryPolicy
+	if d, ok := p.wait(1, resp("2")); !ok || d != 2*time.Second {
+		t.Fatalf("expected 2s got %v %v", d, ok)
+	}
+	if d, ok := p.wait(1, resp(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))); !ok || d != 0 {
+		t.Fatalf("expected no wait for a past date got %v %v", d, ok)
+	}
+	if d, ok := p.wait(1, resp(time.Now().Add(30*time.Second).UTC().Format(http.TimeFormat))); !ok || d <= 20*time.Second || d > 30*time.Second {
+		t.Fatalf("expected about 30s for a date got %v %v", d, ok)
+	}
+	if _, ok := p.wait(1, resp("3600")); ok {
+		t.Fatal("expected an hour to exceed the default MaxRetryAfter")
+	}
+	p.MaxRetryAfter = 2 * time.Hour
+	if d, ok := p.wait(1, resp("3600")); !ok || d != time.Hour {
+		t.Fatalf("expected an hour within MaxRetryAfter got %v %v", d, ok)
+	}
+	if d, ok := p.wait(1, resp("soon")); !ok || d > 240*time.Millisecond {
+		t.Fatalf("expected the backoff for an invalid header got %v %v", d, ok)
+	}
+}
+
+func TestNewTaskClient_InvalidURL(t *testing.T) {
+	for _, u := range []string{"", "localhost:8080", "ftp://example.com", "http://"} {
+		if _, err := NewTaskClient(u); err == nil {
+			t.Fatalf("expected %q to be rejected", u)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Task is a task as the API returns it. Nullable fields are nil when unset.
+type Task struct {
+	ID string `json:"id"`
+	// Key is the human-readable key, e.g. "TM-1042".
+	Key              string     `json:"key"`
+	Number           int64      `json:"number"`
+	Title            string     `json:"title"`
+	Description      *string    `json:"description"`
+	Assignee         *string    `json:"assignee"`
+	Completed        bool       `json:"completed"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	DueDate          *time.Time `json:"due_date"`
+	EstimateMinutes  *int64     `json:"estimate_minutes"`
+	RemainingMinutes *int64     `json:"remaining_minutes"`
+	Position         float64    `json:"position"`
+	TimeSpentSeconds int64      `json:"time_spent_seconds"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// UnmarshalJSON reads the nullable fields either as plain values or null, as
+// documented, or as the {"String": "...", "Valid": true} objects of
+// database/sql the service currently sends.
+func (t *Task) UnmarshalJSON(b []byte) error {
+	type task Task
+	aux := struct {
+		*task
+		Description      nullable[string]    `json:"description"`
+		Assignee         nullable[string]    `json:"assignee"`
+		CompletedAt      nullable[time.Time] `json:"completed_at"`
+		DueDate          nullable[time.Time] `json:"due_date"`
+		EstimateMinutes  nullable[int64]     `json:"estimate_minutes"`
+		RemainingMinutes nullable[int64]     `json:"remaining_minutes"`
+	}{task: (*task)(t)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	t.Description, t.Assignee = aux.Description.v, aux.Assignee.v
+	t.CompletedAt, t.DueDate = aux.CompletedAt.v, aux.DueDate.v
+	t.EstimateMinutes, t.RemainingMinutes = aux.EstimateMinutes.v, aux.RemainingMinutes.v
+	return nil
+}
+
+// nullable decodes a plain value, null, or a database/sql null type object.
+type nullable[T any] struct{ v *T }
+
+func (n *nullable[T]) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	if len(b) == 0 || b[0] != '{' {
+		n.v = new(T)
+		return json.Unmarshal(b, n.v)
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return err
+	}
+	var valid bool
+	if err := json.Unmarshal(obj["Valid"], &valid); err != nil || !valid {
+		return err
+	}
+	for k, raw := range obj {
+		if k != "Valid" {
+			n.v = new(T)
+			return json.Unmarshal(raw, n.v)
+		}
+	}
+	return nil
+}
+
+// CreateTask is the body of Create; only Title is required.
+type CreateTask struct {
+	Title            string     `json:"title"`
+	Description      *string    `json:"description,omitempty"`
+	Assignee         *string    `json:"assignee,omitempty"`
+	DueDate          *time.Time `json:"due_date,omitempty"`
+	EstimateMinutes  *int64     `json:"estimate_minutes,omitempty"`
+	RemainingMinutes *int64     `json:"remaining_minutes,omitempty"`
+}
+
+// UpdateTask is the body of Update: nil fields are left unchanged, an empty
+// Description clears it.
+type UpdateTask struct {
+	Title            *string    `json:"title,omitempty"`
+	Description      *string    `json:"description,omitempty"`
+	Assignee         *string    `json:"assignee,omitempty"`
+	Completed        *bool      `json:"completed,omitempty"`
+	DueDate          *time.Time `json:"due_date,omitempty"`
+	EstimateMinutes  *int64     `json:"estimate_minutes,omitempty"`
+	RemainingMinutes *int64     `json:"remaining_minutes,omitempty"`
+}
+
+// Ptr returns a pointer to v, for the optional fields of CreateTask and
+// UpdateTask.
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// ListOptions filter and page List and All. Zero values are not sent.
+type ListOptions struct {
+	Completed *bool
+	// Assignees matches tasks of any of them; "none" matches unassigned
+	// tasks.
+	Assignees []string
+	// Query matches a case-insensitive substring of the title.
+	Query string
+	// Sort overrides the server's default order, e.g. "due_date asc nulls
+	// last, created_at desc".
+	Sort string
+	// The date ranges of created_at, updated_at and due_date.
+	CreatedAfter, CreatedBefore time.Time
+	UpdatedAfter, UpdatedBefore time.Time
+	DueAfter, DueBefore         time.Time
+	// Limit is the page size (the server's default is 100); Offset the
+	// number of tasks to skip.
+	Limit, Offset int
+}
+
+func (o ListOptions) query() url.Values {
+	q := url.Values{}
+	if o.Completed != nil {
+		q.Set("completed", strconv.FormatBool(*o.Completed))
+	}
+	for _, a := range o.Assignees {
+		q.Add("assignee", a)
+	}
+	if o.Query != "" {
+		q.Set("q", o.Query)
+	}
+	if o.Sort != "" {
+		q.Set("sort", o.Sort)
+	}
+	for name, t := range map[string]time.Time{
+		"created_after": o.CreatedAfter, "created_before": o.CreatedBefore,
+		"updated_after": o.UpdatedAfter, "updated_before": o.UpdatedBefore,
+		"due_after": o.DueAfter, "due_before": o.DueBefore,
+	} {
+		if !t.IsZero() {
+			q.Set(name, t.Format(time.RFC3339Nano))
+		}
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	return q
+}
+
+// Page is one page of List.
+type Page struct {
+	Items  []Task `json:"items"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	// Total counts every matching task; nil when the server did not count.
+	Total *int `json:"total"`
+}
+
+// Create creates a task. It is not retried, so a failed call may still
+// have created it.
+func (c *TaskClient) Create(ctx context.Context, t CreateTask) (*Task, error) {
+	var out Task
+	if _, err := c.do(ctx, http.MethodPost, "/tasks", nil, t, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Get returns the task with id; the error matches ErrNotFound when there is
+// none.
+func (c *TaskClient) Get(ctx context.Context, id string) (*Task, error) {
+	var out Task
+	if _, err := c.do(ctx, http.MethodGet, "/tasks/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// List returns one page of the tasks matching opts.
+func (c *TaskClient) List(ctx context.Context, opts ListOptions) (*Page, error) {
+	var raw json.RawMessage
+	resp, err := c.do(ctx, http.MethodGet, "/tasks", opts.query(), nil, &raw)
+	if err != nil {
+		return nil, err
+	}
+	var page Page
+	if len(raw) > 0 && raw[0] == '[' {
+		// features.bare_list_responses: the page is in the headers
+		if err := json.Unmarshal(raw, &page.Items); err != nil {
+			return nil, err
+		}
+		page.Limit, _ = strconv.Atoi(resp.Header.Get("X-Limit"))
+		page.Offset, _ = strconv.Atoi(resp.Header.Get("X-Offset"))
+		if n, err := strconv.Atoi(resp.Header.Get("X-Total-Count")); err == nil {
+			page.Total = &n
+		}
+		return &page, nil
+	}
+	if err := json.Unmarshal(raw, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// All iterates over every task matching opts, from opts.Offset on, fetching
+// pages of opts.Limit tasks as it goes. It stops at the first error, which
+// it yields. Tasks created or deleted meanwhile may shift the pages, so a
+// task can be skipped or seen twice.
+func (c *TaskClient) All(ctx context.Context, opts ListOptions) iter.Seq2[Task, error] {
+	return func(yield func(Task, error) bool) {
+		for {
+			page, err := c.List(ctx, opts)
+			if err != nil {
+				yield(Task{}, err)
+				return
+			}
+			for _, t := range page.Items {
+				if !yield(t, nil) {
+					return
+				}
+			}
+			if len(page.Items) == 0 || (page.Limit > 0 && len(page.Items) < page.Limit) {
+				return
+			}
+			opts.Offset += len(page.Items)
+		}
+	}
+}
+
+// Update changes the non-nil fields of u on the task with id and returns
+// the updated task.
+func (c *TaskClient) Update(ctx context.Context, id string, u UpdateTask) (*Task, error) {
+	var out Task
+	if _, err := c.do(ctx, http.MethodPut, "/tasks/"+url.PathEscape(id), nil, u, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Delete deletes the task with id.
+func (c *TaskClient) Delete(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/tasks/"+url.PathEscape(id), nil, nil, nil)
+	return err
+}
+
+// Complete marks the task with id completed; completing a completed task
+// returns it unchanged.
+func (c *TaskClient) Complete(ctx context.Context, id string) (*Task, error) {
+	var out Task
+	if _, err := c.do(ctx, http.MethodPost, "/tasks/"+url.PathEscape(id)+"/complete", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Reopen marks the completed task with id open again.
+func (c *TaskClient) Reopen(ctx context.Context, id string) (*Task, error) {
+	var out Task
+	if _, err := c.do(ctx, http.MethodPost, "/tasks/"+url.PathEscape(id)+"/reopen", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// FlushCache drops the service's cached task lists and items (POST
+// /admin/cache/flush) and returns the number of Redis keys removed. It
+// needs an admin key, and a client for the admin listener when the service
+// sets admin.listen.
+func (c *TaskClient) FlushCache(ctx context.Context) (int, error) {
+	var out struct {
+		Flushed int `json:"flushed"`
+	}
+	if _, err := c.do(ctx, http.MethodPost, "/admin/cache/flush", nil, nil, &out); err != nil {
+		return 0, err
+	}
+	return out.Flushed, nil
+}
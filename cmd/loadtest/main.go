@@ -0,0 +1,342 @@
+// Command loadtest drives a running service with a mix of list, get and
+// create requests and reports the throughput and latency percentiles of each,
+// to compare releases and cache setups:
+//
+//	loadtest -server http://localhost:8080 -duration 30s -concurrency 32 -mix list=70,list-filtered=20,create=10
+//
+// With -max-p99 it exits with status 1 when an operation is slower, for use
+// as a release gate.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// op is one kind of request.
+type op struct {
+	name string
+	// request builds the request; ids are tasks known to exist.
+	request func(base string, ids []string, rng *rand.Rand) (*http.Request, error)
+}
+
+var assignees = []string{"alice", "bob", "carol", "dave"}
+
+var ops = map[string]op{
+	"list": {"list", func(base string, _ []string, _ *rand.Rand) (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, base+"/tasks?limit=50", nil)
+	}},
+	"list-filtered": {"list-filtered", func(base string, _ []string, rng *rand.Rand) (*http.Request, error) {
+		q := url.Values{}
+		q.Set("completed", "false")
+		q.Add("assignee", assignees[rng.IntN(len(assignees))])
+		q.Add("assignee", "none")
+		q.Set("sort", "due_date asc nulls last")
+		q.Set("limit", "50")
+		return http.NewRequest(http.MethodGet, base+"/tasks?"+q.Encode(), nil)
+	}},
+	"get": {"get", func(base string, ids []string, rng *rand.Rand) (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, base+"/tasks/"+ids[rng.IntN(len(ids))], nil)
+	}},
+	"create": {"create", func(base string, _ []string, rng *rand.Rand) (*http.Request, error) {
+		return newCreate(base, rng)
+	}},
+}
+
+func newCreate(base string, rng *rand.Rand) (*http.Request, error) {
+	body, _ := json.Marshal(map[string]any{
+		"title":    "load test task " + strconv.Itoa(rng.IntN(1_000_000)),
+		"assignee": assignees[rng.IntN(len(assignees))],
+	})
+	req, err := http.NewRequest(http.MethodPost, base+"/tasks", bytes.NewReader(body))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, err
+}
+
+// result holds the outcomes of one operation.
+type result struct {
+	latencies []time.Duration
+	errors    int
+	// statuses counts the non-2xx responses by status code.
+	statuses map[int]int
+}
+
+func (r *result) merge(o *result) {
+	r.latencies = append(r.latencies, o.latencies...)
+	r.errors += o.errors
+	for s, n := range o.statuses {
+		r.statuses[s] += n
+	}
+}
+
+func newResult() *result { return &result{statuses: map[int]int{}} }
+
+// Summary is the report of one operation (-json prints them).
+type Summary struct {
+	Op       string         `json:"op"`
+	Requests int            `json:"requests"`
+	Errors   int            `json:"errors"`
+	RPS      float64        `json:"rps"`
+	P50      time.Duration  `json:"p50_ns"`
+	P90      time.Duration  `json:"p90_ns"`
+	P99      time.Duration  `json:"p99_ns"`
+	Max      time.Duration  `json:"max_ns"`
+	Statuses map[string]int `json:"statuses,omitempty"`
+}
+
+func main() {
+	server := flag.String("server", envOr("TASKMANAGER_URL", "http://localhost:8080"), "base URL of the service (env: TASKMANAGER_URL)")
+	apiKey := flag.String("api-key", os.Getenv("TASKMANAGER_API_KEY"), "API key (env: TASKMANAGER_API_KEY)")
+	duration := flag.Duration("duration", 30*time.Second, "how long to send requests")
+	concurrency := flag.Int("concurrency", 16, "concurrent workers")
+	rate := flag.Int("rate", 0, "requests per second over all workers; 0 sends as fast as they can")
+	mix := flag.String("mix", "list=70,list-filtered=20,create=10", "weights of the operations: list, list-filtered, get, create")
+	seed := flag.Int("seed", 200, "tasks to create before the run, for list and get to read")
+	asJSON := flag.Bool("json", false, "print the summaries as JSON")
+	maxP99 := flag.Duration("max-p99", 0, "exit with status 1 when an operation's p99 latency exceeds this")
+	flag.Parse()
+
+	weighted, err := parseMix(*mix)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "loadtest: -mix:", err)
+		os.Exit(2)
+	}
+	if *seed <= 0 && slices.ContainsFunc(weighted, func(o op) bool { return o.name == "get" }) {
+		fmt.Fprintln(os.Stderr, "loadtest: get needs -seed tasks to read")
+		os.Exit(2)
+	}
+	if *concurrency <= 0 || *duration <= 0 {
+		fmt.Fprintln(os.Stderr, "loadtest: -concurrency and -duration must be positive")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	base := strings.TrimSuffix(*server, "/") + "/api/v1"
+	hc := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency, MaxConnsPerHost: *concurrency},
+	}
+	send := func(req *http.Request) (int, []byte, error) {
+		if *apiKey != "" {
+			req.Header.Set("X-API-Key", *apiKey)
+		}
+		resp, err := hc.Do(req.WithContext(ctx))
+		if err != nil {
+			return 0, nil, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp.StatusCode, body, err
+	}
+
+	ids, err := seedTasks(base, *seed, send)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "loadtest: seeding:", err)
+		os.Exit(1)
+	}
+
+	results := run(ctx, base, ids, weighted, *concurrency, *rate, *duration, send)
+	summaries := summarize(results, *duration)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(summaries)
+	} else {
+		printTable(summaries)
+	}
+	for _, s := range summaries {
+		if *maxP99 > 0 && s.P99 > *maxP99 {
+			fmt.Fprintf(os.Stderr, "loadtest: %s p99 %v exceeds -max-p99 %v\n", s.Op, s.P99, *maxP99)
+			os.Exit(1)
+		}
+	}
+}
+
+// parseMix parses "list=70,create=30" into a slice with an entry per unit of
+// weight.
+func parseMix(s string) ([]op, error) {
+	var weighted []op
+	for _, part := range strings.Split(s, ",") {
+		name, w, ok := strings.Cut(strings.TrimSpace(part), "=")
+		o, known := ops[name]
+		n, err := strconv.Atoi(w)
+		if !ok || !known || err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid entry %q: expected op=weight with op one of list, list-filtered, get, create", part)
+		}
+		for range n {
+			weighted = append(weighted, o)
+		}
+	}
+	if len(weighted) == 0 {
+		return nil, errors.New("no operation has a weight")
+	}
+	return weighted, nil
+}
+
+func seedTasks(base string, n int, send func(*http.Request) (int, []byte, error)) ([]string, error) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	ids := make([]string, 0, n)
+	for range n {
+		req, err := newCreate(base, rng)
+		if err != nil {
+			return nil, err
+		}
+		status, body, err := send(req)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusCreated {
+			return nil, fmt.Errorf("create: %d %s", status, body)
+		}
+		var t struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(body, &t); err != nil {
+			return nil, err
+		}
+		ids = append(ids, t.ID)
+	}
+	return ids, nil
+}
+
+// run sends requests from concurrency workers for d, or until ctx ends.
+func run(ctx context.Context, base string, ids []string, weighted []op, concurrency, rate int, d time.Duration, send func(*http.Request) (int, []byte, error)) map[string]*result {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	var tokens <-chan time.Time
+	if rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(rate))
+		defer ticker.Stop()
+		tokens = ticker.C
+	}
+
+	var mu sync.Mutex
+	results := map[string]*result{}
+	var wg sync.WaitGroup
+	for w := range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(uint64(w), uint64(time.Now().UnixNano())))
+			local := map[string]*result{}
+			for ctx.Err() == nil {
+				if tokens != nil {
+					select {
+					case <-ctx.Done():
+						continue
+					case <-tokens:
+					}
+				}
+				o := weighted[rng.IntN(len(weighted))]
+				r := local[o.name]
+				if r == nil {
+					r = newResult()
+					local[o.name] = r
+				}
+				req, err := o.request(base, ids, rng)
+				if err != nil {
+					r.errors++
+					continue
+				}
+				start := time.Now()
+				status, _, err := send(req)
+				if ctx.Err() != nil {
+					// cut off by the end of the run
+					break
+				}
+				r.latencies = append(r.latencies, time.Since(start))
+				if err != nil {
+					r.errors++
+				} else if status < 200 || status > 299 {
+					r.errors++
+					r.statuses[status]++
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for name, r := range local {
+				if results[name] == nil {
+					results[name] = newResult()
+				}
+				results[name].merge(r)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func summarize(results map[string]*result, d time.Duration) []Summary {
+	var out []Summary
+	for name, r := range results {
+		slices.Sort(r.latencies)
+		s := Summary{
+			Op:       name,
+			Requests: len(r.latencies),
+			Errors:   r.errors,
+			RPS:      float64(len(r.latencies)) / d.Seconds(),
+			P50:      percentile(r.latencies, 0.50),
+			P90:      percentile(r.latencies, 0.90),
+			P99:      percentile(r.latencies, 0.99),
+			Max:      percentile(r.latencies, 1),
+		}
+		for status, n := range r.statuses {
+			if s.Statuses == nil {
+				s.Statuses = map[string]int{}
+			}
+			s.Statuses[strconv.Itoa(status)] = n
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Op < out[j].Op })
+	return out
+}
+
+// percentile returns the p-th latency of the sorted sample.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[min(len(sorted)-1, int(p*float64(len(sorted))))]
+}
+
+func printTable(summaries []Summary) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OP\tREQUESTS\tERRORS\tRPS\tP50\tP90\tP99\tMAX\t")
+	for _, s := range summaries {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%v\t%v\t%v\t%v\t\n", s.Op, s.Requests, s.Errors, s.RPS,
+			s.P50.Round(time.Microsecond), s.P90.Round(time.Microsecond), s.P99.Round(time.Microsecond), s.Max.Round(time.Microsecond))
+		for status, n := range s.Statuses {
+			fmt.Fprintf(tw, "  %s\t%d\t\t\t\t\t\t\t\n", status, n)
+		}
+	}
+	tw.Flush()
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
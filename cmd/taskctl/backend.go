@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+
+	"taskmanager/client"
+	"taskmanager/internal/config"
+	"taskmanager/internal/database"
+	"taskmanager/internal/model"
+	dtos "taskmanager/internal/model/DTOs"
+	"taskmanager/internal/repositories"
+	"taskmanager/internal/service"
+)
+
+// backend carries out the task commands, through the API or directly on the
+// database.
+type backend interface {
+	All(ctx context.Context, opts client.ListOptions) iter.Seq2[client.Task, error]
+	Create(ctx context.Context, t client.CreateTask) (*client.Task, error)
+	Complete(ctx context.Context, id string) (*client.Task, error)
+	Delete(ctx context.Context, id string) error
+	FlushCache(ctx context.Context) (int, error)
+}
+
+// *client.TaskClient is the API backend.
+var _ backend = (*client.TaskClient)(nil)
+
+// dbBackend works on the database (and Redis cache) of the service's
+// configuration, through the same service layer as the API but without its
+// authentication and WIP limit.
+type dbBackend struct {
+	db   *sqlx.DB
+	rdb  *redis.Client
+	repo repositories.TaskRepository
+	svc  service.TaskService
+}
+
+func openDB(cfg *config.Config) (*dbBackend, error) {
+	if cfg.Database.InMemory() {
+		return nil, errors.New("database.url (DATABASE_URL) is memory: there is no database to talk to")
+	}
+	if cfg.Database.URL == "" {
+		return nil, errors.New("database.url (DATABASE_URL) is not set; taskctl does not resolve secrets.* references")
+	}
+	db, err := database.Open(cfg.Database.Driver, cfg.Database.URL)
+	if err != nil {
+		return nil, err
+	}
+	b := &dbBackend{db: db, repo: repositories.NewTaskRepository(db)}
+	if cr, ok := b.repo.(interface {
+		SetCacheOptions(repositories.CacheOptions)
+	}); ok {
+		// writes invalidate the service instances' caches, including their
+		// L1 copies when Pub/Sub is on
+		cr.SetCacheOptions(repositories.CacheOptions{
+			ListTTL: cfg.Cache.ListTTL.Duration,
+			ItemTTL: cfg.Cache.ItemTTL.Duration,
+			PubSub:  cfg.Cache.PubSub,
+		})
+	}
+	if sort, err := repositories.ParseSort(cfg.List.DefaultSort); err == nil {
+		if sr, ok := b.repo.(interface {
+			SetDefaultSort([]repositories.SortField)
+		}); ok {
+			sr.SetDefaultSort(sort)
+		}
+	}
+	b.svc = service.NewTaskService(b.repo)
+	if addr := strings.TrimPrefix(cfg.Redis.Addr, "redis://"); addr != "" {
+		rdb := redis.NewClient(&redis.Options{Addr: addr, Password: cfg.Redis.Password, DB: cfg.Redis.DB, MaxRetries: -1})
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		// like the service, work uncached when Redis is down; the service
+		// instances' caches then expire on their own
+		if err := rdb.Ping(ctx).Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "taskctl: redis not available, caches are not invalidated: %v\n", err)
+			rdb.Close()
+		} else {
+			b.rdb = rdb
+			b.svc.SetCacheClient(rdb)
+		}
+	}
+	return b, nil
+}
+
+func (b *dbBackend) Close() {
+	if b.rdb != nil {
+		b.rdb.Close()
+	}
+	b.db.Close()
+}
+
+func (b *dbBackend) All(ctx context.Context, opts client.ListOptions) iter.Seq2[client.Task, error] {
+	return func(yield func(client.Task, error) bool) {
+		list := service.ListOptions{Count: repositories.CountNone}
+		if opts.Sort != "" {
+			var err error
+			if list.Sort, err = repositories.ParseSort(opts.Sort); err != nil {
+				yield(client.Task{}, err)
+				return
+			}
+		}
+		list.Filter = repositories.TaskFilter{Completed: opts.Completed, Dates: dateRange(opts), Title: opts.Query}
+		for _, a := range opts.Assignees {
+			if a == "none" {
+				list.Filter.Assignee.Unassigned = true
+			} else {
+				list.Filter.Assignee.Names = append(list.Filter.Assignee.Names, a)
+			}
+		}
+		limit := opts.Limit
+		if limit <= 0 {
+			limit = 100
+		}
+		for offset := opts.Offset; ; offset += limit {
+			list.Limit, list.Offset = limit, offset
+			tasks, _, err := b.svc.List(ctx, list)
+			if err != nil {
+				yield(client.Task{}, err)
+				return
+			}
+			for i := range tasks {
+				if !yield(toClient(&tasks[i])) {
+					return
+				}
+			}
+			if len(tasks) < limit {
+				return
+			}
+		}
+	}
+}
+
+func (b *dbBackend) Create(ctx context.Context, t client.CreateTask) (*client.Task, error) {
+	var dto dtos.CreateTaskDTO
+	if err := convert(t, &dto); err != nil {
+		return nil, err
+	}
+	if err := binding.Validator.ValidateStruct(&dto); err != nil {
+		return nil, err
+	}
+	created, err := b.svc.Create(ctx, dto.ToModel())
+	if err != nil {
+		return nil, err
+	}
+	return taskOrErr(toClient(created))
+}
+
+func (b *dbBackend) Complete(ctx context.Context, id string) (*client.Task, error) {
+	t, err := b.svc.Complete(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return taskOrErr(toClient(t))
+}
+
+func (b *dbBackend) Delete(ctx context.Context, id string) error {
+	return b.svc.Delete(ctx, id)
+}
+
+func (b *dbBackend) FlushCache(ctx context.Context) (int, error) {
+	if b.rdb == nil {
+		return 0, errors.New("redis.addr (REDIS_ADDR) is not set: there is no cache to flush")
+	}
+	f, ok := b.repo.(interface {
+		FlushCache(ctx context.Context) (int, error)
+	})
+	if !ok {
+		return 0, errors.New("the task repository has no cache")
+	}
+	return f.FlushCache(ctx)
+}
+
+func dateRange(o client.ListOptions) repositories.DateRange {
+	var r repositories.DateRange
+	if !o.CreatedAfter.IsZero() {
+		r.CreatedAfter = &o.CreatedAfter
+	}
+	if !o.CreatedBefore.IsZero() {
+		r.CreatedBefore = &o.CreatedBefore
+	}
+	if !o.UpdatedAfter.IsZero() {
+		r.UpdatedAfter = &o.UpdatedAfter
+	}
+	if !o.UpdatedBefore.IsZero() {
+		r.UpdatedBefore = &o.UpdatedBefore
+	}
+	if !o.DueAfter.IsZero() {
+		r.DueAfter = &o.DueAfter
+	}
+	if !o.DueBefore.IsZero() {
+		r.DueBefore = &o.DueBefore
+	}
+	return r
+}
+
+// toClient converts a task to its API representation.
+func toClient(t *model.Task) (client.Task, error) {
+	var out client.Task
+	err := convert(t, &out)
+	return out, err
+}
+
+func taskOrErr(t client.Task, err error) (*client.Task, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// convert copies in to out through their JSON representations.
+func convert(in, out any) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
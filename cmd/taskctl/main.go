@@ -0,0 +1,475 @@
+// Command taskctl lists, creates, completes and deletes tasks, imports and
+// exports them, flushes the cache and runs migrations, for operators and
+// scripts. It talks to the API of a running service, or with -db directly to
+// the database of the service's configuration.
+//
+//	taskctl [global flags] <command> [flags] [args]
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"taskmanager/client"
+	"taskmanager/internal/config"
+	"taskmanager/internal/database"
+	"taskmanager/internal/seed"
+	"taskmanager/migrations"
+)
+
+const usage = `usage: taskctl [global flags] <command> [flags] [args]
+
+commands:
+  list         list tasks
+  create       create a task
+  complete     complete tasks: complete ID...
+  delete       delete tasks: delete ID...
+  export       write every task as JSON lines: export [-o FILE]
+  import       create the tasks of JSON lines: import [FILE|-]
+  seed         create demo tasks: seed [-count N] [flags]
+  flush-cache  drop the cached task lists and items
+  migrate      run database migrations: migrate ` + migrations.CommandUsage + `
+
+global flags:`
+
+// errUsage makes the command exit with status 2.
+var errUsage = errors.New("usage")
+
+// globals are the flags before the command.
+type globals struct {
+	server     string
+	apiKey     string
+	direct     bool
+	configPath string
+}
+
+func main() {
+	var g globals
+	fs := flag.NewFlagSet("taskctl", flag.ContinueOnError)
+	fs.StringVar(&g.server, "server", envOr("TASKMANAGER_URL", "http://localhost:8080"), "base URL of the service (env: TASKMANAGER_URL)")
+	fs.StringVar(&g.apiKey, "api-key", os.Getenv("TASKMANAGER_API_KEY"), "API key; flush-cache needs an admin key (env: TASKMANAGER_API_KEY)")
+	fs.BoolVar(&g.direct, "db", false, "talk to the configured database instead of the API")
+	fs.StringVar(&g.configPath, "config", os.Getenv("CONFIG_FILE"), "config file of the service, with -db and for migrate (env: CONFIG_FILE)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := run(ctx, g, fs.Arg(0), fs.Args()[1:])
+	stop()
+	switch {
+	case errors.Is(err, errUsage):
+		if err != errUsage {
+			fmt.Fprintln(os.Stderr, strings.TrimPrefix(err.Error(), "usage: "))
+		}
+		os.Exit(2)
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(0)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "taskctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, g globals, cmd string, args []string) error {
+	if cmd == "migrate" {
+		return migrate(ctx, g, args)
+	}
+	cmds := map[string]func(context.Context, backend, []string) error{
+		"list":        list,
+		"create":      create,
+		"complete":    complete,
+		"delete":      remove,
+		"export":      export,
+		"import":      importTasks,
+		"seed":        seedTasks,
+		"flush-cache": flushCache,
+	}
+	fn, ok := cmds[cmd]
+	if !ok {
+		return fmt.Errorf("%w: unknown command %q", errUsage, cmd)
+	}
+	b, closeFn, err := open(g)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	return fn(ctx, b, args)
+}
+
+// open returns the backend the global flags select.
+func open(g globals) (backend, func(), error) {
+	if !g.direct {
+		c, err := client.NewTaskClient(g.server)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.APIKey = g.apiKey
+		return c, func() {}, nil
+	}
+	cfg, err := loadConfig(g.configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := openDB(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, b.Close, nil
+}
+
+func loadConfig(path string) (*config.Config, error) {
+	cfg, err := config.Load(path)
+	var verr *config.ValidationError
+	if errors.As(err, &verr) {
+		return nil, fmt.Errorf("invalid configuration: %s", strings.Join(verr.Problems, "; "))
+	}
+	return cfg, err
+}
+
+// flags returns a flag set for the command name whose parse errors are
+// usage errors.
+func flags(name, argsUsage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: taskctl %s %s\n", name, argsUsage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		// the flag set has printed the error and its usage
+		return errUsage
+	}
+	return nil
+}
+
+// multi is a repeatable string flag.
+type multi []string
+
+func (m *multi) String() string     { return strings.Join(*m, ",") }
+func (m *multi) Set(v string) error { *m = append(*m, v); return nil }
+
+func list(ctx context.Context, b backend, args []string) error {
+	fs := flags("list", "[flags]")
+	var opts client.ListOptions
+	var assignees multi
+	completed := fs.String("completed", "", "true or false: only completed or only open tasks")
+	fs.Var(&assignees, "assignee", "only tasks of this assignee, none for unassigned (repeatable)")
+	fs.StringVar(&opts.Query, "q", "", "only tasks whose title contains this")
+	fs.StringVar(&opts.Sort, "sort", "", `order, e.g. "due_date asc nulls last"`)
+	limit := fs.Int("limit", 0, "at most this many tasks (default all)")
+	output := fs.String("o", "table", "output format: table or json")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *completed != "" {
+		v, err := strconv.ParseBool(*completed)
+		if err != nil {
+			return fmt.Errorf("%w: -completed: expected true or false", errUsage)
+		}
+		opts.Completed = &v
+	}
+	if *output != "table" && *output != "json" {
+		return fmt.Errorf("%w: -o: expected table or json", errUsage)
+	}
+	opts.Assignees = assignees
+
+	var tasks []client.Task
+	for t, err := range b.All(ctx, opts) {
+		if err != nil {
+			return err
+		}
+		tasks = append(tasks, t)
+		if *limit > 0 && len(tasks) == *limit {
+			break
+		}
+	}
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if tasks == nil {
+			tasks = []client.Task{}
+		}
+		return enc.Encode(tasks)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tKEY\tTITLE\tASSIGNEE\tCOMPLETED\tDUE")
+	for _, t := range tasks {
+		due := ""
+		if t.DueDate != nil {
+			due = t.DueDate.Format(time.DateOnly)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%t\t%s\n", t.ID, t.Key, t.Title, deref(t.Assignee), t.Completed, due)
+	}
+	return tw.Flush()
+}
+
+func create(ctx context.Context, b backend, args []string) error {
+	fs := flags("create", "-title TITLE [flags]")
+	var t client.CreateTask
+	fs.StringVar(&t.Title, "title", "", "title (required)")
+	assignee := fs.String("assignee", "", "assignee")
+	description := fs.String("description", "", "description")
+	due := fs.String("due", "", "due date, YYYY-MM-DD or RFC 3339")
+	estimate := fs.Int64("estimate", 0, "estimate in minutes")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if t.Title == "" {
+		return fmt.Errorf("%w: -title is required", errUsage)
+	}
+	if *assignee != "" {
+		t.Assignee = assignee
+	}
+	if *description != "" {
+		t.Description = description
+	}
+	if *due != "" {
+		d, err := parseDate(*due)
+		if err != nil {
+			return fmt.Errorf("%w: -due: %v", errUsage, err)
+		}
+		t.DueDate = &d
+	}
+	if *estimate > 0 {
+		t.EstimateMinutes = estimate
+	}
+	created, err := b.Create(ctx, t)
+	if err != nil {
+		return err
+	}
+	fmt.Println(created.ID, created.Key)
+	return nil
+}
+
+func complete(ctx context.Context, b backend, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: usage: taskctl complete ID...", errUsage)
+	}
+	for _, id := range args {
+		if _, err := b.Complete(ctx, id); err != nil {
+			return fmt.Errorf("complete %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func remove(ctx context.Context, b backend, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: usage: taskctl delete ID...", errUsage)
+	}
+	for _, id := range args {
+		if err := b.Delete(ctx, id); err != nil {
+			return fmt.Errorf("delete %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func export(ctx context.Context, b backend, args []string) error {
+	fs := flags("export", "[-o FILE]")
+	out := fs.String("o", "-", "file to write, - for standard output")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	w := io.Writer(os.Stdout)
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for t, err := range b.All(ctx, client.ListOptions{Sort: "created_at asc"}) {
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(t); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// importTasks creates a task for every exported one (they get new ids and
+// keys), completing those exported completed.
+func importTasks(ctx context.Context, b backend, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("%w: usage: taskctl import [FILE|-]", errUsage)
+	}
+	r := io.Reader(os.Stdin)
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	dec := json.NewDecoder(r)
+	n := 0
+	for {
+		var t client.Task
+		if err := dec.Decode(&t); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("task %d: %w", n+1, err)
+		}
+		created, err := b.Create(ctx, client.CreateTask{
+			Title:            t.Title,
+			Description:      t.Description,
+			Assignee:         t.Assignee,
+			DueDate:          t.DueDate,
+			EstimateMinutes:  t.EstimateMinutes,
+			RemainingMinutes: t.RemainingMinutes,
+		})
+		if err != nil {
+			return fmt.Errorf("task %d (%q): %w", n+1, t.Title, err)
+		}
+		if t.Completed {
+			if _, err := b.Complete(ctx, created.ID); err != nil {
+				return fmt.Errorf("task %d (%q): %w", n+1, t.Title, err)
+			}
+		}
+		n++
+	}
+	fmt.Fprintf(os.Stderr, "imported %d tasks\n", n)
+	return nil
+}
+
+// seedTasks creates generated demo tasks (see package seed).
+func seedTasks(ctx context.Context, b backend, args []string) error {
+	o := seed.DefaultOptions()
+	fs := flags("seed", "[flags]")
+	fs.IntVar(&o.Count, "count", o.Count, "number of tasks")
+	assignees := fs.String("assignees", strings.Join(o.Assignees, ","), "comma-separated assignees, the first getting the most tasks")
+	fs.Float64Var(&o.UnassignedRatio, "unassigned", o.UnassignedRatio, "fraction of unassigned tasks")
+	fs.Float64Var(&o.CompletedRatio, "completed", o.CompletedRatio, "fraction of completed tasks")
+	fs.Float64Var(&o.DueRatio, "due", o.DueRatio, "fraction of tasks with a due date")
+	fs.Float64Var(&o.OverdueRatio, "overdue", o.OverdueRatio, "fraction of the open tasks with a due date that are overdue")
+	fs.DurationVar(&o.DueWithin, "due-within", o.DueWithin, "latest due date from now")
+	fs.Float64Var(&o.EstimateRatio, "estimated", o.EstimateRatio, "fraction of tasks with an estimate")
+	fs.Uint64Var(&o.Seed, "seed", o.Seed, "random seed; the same seed creates the same tasks")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	o.Assignees = nil
+	for _, a := range strings.Split(*assignees, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			o.Assignees = append(o.Assignees, a)
+		}
+	}
+	tasks, err := seed.Tasks(o, time.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	for i, t := range tasks {
+		ct := client.CreateTask{Title: t.Title, DueDate: t.DueDate}
+		if t.Description != "" {
+			ct.Description = &t.Description
+		}
+		if t.Assignee != "" {
+			ct.Assignee = &t.Assignee
+		}
+		if t.EstimateMinutes > 0 {
+			ct.EstimateMinutes = &t.EstimateMinutes
+		}
+		if t.RemainingMinutes > 0 {
+			ct.RemainingMinutes = &t.RemainingMinutes
+		}
+		created, err := b.Create(ctx, ct)
+		if err == nil && t.Completed {
+			_, err = b.Complete(ctx, created.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("task %d of %d: %w", i+1, len(tasks), err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "created %d tasks\n", len(tasks))
+	return nil
+}
+
+func flushCache(ctx context.Context, b backend, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: usage: taskctl flush-cache", errUsage)
+	}
+	n, err := b.FlushCache(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("flushed %d keys\n", n)
+	return nil
+}
+
+// migrate always works on the database, like `taskmanager migrate`.
+func migrate(ctx context.Context, g globals, args []string) error {
+	cfg, err := loadConfig(g.configPath)
+	if err != nil {
+		return err
+	}
+	if cfg.Database.InMemory() {
+		return errors.New("nothing to migrate: database.url (DATABASE_URL) is memory")
+	}
+	db, err := database.OpenLazy(cfg.Database.Driver, cfg.Database.URL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := migrations.Run(ctx, db, args, os.Stdout); err != nil {
+		if errors.Is(err, migrations.ErrUsage) {
+			return fmt.Errorf("%w: usage: taskctl migrate %s", errUsage, migrations.CommandUsage)
+		}
+		return err
+	}
+	return nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -2,122 +2,894 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql/driver"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"runtime"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
-
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redis/go-redis/v9"
 
+	"taskmanager/internal/breaker"
+	"taskmanager/internal/cache"
+	"taskmanager/internal/config"
+	"taskmanager/internal/database"
 	"taskmanager/internal/handler"
+	"taskmanager/internal/jobs"
+	"taskmanager/internal/leader"
+	"taskmanager/internal/listener"
+	"taskmanager/internal/logging"
 	"taskmanager/internal/metric"
+	"taskmanager/internal/middleware"
+	"taskmanager/internal/outbox"
+	"taskmanager/internal/problem"
 	"taskmanager/internal/repositories"
+	"taskmanager/internal/retention"
+	"taskmanager/internal/retry"
+	"taskmanager/internal/sandbox"
+	"taskmanager/internal/secrets"
+	"taskmanager/internal/sentry"
 	"taskmanager/internal/service"
 	"taskmanager/migrations"
 )
 
+// Set at build time via -ldflags "-X main.version=... -X main.commit=...".
+var (
+	version = "dev"
+	commit  = "unknown"
+)
+
 func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or JSON config file (env: CONFIG_FILE)")
+	flag.Parse()
+
+	// Configuration: defaults < config file < environment variables
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		var verr *config.ValidationError
+		if errors.As(err, &verr) {
+			fatal(slog.Default(), "invalid configuration", "problems", verr.Problems)
+		}
+		fatal(slog.Default(), "failed to load configuration", "err", err)
+	}
+
+	// Structured JSON logging; the standard library logger is routed through it too
+	logger, logs, err := logging.New(os.Stdout, cfg.Log.Level)
+	if err != nil {
+		fatal(slog.Default(), "invalid log level", "err", err)
+	}
+	slog.SetDefault(logger)
+
+	// The reloadable settings (config.Reloadable) are read from the store's
+	// current snapshot; it is created before secrets are copied into cfg
+	store := config.NewStore(*configPath, cfg)
+
+	// Credentials kept in Vault or AWS Secrets Manager replace the configured ones
+	creds := loadSecrets(cfg, logger)
+
+	if flag.Arg(0) == "migrate" {
+		os.Exit(runMigrate(context.Background(), cfg, logger, flag.Args()[1:]))
+	}
+
+	// Stop background work and the HTTP server on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// SIGHUP reloads the configuration once everything is wired up (below)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	if creds != nil && cfg.Secrets.RefreshInterval.Duration > 0 {
+		go creds.Run(ctx, cfg.Secrets.RefreshInterval.Duration)
+	}
 
 	// Init Metrics
+	metric.SetLatencyBuckets(cfg.Metrics.LatencyBuckets)
 	metric.InitMetrics()
+	metric.SetBuildInfo(version, commit)
+	startedAt := time.Now().UTC()
 
-	// Configuration via environment variables
-	dbURL := getenv("DATABASE_URL", "")
-	redisAddr := getenv("REDIS_ADDR", "")
-	port := getenv("PORT", "8080")
+	// Storage: Postgres or MySQL (or SQLite for local development), or no
+	// database at all with DATABASE_URL=memory
+	var (
+		db            *sqlx.DB
+		repo          repositories.TaskRepository
+		incidents     repositories.IncidentRepository
+		watchers      repositories.WatcherRepository
+		views         repositories.ViewRepository
+		timeEntries   repositories.TimeEntryRepository
+		tombstones    repositories.TombstoneRepository
+		checks        []handler.DependencyCheck
+		schemaVersion func(ctx context.Context) (int, error)
+	)
+	if cfg.Database.InMemory() {
+		if !cfg.Sandbox.Enabled {
+			logger.Warn("using the in-memory repository — tasks are lost on exit and no outbox events are recorded")
+		}
+		repo = repositories.NewMemoryTaskRepository()
+		incidents = repositories.NewMemoryIncidentRepository()
+		watchers = repositories.NewMemoryWatcherRepository(repo)
+		views = repositories.NewMemoryViewRepository()
+		timeEntries = repositories.NewMemoryTimeEntryRepository(repo)
+		tombstones = repositories.NewMemoryTombstoneRepository(repo)
+	} else {
+		// every query is timed (db_query_*); circuit breakers, wrapped around
+		// that, fail database calls fast while it is down or overloaded
+		wrap := []func(driver.Connector) driver.Connector{database.Instrument}
+		replicaWrap := []func(driver.Connector) driver.Connector{database.Instrument}
+		if cb := cfg.CircuitBreaker; cb.FailureThreshold > 0 {
+			guard := func(name string) func(driver.Connector) driver.Connector {
+				br := breaker.New(name, cb.FailureThreshold, cb.Cooldown.Duration)
+				checks = append(checks, handler.DependencyCheck{Name: name + "_circuit", Required: true, Check: br.Check})
+				return br.WrapConnector
+			}
+			wrap = append(wrap, guard(cfg.Database.Driver))
+			if cfg.Database.ReplicaURL != "" {
+				replicaWrap = append(replicaWrap, guard(cfg.Database.Driver+"_replica"))
+			}
+		}
+		var dbReady *atomic.Bool
+		db, dbReady = openDatabase(ctx, cfg, logger, creds.Source("database.url", cfg.Database.URL), wrap...)
+		defer db.Close()
+		repo = repositories.NewTaskRepository(db)
+		incidents = repositories.NewIncidentRepository(db)
+		watchers = repositories.NewWatcherRepository(db)
+		views = repositories.NewViewRepository(db)
+		timeEntries = repositories.NewTimeEntryRepository(db, repo)
+		tombstones = repositories.NewTombstoneRepository(db)
+		// Dependency checks for /readyz; Redis is optional (the service runs uncached without it)
+		checks = append(checks, handler.DependencyCheck{Name: cfg.Database.Driver, Required: true, Check: func(ctx context.Context) error {
+			if !dbReady.Load() {
+				return errors.New("waiting for the database to come up and migrate")
+			}
+			return db.PingContext(ctx)
+		}})
+		schemaVersion = func(ctx context.Context) (int, error) { return migrations.Version(ctx, db) }
 
-	// Connect to Postgres
-	db, err := sqlx.Connect("postgres", dbURL)
-	if err != nil {
-		log.Fatalf("unable to connect to database: %v", err)
+		// Optional read replica for task reads; migrations only run on the primary
+		if cfg.Database.ReplicaURL != "" {
+			// not pinged here: until the replica answers, its readiness check fails
+			replica, err := database.OpenRotating(cfg.Database.Driver, creds.Source("database.replica_url", cfg.Database.ReplicaURL), replicaWrap...)
+			if err != nil {
+				fatal(logger, "invalid read replica configuration", "driver", cfg.Database.Driver, "err", err)
+			}
+			defer replica.Close()
+			setPoolLimits(replica, cfg.Database)
+			metric.RegisterDBStats(replica.DB, "taskmanager_replica")
+			if rr, ok := repo.(interface {
+				SetReadReplica(*sqlx.DB, time.Duration)
+			}); ok {
+				rr.SetReadReplica(replica, cfg.Database.ReadYourWrites.Duration)
+			}
+			checks = append(checks, handler.DependencyCheck{Name: cfg.Database.Driver + "_replica", Required: true, Check: replica.PingContext})
+			logger.Info("task reads routed to the read replica", "read_your_writes", cfg.Database.ReadYourWrites.String())
+		}
 	}
-	defer db.Close()
 
-	// Ensure schema (migration-lite)
-	if err := migrations.EnsureSchema(db); err != nil {
-		log.Fatalf("failed to ensure schema: %v", err)
+	// Repository / Service / Handler wiring
+	if cr, ok := repo.(interface {
+		SetCacheOptions(repositories.CacheOptions)
+	}); ok {
+		cr.SetCacheOptions(repositories.CacheOptions{
+			ListTTL:         cfg.Cache.ListTTL.Duration,
+			ItemTTL:         cfg.Cache.ItemTTL.Duration,
+			NegativeTTL:     cfg.Cache.NegativeTTL.Duration,
+			Jitter:          cfg.Cache.TTLJitter.Duration,
+			StaleTTL:        cfg.Cache.StaleTTL.Duration,
+			LocalMaxEntries: cfg.Cache.LocalMaxEntries,
+			LocalTTL:        cfg.Cache.LocalTTL.Duration,
+			PubSub:          cfg.Cache.PubSub,
+		})
+	}
+	sortFields, err := repositories.ParseSort(cfg.List.DefaultSort)
+	if err != nil {
+		fatal(logger, "invalid list.default_sort (LIST_DEFAULT_SORT)", "err", err)
+	}
+	if sr, ok := repo.(interface {
+		SetDefaultSort([]repositories.SortField)
+	}); ok {
+		sr.SetDefaultSort(sortFields)
 	}
 
-	// initialize tasks_count metric
-	if err := metric.UpdateTasksCountFromDB(db); err != nil {
-		log.Printf("warning: failed to update tasks_count metric: %v", err)
+	svc := service.NewTaskService(repo)
+	if cfg.Tasks.WIPLimit > 0 {
+		if wl, ok := svc.(interface{ SetWIPLimit(int) }); ok {
+			wl.SetWIPLimit(cfg.Tasks.WIPLimit)
+		}
+		logger.Info("WIP limit enabled", "open_tasks_per_assignee", cfg.Tasks.WIPLimit)
 	}
 
-	// Repository / Service / Handler wiring
-	repo := repositories.NewTaskRepository(db)
+	// Remaining work of open tasks per assignee, computed on every scrape
+	metric.RegisterRemainingWork(func(ctx context.Context) (map[string]int64, error) {
+		open := false
+		stats, err := svc.Stats(ctx, repositories.TaskFilter{Completed: &open})
+		if err != nil {
+			return nil, err
+		}
+		minutes := make(map[string]int64, len(stats.ByAssignee))
+		for _, g := range stats.ByAssignee {
+			name := "none"
+			if g.Assignee != nil {
+				name = *g.Assignee
+			}
+			minutes[name] += g.RemainingMinutes
+		}
+		return minutes, nil
+	})
+
+	// Sandbox mode: demo data in memory, re-seeded every reset_interval
+	if cfg.Sandbox.Enabled {
+		sb, err := sandbox.New(repo, incidents)
+		if err != nil {
+			fatal(logger, "sandbox setup failed", "err", err)
+		}
+		if err := sb.Reset(ctx); err != nil {
+			fatal(logger, "sandbox seeding failed", "err", err)
+		}
+		if d := cfg.Sandbox.ResetInterval.Duration; d > 0 {
+			go sb.Run(ctx, d)
+		}
+		logger.Warn("sandbox mode — demo data only, outbound integrations disabled", "reset_interval", cfg.Sandbox.ResetInterval.String())
+	}
 
 	// Redis cache-aside for list endpoints
-	// Accepts REDIS_ADDR like "localhost:6379" or "redis://localhost:6379"
-	redisAddr = strings.TrimPrefix(redisAddr, "redis://")
-	rdb := redis.NewClient(&redis.Options{
-		Addr: redisAddr,
-	})
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-	if err := rdb.Ping(ctx).Err(); err != nil {
-		log.Printf("redis not available at %s: %v — continuing without cache", redisAddr, err)
+	// Accepts redis.addr like "localhost:6379" or "redis://localhost:6379"; empty disables the cache
+	if redisAddr := strings.TrimPrefix(cfg.Redis.Addr, "redis://"); redisAddr == "" {
+		logger.Info("redis not configured — continuing without cache")
+	} else if db == nil {
+		logger.Info("in-memory repository — redis cache not used")
 	} else {
-		// attach to repository (repo will no-op if not supported)
-		repo.SetCacheClient(rdb)
-		log.Printf("redis cache enabled (addr=%s)", redisAddr)
+		opts := &redis.Options{
+			Addr:         redisAddr,
+			Password:     cfg.Redis.Password,
+			DB:           cfg.Redis.DB,
+			PoolSize:     cfg.Redis.PoolSize,
+			MinIdleConns: cfg.Redis.MinIdleConns,
+		}
+		if _, ok := creds.Get("redis.password"); ok {
+			// new connections authenticate with the latest password
+			password := creds.Source("redis.password", cfg.Redis.Password)
+			opts.CredentialsProvider = func() (string, string) { return "", password() }
+		}
+		rdb := redis.NewClient(opts)
+		defer rdb.Close()
+		metric.RegisterRedisPoolStats(rdb)
+		// a dead Redis fails fast (cache misses) instead of each call waiting for its timeout
+		if cfg.CircuitBreaker.FailureThreshold > 0 {
+			br := breaker.New("redis", cfg.CircuitBreaker.FailureThreshold, cfg.CircuitBreaker.Cooldown.Duration)
+			rdb.AddHook(breaker.RedisHook(br))
+			checks = append(checks, handler.DependencyCheck{Name: "redis_circuit", Required: cfg.Redis.Required, Check: br.Check})
+		}
+		checks = append(checks, handler.DependencyCheck{
+			Name:     "redis",
+			Required: cfg.Redis.Required,
+			Check:    func(ctx context.Context) error { return rdb.Ping(ctx).Err() },
+		})
+		// The watcher attaches the cache (service forwards the client to the
+		// repository) once Redis answers and detaches it on sustained failures.
+		watcher := cache.NewWatcher(rdb, svc.SetCacheClient, cfg.Redis.FailureThreshold)
+		if cfg.Redis.Required {
+			if err := retry.Do(ctx, retry.Policy{Timeout: cfg.Redis.ConnectTimeout.Duration}, "redis", watcher.Check); err != nil {
+				fatal(logger, "redis not available", "addr", redisAddr, "err", err)
+			}
+		} else if err := watcher.Check(ctx); err != nil {
+			logger.Warn("redis not available — continuing without cache until it recovers", "addr", redisAddr, "err", err)
+		}
+		go watcher.Run(ctx, cfg.Redis.ReconnectInterval.Duration)
+		// follow the other instances' writes in the L1 cache
+		if l, ok := repo.(repositories.InvalidationListener); ok && cfg.Cache.PubSub {
+			go l.ListenInvalidations(ctx, rdb)
+		}
 	}
 
-	svc := service.NewTaskService(repo)
-	// If service exposes SetCacheClient, forward rdb (service will call repo.SetCacheClient)
-	// Note: service interface in this codebase implements SetCacheClient on the struct method.
-	// Use type assertion to avoid compile-time dependency on optional method
-	if ss, ok := svc.(interface{ SetCacheClient(*redis.Client) }); ok {
-		ss.SetCacheClient(rdb)
+	h := handler.NewTaskHandler(svc)
+	// legacy clients: GET /tasks as a bare array, pagination in headers only
+	h.SetBareListResponses(cfg.Feature("bare_list_responses"))
+	// ?include=watchers,time_entries on task reads
+	h.SetRelatedLoader(service.NewRelatedLoader(watchers, timeEntries))
+
+	// Reloaded settings held outside the config snapshot; a reload also
+	// undoes PUT /admin/log-level
+	store.OnReload(func(c *config.Config) {
+		lvl, _ := logging.ParseLevel(c.Log.Level)
+		logs.SetLevel(lvl)
+		logs.LogBodiesUntil(time.Time{})
+		if cr, ok := repo.(interface {
+			SetCacheTTLs(repositories.CacheOptions)
+		}); ok {
+			cr.SetCacheTTLs(repositories.CacheOptions{
+				ListTTL:     c.Cache.ListTTL.Duration,
+				ItemTTL:     c.Cache.ItemTTL.Duration,
+				NegativeTTL: c.Cache.NegativeTTL.Duration,
+				Jitter:      c.Cache.TTLJitter.Duration,
+				StaleTTL:    c.Cache.StaleTTL.Duration,
+			})
+		}
+		if wl, ok := svc.(interface{ SetWIPLimit(int) }); ok {
+			wl.SetWIPLimit(c.Tasks.WIPLimit)
+		}
+		h.SetBareListResponses(c.Feature("bare_list_responses"))
+	})
+	health := handler.NewHealthHandler(time.Second, schemaVersion, checks...)
+
+	// the task gauges are recomputed from the database rather than tracked
+	// per write, so every replica reports the real figures
+	go metric.NewTaskGauges(func(ctx context.Context) (metric.TaskFigures, error) {
+		return taskFigures(ctx, repo)
+	}, cfg.Metrics.RefreshInterval.Duration, cfg.Metrics.TopAssignees).Run(ctx)
+
+	// Status page data: sample health/availability every 30s and keep one hour
+	status := handler.NewStatusHandler(health, incidents, metric.AvailabilityCounts, 120)
+	go status.Run(ctx, 30*time.Second)
+
+	// Leader election among the replicas: only the leader relays the outbox
+	// and schedules retention runs
+	var elector *leader.Elector
+	if db != nil {
+		elector = leader.New(db, "schedulers", 5*time.Second)
+		go elector.Run(ctx)
 	}
 
-	h := handler.NewTaskHandler(svc)
+	// Outbox relay: publishes events written alongside task changes, on the
+	// leader only so that events go out in id order.
+	// outbox.publisher selects the broker ("log" by default, "nats", or "none").
+	if cfg.Outbox.Publisher == "none" || db == nil {
+		logger.Info("outbox relay disabled")
+	} else {
+		var pub outbox.Publisher = outbox.LogPublisher{}
+		if cfg.Outbox.Publisher == "nats" {
+			np := outbox.NewNATSPublisher(cfg.Outbox.NATSURL, cfg.Outbox.NATSSubjectPrefix)
+			defer np.Close()
+			pub = np
+		}
+		relay := outbox.NewRelay(db, pub, cfg.Outbox.PollInterval.Duration)
+		relay.SetLeader(elector.IsLeader)
+		go relay.Run(ctx)
+		logger.Info("outbox relay started", "publisher", cfg.Outbox.Publisher)
+	}
+
+	// Retention policy: purge old completed tasks and tombstones every
+	// retention.interval; admins can also trigger a run through the API
+	retainer := retention.New(svc, retention.Policy{
+		PurgeCompletedAfter:  cfg.Retention.PurgeCompletedAfter.Duration,
+		PurgeTombstonesAfter: cfg.Retention.PurgeTombstonesAfter.Duration,
+	})
+	retainer.SetTombstones(tombstones)
 
-	// Gin router setup
+	// Job queue: asynchronous work stored in the jobs table, retried with
+	// backoff and dead-lettered to dead_jobs. Scheduled retention runs go
+	// through it so a failed purge is retried.
+	if db != nil {
+		// only the leader schedules retention runs; the jobs themselves may
+		// run on any instance
+		retainer.SetLeader(elector.IsLeader)
+
+		jw := jobs.NewWorker(db, cfg.Jobs.Concurrency, cfg.Jobs.PollInterval.Duration)
+		policy := jobs.RetryPolicy{MaxAttempts: cfg.Jobs.MaxAttempts, Backoff: cfg.Jobs.Backoff.Duration, MaxBackoff: jobs.DefaultRetryPolicy.MaxBackoff}
+		jw.Handle(retention.JobKind, policy, retainer.HandleJob)
+		retainer.SetEnqueue(func(ctx context.Context) error {
+			return jobs.Enqueue(ctx, db, retention.JobKind, nil)
+		})
+		metric.RegisterJobQueue(func(ctx context.Context) (map[string]int64, map[string]int64, error) {
+			return jobs.Counts(ctx, db)
+		})
+		go jw.Run(ctx)
+		logger.Info("job worker started", "concurrency", cfg.Jobs.Concurrency, "kinds", jw.Kinds())
+	}
+	if retainer.Policy().Enabled() {
+		go retainer.Run(ctx, cfg.Retention.Interval.Duration)
+		logger.Info("retention policy enabled", "purge_completed_after", cfg.Retention.PurgeCompletedAfter.String(), "purge_tombstones_after", cfg.Retention.PurgeTombstonesAfter.String(), "interval", cfg.Retention.Interval.String())
+	}
+
+	// Gin router setup; panics and unknown routes answer with problem+json
+	// like every other error
 	gin.SetMode(gin.ReleaseMode)
-	r := gin.New()
-	r.Use(gin.Recovery())
-	r.Use(gin.Logger())
+	// recovery runs inside logging.Middleware so a panic is logged with its
+	// request id and still gets an access log line; http.ErrAbortHandler is
+	// passed on so the server drops the connection (see ExportHandler)
+	recovery := gin.CustomRecovery(func(c *gin.Context, err any) {
+		if err == http.ErrAbortHandler {
+			panic(err)
+		}
+		logging.FromContext(c.Request.Context()).Error("panic", "err", fmt.Sprint(err))
+		problem.Abort(c, http.StatusInternalServerError, "internal server error")
+	})
+	noRoute := func(c *gin.Context) {
+		problem.Abort(c, http.StatusNotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path)
+	}
+	// optional error tracking of panics and 5xx responses
+	var reporter *sentry.Client
+	if cfg.Sentry.DSN != "" {
+		var err error
+		if reporter, err = sentry.New(cfg.Sentry.DSN, cfg.Sentry.Environment, version); err != nil {
+			fatal(logger, "invalid sentry configuration", "err", err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			reporter.Close(ctx)
+		}()
+		logger.Info("error reporting to sentry enabled", "environment", cfg.Sentry.Environment)
+	}
+	chain := []gin.HandlerFunc{logging.Middleware(logger, logs), recovery}
+	if reporter != nil {
+		chain = append(chain, reporter.Middleware())
+	}
+	r := newRouter(cfg, logger, noRoute, chain...)
 	r.Use(metric.PrometheusMiddleware())
+	r.Use(middleware.CORSFrom(func() ([]string, []string, []string) {
+		c := store.Current().CORS
+		return c.AllowedOrigins, c.AllowedMethods, c.AllowedHeaders
+	}))
 
-	// Health
-	r.GET("/health", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) })
+	// Every public route lives under server.base_path (empty by default)
+	root := r.Group(cfg.Server.BasePath)
+
+	// Internal endpoints (readiness, metrics) move to a separate router with
+	// its own middleware chain when admin.listen is set; otherwise they are
+	// served next to the API as before.
+	internal := root
+	var adminRouter *gin.Engine
+	if cfg.Admin.Listen != "" {
+		adminRouter = newRouter(cfg, logger, noRoute, chain...)
+		internal = &adminRouter.RouterGroup
+		internal.GET("/livez", health.Livez)
+	}
+
+	// Probes: liveness never touches dependencies, readiness does; /healthz
+	// is the same dependency report for checkers that expect that name
+	root.GET("/livez", health.Livez)
+	internal.GET("/readyz", health.Readyz)
+	internal.GET("/healthz", health.Readyz)
+	root.GET("/statusz", status.Statusz)
 
 	// Prometheus metrics
-	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	internal.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	// pprof and runtime stats; without an admin listener they move under
+	// /api/v1/admin below
+	if adminRouter != nil && cfg.Admin.Debug {
+		handler.RegisterDebug(internal)
+	}
 
-	// Serve OpenAPI spec and minimal Swagger UI
-	r.StaticFile("/docs/openapi.yaml", "/app/docs/openapi.yaml")
-	r.GET("/docs", func(c *gin.Context) { c.File("/app/docs/swagger.html") })
+	// Serve OpenAPI spec and minimal Swagger UI (links rewritten for the base path)
+	if docs, err := handler.NewDocsHandler("/app/docs", cfg.Server.BasePath); err != nil {
+		logger.Warn("API docs not available", "err", err)
+	} else {
+		root.GET("/docs/openapi.yaml", docs.Spec)
+		root.GET("/docs", docs.UI)
+	}
 
 	// API v1
-	api := r.Group("/api/v1")
+	api := root.Group("/api/v1")
+	api.Use(
+		middleware.BodyLimitFrom(func() int64 { return store.Current().Server.MaxBodyBytes }),
+		// the export streams for as long as the dataset takes
+		middleware.TimeoutFrom(func() (time.Duration, time.Duration) {
+			c := store.Current().Server
+			return c.RequestTimeout.Duration, c.TimeoutReserve.Duration
+		}, api.BasePath()+"/tasks/export"),
+	)
+	var auth []gin.HandlerFunc
+	if cfg.Auth.Enabled() {
+		keys := creds.Source("auth.api_keys", strings.Join(cfg.Auth.APIKeys, ","))
+		adminKeys := creds.Source("auth.admin_keys", strings.Join(cfg.Auth.AdminKeys, ","))
+		auth = append(auth, middleware.APIKeyAuthFrom(func() ([]string, []string) {
+			return splitKeys(keys()), splitKeys(adminKeys())
+		}))
+		api.Use(auth...)
+	}
+	if cfg.Server.StrictJSON {
+		api.Use(middleware.StrictJSON())
+	}
+	// read-only mode, toggled at runtime through the admin API; batch-get is a
+	// read sent as POST, and the operations of /batch are checked one by one
+	var readOnly atomic.Bool
+	api.Use(middleware.ReadOnly(&readOnly, api.BasePath()+"/admin", api.BasePath()+"/tasks/batch-get", api.BasePath()+"/batch"))
 	{
 		api.POST("/tasks", h.CreateTask)
 		api.GET("/tasks", h.ListTasks)
-		api.GET("/tasks/:id", h.GetTask)
-		api.PUT("/tasks/:id", h.UpdateTask)
-		api.DELETE("/tasks/:id", h.DeleteTask)
+		api.DELETE("/tasks", h.DeleteCompletedTasks)
+		api.GET("/tasks/stats", h.TaskStats)
+		api.POST("/tasks/reassign", h.ReassignTasks)
+		api.POST("/tasks/batch-get", h.BatchGetTasks)
+		if exporter, ok := repo.(handler.TaskExporter); ok {
+			api.GET("/tasks/export", handler.NewExportHandler(exporter, cfg.Server.WriteTimeout.Duration).Export)
+		}
+
+		// single-task routes also take the task key (TM-1042) as :id
+		task := api.Group("/tasks/:id", handler.ResolveTaskKey(repo))
+		task.GET("", h.GetTask)
+		task.PUT("", h.UpdateTask)
+		task.DELETE("", h.DeleteTask)
+		task.POST("/complete", h.CompleteTask)
+		task.POST("/reopen", h.ReopenTask)
+		task.POST("/duplicate", h.DuplicateTask)
+		task.POST("/move", h.MoveTask)
+
+		wh := handler.NewWatcherHandler(watchers)
+		task.GET("/watchers", wh.ListWatchers)
+		task.POST("/watchers", wh.AddWatcher)
+		task.DELETE("/watchers", wh.RemoveWatcher)
+
+		th := handler.NewTimeHandler(timeEntries)
+		task.POST("/timer/start", th.StartTimer)
+		task.POST("/timer/stop", th.StopTimer)
+		task.GET("/time-entries", th.ListTimeEntries)
+		task.POST("/time-entries", th.AddTimeEntry)
+		api.GET("/reports/time", th.TimeReport)
+
+		vh := handler.NewViewHandler(views, h)
+		api.POST("/views", vh.CreateView)
+		api.GET("/views", vh.ListViews)
+		api.GET("/views/:id", vh.GetView)
+		api.DELETE("/views/:id", vh.DeleteView)
+		api.GET("/views/:id/tasks", vh.ListViewTasks)
+
+		// long-poll change log, sync deltas and activity feed over the outbox;
+		// the memory backend has none
+		if db != nil {
+			feed := outbox.NewFeed(db, cfg.Outbox.PollInterval.Duration)
+			feed.SetLag(cfg.Outbox.FeedLag.Duration)
+			changes := handler.NewChangesHandler(feed)
+			api.GET("/changes", changes.Changes)
+			sync := handler.NewSyncHandler(feed, svc, tombstones)
+			api.GET("/tasks/changes", sync.TaskChanges)
+
+			activity := handler.NewActivityHandler(feed)
+			api.GET("/activity", activity.Activity)
+			task.GET("/activity", activity.TaskActivity)
+		}
+
+		// several operations in one round trip, run through r like separate
+		// requests
+		api.POST("/batch", handler.NewBatchHandler(r, api.BasePath(), svc).Batch)
+
+		// operational actions, admin keys and identities only
+		admin := adminGroup(api, adminRouter, auth, func() int64 { return store.Current().Server.MaxBodyBytes })
+		flusher, _ := repo.(handler.CacheFlusher)
+		build := handler.BuildInfo{Version: version, Commit: commit, GoVersion: runtime.Version(), StartedAt: startedAt}
+		ah := handler.NewAdminHandler(build, flusher, svc, retainer, &readOnly, logs, store)
+		admin.GET("/build", ah.Build)
+		admin.POST("/cache/flush", ah.FlushCache)
+		admin.POST("/tasks-count/resync", ah.ResyncTaskCount)
+		admin.POST("/retention/run", ah.RunRetention)
+		admin.GET("/read-only", ah.ReadOnly)
+		admin.PUT("/read-only", ah.SetReadOnly)
+		admin.GET("/log-level", ah.LogLevel)
+		admin.PUT("/log-level", ah.SetLogLevel)
+		admin.POST("/config/reload", ah.ReloadConfig)
+		// incidents are published on the public status page
+		admin.POST("/incidents", status.CreateIncident)
+		admin.POST("/incidents/:id/resolve", status.ResolveIncident)
+		if adminRouter == nil && cfg.Admin.Debug {
+			handler.RegisterDebug(admin)
+		}
 	}
 
-	addr := fmt.Sprintf(":%s", port)
-	log.Printf("starting server on %s", addr)
-	log.Printf("OpenAPI UI available at http://localhost%s/docs", addr)
-	if err := r.Run(addr); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("server exited: %v", err)
+	go reloadOnSIGHUP(ctx, store, hup)
+
+	newServer := func(name string, routes http.Handler, spec, certFile, keyFile string) *server {
+		ln, err := listener.Listen(spec)
+		if err != nil {
+			fatal(logger, "failed to listen", "server", name, "listen", spec, "err", err)
+		}
+		return &server{
+			name: name,
+			ln:   ln,
+			srv: &http.Server{
+				Handler:      routes,
+				ReadTimeout:  cfg.Server.ReadTimeout.Duration,
+				WriteTimeout: cfg.Server.WriteTimeout.Duration,
+				IdleTimeout:  cfg.Server.IdleTimeout.Duration,
+			},
+			certFile: certFile,
+			keyFile:  keyFile,
+		}
+	}
+	servers := []*server{newServer("public", r, cfg.Server.ListenSpec(), cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)}
+	var tlsConfig *tls.Config
+	if cfg.Server.TLSClientCAFile != "" {
+		var err error
+		if tlsConfig, err = clientAuthTLS(cfg.Server); err != nil {
+			fatal(logger, "invalid client CA", "file", cfg.Server.TLSClientCAFile, "err", err)
+		}
+		servers[0].srv.TLSConfig = tlsConfig
+		logger.Info("client certificate authentication enabled", "mode", cfg.Server.TLSClientAuth)
+	}
+	if adminRouter != nil {
+		admin := newServer("admin", adminRouter, cfg.Admin.Listen, cfg.Admin.TLSCertFile, cfg.Admin.TLSKeyFile)
+		if tlsConfig != nil {
+			// probes and metric scrapers connect without a certificate
+			admin.srv.TLSConfig = tlsConfig.Clone()
+			admin.srv.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+		servers = append(servers, admin)
+	}
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		health.SetShuttingDown()
+		logger.Info("shutting down", "timeout", cfg.Server.ShutdownTimeout.String())
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Duration)
+		defer cancel()
+		// one at a time, public first: the admin listener keeps answering
+		// /readyz (503 from now on) and /metrics while the API drains
+		for _, s := range servers {
+			if err := s.srv.Shutdown(shutdownCtx); err != nil {
+				logger.Error("graceful shutdown failed", "server", s.name, "err", err)
+			}
+		}
+	}()
+
+	serveErr := make(chan error, len(servers))
+	for _, s := range servers {
+		logger.Info("starting server", "server", s.name, "addr", s.ln.Addr().String(), "network", s.ln.Addr().Network(), "tls", s.certFile != "")
+		go func(s *server) {
+			if err := s.serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serveErr <- fmt.Errorf("%s server: %w", s.name, err)
+			}
+		}(s)
+	}
+	select {
+	case err := <-serveErr:
+		fatal(logger, "server exited", "err", err)
+	case <-shutdownDone:
+	}
+}
+
+// openDatabase connects to the primary database, retrying with backoff for
+// database.connect_timeout, and applies the migrations; the returned flag is
+// set once both are done. With database.start_degraded a database that is
+// still down does not stop startup: the wait continues in the background.
+func openDatabase(ctx context.Context, cfg *config.Config, logger *slog.Logger, dsn func() string, wrap ...func(driver.Connector) driver.Connector) (*sqlx.DB, *atomic.Bool) {
+	db, err := database.OpenRotating(cfg.Database.Driver, dsn, wrap...)
+	if err != nil {
+		fatal(logger, "invalid database configuration", "driver", cfg.Database.Driver, "err", err)
+	}
+	if cfg.Database.Driver != database.SQLite {
+		setPoolLimits(db, cfg.Database)
+	}
+	metric.RegisterDBStats(db.DB, "taskmanager")
+
+	ready := new(atomic.Bool)
+	ping := func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		return db.PingContext(ctx)
+	}
+	err = retry.Do(ctx, retry.Policy{Timeout: cfg.Database.ConnectTimeout.Duration}, "database", ping)
+	if err == nil {
+		migrateDatabase(ctx, db, logger)
+		ready.Store(true)
+		return db, ready
+	}
+	if !cfg.Database.StartDegraded {
+		fatal(logger, "unable to connect to database", "driver", cfg.Database.Driver, "err", err)
+	}
+	logger.Warn("starting degraded — the database is not reachable yet; /readyz fails until it is", "driver", cfg.Database.Driver, "err", err)
+	go func() {
+		if retry.Do(ctx, retry.Policy{}, "database", ping) != nil {
+			return
+		}
+		migrateDatabase(ctx, db, logger)
+		ready.Store(true)
+		logger.Info("database ready — leaving degraded mode")
+	}()
+	return db, ready
+}
+
+// migrateDatabase applies the pending migrations. A failing migration is not
+// a transient problem, so it is fatal.
+func migrateDatabase(ctx context.Context, db *sqlx.DB, logger *slog.Logger) {
+	migrator, err := migrations.New(db)
+	if err != nil {
+		fatal(logger, "failed to load migrations", "err", err)
+	}
+	if err := migrator.Up(ctx); err != nil {
+		fatal(logger, "failed to apply migrations", "err", err)
+	}
+}
+
+// taskFigures computes the task gauges with two grouped queries: all tasks
+// per assignee, and the open ones past their due date.
+func taskFigures(ctx context.Context, repo repositories.TaskRepository) (metric.TaskFigures, error) {
+	all, err := repo.Stats(ctx, repositories.TaskFilter{})
+	if err != nil {
+		return metric.TaskFigures{}, err
+	}
+	open, now := false, time.Now()
+	late, err := repo.Stats(ctx, repositories.TaskFilter{Completed: &open, Dates: repositories.DateRange{DueBefore: &now}})
+	if err != nil {
+		return metric.TaskFigures{}, err
+	}
+	f := metric.TaskFigures{Open: all.Count - all.Completed, Completed: all.Completed, Overdue: map[string]int{}}
+	for _, g := range late.ByAssignee {
+		name := ""
+		if g.Assignee != nil {
+			name = *g.Assignee
+		}
+		f.Overdue[name] += g.Count
+	}
+	return f, nil
+}
+
+// newRouter returns an engine with the middleware the public and admin
+// listeners share: chain (access log, recovery, error reporting) and, with
+// client certificates configured, the identity of the caller's certificate.
+func newRouter(cfg *config.Config, logger *slog.Logger, noRoute gin.HandlerFunc, chain ...gin.HandlerFunc) *gin.Engine {
+	e := gin.New()
+	trustProxies(e, cfg.Server, logger)
+	e.NoRoute(noRoute)
+	e.Use(chain...)
+	if cfg.Server.TLSClientCAFile != "" {
+		e.Use(middleware.ClientCert(cfg.Auth.AdminIdentities))
+	}
+	return e
+}
+
+// adminGroup returns the group of the admin API: /admin under api, or the
+// same path on adminRouter when there is an admin listener, so it is not
+// reachable from the public port. auth authenticates API keys there as it
+// does on api.
+func adminGroup(api *gin.RouterGroup, adminRouter *gin.Engine, auth []gin.HandlerFunc, maxBody func() int64) *gin.RouterGroup {
+	if adminRouter == nil {
+		return api.Group("/admin", middleware.RequireAdmin())
+	}
+	admin := adminRouter.Group("/api/v1/admin", middleware.BodyLimitFrom(maxBody))
+	admin.Use(auth...)
+	admin.Use(middleware.RequireAdmin())
+	return admin
+}
+
+// trustProxies sets where e takes the client IP (c.ClientIP, found in access
+// log lines and error reports) from: the platform header, then the remote IP
+// headers of requests coming from a trusted proxy, then the peer address.
+func trustProxies(e *gin.Engine, c config.ServerConfig, logger *slog.Logger) {
+	e.TrustedPlatform = c.PlatformHeader()
+	e.RemoteIPHeaders = c.RemoteIPHeaders
+	if err := e.SetTrustedProxies(c.TrustedProxies); err != nil {
+		fatal(logger, "invalid trusted proxies", "err", err)
+	}
+}
+
+// loadSecrets fetches the secret references of cfg.Secrets, fatally when one
+// cannot be resolved, and copies their values over the configured ones; the
+// returned set (nil without a provider) serves the later refreshes.
+func loadSecrets(cfg *config.Config, logger *slog.Logger) *secrets.Set {
+	var p secrets.Provider
+	switch sc := cfg.Secrets; sc.Provider {
+	case "vault":
+		p = secrets.NewVault(sc.Vault.Addr, sc.Vault.Token, sc.Vault.Mount)
+	case "aws":
+		p = secrets.NewAWS(sc.AWS.Region, sc.AWS.Endpoint, secrets.AWSCredentials{
+			AccessKeyID:     sc.AWS.AccessKeyID,
+			SecretAccessKey: sc.AWS.SecretAccessKey,
+			SessionToken:    sc.AWS.SessionToken,
+		})
+	default:
+		return nil
+	}
+	refs := cfg.Secrets.Refs()
+	set := secrets.NewSet(p, refs)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := set.Refresh(ctx); err != nil {
+		fatal(logger, "failed to load secrets", "provider", cfg.Secrets.Provider, "err", err)
+	}
+	for name, dst := range map[string]*string{
+		"database.url":         &cfg.Database.URL,
+		"database.replica_url": &cfg.Database.ReplicaURL,
+		"redis.password":       &cfg.Redis.Password,
+	} {
+		if v, ok := set.Get(name); ok {
+			*dst = v
+		}
+	}
+	if v, ok := set.Get("auth.api_keys"); ok {
+		cfg.Auth.APIKeys = splitKeys(v)
+	}
+	if v, ok := set.Get("auth.admin_keys"); ok {
+		cfg.Auth.AdminKeys = splitKeys(v)
+	}
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	logger.Info("secrets loaded", "provider", cfg.Secrets.Provider, "keys", names, "refresh_interval", cfg.Secrets.RefreshInterval.String())
+	return set
+}
+
+// splitKeys splits a comma-separated list of API keys.
+func splitKeys(s string) []string {
+	var keys []string
+	for _, k := range strings.Split(s, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// clientAuthTLS returns the TLS configuration verifying client certificates
+// against the CAs of c.TLSClientCAFile.
+func clientAuthTLS(c config.ServerConfig) (*tls.Config, error) {
+	pem, err := os.ReadFile(c.TLSClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no PEM certificates found")
+	}
+	mode := tls.RequireAndVerifyClientCert
+	if c.TLSClientAuth == "optional" {
+		mode = tls.VerifyClientCertIfGiven
+	}
+	return &tls.Config{ClientCAs: pool, ClientAuth: mode, MinVersion: tls.VersionTLS12}, nil
+}
+
+// reloadOnSIGHUP reloads the configuration on every signal received on hup.
+// A configuration that no longer loads is logged and ignored.
+func reloadOnSIGHUP(ctx context.Context, store *config.Store, hup <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+		res, err := store.Reload()
+		if err != nil {
+			slog.Error("SIGHUP: configuration not reloaded", "err", err)
+			continue
+		}
+		slog.Warn("SIGHUP: configuration reloaded", "applied", res.Applied, "restart_required", res.RestartRequired,
+			"log_level", store.Current().Log.Level)
 	}
 }
 
-// getenv returns environment variable or defaultVal
-func getenv(key, defaultVal string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+// setPoolLimits applies the database.* connection pool settings to db.
+func setPoolLimits(db *sqlx.DB, c config.DatabaseConfig) {
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime.Duration)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime.Duration)
+}
+
+// server is one HTTP listener (public API or internal admin endpoints).
+type server struct {
+	name              string
+	srv               *http.Server
+	ln                net.Listener
+	certFile, keyFile string
+}
+
+func (s *server) serve() error {
+	if s.certFile != "" {
+		return s.srv.ServeTLS(s.ln, s.certFile, s.keyFile)
 	}
-	return defaultVal
+	return s.srv.Serve(s.ln)
+}
+
+// fatal logs msg at error level and exits.
+func fatal(l *slog.Logger, msg string, args ...any) {
+	l.Error(msg, args...)
+	os.Exit(1)
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/config"
+	"taskmanager/internal/handler"
+	"taskmanager/internal/middleware"
+	"taskmanager/internal/repositories"
+)
+
+func TestAdminGroup_AdminCertificateOnAdminListener(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Server.TLSClientCAFile = "ca.pem"
+	cfg.Auth.AdminIdentities = []string{"ops-bot"}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	public := newRouter(cfg, logger, func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	adminRouter := newRouter(cfg, logger, func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	// API keys are off, so a certificate is the only way in
+	admin := adminGroup(public.Group("/api/v1"), adminRouter, nil, func() int64 { return 1 << 20 })
+	admin.GET("/build", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(e *gin.Engine, cn string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/build", nil)
+		if cn != "" {
+			cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := do(adminRouter, "ops-bot"); code != http.StatusOK {
+		t.Fatalf("expected the admin certificate let in got %d", code)
+	}
+	if code := do(adminRouter, "billing"); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a non-admin certificate got %d", code)
+	}
+	if code := do(adminRouter, ""); code != http.StatusForbidden {
+		t.Fatalf("expected 403 without a certificate got %d", code)
+	}
+	if code := do(public, "ops-bot"); code != http.StatusNotFound {
+		t.Fatalf("expected the admin API off the public port got %d", code)
+	}
+}
+
+func TestAdminGroup_IncidentsNeedAnAdminKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	auth := []gin.HandlerFunc{middleware.APIKeyAuth([]string{"user"}, []string{"root"})}
+	api := r.Group("/api/v1", auth...)
+	status := handler.NewStatusHandler(handler.NewHealthHandler(time.Second, nil), repositories.NewMemoryIncidentRepository(), func() (float64, float64) { return 0, 0 }, 1)
+	admin := adminGroup(api, nil, auth, func() int64 { return 1 << 20 })
+	admin.POST("/incidents", status.CreateIncident)
+
+	do := func(key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/incidents", strings.NewReader(`{"title":"Redis outage","severity":"major"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := do("user"); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a non-admin key got %d", code)
+	}
+	if code := do("root"); code != http.StatusCreated {
+		t.Fatalf("expected 201 for an admin key got %d", code)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"taskmanager/internal/config"
+	"taskmanager/internal/database"
+	"taskmanager/migrations"
+)
+
+const migrateUsage = "usage: taskmanager migrate " + migrations.CommandUsage
+
+// runMigrate implements the `migrate` subcommand and returns the exit code.
+func runMigrate(ctx context.Context, cfg *config.Config, logger *slog.Logger, args []string) int {
+	if cfg.Database.InMemory() {
+		logger.Error("nothing to migrate: database.url (DATABASE_URL) is memory")
+		return 1
+	}
+
+	// connects on first use, after the arguments have been checked
+	db, err := database.OpenLazy(cfg.Database.Driver, cfg.Database.URL)
+	if err != nil {
+		logger.Error("invalid database configuration", "err", err)
+		return 1
+	}
+	defer db.Close()
+
+	if err := migrations.Run(ctx, db, args, os.Stdout); err != nil {
+		if errors.Is(err, migrations.ErrUsage) {
+			fmt.Println(migrateUsage)
+			return 2
+		}
+		logger.Error("migration failed", "command", args[0], "err", err)
+		return 1
+	}
+	if args[0] != "version" {
+		v, _ := migrations.Version(ctx, db)
+		logger.Info("migration complete", "command", args[0], "version", v)
+	}
+	return 0
+}
@@ -0,0 +1,144 @@
+// Package breaker is a circuit breaker for the service's dependencies. After
+// FailureThreshold consecutive failures the breaker opens and calls fail
+// fast with ErrOpen instead of waiting on a dead Redis or a saturated
+// database; after the cooldown one probe call is let through (half-open) and
+// its outcome closes or re-opens the breaker. WrapConnector and RedisHook put
+// a breaker in front of every database and Redis call.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"taskmanager/internal/metric"
+)
+
+// ErrOpen is returned instead of calling a dependency whose breaker is open.
+var ErrOpen = errors.New("circuit breaker open")
+
+// State is the breaker state; its value is exported as the
+// circuit_breaker_state gauge.
+type State int
+
+const (
+	Closed State = iota
+	HalfOpen
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case HalfOpen:
+		return "half-open"
+	case Open:
+		return "open"
+	}
+	return "closed"
+}
+
+// Breaker guards one dependency.
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// New creates a closed breaker that opens after threshold consecutive
+// failures and probes again after cooldown.
+func New(name string, threshold int, cooldown time.Duration) *Breaker {
+	b := &Breaker{name: name, threshold: max(threshold, 1), cooldown: cooldown, now: time.Now}
+	metric.CircuitBreakerState.WithLabelValues(name).Set(float64(Closed))
+	return b
+}
+
+// Name returns the dependency the breaker guards.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the current state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a call may go ahead, returning ErrOpen if not. Every
+// allowed call must be followed by Record.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case Open:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			break
+		}
+		b.setState(HalfOpen)
+		fallthrough
+	case HalfOpen:
+		if b.probing {
+			break
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+	metric.CircuitBreakerRejections.WithLabelValues(b.name).Inc()
+	return ErrOpen
+}
+
+// Record reports the outcome of an allowed call.
+func (b *Breaker) Record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == HalfOpen {
+		b.probing = false
+		if failed {
+			b.open()
+		} else {
+			b.failures = 0
+			b.setState(Closed)
+			slog.Info("circuit breaker closed", "dependency", b.name)
+		}
+		return
+	}
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == Closed && b.failures >= b.threshold {
+		b.open()
+		slog.Warn("circuit breaker opened", "dependency", b.name, "failures", b.failures, "cooldown", b.cooldown.String())
+	}
+}
+
+// Check fails while the breaker is open, for the /readyz dependency checks.
+func (b *Breaker) Check(context.Context) error {
+	if s := b.State(); s == Open {
+		return fmt.Errorf("%s: %w", b.name, ErrOpen)
+	}
+	return nil
+}
+
+func (b *Breaker) open() {
+	b.openedAt = b.now()
+	b.setState(Open)
+}
+
+func (b *Breaker) setState(s State) {
+	b.state = s
+	metric.CircuitBreakerState.WithLabelValues(b.name).Set(float64(s))
+}
@@ -0,0 +1,137 @@
+package breaker
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+
+	"taskmanager/internal/metric"
+)
+
+func TestBreaker_OpensProbesAndCloses(t *testing.T) {
+	now := time.Now()
+	b := New("test", 2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("call %d: unexpected %v", i, err)
+		}
+		b.Record(true)
+	}
+	if b.State() != Open || !errors.Is(b.Check(context.Background()), ErrOpen) {
+		t.Fatalf("expected open after 2 failures got %v", b.State())
+	}
+	if v := testutil.ToFloat64(metric.CircuitBreakerState.WithLabelValues("test")); v != float64(Open) {
+		t.Fatalf("expected state gauge %d got %v", Open, v)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected a fast failure while open got %v", err)
+	}
+
+	// after the cooldown a single probe goes through
+	now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected a probe got %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) || b.State() != HalfOpen {
+		t.Fatalf("expected other calls to wait for the probe got %v state=%v", err, b.State())
+	}
+	b.Record(true)
+	if b.State() != Open {
+		t.Fatalf("expected a failed probe to re-open got %v", b.State())
+	}
+
+	now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected a probe got %v", err)
+	}
+	b.Record(false)
+	if b.State() != Closed || b.Check(context.Background()) != nil {
+		t.Fatalf("expected a successful probe to close got %v", b.State())
+	}
+
+	// successes reset the count of consecutive failures
+	b.Record(true)
+	b.Record(false)
+	b.Record(true)
+	if b.State() != Closed {
+		t.Fatalf("expected non-consecutive failures to keep it closed")
+	}
+}
+
+type testConnector struct {
+	d   driver.Driver
+	dsn string
+}
+
+func (c testConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open(c.dsn) }
+func (c testConnector) Driver() driver.Driver                        { return c.d }
+
+func TestWrapConnector_CountsOnlyDatabaseFailures(t *testing.T) {
+	raw, mock, err := sqlmock.NewWithDSN("breaker_test")
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer raw.Close()
+	b := New("db_test", 2, time.Hour)
+	db := sql.OpenDB(b.WrapConnector(testConnector{d: raw.Driver(), dsn: "breaker_test"}))
+	ctx := context.Background()
+
+	mock.ExpectExec("INSERT INTO tasks").WillReturnError(errors.New("duplicate key value violates unique constraint"))
+	mock.ExpectExec("INSERT INTO tasks").WillReturnError(errors.New("duplicate key value violates unique constraint"))
+	mock.ExpectQuery("SELECT 1").WillReturnError(&net.OpError{Op: "read", Err: errors.New("connection reset by peer")})
+	mock.ExpectQuery("SELECT 1").WillReturnError(context.DeadlineExceeded)
+	for i := 0; i < 2; i++ {
+		if _, err := db.ExecContext(ctx, "INSERT INTO tasks DEFAULT VALUES"); err == nil {
+			t.Fatalf("expected the constraint error")
+		}
+	}
+	if b.State() != Closed {
+		t.Fatalf("expected query errors not to open the breaker")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := db.QueryContext(ctx, "SELECT 1"); err == nil {
+			t.Fatalf("expected the connection error")
+		}
+	}
+	if b.State() != Open {
+		t.Fatalf("expected connection errors to open the breaker got %v", b.State())
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM tasks"); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected ErrOpen without reaching the database got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestRedisHook_FailsFastWhileOpen(t *testing.T) {
+	// nothing listens on port 1
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: time.Second})
+	defer rdb.Close()
+	b := New("redis_test", 1, time.Hour)
+	rdb.AddHook(RedisHook(b))
+	ctx := context.Background()
+
+	if err := rdb.Get(ctx, "k").Err(); err == nil || errors.Is(err, ErrOpen) {
+		t.Fatalf("expected a connection error got %v", err)
+	}
+	if b.State() != Open {
+		t.Fatalf("expected the failure to open the breaker got %v", b.State())
+	}
+	if err := rdb.Get(ctx, "k").Err(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected ErrOpen got %v", err)
+	}
+	if redisFailure(redis.Nil) || redisFailure(context.Canceled) {
+		t.Fatalf("a cache miss or a cancelled call is not a Redis failure")
+	}
+}
@@ -0,0 +1,52 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisHook returns a go-redis hook that runs every command and pipeline
+// through b; add it with (*redis.Client).AddHook. A cache miss (redis.Nil)
+// is not a failure. Dials are not guarded separately: they happen inside
+// commands.
+func RedisHook(b *Breaker) redis.Hook {
+	return redisHook{b: b}
+}
+
+type redisHook struct{ b *Breaker }
+
+func (h redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.b.Allow(); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		err := next(ctx, cmd)
+		h.b.Record(redisFailure(err))
+		return err
+	}
+}
+
+func (h redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.b.Allow(); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		err := next(ctx, cmds)
+		h.b.Record(redisFailure(err))
+		return err
+	}
+}
+
+func redisFailure(err error) bool {
+	return err != nil && !errors.Is(err, redis.Nil) && !errors.Is(err, context.Canceled)
+}
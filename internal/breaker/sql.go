@@ -0,0 +1,171 @@
+package breaker
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// WrapConnector wraps a database/sql connector so every connect, query,
+// exec, prepare, begin and ping goes through b. Only errors that point at
+// the database itself count as failures (see dbFailure); a constraint
+// violation or a missing row does not.
+func (b *Breaker) WrapConnector(c driver.Connector) driver.Connector {
+	return &connector{Connector: c, b: b}
+}
+
+type connector struct {
+	driver.Connector
+	b *Breaker
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := c.b.Allow(); err != nil {
+		return nil, err
+	}
+	dc, err := c.Connector.Connect(ctx)
+	c.b.Record(dbFailure(err))
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: dc, b: c.b}, nil
+}
+
+// conn forwards to the driver's connection. Optional interfaces the driver
+// lacks answer with driver.ErrSkip (or the database/sql default), so
+// database/sql falls back as it would without the wrapper.
+type conn struct {
+	driver.Conn
+	b *Breaker
+}
+
+func (c *conn) guard(err error) error {
+	c.b.Record(dbFailure(err))
+	return err
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := c.b.Allow(); err != nil {
+		return nil, err
+	}
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	return stmt, c.guard(err)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := c.b.Allow(); err != nil {
+		return nil, err
+	}
+	if bt, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err := bt.BeginTx(ctx, opts)
+		return tx, c.guard(err)
+	}
+	tx, err := c.Conn.Begin()
+	return tx, c.guard(err)
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.b.Allow(); err != nil {
+		return nil, err
+	}
+	res, err := e.ExecContext(ctx, query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		c.b.Record(false)
+		return nil, err
+	}
+	return res, c.guard(err)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.b.Allow(); err != nil {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx, query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		c.b.Record(false)
+		return nil, err
+	}
+	return rows, c.guard(err)
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if err := c.b.Allow(); err != nil {
+		return err
+	}
+	p, ok := c.Conn.(driver.Pinger)
+	if !ok {
+		c.b.Record(false)
+		return nil
+	}
+	return c.guard(p.Ping(ctx))
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// dbFailure reports whether err means the database is down or overloaded:
+// broken or refused connections, timeouts, and PostgreSQL/MySQL errors
+// about connections and resources. Cancellation by the client is not.
+func dbFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrOpen) {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true
+	}
+	var pe *pq.Error
+	if errors.As(err, &pe) {
+		// 08 connection exception, 53 insufficient resources, 57 operator
+		// intervention (shutdown, statement timeout)
+		class := string(pe.Code.Class())
+		return class == "08" || class == "53" || class == "57"
+	}
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		// 1040 too many connections, 1205 lock wait timeout, 1053 shutdown
+		return me.Number == 1040 || me.Number == 1205 || me.Number == 1053
+	}
+	return false
+}
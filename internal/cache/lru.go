@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LRU is a size-bounded in-process cache with per-entry expiry. It is used as
+// an L1 in front of Redis and as the only cache while Redis is unavailable.
+// Values are stored as strings (the same encoded form kept in Redis) so
+// callers never share mutable data.
+type LRU struct {
+	max     int
+	onEvict func()
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	val     string
+	expires time.Time
+}
+
+// NewLRU creates an LRU holding at most max entries. onEvict, if not nil, is
+// called whenever an entry is evicted to make room.
+func NewLRU(max int, onEvict func()) *LRU {
+	return &LRU{max: max, onEvict: onEvict, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get returns the value for key if present and not expired.
+func (c *LRU) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	e := el.Value.(*lruEntry)
+	if time.Now().After(e.expires) {
+		c.remove(el)
+		return "", false
+	}
+	c.ll.MoveToFront(el)
+	return e.val, true
+}
+
+// Set stores val under key for ttl, evicting the least recently used entry
+// when the cache is full.
+func (c *LRU) Set(key, val string, ttl time.Duration) {
+	if ttl <= 0 || c.max <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := time.Now().Add(ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.val, e.expires = val, expires
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, val: val, expires: expires})
+	for c.ll.Len() > c.max {
+		c.remove(c.ll.Back())
+		if c.onEvict != nil {
+			c.onEvict()
+		}
+	}
+}
+
+// Delete removes keys.
+func (c *LRU) Delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, k := range keys {
+		if el, ok := c.items[k]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+// DeletePrefix removes every key starting with prefix.
+func (c *LRU) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, el := range c.items {
+		if strings.HasPrefix(k, prefix) {
+			c.remove(el)
+		}
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet removed.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*lruEntry).key)
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	evictions := 0
+	c := NewLRU(2, func() { evictions++ })
+	c.Set("a", "1", time.Minute)
+	c.Set("b", "2", time.Minute)
+	c.Get("a") // b is now the least recently used
+	c.Set("c", "3", time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatalf("expected b to be evicted")
+	}
+	if v, ok := c.Get("a"); !ok || v != "1" {
+		t.Fatalf("expected a to survive, got %q %v", v, ok)
+	}
+	if evictions != 1 || c.Len() != 2 {
+		t.Fatalf("expected 1 eviction and 2 entries, got %d and %d", evictions, c.Len())
+	}
+}
+
+func TestLRU_ExpiryAndDeletePrefix(t *testing.T) {
+	c := NewLRU(10, nil)
+	c.Set("tasks:list:1", "x", time.Minute)
+	c.Set("tasks:list:2", "y", time.Minute)
+	c.Set("tasks:id:1", "z", time.Minute)
+	c.Set("short", "v", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	if _, ok := c.Get("short"); ok {
+		t.Fatalf("expected expired entry to be gone")
+	}
+	c.DeletePrefix("tasks:list:")
+	if c.Len() != 1 {
+		t.Fatalf("expected only the item entry to remain, got %d", c.Len())
+	}
+	c.Delete("tasks:id:1")
+	if _, ok := c.Get("tasks:id:1"); ok {
+		t.Fatalf("expected deleted entry to be gone")
+	}
+}
@@ -0,0 +1,83 @@
+// Package cache manages the lifecycle of the optional Redis cache.
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Watcher pings Redis periodically and attaches the client (through attach)
+// once it answers, so a Redis that is down at boot is picked up later. After
+// threshold consecutive failed pings the client is detached again
+// (attach(nil)) and the service runs uncached until Redis recovers.
+type Watcher struct {
+	rdb       *redis.Client
+	attach    func(*redis.Client)
+	threshold int
+	timeout   time.Duration
+
+	mu       sync.Mutex
+	attached bool
+	failures int
+}
+
+// NewWatcher creates a Watcher. threshold < 1 is treated as 1.
+func NewWatcher(rdb *redis.Client, attach func(*redis.Client), threshold int) *Watcher {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Watcher{rdb: rdb, attach: attach, threshold: threshold, timeout: 2 * time.Second}
+}
+
+// Run checks Redis every interval until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = w.Check(ctx)
+		}
+	}
+}
+
+// Check pings Redis once, attaching or detaching the client as needed, and
+// returns the ping error.
+func (w *Watcher) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	err := w.rdb.Ping(ctx).Err()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err == nil {
+		w.failures = 0
+		if !w.attached {
+			w.attach(w.rdb)
+			w.attached = true
+			slog.Info("redis cache attached", "addr", w.rdb.Options().Addr)
+		}
+		return nil
+	}
+
+	w.failures++
+	if w.attached && w.failures >= w.threshold {
+		w.attach(nil)
+		w.attached = false
+		slog.Warn("redis cache detached after repeated failures", "addr", w.rdb.Options().Addr, "failures", w.failures, "err", err)
+	}
+	return err
+}
+
+// Attached reports whether the cache client is currently attached.
+func (w *Watcher) Attached() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.attached
+}
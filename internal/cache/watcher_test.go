@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	redismock "github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestWatcher_AttachesLateAndDetachesAfterThreshold(t *testing.T) {
+	rdb, mock := redismock.NewClientMock()
+	var current *redis.Client
+	w := NewWatcher(rdb, func(c *redis.Client) { current = c }, 2)
+	ctx := context.Background()
+
+	// down at boot: nothing attached
+	mock.ExpectPing().SetErr(errors.New("connection refused"))
+	if err := w.Check(ctx); err == nil || current != nil || w.Attached() {
+		t.Fatalf("expected no client while redis is down")
+	}
+
+	// comes up later: attached
+	mock.ExpectPing().SetVal("PONG")
+	if err := w.Check(ctx); err != nil || current != rdb {
+		t.Fatalf("expected client to be attached, err=%v", err)
+	}
+
+	// a single failure is tolerated
+	mock.ExpectPing().SetErr(errors.New("timeout"))
+	_ = w.Check(ctx)
+	if current != rdb {
+		t.Fatalf("expected client to stay attached after one failure")
+	}
+
+	// sustained failure detaches
+	mock.ExpectPing().SetErr(errors.New("timeout"))
+	_ = w.Check(ctx)
+	if current != nil || w.Attached() {
+		t.Fatalf("expected client to be detached")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("redis expectations: %v", err)
+	}
+}
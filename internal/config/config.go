@@ -0,0 +1,930 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+
+	"taskmanager/internal/database"
+	"taskmanager/internal/listener"
+)
+
+// Config is the full application configuration. Values are resolved in order:
+// built-in defaults, then the optional config file (YAML or JSON), then
+// environment variable overrides.
+type Config struct {
+	Server         ServerConfig         `yaml:"server" json:"server"`
+	Admin          AdminConfig          `yaml:"admin" json:"admin"`
+	Database       DatabaseConfig       `yaml:"database" json:"database"`
+	Redis          RedisConfig          `yaml:"redis" json:"redis"`
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker" json:"circuit_breaker"`
+	Cache          CacheConfig          `yaml:"cache" json:"cache"`
+	List           ListConfig           `yaml:"list" json:"list"`
+	Tasks          TasksConfig          `yaml:"tasks" json:"tasks"`
+	Retention      RetentionConfig      `yaml:"retention" json:"retention"`
+	Jobs           JobsConfig           `yaml:"jobs" json:"jobs"`
+	CORS           CORSConfig           `yaml:"cors" json:"cors"`
+	Auth           AuthConfig           `yaml:"auth" json:"auth"`
+	Outbox         OutboxConfig         `yaml:"outbox" json:"outbox"`
+	Log            LogConfig            `yaml:"log" json:"log"`
+	Metrics        MetricsConfig        `yaml:"metrics" json:"metrics"`
+	Sentry         SentryConfig         `yaml:"sentry" json:"sentry"`
+	Secrets        SecretsConfig        `yaml:"secrets" json:"secrets"`
+	Sandbox        SandboxConfig        `yaml:"sandbox" json:"sandbox"`
+	Features       map[string]bool      `yaml:"features" json:"features"`
+}
+
+type ServerConfig struct {
+	Port            string   `yaml:"port" json:"port"`
+	ReadTimeout     Duration `yaml:"read_timeout" json:"read_timeout"`
+	WriteTimeout    Duration `yaml:"write_timeout" json:"write_timeout"`
+	IdleTimeout     Duration `yaml:"idle_timeout" json:"idle_timeout"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" json:"shutdown_timeout"`
+	// RequestTimeout is the per-request deadline propagated to DB and Redis calls.
+	RequestTimeout Duration `yaml:"request_timeout" json:"request_timeout"`
+	// TimeoutReserve is held back from the request deadline so a response can
+	// still be written after downstream calls time out.
+	TimeoutReserve Duration `yaml:"timeout_reserve" json:"timeout_reserve"`
+	// MaxBodyBytes caps the size of request bodies.
+	MaxBodyBytes int64 `yaml:"max_body_bytes" json:"max_body_bytes"`
+	// StrictJSON rejects request bodies with unknown fields on /api/v1.
+	// Off by default for compatibility; new API versions enable it always.
+	StrictJSON bool `yaml:"strict_json" json:"strict_json"`
+	// BasePath mounts every route under a prefix (e.g. "/taskmanager") for
+	// shared ingress deployments. Empty serves from the root.
+	BasePath string `yaml:"base_path" json:"base_path"`
+	// TrustedPlatform names the platform whose client IP header is trusted:
+	// "appengine", "cloudflare", "flyio", or a raw header name.
+	TrustedPlatform string `yaml:"trusted_platform" json:"trusted_platform"`
+	// TrustedProxies lists the addresses (IPs or CIDRs) of the load balancers
+	// and reverse proxies in front of the service. Only requests arriving from
+	// them have their client IP taken from RemoteIPHeaders; empty trusts no
+	// proxy and the client IP is always the peer address.
+	TrustedProxies []string `yaml:"trusted_proxies" json:"trusted_proxies"`
+	// RemoteIPHeaders are the headers carrying the client IP set by a trusted
+	// proxy, tried in order. X-Forwarded-For is read right to left, skipping
+	// trusted proxies, so a client cannot spoof its address by sending one.
+	RemoteIPHeaders []string `yaml:"remote_ip_headers" json:"remote_ip_headers"`
+	// Listen overrides Port: "unix:/run/taskmanager.sock", "systemd" (socket
+	// activation) or a TCP address such as "127.0.0.1:8080".
+	Listen string `yaml:"listen" json:"listen"`
+	// TLSCertFile and TLSKeyFile enable HTTPS on the public listener.
+	TLSCertFile string `yaml:"tls_cert_file" json:"tls_cert_file"`
+	TLSKeyFile  string `yaml:"tls_key_file" json:"tls_key_file"`
+	// TLSClientCAFile enables mutual TLS on the public listener: client
+	// certificates are verified against the CAs in this PEM file and their
+	// identity authenticates the caller (see middleware.ClientCert).
+	TLSClientCAFile string `yaml:"tls_client_ca_file" json:"tls_client_ca_file"`
+	// TLSClientAuth is "require" (default: connections without a valid
+	// certificate are refused) or "optional" (a certificate is verified when
+	// given, so API key clients can still connect).
+	TLSClientAuth string `yaml:"tls_client_auth" json:"tls_client_auth"`
+}
+
+// AdminConfig configures the optional internal listener for /readyz,
+// /metrics and the admin API. Empty Listen serves them on the public
+// listener.
+type AdminConfig struct {
+	Listen      string `yaml:"listen" json:"listen"`
+	TLSCertFile string `yaml:"tls_cert_file" json:"tls_cert_file"`
+	TLSKeyFile  string `yaml:"tls_key_file" json:"tls_key_file"`
+	// Debug serves pprof, expvar and runtime stats under /debug: on the admin
+	// listener when Listen is set, otherwise under /api/v1/admin for admin
+	// keys only.
+	Debug bool `yaml:"debug" json:"debug"`
+}
+
+// ListenSpec returns the listener spec to serve on (see listener.Listen).
+func (s ServerConfig) ListenSpec() string {
+	if s.Listen != "" {
+		return s.Listen
+	}
+	return ":" + s.Port
+}
+
+// PlatformHeader resolves TrustedPlatform to the header gin should trust.
+func (s ServerConfig) PlatformHeader() string {
+	switch strings.ToLower(s.TrustedPlatform) {
+	case "":
+		return ""
+	case "appengine":
+		return "X-Appengine-Remote-Addr"
+	case "cloudflare":
+		return "CF-Connecting-IP"
+	case "flyio", "fly":
+		return "Fly-Client-IP"
+	default:
+		return s.TrustedPlatform
+	}
+}
+
+type DatabaseConfig struct {
+	// Driver is "postgres" (default), "mysql" (MySQL/MariaDB, URL is a
+	// go-sql-driver DSN) or "sqlite" for local development and tests; with
+	// sqlite, URL is a file path or ":memory:".
+	Driver string `yaml:"driver" json:"driver"`
+	// URL "memory" runs without a database (see InMemory).
+	URL string `yaml:"url" json:"url"`
+	// Connection pool limits; zero values keep the database/sql defaults
+	// (unlimited open connections, no lifetime limit).
+	MaxOpenConns    int      `yaml:"max_open_conns" json:"max_open_conns"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" json:"max_idle_conns"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" json:"conn_max_lifetime"`
+	ConnMaxIdleTime Duration `yaml:"conn_max_idle_time" json:"conn_max_idle_time"`
+	// ReplicaURL optionally points at a read replica of the same driver;
+	// task reads (get, list, count) go there and writes stay on URL.
+	ReplicaURL string `yaml:"replica_url" json:"replica_url"`
+	// ReadYourWrites sends reads to the primary for this long after a write
+	// from this instance, so clients see their own changes despite
+	// replication lag. Zero always reads from the replica.
+	ReadYourWrites Duration `yaml:"read_your_writes" json:"read_your_writes"`
+	// ConnectTimeout is how long startup retries the first connection, with
+	// exponential backoff, before giving up; 0 tries once.
+	ConnectTimeout Duration `yaml:"connect_timeout" json:"connect_timeout"`
+	// StartDegraded starts the service when the database is still down after
+	// ConnectTimeout instead of exiting: /readyz fails and requests needing
+	// the database error until it appears and migrations have run.
+	StartDegraded bool `yaml:"start_degraded" json:"start_degraded"`
+}
+
+// InMemory reports whether tasks are kept in process memory instead of a
+// database (url: memory), for demos and embedding. Driver and pool settings
+// are ignored then.
+func (d DatabaseConfig) InMemory() bool {
+	return d.URL == "memory"
+}
+
+type RedisConfig struct {
+	// Addr accepts "host:port" or "redis://host:port". Empty disables the cache.
+	Addr     string `yaml:"addr" json:"addr"`
+	Password string `yaml:"password" json:"password"`
+	DB       int    `yaml:"db" json:"db"`
+	// PoolSize 0 keeps the go-redis default (10 per GOMAXPROCS).
+	PoolSize     int `yaml:"pool_size" json:"pool_size"`
+	MinIdleConns int `yaml:"min_idle_conns" json:"min_idle_conns"`
+	// Required fails startup (and readiness) when Redis is down. Otherwise the
+	// service starts uncached and attaches the cache once Redis answers.
+	Required bool `yaml:"required" json:"required"`
+	// ReconnectInterval is how often Redis is pinged to attach/detach the cache.
+	ReconnectInterval Duration `yaml:"reconnect_interval" json:"reconnect_interval"`
+	// FailureThreshold is the number of consecutive failed pings before the
+	// cache is detached.
+	FailureThreshold int `yaml:"failure_threshold" json:"failure_threshold"`
+	// ConnectTimeout is how long startup waits for a Required Redis, retrying
+	// with exponential backoff; 0 tries once.
+	ConnectTimeout Duration `yaml:"connect_timeout" json:"connect_timeout"`
+}
+
+type CircuitBreakerConfig struct {
+	// FailureThreshold consecutive database (or Redis) failures open the
+	// breaker, after which calls fail fast with 503 (Redis: as cache
+	// misses). 0 disables the breakers.
+	FailureThreshold int `yaml:"failure_threshold" json:"failure_threshold"`
+	// Cooldown is how long an open breaker waits before letting one probe
+	// call through.
+	Cooldown Duration `yaml:"cooldown" json:"cooldown"`
+}
+
+type CacheConfig struct {
+	ListTTL Duration `yaml:"list_ttl" json:"list_ttl"`
+	ItemTTL Duration `yaml:"item_ttl" json:"item_ttl"`
+	// NegativeTTL caches GetByID misses for this long; 0 disables negative caching.
+	NegativeTTL Duration `yaml:"negative_ttl" json:"negative_ttl"`
+	// TTLJitter adds a random [0, TTLJitter) to each TTL to avoid synchronized expiry.
+	TTLJitter Duration `yaml:"ttl_jitter" json:"ttl_jitter"`
+	// StaleTTL serves expired list pages for this long while refreshing them
+	// in the background; 0 disables stale-while-revalidate.
+	StaleTTL Duration `yaml:"stale_ttl" json:"stale_ttl"`
+	// LocalMaxEntries sizes the in-process L1 cache (0 disables it); LocalTTL
+	// bounds how long an entry is served from it.
+	LocalMaxEntries int      `yaml:"local_max_entries" json:"local_max_entries"`
+	LocalTTL        Duration `yaml:"local_ttl" json:"local_ttl"`
+	// PubSub announces invalidations on Redis Pub/Sub so every instance
+	// drops its L1 copies within milliseconds of another's write.
+	PubSub bool `yaml:"pubsub" json:"pubsub"`
+}
+
+type ListConfig struct {
+	// DefaultSort is the list ordering, e.g. "due_date asc nulls last, created_at desc".
+	// id is always appended as a tie-breaker.
+	DefaultSort string `yaml:"default_sort" json:"default_sort"`
+}
+
+type TasksConfig struct {
+	// WIPLimit caps the open (not completed) tasks per assignee; assignments
+	// beyond it fail with 409 unless an admin key overrides. 0 disables it.
+	WIPLimit int `yaml:"wip_limit" json:"wip_limit"`
+}
+
+type RetentionConfig struct {
+	// PurgeCompletedAfter deletes tasks completed longer ago than this, e.g.
+	// "2160h" for 90 days. 0 disables it.
+	PurgeCompletedAfter Duration `yaml:"purge_completed_after" json:"purge_completed_after"`
+	// PurgeTombstonesAfter deletes the tombstones of tasks deleted longer
+	// ago than this. 0 keeps them forever.
+	PurgeTombstonesAfter Duration `yaml:"purge_tombstones_after" json:"purge_tombstones_after"`
+	// Interval is how often the policy runs.
+	Interval Duration `yaml:"interval" json:"interval"`
+}
+
+type JobsConfig struct {
+	// Concurrency is how many jobs one instance runs at a time.
+	Concurrency int `yaml:"concurrency" json:"concurrency"`
+	// PollInterval is how often an idle worker looks for due jobs.
+	PollInterval Duration `yaml:"poll_interval" json:"poll_interval"`
+	// MaxAttempts is how often a failing job runs before it moves to the
+	// dead_jobs table.
+	MaxAttempts int `yaml:"max_attempts" json:"max_attempts"`
+	// Backoff is the delay before the first retry; it doubles per attempt.
+	Backoff Duration `yaml:"backoff" json:"backoff"`
+}
+
+type CORSConfig struct {
+	// AllowedOrigins enables CORS when non-empty; "*" allows any origin.
+	AllowedOrigins []string `yaml:"allowed_origins" json:"allowed_origins"`
+	AllowedMethods []string `yaml:"allowed_methods" json:"allowed_methods"`
+	AllowedHeaders []string `yaml:"allowed_headers" json:"allowed_headers"`
+}
+
+type AuthConfig struct {
+	// APIKeys, when non-empty, are required on /api/v1 via "Authorization: Bearer <key>"
+	// or the X-API-Key header.
+	APIKeys []string `yaml:"api_keys" json:"api_keys"`
+	// AdminKeys are accepted like APIKeys and additionally allow admin-only
+	// actions such as overriding the WIP limit.
+	AdminKeys []string `yaml:"admin_keys" json:"admin_keys"`
+	// AdminIdentities are the client certificate identities (see
+	// server.tls_client_ca_file) granted what admin keys allow.
+	AdminIdentities []string `yaml:"admin_identities" json:"admin_identities"`
+}
+
+// Enabled reports whether /api/v1 requires an API key.
+func (a AuthConfig) Enabled() bool {
+	return len(a.APIKeys) > 0 || len(a.AdminKeys) > 0
+}
+
+type OutboxConfig struct {
+	// Publisher is one of "log", "nats" or "none".
+	Publisher    string   `yaml:"publisher" json:"publisher"`
+	PollInterval Duration `yaml:"poll_interval" json:"poll_interval"`
+	// FeedLag holds events written less than this long ago back from
+	// GET /changes and GET /tasks/changes, so that one whose transaction
+	// commits after a later one isn't skipped. It must exceed the time a
+	// transaction stays open after writing its events.
+	FeedLag           Duration `yaml:"feed_lag" json:"feed_lag"`
+	NATSURL           string   `yaml:"nats_url" json:"nats_url"`
+	NATSSubjectPrefix string   `yaml:"nats_subject_prefix" json:"nats_subject_prefix"`
+}
+
+type LogConfig struct {
+	// Level is one of "debug", "info", "warn" or "error".
+	Level string `yaml:"level" json:"level"`
+}
+
+type MetricsConfig struct {
+	// LatencyBuckets are the upper bounds, in seconds, of the
+	// request_latency_seconds histogram buckets; the default is
+	// prometheus.DefBuckets.
+	LatencyBuckets []float64 `yaml:"latency_buckets" json:"latency_buckets"`
+	// RefreshInterval is how often the task gauges (tasks_count) are
+	// recomputed from the database.
+	RefreshInterval Duration `yaml:"refresh_interval" json:"refresh_interval"`
+	// TopAssignees is how many assignees overdue_tasks names; the others are
+	// summed under "(other)".
+	TopAssignees int `yaml:"top_assignees" json:"top_assignees"`
+}
+
+// SentryConfig enables error reporting of panics and 5xx responses.
+type SentryConfig struct {
+	// DSN is the project's client key URL; empty disables reporting.
+	DSN         string `yaml:"dsn" json:"dsn"`
+	Environment string `yaml:"environment" json:"environment"`
+}
+
+// SecretsConfig fetches credentials from a secrets manager at startup, and
+// again every RefreshInterval, instead of taking them from the file or the
+// environment. Each reference names a secret and, after "#", a field of its
+// JSON value: "taskmanager/prod#database_url". Empty references keep the
+// configured value.
+type SecretsConfig struct {
+	// Provider is "vault", "aws" or empty (off).
+	Provider string `yaml:"provider" json:"provider"`
+	// RefreshInterval re-fetches the secrets so rotated credentials are used
+	// for new database and Redis connections and API key checks; 0 fetches
+	// them once.
+	RefreshInterval Duration           `yaml:"refresh_interval" json:"refresh_interval"`
+	Vault           VaultSecretsConfig `yaml:"vault" json:"vault"`
+	AWS             AWSSecretsConfig   `yaml:"aws" json:"aws"`
+
+	DatabaseURL   string `yaml:"database_url" json:"database_url"`
+	ReplicaURL    string `yaml:"replica_url" json:"replica_url"`
+	RedisPassword string `yaml:"redis_password" json:"redis_password"`
+	// APIKeys and AdminKeys point at comma-separated lists of keys.
+	APIKeys   string `yaml:"api_keys" json:"api_keys"`
+	AdminKeys string `yaml:"admin_keys" json:"admin_keys"`
+}
+
+// VaultSecretsConfig locates a HashiCorp Vault KV version 2 engine.
+type VaultSecretsConfig struct {
+	Addr  string `yaml:"addr" json:"addr"`
+	Token string `yaml:"token" json:"token"`
+	Mount string `yaml:"mount" json:"mount"`
+}
+
+// AWSSecretsConfig locates AWS Secrets Manager. The credentials are static
+// keys; instance and task roles are not supported.
+type AWSSecretsConfig struct {
+	Region string `yaml:"region" json:"region"`
+	// Endpoint overrides the regional endpoint (VPC endpoint, LocalStack).
+	Endpoint        string `yaml:"endpoint" json:"endpoint"`
+	AccessKeyID     string `yaml:"access_key_id" json:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key" json:"secret_access_key"`
+	SessionToken    string `yaml:"session_token" json:"session_token"`
+}
+
+// Refs returns the secret references that are set, by the name of the
+// configuration key they replace.
+func (s SecretsConfig) Refs() map[string]string {
+	refs := map[string]string{}
+	for name, ref := range map[string]string{
+		"database.url":         s.DatabaseURL,
+		"database.replica_url": s.ReplicaURL,
+		"redis.password":       s.RedisPassword,
+		"auth.api_keys":        s.APIKeys,
+		"auth.admin_keys":      s.AdminKeys,
+	} {
+		if ref != "" {
+			refs[name] = ref
+		}
+	}
+	return refs
+}
+
+type SandboxConfig struct {
+	// Enabled runs a self-contained demo instance: in-memory storage seeded
+	// with demo data, no Redis, replica or outbox publishing (see
+	// applySandbox).
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// ResetInterval wipes and re-seeds the data; 0 never resets.
+	ResetInterval Duration `yaml:"reset_interval" json:"reset_interval"`
+}
+
+// Duration is a time.Duration that decodes from strings like "5s" in YAML and JSON.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Default returns the configuration used when nothing is overridden.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            "8080",
+			ReadTimeout:     Duration{15 * time.Second},
+			WriteTimeout:    Duration{30 * time.Second},
+			IdleTimeout:     Duration{60 * time.Second},
+			ShutdownTimeout: Duration{10 * time.Second},
+			RequestTimeout:  Duration{10 * time.Second},
+			TimeoutReserve:  Duration{50 * time.Millisecond},
+			MaxBodyBytes:    1 << 20,
+			RemoteIPHeaders: []string{"X-Forwarded-For", "X-Real-IP"},
+			TLSClientAuth:   "require",
+		},
+		Admin:          AdminConfig{Debug: true},
+		Database:       DatabaseConfig{Driver: database.Postgres, MaxIdleConns: 2, ReadYourWrites: Duration{time.Second}, ConnectTimeout: Duration{30 * time.Second}},
+		Redis:          RedisConfig{Addr: "localhost:6379", ReconnectInterval: Duration{5 * time.Second}, FailureThreshold: 3, ConnectTimeout: Duration{30 * time.Second}},
+		CircuitBreaker: CircuitBreakerConfig{FailureThreshold: 5, Cooldown: Duration{10 * time.Second}},
+		Cache: CacheConfig{
+			ListTTL:         Duration{60 * time.Second},
+			ItemTTL:         Duration{60 * time.Second},
+			LocalMaxEntries: 1000,
+			LocalTTL:        Duration{5 * time.Second},
+			PubSub:          true,
+		},
+		List:      ListConfig{DefaultSort: "created_at desc"},
+		Retention: RetentionConfig{Interval: Duration{time.Hour}},
+		Jobs:      JobsConfig{Concurrency: 2, PollInterval: Duration{time.Second}, MaxAttempts: 5, Backoff: Duration{10 * time.Second}},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "X-API-Key", "X-Request-Timeout", "If-None-Match"},
+		},
+		Outbox: OutboxConfig{
+			Publisher:         "log",
+			PollInterval:      Duration{time.Second},
+			FeedLag:           Duration{2 * time.Second},
+			NATSURL:           "localhost:4222",
+			NATSSubjectPrefix: "taskmanager.",
+		},
+		Log:      LogConfig{Level: "info"},
+		Metrics:  MetricsConfig{LatencyBuckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}, RefreshInterval: Duration{30 * time.Second}, TopAssignees: 10},
+		Sandbox:  SandboxConfig{ResetInterval: Duration{time.Hour}},
+		Features: map[string]bool{},
+	}
+}
+
+// ValidationError lists every missing or invalid configuration key.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load builds the configuration from defaults, the file at path (skipped when
+// path is empty) and the process environment, then validates it.
+func Load(path string) (*Config, error) {
+	return load(path, os.Environ())
+}
+
+func load(path string, environ []string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var problems []string
+	problems = append(problems, cfg.applyEnv(environ)...)
+	cfg.applySandbox()
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// applySandbox makes a sandbox instance self-contained whatever else is
+// configured: tasks live in memory and nothing is sent to Redis, a replica
+// or the message broker.
+func (c *Config) applySandbox() {
+	if !c.Sandbox.Enabled {
+		return
+	}
+	c.Database.URL = "memory"
+	c.Database.ReplicaURL = ""
+	c.Redis.Addr = ""
+	c.Redis.Required = false
+	c.Outbox.Publisher = "none"
+	c.Secrets.DatabaseURL = ""
+	c.Secrets.ReplicaURL = ""
+	c.Secrets.RedisPassword = ""
+}
+
+// Feature reports whether the named feature flag is enabled.
+func (c *Config) Feature(name string) bool {
+	return c.Features[name]
+}
+
+func (c *Config) readFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalWithOptions(b, c, yaml.Strict())
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+	default:
+		return fmt.Errorf("config file %s: unsupported extension (use .yaml, .yml or .json)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides values from environment variables and returns a problem
+// for each variable that could not be parsed.
+func (c *Config) applyEnv(environ []string) []string {
+	env := make(map[string]string, len(environ))
+	var featureKeys []string
+	for _, kv := range environ {
+		k, v, _ := strings.Cut(kv, "=")
+		env[k] = v
+		if strings.HasPrefix(k, "FEATURE_") {
+			featureKeys = append(featureKeys, k)
+		}
+	}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	var problems []string
+
+	str := func(key string, dst *string) {
+		if v, ok := lookup(key); ok && v != "" {
+			*dst = v
+		}
+	}
+	boolean := func(key string, dst *bool) {
+		if v, ok := lookup(key); ok && v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid boolean %q", key, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	list := func(key string, dst *[]string) {
+		if v, ok := lookup(key); ok && v != "" {
+			*dst = splitList(v)
+		}
+	}
+	dur := func(key string, dst *Duration) {
+		if v, ok := lookup(key); ok && v != "" {
+			if err := dst.UnmarshalText([]byte(v)); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid duration %q", key, v))
+			}
+		}
+	}
+	num := func(key string, dst *int) {
+		if v, ok := lookup(key); ok && v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid integer %q", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	floats := func(key string, dst *[]float64) {
+		if v, ok := lookup(key); ok && v != "" {
+			var out []float64
+			for _, f := range splitList(v) {
+				n, err := strconv.ParseFloat(f, 64)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("%s: invalid number %q", key, f))
+					return
+				}
+				out = append(out, n)
+			}
+			*dst = out
+		}
+	}
+	num64 := func(key string, dst *int64) {
+		if v, ok := lookup(key); ok && v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid integer %q", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+
+	str("PORT", &c.Server.Port)
+	dur("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout)
+	dur("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	dur("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	dur("SERVER_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	dur("SERVER_REQUEST_TIMEOUT", &c.Server.RequestTimeout)
+	dur("SERVER_TIMEOUT_RESERVE", &c.Server.TimeoutReserve)
+	num64("SERVER_MAX_BODY_BYTES", &c.Server.MaxBodyBytes)
+	boolean("SERVER_STRICT_JSON", &c.Server.StrictJSON)
+	str("SERVER_BASE_PATH", &c.Server.BasePath)
+	str("SERVER_TRUSTED_PLATFORM", &c.Server.TrustedPlatform)
+	list("SERVER_TRUSTED_PROXIES", &c.Server.TrustedProxies)
+	list("SERVER_REMOTE_IP_HEADERS", &c.Server.RemoteIPHeaders)
+	str("LISTEN", &c.Server.Listen)
+	str("SERVER_TLS_CERT_FILE", &c.Server.TLSCertFile)
+	str("SERVER_TLS_KEY_FILE", &c.Server.TLSKeyFile)
+	str("SERVER_TLS_CLIENT_CA_FILE", &c.Server.TLSClientCAFile)
+	str("SERVER_TLS_CLIENT_AUTH", &c.Server.TLSClientAuth)
+
+	str("ADMIN_LISTEN", &c.Admin.Listen)
+	str("ADMIN_TLS_CERT_FILE", &c.Admin.TLSCertFile)
+	str("ADMIN_TLS_KEY_FILE", &c.Admin.TLSKeyFile)
+	boolean("ADMIN_DEBUG", &c.Admin.Debug)
+
+	str("DATABASE_DRIVER", &c.Database.Driver)
+	str("DATABASE_URL", &c.Database.URL)
+	num("DATABASE_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	num("DATABASE_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	dur("DATABASE_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	dur("DATABASE_CONN_MAX_IDLE_TIME", &c.Database.ConnMaxIdleTime)
+	str("DATABASE_REPLICA_URL", &c.Database.ReplicaURL)
+	dur("DATABASE_REPLICA_READ_YOUR_WRITES", &c.Database.ReadYourWrites)
+	dur("DATABASE_CONNECT_TIMEOUT", &c.Database.ConnectTimeout)
+	boolean("DATABASE_START_DEGRADED", &c.Database.StartDegraded)
+
+	str("REDIS_ADDR", &c.Redis.Addr)
+	str("REDIS_PASSWORD", &c.Redis.Password)
+	num("REDIS_DB", &c.Redis.DB)
+	num("REDIS_POOL_SIZE", &c.Redis.PoolSize)
+	num("REDIS_MIN_IDLE_CONNS", &c.Redis.MinIdleConns)
+	boolean("REDIS_REQUIRED", &c.Redis.Required)
+	dur("REDIS_RECONNECT_INTERVAL", &c.Redis.ReconnectInterval)
+	num("REDIS_FAILURE_THRESHOLD", &c.Redis.FailureThreshold)
+	dur("REDIS_CONNECT_TIMEOUT", &c.Redis.ConnectTimeout)
+
+	num("CIRCUIT_BREAKER_FAILURE_THRESHOLD", &c.CircuitBreaker.FailureThreshold)
+	dur("CIRCUIT_BREAKER_COOLDOWN", &c.CircuitBreaker.Cooldown)
+
+	dur("CACHE_LIST_TTL", &c.Cache.ListTTL)
+	dur("CACHE_ITEM_TTL", &c.Cache.ItemTTL)
+	dur("CACHE_NEGATIVE_TTL", &c.Cache.NegativeTTL)
+	dur("CACHE_TTL_JITTER", &c.Cache.TTLJitter)
+	dur("CACHE_STALE_TTL", &c.Cache.StaleTTL)
+	num("CACHE_LOCAL_MAX_ENTRIES", &c.Cache.LocalMaxEntries)
+	dur("CACHE_LOCAL_TTL", &c.Cache.LocalTTL)
+	boolean("CACHE_PUBSUB", &c.Cache.PubSub)
+
+	str("LIST_DEFAULT_SORT", &c.List.DefaultSort)
+
+	num("TASKS_WIP_LIMIT", &c.Tasks.WIPLimit)
+
+	dur("RETENTION_PURGE_COMPLETED_AFTER", &c.Retention.PurgeCompletedAfter)
+	dur("RETENTION_PURGE_TOMBSTONES_AFTER", &c.Retention.PurgeTombstonesAfter)
+	dur("RETENTION_INTERVAL", &c.Retention.Interval)
+
+	num("JOBS_CONCURRENCY", &c.Jobs.Concurrency)
+	dur("JOBS_POLL_INTERVAL", &c.Jobs.PollInterval)
+	num("JOBS_MAX_ATTEMPTS", &c.Jobs.MaxAttempts)
+	dur("JOBS_BACKOFF", &c.Jobs.Backoff)
+
+	list("CORS_ALLOWED_ORIGINS", &c.CORS.AllowedOrigins)
+	list("CORS_ALLOWED_METHODS", &c.CORS.AllowedMethods)
+	list("CORS_ALLOWED_HEADERS", &c.CORS.AllowedHeaders)
+
+	list("AUTH_API_KEYS", &c.Auth.APIKeys)
+	list("AUTH_ADMIN_KEYS", &c.Auth.AdminKeys)
+	list("AUTH_ADMIN_IDENTITIES", &c.Auth.AdminIdentities)
+
+	str("OUTBOX_PUBLISHER", &c.Outbox.Publisher)
+	dur("OUTBOX_POLL_INTERVAL", &c.Outbox.PollInterval)
+	dur("OUTBOX_FEED_LAG", &c.Outbox.FeedLag)
+	str("NATS_URL", &c.Outbox.NATSURL)
+	str("NATS_SUBJECT_PREFIX", &c.Outbox.NATSSubjectPrefix)
+
+	str("LOG_LEVEL", &c.Log.Level)
+
+	floats("METRICS_LATENCY_BUCKETS", &c.Metrics.LatencyBuckets)
+	dur("METRICS_REFRESH_INTERVAL", &c.Metrics.RefreshInterval)
+	num("METRICS_TOP_ASSIGNEES", &c.Metrics.TopAssignees)
+
+	str("SENTRY_DSN", &c.Sentry.DSN)
+	str("SENTRY_ENVIRONMENT", &c.Sentry.Environment)
+
+	str("SECRETS_PROVIDER", &c.Secrets.Provider)
+	dur("SECRETS_REFRESH_INTERVAL", &c.Secrets.RefreshInterval)
+	str("VAULT_ADDR", &c.Secrets.Vault.Addr)
+	str("VAULT_TOKEN", &c.Secrets.Vault.Token)
+	str("SECRETS_VAULT_MOUNT", &c.Secrets.Vault.Mount)
+	str("AWS_REGION", &c.Secrets.AWS.Region)
+	str("SECRETS_AWS_ENDPOINT", &c.Secrets.AWS.Endpoint)
+	str("AWS_ACCESS_KEY_ID", &c.Secrets.AWS.AccessKeyID)
+	str("AWS_SECRET_ACCESS_KEY", &c.Secrets.AWS.SecretAccessKey)
+	str("AWS_SESSION_TOKEN", &c.Secrets.AWS.SessionToken)
+	str("SECRETS_DATABASE_URL", &c.Secrets.DatabaseURL)
+	str("SECRETS_DATABASE_REPLICA_URL", &c.Secrets.ReplicaURL)
+	str("SECRETS_REDIS_PASSWORD", &c.Secrets.RedisPassword)
+	str("SECRETS_AUTH_API_KEYS", &c.Secrets.APIKeys)
+	str("SECRETS_AUTH_ADMIN_KEYS", &c.Secrets.AdminKeys)
+
+	boolean("SANDBOX", &c.Sandbox.Enabled)
+	dur("SANDBOX_RESET_INTERVAL", &c.Sandbox.ResetInterval)
+
+	// FEATURE_<NAME>=true|false toggles features.<name>
+	for _, k := range featureKeys {
+		v := env[k]
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid boolean %q", k, v))
+			continue
+		}
+		if c.Features == nil {
+			c.Features = map[string]bool{}
+		}
+		c.Features[strings.ToLower(strings.TrimPrefix(k, "FEATURE_"))] = on
+	}
+
+	return problems
+}
+
+func (c *Config) validate() []string {
+	var problems []string
+	if c.Database.URL == "" && c.Secrets.DatabaseURL == "" {
+		problems = append(problems, "database.url (DATABASE_URL) is required")
+	}
+	if !database.Valid(c.Database.Driver) {
+		problems = append(problems, fmt.Sprintf("database.driver (DATABASE_DRIVER): %q is not one of postgres, mysql, sqlite", c.Database.Driver))
+	}
+	if (c.Database.ReplicaURL != "" || c.Secrets.ReplicaURL != "") && (c.Database.Driver == database.SQLite || c.Database.InMemory()) {
+		problems = append(problems, "database.replica_url (DATABASE_REPLICA_URL) is not supported with sqlite or the in-memory repository")
+	}
+	if p, err := strconv.Atoi(c.Server.Port); err != nil || p <= 0 || p > 65535 {
+		problems = append(problems, fmt.Sprintf("server.port (PORT): %q is not a valid port", c.Server.Port))
+	}
+	for _, d := range []struct {
+		name string
+		val  Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+		{"server.request_timeout", c.Server.RequestTimeout},
+		{"server.timeout_reserve", c.Server.TimeoutReserve},
+		{"database.conn_max_lifetime", c.Database.ConnMaxLifetime},
+		{"database.conn_max_idle_time", c.Database.ConnMaxIdleTime},
+		{"database.read_your_writes", c.Database.ReadYourWrites},
+		{"database.connect_timeout", c.Database.ConnectTimeout},
+		{"redis.connect_timeout", c.Redis.ConnectTimeout},
+		{"cache.list_ttl", c.Cache.ListTTL},
+		{"cache.item_ttl", c.Cache.ItemTTL},
+		{"cache.negative_ttl", c.Cache.NegativeTTL},
+		{"cache.ttl_jitter", c.Cache.TTLJitter},
+		{"cache.stale_ttl", c.Cache.StaleTTL},
+		{"cache.local_ttl", c.Cache.LocalTTL},
+		{"retention.purge_completed_after", c.Retention.PurgeCompletedAfter},
+		{"retention.purge_tombstones_after", c.Retention.PurgeTombstonesAfter},
+		{"jobs.backoff", c.Jobs.Backoff},
+		{"outbox.poll_interval", c.Outbox.PollInterval},
+		{"outbox.feed_lag", c.Outbox.FeedLag},
+		{"sandbox.reset_interval", c.Sandbox.ResetInterval},
+		{"secrets.refresh_interval", c.Secrets.RefreshInterval},
+	} {
+		if d.val.Duration < 0 {
+			problems = append(problems, fmt.Sprintf("%s must not be negative", d.name))
+		}
+	}
+	for _, n := range []struct {
+		name string
+		val  int
+	}{
+		{"database.max_open_conns", c.Database.MaxOpenConns},
+		{"database.max_idle_conns", c.Database.MaxIdleConns},
+		{"redis.pool_size", c.Redis.PoolSize},
+		{"redis.min_idle_conns", c.Redis.MinIdleConns},
+		{"cache.local_max_entries", c.Cache.LocalMaxEntries},
+		{"tasks.wip_limit", c.Tasks.WIPLimit},
+		{"circuit_breaker.failure_threshold", c.CircuitBreaker.FailureThreshold},
+		{"metrics.top_assignees", c.Metrics.TopAssignees},
+	} {
+		if n.val < 0 {
+			problems = append(problems, fmt.Sprintf("%s must not be negative", n.name))
+		}
+	}
+	if c.Server.RequestTimeout.Duration == 0 {
+		problems = append(problems, "server.request_timeout (SERVER_REQUEST_TIMEOUT) must be positive")
+	}
+	if c.Server.TimeoutReserve.Duration >= c.Server.RequestTimeout.Duration && c.Server.RequestTimeout.Duration > 0 {
+		problems = append(problems, "server.timeout_reserve (SERVER_TIMEOUT_RESERVE) must be shorter than server.request_timeout")
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		problems = append(problems, "server.max_body_bytes (SERVER_MAX_BODY_BYTES) must be positive")
+	}
+	if c.Server.Listen != "" {
+		if err := listener.Valid(c.Server.Listen); err != nil {
+			problems = append(problems, fmt.Sprintf("server.listen (LISTEN): invalid %q: %v", c.Server.Listen, err))
+		}
+	}
+	if c.Admin.Listen != "" {
+		if err := listener.Valid(c.Admin.Listen); err != nil {
+			problems = append(problems, fmt.Sprintf("admin.listen (ADMIN_LISTEN): invalid %q: %v", c.Admin.Listen, err))
+		}
+	}
+	for _, t := range []struct{ name, cert, key string }{
+		{"server", c.Server.TLSCertFile, c.Server.TLSKeyFile},
+		{"admin", c.Admin.TLSCertFile, c.Admin.TLSKeyFile},
+	} {
+		if (t.cert == "") != (t.key == "") {
+			problems = append(problems, fmt.Sprintf("%s.tls_cert_file and %s.tls_key_file must be set together", t.name, t.name))
+		}
+	}
+	if c.Server.TLSClientCAFile != "" && c.Server.TLSCertFile == "" {
+		problems = append(problems, "server.tls_client_ca_file (SERVER_TLS_CLIENT_CA_FILE) requires server.tls_cert_file")
+	}
+	switch c.Server.TLSClientAuth {
+	case "require", "optional":
+	default:
+		problems = append(problems, fmt.Sprintf("server.tls_client_auth (SERVER_TLS_CLIENT_AUTH): unknown mode %q (want require or optional)", c.Server.TLSClientAuth))
+	}
+	if bp := c.Server.BasePath; bp != "" && (!strings.HasPrefix(bp, "/") || strings.HasSuffix(bp, "/")) {
+		problems = append(problems, fmt.Sprintf("server.base_path (SERVER_BASE_PATH): %q must start with / and not end with /", bp))
+	}
+	for _, p := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			problems = append(problems, fmt.Sprintf("server.trusted_proxies (SERVER_TRUSTED_PROXIES): %q is not an IP address or CIDR", p))
+		}
+	}
+	if len(c.Server.TrustedProxies) > 0 && len(c.Server.RemoteIPHeaders) == 0 {
+		problems = append(problems, "server.remote_ip_headers (SERVER_REMOTE_IP_HEADERS) must not be empty when server.trusted_proxies is set")
+	}
+	if c.Redis.ReconnectInterval.Duration <= 0 {
+		problems = append(problems, "redis.reconnect_interval (REDIS_RECONNECT_INTERVAL) must be positive")
+	}
+	if c.Redis.FailureThreshold < 1 {
+		problems = append(problems, "redis.failure_threshold (REDIS_FAILURE_THRESHOLD) must be at least 1")
+	}
+	if c.Retention.Interval.Duration <= 0 {
+		problems = append(problems, "retention.interval (RETENTION_INTERVAL) must be positive")
+	}
+	if c.CircuitBreaker.FailureThreshold > 0 && c.CircuitBreaker.Cooldown.Duration <= 0 {
+		problems = append(problems, "circuit_breaker.cooldown (CIRCUIT_BREAKER_COOLDOWN) must be positive")
+	}
+	if c.Jobs.Concurrency < 1 {
+		problems = append(problems, "jobs.concurrency (JOBS_CONCURRENCY) must be at least 1")
+	}
+	if c.Jobs.PollInterval.Duration <= 0 {
+		problems = append(problems, "jobs.poll_interval (JOBS_POLL_INTERVAL) must be positive")
+	}
+	if c.Jobs.MaxAttempts < 1 {
+		problems = append(problems, "jobs.max_attempts (JOBS_MAX_ATTEMPTS) must be at least 1")
+	}
+	if c.Outbox.PollInterval.Duration == 0 {
+		problems = append(problems, "outbox.poll_interval (OUTBOX_POLL_INTERVAL) must be positive")
+	}
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, fmt.Sprintf("log.level (LOG_LEVEL): unknown level %q (want debug, info, warn or error)", c.Log.Level))
+	}
+	if c.Metrics.RefreshInterval.Duration <= 0 {
+		problems = append(problems, "metrics.refresh_interval (METRICS_REFRESH_INTERVAL) must be positive")
+	}
+	if b := c.Metrics.LatencyBuckets; len(b) == 0 {
+		problems = append(problems, "metrics.latency_buckets (METRICS_LATENCY_BUCKETS) must not be empty")
+	} else {
+		for i, v := range b {
+			if v <= 0 || (i > 0 && v <= b[i-1]) {
+				problems = append(problems, "metrics.latency_buckets (METRICS_LATENCY_BUCKETS) must be positive and increasing")
+				break
+			}
+		}
+	}
+	switch c.Secrets.Provider {
+	case "":
+		if len(c.Secrets.Refs()) > 0 {
+			problems = append(problems, "secrets.provider (SECRETS_PROVIDER) is required to resolve secret references")
+		}
+	case "vault":
+		if c.Secrets.Vault.Addr == "" || c.Secrets.Vault.Token == "" {
+			problems = append(problems, "secrets.vault.addr (VAULT_ADDR) and secrets.vault.token (VAULT_TOKEN) are required with the vault provider")
+		}
+	case "aws":
+		if c.Secrets.AWS.Region == "" || c.Secrets.AWS.AccessKeyID == "" || c.Secrets.AWS.SecretAccessKey == "" {
+			problems = append(problems, "secrets.aws.region (AWS_REGION), secrets.aws.access_key_id (AWS_ACCESS_KEY_ID) and secrets.aws.secret_access_key (AWS_SECRET_ACCESS_KEY) are required with the aws provider")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("secrets.provider (SECRETS_PROVIDER): unknown provider %q (want vault or aws)", c.Secrets.Provider))
+	}
+	for name, ref := range c.Secrets.Refs() {
+		if secret, _, _ := strings.Cut(ref, "#"); secret == "" {
+			problems = append(problems, fmt.Sprintf("secrets reference for %s: %q names no secret", name, ref))
+		}
+	}
+	if c.Sentry.DSN != "" {
+		if u, err := url.Parse(c.Sentry.DSN); err != nil || u.Host == "" || u.User.Username() == "" {
+			problems = append(problems, "sentry.dsn (SENTRY_DSN) must look like https://<key>@<host>/<project id>")
+		}
+	}
+	switch c.Outbox.Publisher {
+	case "log", "none":
+	case "nats":
+		if c.Outbox.NATSURL == "" {
+			problems = append(problems, "outbox.nats_url (NATS_URL) is required when outbox.publisher is nats")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("outbox.publisher (OUTBOX_PUBLISHER): unknown publisher %q (want log, nats or none)", c.Outbox.Publisher))
+	}
+	return problems
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
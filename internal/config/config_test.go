@@ -0,0 +1,301 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return p
+}
+
+func TestLoad_FileThenEnvOverrides(t *testing.T) {
+	p := writeFile(t, "config.yaml", `
+server:
+  port: "9090"
+  read_timeout: 5s
+database:
+  url: postgres://file
+cache:
+  list_ttl: 2m
+features:
+  beta: true
+`)
+	cfg, err := load(p, []string{"DATABASE_URL=postgres://env", "CORS_ALLOWED_ORIGINS=https://a.example, https://b.example", "FEATURE_BETA=false"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Server.Port != "9090" || cfg.Server.ReadTimeout.Duration != 5*time.Second {
+		t.Fatalf("file values not applied: %+v", cfg.Server)
+	}
+	if cfg.Database.URL != "postgres://env" {
+		t.Fatalf("expected env override, got %q", cfg.Database.URL)
+	}
+	if cfg.Cache.ListTTL.Duration != 2*time.Minute {
+		t.Fatalf("expected list ttl 2m got %s", cfg.Cache.ListTTL)
+	}
+	if len(cfg.CORS.AllowedOrigins) != 2 || cfg.CORS.AllowedOrigins[1] != "https://b.example" {
+		t.Fatalf("unexpected origins %v", cfg.CORS.AllowedOrigins)
+	}
+	if cfg.Feature("beta") {
+		t.Fatalf("expected FEATURE_BETA to disable the flag")
+	}
+	// untouched keys keep their defaults
+	if cfg.Server.ShutdownTimeout.Duration != 10*time.Second {
+		t.Fatalf("expected default shutdown timeout got %s", cfg.Server.ShutdownTimeout)
+	}
+}
+
+func TestLoad_JSONFile(t *testing.T) {
+	p := writeFile(t, "config.json", `{"database":{"url":"postgres://json"},"outbox":{"publisher":"none"}}`)
+	cfg, err := load(p, nil)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Database.URL != "postgres://json" || cfg.Outbox.Publisher != "none" {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
+
+func TestLoad_UnknownKeyRejected(t *testing.T) {
+	p := writeFile(t, "config.yaml", "database:\n  uri: postgres://typo\n")
+	if _, err := load(p, nil); err == nil {
+		t.Fatalf("expected unknown key to be rejected")
+	}
+}
+
+func TestLoad_ValidationListsAllProblems(t *testing.T) {
+	_, err := load("", []string{"PORT=abc", "SERVER_READ_TIMEOUT=soon", "OUTBOX_PUBLISHER=kafka", "DATABASE_DRIVER=oracle"})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError got %v", err)
+	}
+	msg := err.Error()
+	for _, want := range []string{"DATABASE_URL", "DATABASE_DRIVER", "PORT", "SERVER_READ_TIMEOUT", "OUTBOX_PUBLISHER"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("expected %s in error, got:\n%s", want, msg)
+		}
+	}
+}
+
+func TestLoad_PoolLimitsFromEnv(t *testing.T) {
+	cfg, err := load("", []string{
+		"DATABASE_URL=postgres://env",
+		"DATABASE_MAX_OPEN_CONNS=20",
+		"DATABASE_CONN_MAX_LIFETIME=5m",
+		"REDIS_POOL_SIZE=50",
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Database.MaxOpenConns != 20 || cfg.Database.ConnMaxLifetime.Duration != 5*time.Minute {
+		t.Fatalf("unexpected database pool config %+v", cfg.Database)
+	}
+	if cfg.Database.MaxIdleConns != 2 {
+		t.Fatalf("expected default max idle conns 2 got %d", cfg.Database.MaxIdleConns)
+	}
+	if cfg.Redis.PoolSize != 50 {
+		t.Fatalf("expected redis pool size 50 got %d", cfg.Redis.PoolSize)
+	}
+
+	if _, err := load("", []string{"DATABASE_URL=postgres://env", "DATABASE_MAX_IDLE_CONNS=-1"}); err == nil || !strings.Contains(err.Error(), "database.max_idle_conns") {
+		t.Fatalf("expected negative pool size to be rejected, got %v", err)
+	}
+}
+
+func TestLoad_ReadReplica(t *testing.T) {
+	cfg, err := load("", []string{"DATABASE_URL=postgres://primary", "DATABASE_REPLICA_URL=postgres://replica", "DATABASE_REPLICA_READ_YOUR_WRITES=3s"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Database.ReplicaURL != "postgres://replica" || cfg.Database.ReadYourWrites.Duration != 3*time.Second {
+		t.Fatalf("unexpected replica config %+v", cfg.Database)
+	}
+	if _, err := load("", []string{"DATABASE_DRIVER=sqlite", "DATABASE_URL=x.db", "DATABASE_REPLICA_URL=y.db"}); err == nil || !strings.Contains(err.Error(), "database.replica_url") {
+		t.Fatalf("expected replica with sqlite to be rejected, got %v", err)
+	}
+}
+
+func TestLoad_WIPLimitAndAdminKeys(t *testing.T) {
+	cfg, err := load("", []string{"DATABASE_URL=postgres://env", "TASKS_WIP_LIMIT=5", "AUTH_ADMIN_KEYS=root"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Tasks.WIPLimit != 5 || len(cfg.Auth.AdminKeys) != 1 || !cfg.Auth.Enabled() {
+		t.Fatalf("unexpected config tasks=%+v auth=%+v", cfg.Tasks, cfg.Auth)
+	}
+	if _, err := load("", []string{"DATABASE_URL=postgres://env", "TASKS_WIP_LIMIT=-1"}); err == nil || !strings.Contains(err.Error(), "tasks.wip_limit") {
+		t.Fatalf("expected a negative WIP limit to be rejected, got %v", err)
+	}
+}
+
+func TestLoad_Retention(t *testing.T) {
+	cfg, err := load("", []string{"DATABASE_URL=postgres://env", "RETENTION_PURGE_COMPLETED_AFTER=2160h", "RETENTION_PURGE_TOMBSTONES_AFTER=720h"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Retention.PurgeCompletedAfter.Duration != 90*24*time.Hour || cfg.Retention.PurgeTombstonesAfter.Duration != 30*24*time.Hour || cfg.Retention.Interval.Duration != time.Hour {
+		t.Fatalf("unexpected retention %+v", cfg.Retention)
+	}
+	if _, err := load("", []string{"DATABASE_URL=postgres://env", "RETENTION_INTERVAL=0s"}); err == nil || !strings.Contains(err.Error(), "retention.interval") {
+		t.Fatalf("expected a zero interval to be rejected, got %v", err)
+	}
+}
+
+func TestLoad_ConnectRetry(t *testing.T) {
+	cfg, err := load("", []string{"DATABASE_URL=postgres://env", "DATABASE_CONNECT_TIMEOUT=2m", "DATABASE_START_DEGRADED=true"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Database.ConnectTimeout.Duration != 2*time.Minute || !cfg.Database.StartDegraded || cfg.Redis.ConnectTimeout.Duration != 30*time.Second {
+		t.Fatalf("unexpected connect settings %+v %+v", cfg.Database, cfg.Redis)
+	}
+	if _, err := load("", []string{"DATABASE_URL=postgres://env", "REDIS_CONNECT_TIMEOUT=-1s"}); err == nil || !strings.Contains(err.Error(), "redis.connect_timeout") {
+		t.Fatalf("expected a negative timeout to be rejected, got %v", err)
+	}
+}
+
+func TestLoad_Jobs(t *testing.T) {
+	cfg, err := load("", []string{"DATABASE_URL=postgres://env", "JOBS_CONCURRENCY=4", "JOBS_BACKOFF=30s"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Jobs.Concurrency != 4 || cfg.Jobs.Backoff.Duration != 30*time.Second || cfg.Jobs.MaxAttempts != 5 || cfg.Jobs.PollInterval.Duration != time.Second {
+		t.Fatalf("unexpected jobs %+v", cfg.Jobs)
+	}
+	if _, err := load("", []string{"DATABASE_URL=postgres://env", "JOBS_CONCURRENCY=0"}); err == nil || !strings.Contains(err.Error(), "jobs.concurrency") {
+		t.Fatalf("expected zero concurrency to be rejected, got %v", err)
+	}
+}
+
+func TestLoad_LatencyBuckets(t *testing.T) {
+	cfg, err := load("", []string{"DATABASE_URL=postgres://env", "METRICS_LATENCY_BUCKETS=0.05, 0.2,1"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if b := cfg.Metrics.LatencyBuckets; len(b) != 3 || b[0] != 0.05 || b[2] != 1 {
+		t.Fatalf("unexpected buckets %v", b)
+	}
+	if cfg.Metrics.RefreshInterval.Duration != 30*time.Second || cfg.Metrics.TopAssignees != 10 {
+		t.Fatalf("unexpected metrics %+v", cfg.Metrics)
+	}
+	for _, v := range []string{"0.5,0.1", "0,1", "fast"} {
+		if _, err := load("", []string{"DATABASE_URL=postgres://env", "METRICS_LATENCY_BUCKETS=" + v}); err == nil || !strings.Contains(err.Error(), "METRICS_LATENCY_BUCKETS") {
+			t.Fatalf("expected buckets %q to be rejected, got %v", v, err)
+		}
+	}
+}
+
+func TestLoad_SandboxIsSelfContained(t *testing.T) {
+	cfg, err := load("", []string{"SANDBOX=true", "SANDBOX_RESET_INTERVAL=15m", "OUTBOX_PUBLISHER=nats", "REDIS_REQUIRED=true"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if !cfg.Database.InMemory() || cfg.Redis.Addr != "" || cfg.Redis.Required || cfg.Outbox.Publisher != "none" {
+		t.Fatalf("expected sandbox to override storage and integrations, got %+v %+v %+v", cfg.Database, cfg.Redis, cfg.Outbox)
+	}
+	if cfg.Sandbox.ResetInterval.Duration != 15*time.Minute {
+		t.Fatalf("unexpected reset interval %s", cfg.Sandbox.ResetInterval)
+	}
+}
+
+func TestLoad_AdminListenerAndTLS(t *testing.T) {
+	cfg, err := load("", []string{"DATABASE_URL=postgres://env", "ADMIN_LISTEN=127.0.0.1:9090"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Admin.Listen != "127.0.0.1:9090" {
+		t.Fatalf("unexpected admin listen %q", cfg.Admin.Listen)
+	}
+
+	_, err = load("", []string{"DATABASE_URL=postgres://env", "SERVER_TLS_CERT_FILE=/tls/cert.pem", "ADMIN_LISTEN=9090"})
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	for _, want := range []string{"server.tls_cert_file", "ADMIN_LISTEN"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %s in error, got:\n%s", want, err)
+		}
+	}
+}
+
+func TestLoad_TrustedProxies(t *testing.T) {
+	cfg, err := load("", []string{"DATABASE_URL=postgres://env"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(cfg.Server.TrustedProxies) != 0 || strings.Join(cfg.Server.RemoteIPHeaders, ",") != "X-Forwarded-For,X-Real-IP" {
+		t.Fatalf("unexpected defaults %v %v", cfg.Server.TrustedProxies, cfg.Server.RemoteIPHeaders)
+	}
+
+	cfg, err = load("", []string{"DATABASE_URL=postgres://env", "SERVER_TRUSTED_PROXIES=10.0.0.0/8, 192.168.1.10", "SERVER_REMOTE_IP_HEADERS=X-Real-IP"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(cfg.Server.TrustedProxies) != 2 || cfg.Server.TrustedProxies[1] != "192.168.1.10" || len(cfg.Server.RemoteIPHeaders) != 1 {
+		t.Fatalf("unexpected proxies %v headers %v", cfg.Server.TrustedProxies, cfg.Server.RemoteIPHeaders)
+	}
+
+	_, err = load("", []string{"DATABASE_URL=postgres://env", "SERVER_TRUSTED_PROXIES=10.0.0.0/33,lb.internal"})
+	if err == nil || !strings.Contains(err.Error(), `"10.0.0.0/33"`) || !strings.Contains(err.Error(), `"lb.internal"`) {
+		t.Fatalf("expected both proxies rejected, got %v", err)
+	}
+}
+
+func TestLoad_ClientCertAuth(t *testing.T) {
+	cfg, err := load("", []string{"DATABASE_URL=postgres://env", "SERVER_TLS_CERT_FILE=/tls/cert.pem", "SERVER_TLS_KEY_FILE=/tls/key.pem",
+		"SERVER_TLS_CLIENT_CA_FILE=/tls/ca.pem", "SERVER_TLS_CLIENT_AUTH=optional", "AUTH_ADMIN_IDENTITIES=ops-bot"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Server.TLSClientCAFile != "/tls/ca.pem" || cfg.Server.TLSClientAuth != "optional" || len(cfg.Auth.AdminIdentities) != 1 {
+		t.Fatalf("unexpected config %+v %+v", cfg.Server, cfg.Auth)
+	}
+
+	_, err = load("", []string{"DATABASE_URL=postgres://env", "SERVER_TLS_CLIENT_CA_FILE=/tls/ca.pem", "SERVER_TLS_CLIENT_AUTH=sometimes"})
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	for _, want := range []string{"requires server.tls_cert_file", `unknown mode "sometimes"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %s in error, got:\n%s", want, err)
+		}
+	}
+}
+
+func TestLoad_Secrets(t *testing.T) {
+	cfg, err := load("", []string{"SECRETS_PROVIDER=vault", "VAULT_ADDR=https://vault:8200", "VAULT_TOKEN=t",
+		"SECRETS_DATABASE_URL=taskmanager/prod#database_url", "SECRETS_REDIS_PASSWORD=taskmanager/prod#redis", "SECRETS_REFRESH_INTERVAL=5m"})
+	if err != nil {
+		t.Fatalf("a database url reference must stand in for DATABASE_URL: %v", err)
+	}
+	refs := cfg.Secrets.Refs()
+	if len(refs) != 2 || refs["database.url"] != "taskmanager/prod#database_url" || refs["redis.password"] != "taskmanager/prod#redis" {
+		t.Fatalf("unexpected refs %v", refs)
+	}
+	if cfg.Secrets.RefreshInterval.Duration != 5*time.Minute {
+		t.Fatalf("unexpected refresh interval %s", cfg.Secrets.RefreshInterval)
+	}
+
+	_, err = load("", []string{"DATABASE_URL=postgres://env", "SECRETS_AUTH_API_KEYS=#keys", "SECRETS_PROVIDER=aws", "AWS_REGION=eu-west-1"})
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	for _, want := range []string{"AWS_ACCESS_KEY_ID", `"#keys" names no secret`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %s in error, got:\n%s", want, err)
+		}
+	}
+	if _, err := load("", []string{"DATABASE_URL=postgres://env", "SECRETS_REDIS_PASSWORD=taskmanager#redis"}); err == nil || !strings.Contains(err.Error(), "SECRETS_PROVIDER") {
+		t.Fatalf("expected the missing provider reported, got %v", err)
+	}
+}
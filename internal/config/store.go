@@ -0,0 +1,160 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Reloadable lists the settings a running service picks up on Store.Reload;
+// "section.*" covers every key of a section. Everything else only takes
+// effect on restart.
+var Reloadable = []string{
+	"log.level",
+	"cache.list_ttl",
+	"cache.item_ttl",
+	"cache.negative_ttl",
+	"cache.ttl_jitter",
+	"cache.stale_ttl",
+	"cors.*",
+	"features.*",
+	"server.request_timeout",
+	"server.timeout_reserve",
+	"server.max_body_bytes",
+	"tasks.wip_limit",
+}
+
+// IsReloadable reports whether key (e.g. "cache.list_ttl") is in Reloadable.
+func IsReloadable(key string) bool {
+	for _, k := range Reloadable {
+		if k == key || (strings.HasSuffix(k, ".*") && strings.HasPrefix(key, strings.TrimSuffix(k, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReloadResult names the settings a reload changed, by key.
+type ReloadResult struct {
+	// Applied are the changed settings now in effect.
+	Applied []string `json:"applied"`
+	// RestartRequired are changed settings that are not reloadable; the
+	// service keeps running with their old values.
+	RestartRequired []string `json:"restart_required"`
+}
+
+// Store holds the configuration of a running service and swaps in a new
+// snapshot when it is reloaded from the same file and environment.
+type Store struct {
+	path    string
+	environ func() []string
+
+	cur atomic.Pointer[Config]
+
+	// mu serializes reloads; running is the flattened configuration in
+	// effect (see flatten)
+	mu      sync.Mutex
+	running map[string]string
+	hooks   []func(*Config)
+}
+
+// NewStore creates a store serving cfg, loaded from path. Create it before
+// cfg is changed in place (e.g. by resolved secrets) so reloads compare
+// against what the file and environment said.
+func NewStore(path string, cfg *Config) *Store {
+	s := &Store{path: path, environ: os.Environ, running: flatten(cfg)}
+	s.cur.Store(cfg)
+	return s
+}
+
+// Current returns the latest configuration snapshot. Only its Reloadable
+// settings are guaranteed to be the ones in effect.
+func (s *Store) Current() *Config {
+	return s.cur.Load()
+}
+
+// OnReload registers fn to be called with the new snapshot after every
+// successful reload, changed or not, so it can re-apply settings that were
+// adjusted at runtime in the meantime.
+func (s *Store) OnReload(fn func(*Config)) {
+	s.mu.Lock()
+	s.hooks = append(s.hooks, fn)
+	s.mu.Unlock()
+}
+
+// Reload loads and validates the configuration again and, when that
+// succeeds, swaps the snapshot returned by Current and runs the OnReload
+// hooks. An invalid configuration is returned as a *ValidationError and
+// leaves the current one in place.
+func (s *Store) Reload() (ReloadResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, err := load(s.path, s.environ())
+	if err != nil {
+		return ReloadResult{}, err
+	}
+	next := flatten(cfg)
+	res := ReloadResult{Applied: []string{}, RestartRequired: []string{}}
+	for _, key := range changedKeys(s.running, next) {
+		if !IsReloadable(key) {
+			res.RestartRequired = append(res.RestartRequired, key)
+			continue
+		}
+		res.Applied = append(res.Applied, key)
+		if v, ok := next[key]; ok {
+			s.running[key] = v
+		} else {
+			delete(s.running, key)
+		}
+	}
+	s.cur.Store(cfg)
+	for _, fn := range s.hooks {
+		fn(cfg)
+	}
+	return res, nil
+}
+
+// flatten maps every setting of cfg, by its dotted key, to its JSON value.
+// Lists are single values; maps (features) are sections.
+func flatten(cfg *Config) map[string]string {
+	b, _ := json.Marshal(cfg)
+	var tree map[string]any
+	_ = json.Unmarshal(b, &tree)
+	out := map[string]string{}
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		if m, ok := v.(map[string]any); ok {
+			for k, child := range m {
+				walk(prefix+k+".", child)
+			}
+			return
+		}
+		b, _ := json.Marshal(v)
+		out[strings.TrimSuffix(prefix, ".")] = string(b)
+	}
+	walk("", tree)
+	return out
+}
+
+// changedKeys returns, sorted, the keys whose values differ between a and b,
+// including keys only one of them has.
+func changedKeys(a, b map[string]string) []string {
+	var keys []string
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			keys = append(keys, k)
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return slices.Compact(keys)
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStore_Reload(t *testing.T) {
+	p := writeFile(t, "config.yaml", `
+server:
+  port: "9090"
+database:
+  url: postgres://file
+cache:
+  list_ttl: 2m
+`)
+	environ := func() []string { return []string{"FEATURE_BETA=true"} }
+	cfg, err := load(p, environ())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	s := NewStore(p, cfg)
+	s.environ = environ
+	// what loadSecrets does must not count as a change
+	cfg.Database.URL = "postgres://from-vault"
+	var seen *Config
+	s.OnReload(func(c *Config) { seen = c })
+
+	if res, err := s.Reload(); err != nil || len(res.Applied) != 0 || len(res.RestartRequired) != 0 || seen == nil {
+		t.Fatalf("expected an unchanged file to apply nothing but run the hooks, got %+v %v", res, err)
+	}
+
+	if err := os.WriteFile(p, []byte(`
+server:
+  port: "9191"
+database:
+  url: postgres://file
+cache:
+  list_ttl: 30s
+cors:
+  allowed_origins: ["https://app.example"]
+log:
+  level: debug
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	res, err := s.Reload()
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if want := []string{"cache.list_ttl", "cors.allowed_origins", "log.level"}; !slices.Equal(res.Applied, want) {
+		t.Fatalf("applied %v, want %v", res.Applied, want)
+	}
+	if want := []string{"server.port"}; !slices.Equal(res.RestartRequired, want) {
+		t.Fatalf("restart required %v, want %v", res.RestartRequired, want)
+	}
+	if seen == nil || s.Current() != seen || seen.Cache.ListTTL.Duration != 30*time.Second || seen.Log.Level != "debug" {
+		t.Fatalf("expected the hook to see the new snapshot, got %+v", seen)
+	}
+
+	// the port still differs from the running one; the env flag is unchanged
+	res, err = s.Reload()
+	if err != nil || len(res.Applied) != 0 || !slices.Equal(res.RestartRequired, []string{"server.port"}) {
+		t.Fatalf("unexpected second reload %+v %v", res, err)
+	}
+	if !s.Current().Feature("beta") {
+		t.Fatalf("expected env overrides to apply on reload")
+	}
+}
+
+func TestStore_ReloadRejectsInvalidConfig(t *testing.T) {
+	p := writeFile(t, "config.yaml", "database:\n  url: postgres://file\n")
+	cfg, err := load(p, nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	s := NewStore(p, cfg)
+	s.environ = func() []string { return nil }
+	if err := os.WriteFile(p, []byte("database:\n  url: postgres://file\ncache:\n  list_ttl: -1s\ntasks:\n  wip_limit: -2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Reload()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Problems) != 2 {
+		t.Fatalf("expected both problems reported, got %v", err)
+	}
+	if s.Current() != cfg {
+		t.Fatalf("expected the invalid configuration not to be swapped in")
+	}
+}
+
+func TestIsReloadable(t *testing.T) {
+	for key, want := range map[string]bool{
+		"log.level":            true,
+		"features.beta":        true,
+		"cors.allowed_origins": true,
+		"cache.local_ttl":      false,
+		"server.port":          false,
+		"features":             false,
+	} {
+		if got := IsReloadable(key); got != want {
+			t.Errorf("IsReloadable(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// Package database opens the configured SQL backend and covers the few
+// dialect differences between PostgreSQL, SQLite and MySQL/MariaDB that the
+// repositories need to know about. Queries are written for PostgreSQL ($n
+// placeholders) and rewritten for the other drivers by Dialect.Rebind.
+package database
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// Supported values of database.driver.
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+	MySQL    = "mysql"
+)
+
+func init() {
+	// sqlx only knows the cgo driver's name; named queries need "?" for ours too
+	sqlx.BindDriver(SQLite, sqlx.QUESTION)
+}
+
+// Valid reports whether driver is supported.
+func Valid(driver string) bool {
+	return driver == Postgres || driver == SQLite || driver == MySQL
+}
+
+// Open connects to url using driver. SQLite is limited to one connection:
+// it allows a single writer anyway, and ":memory:" databases are per
+// connection. For MySQL, url is a go-sql-driver DSN
+// (user:pass@tcp(host:3306)/db).
+func Open(driver, url string) (*sqlx.DB, error) {
+	db, err := OpenLazy(driver, url)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// OpenLazy is Open without connecting: it only fails on a bad driver or URL,
+// and the database may come up later (see retry.Do). Each wrap decorates the
+// driver's connector, e.g. with (*breaker.Breaker).WrapConnector.
+func OpenLazy(driver, url string, wrap ...func(sqldriver.Connector) sqldriver.Connector) (*sqlx.DB, error) {
+	if !Valid(driver) {
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+	if driver == MySQL {
+		var err error
+		if url, err = mysqlDSN(url); err != nil {
+			return nil, err
+		}
+	}
+	db, err := sqlx.Open(driver, url)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrap) > 0 {
+		c, err := connector(db.Driver(), url)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		for _, w := range wrap {
+			c = w(c)
+		}
+		db.Close()
+		db = sqlx.NewDb(sql.OpenDB(c), driver)
+	}
+	if driver == SQLite {
+		db.SetMaxOpenConns(1)
+	}
+	return db, nil
+}
+
+// OpenRotating is OpenLazy for a DSN that can change while the service runs,
+// such as credentials rotated in a secrets manager (see secrets.Set): every
+// new connection uses the DSN dsn returns at that time. Open connections are
+// kept until the pool retires them (database.conn_max_lifetime).
+func OpenRotating(driver string, dsn func() string, wrap ...func(sqldriver.Connector) sqldriver.Connector) (*sqlx.DB, error) {
+	if !Valid(driver) {
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+	// sql.Open doesn't connect; the handle only gives the driver
+	probe, err := sql.Open(driver, "")
+	if err != nil {
+		return nil, err
+	}
+	rc := &rotatingConnector{driver: driver, d: probe.Driver(), dsn: dsn}
+	probe.Close()
+	if _, err := rc.current(); err != nil {
+		return nil, err
+	}
+	var c sqldriver.Connector = rc
+	for _, w := range wrap {
+		c = w(c)
+	}
+	db := sqlx.NewDb(sql.OpenDB(c), driver)
+	if driver == SQLite {
+		db.SetMaxOpenConns(1)
+	}
+	return db, nil
+}
+
+// rotatingConnector connects with the DSN current at the time, rebuilding
+// the driver's connector when it changes.
+type rotatingConnector struct {
+	driver string
+	d      sqldriver.Driver
+	dsn    func() string
+
+	mu   sync.Mutex
+	last string
+	c    sqldriver.Connector
+}
+
+func (r *rotatingConnector) current() (sqldriver.Connector, error) {
+	dsn := r.dsn()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.c != nil && dsn == r.last {
+		return r.c, nil
+	}
+	url := dsn
+	if r.driver == MySQL {
+		var err error
+		if url, err = mysqlDSN(dsn); err != nil {
+			return nil, err
+		}
+	}
+	c, err := connector(r.d, url)
+	if err != nil {
+		return nil, err
+	}
+	r.c, r.last = c, dsn
+	return c, nil
+}
+
+func (r *rotatingConnector) Connect(ctx context.Context) (sqldriver.Conn, error) {
+	c, err := r.current()
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(ctx)
+}
+
+func (r *rotatingConnector) Driver() sqldriver.Driver {
+	return r.d
+}
+
+// connector returns d's connector for dsn.
+func connector(d sqldriver.Driver, dsn string) (sqldriver.Connector, error) {
+	if dc, ok := d.(sqldriver.DriverContext); ok {
+		return dc.OpenConnector(dsn)
+	}
+	return dsnConnector{d: d, dsn: dsn}, nil
+}
+
+// dsnConnector is the connector of drivers without one (as in database/sql).
+type dsnConnector struct {
+	d   sqldriver.Driver
+	dsn string
+}
+
+func (c dsnConnector) Connect(context.Context) (sqldriver.Conn, error) {
+	return c.d.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() sqldriver.Driver {
+	return c.d
+}
+
+// mysqlDSN enables the options the repositories rely on: DATETIME columns
+// scanned into time.Time, and multi-statement migration files.
+func mysqlDSN(dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid mysql DSN: %w", err)
+	}
+	cfg.ParseTime = true
+	cfg.MultiStatements = true
+	return cfg.FormatDSN(), nil
+}
+
+// Dialect describes the SQL flavour behind a connection.
+// The zero value is PostgreSQL.
+type Dialect struct {
+	driver string
+}
+
+// For returns the dialect of db (a *sqlx.DB, *sqlx.Tx or *sqlx.Conn).
+func For(db interface{ DriverName() string }) Dialect {
+	switch name := db.DriverName(); name {
+	case SQLite, MySQL:
+		return Dialect{driver: name}
+	}
+	return Dialect{}
+}
+
+// Postgres reports whether the dialect is PostgreSQL.
+func (d Dialect) Postgres() bool {
+	return d.driver == ""
+}
+
+// SQLite reports whether the dialect is SQLite.
+func (d Dialect) SQLite() bool {
+	return d.driver == SQLite
+}
+
+// MySQL reports whether the dialect is MySQL or MariaDB.
+func (d Dialect) MySQL() bool {
+	return d.driver == MySQL
+}
+
+var placeholder = regexp.MustCompile(`\$(\d+)`)
+
+// Rebind rewrites $n placeholders for the driver. SQLite's numbered ?n form
+// keeps their meaning when a parameter is used out of order or more than
+// once; MySQL only has positional ?, so queries shared with it must use each
+// placeholder once and in ascending order.
+func (d Dialect) Rebind(query string) string {
+	switch d.driver {
+	case SQLite:
+		return placeholder.ReplaceAllString(query, "?$1")
+	case MySQL:
+		return placeholder.ReplaceAllString(query, "?")
+	}
+	return query
+}
+
+// Now is the SQL expression for the current time.
+func (d Dialect) Now() string {
+	if d.Postgres() {
+		return "now()"
+	}
+	return "CURRENT_TIMESTAMP"
+}
+
+// ForUpdate is the row-locking suffix for SELECTs in a transaction. SQLite
+// has no row locks (writers are serialized), so it is empty there.
+func (d Dialect) ForUpdate() string {
+	if d.SQLite() {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// ILike renders a case-insensitive LIKE of column against the pattern
+// placeholder ph, with backslash as the escape character. SQLite's LIKE is
+// already case-insensitive for ASCII and MySQL's for its default collations.
+func (d Dialect) ILike(column, ph string) string {
+	switch d.driver {
+	case SQLite:
+		return column + " LIKE " + ph + ` ESCAPE '\'`
+	case MySQL:
+		return column + " LIKE " + ph
+	}
+	return column + " ILIKE " + ph
+}
+
+// OrderBy renders one ORDER BY term. nulls is "", "FIRST" or "LAST"; MySQL
+// has no NULLS clause (NULLs sort first ascending), so it is emulated with an
+// IS NULL key.
+func (d Dialect) OrderBy(column string, desc bool, nulls string) string {
+	dir := " ASC"
+	if desc {
+		dir = " DESC"
+	}
+	switch {
+	case nulls == "":
+		return column + dir
+	case d.MySQL() && nulls == "FIRST":
+		return column + " IS NULL DESC, " + column + dir
+	case d.MySQL():
+		return column + " IS NULL ASC, " + column + dir
+	}
+	return column + dir + " NULLS " + nulls
+}
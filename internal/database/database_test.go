@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
+	"taskmanager/internal/metric"
+)
+
+func TestDialect(t *testing.T) {
+	pg, lite, my := Dialect{}, Dialect{driver: SQLite}, Dialect{driver: MySQL}
+	q := "UPDATE incidents SET resolved_at = COALESCE(resolved_at, $2) WHERE id = $1"
+	if got := pg.Rebind(q); got != q {
+		t.Fatalf("postgres query must be unchanged, got %q", got)
+	}
+	if got := lite.Rebind(q); got != "UPDATE incidents SET resolved_at = COALESCE(resolved_at, ?2) WHERE id = ?1" {
+		t.Fatalf("unexpected sqlite query %q", got)
+	}
+	if got := my.Rebind("UPDATE incidents SET resolved_at = $1 WHERE id = $2"); got != "UPDATE incidents SET resolved_at = ? WHERE id = ?" {
+		t.Fatalf("unexpected mysql query %q", got)
+	}
+	if pg.Now() != "now()" || lite.Now() != "CURRENT_TIMESTAMP" || my.Now() != "CURRENT_TIMESTAMP" {
+		t.Fatalf("unexpected now expressions")
+	}
+	if pg.ForUpdate() == "" || lite.ForUpdate() != "" || my.ForUpdate() == "" {
+		t.Fatalf("unexpected FOR UPDATE suffixes")
+	}
+	if got := pg.OrderBy("due_date", false, "LAST"); got != "due_date ASC NULLS LAST" {
+		t.Fatalf("unexpected postgres order term %q", got)
+	}
+	if got := my.OrderBy("due_date", false, "LAST"); got != "due_date IS NULL ASC, due_date ASC" {
+		t.Fatalf("unexpected mysql order term %q", got)
+	}
+	if got := my.OrderBy("due_date", true, "FIRST"); got != "due_date IS NULL DESC, due_date DESC" {
+		t.Fatalf("unexpected mysql order term %q", got)
+	}
+}
+
+func TestOpen_SQLiteMemory(t *testing.T) {
+	db, err := Open(SQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	if !For(db).SQLite() {
+		t.Fatalf("expected sqlite dialect")
+	}
+	var n int
+	if err := db.Get(&n, For(db).Rebind("SELECT $1 + $1"), 2); err != nil || n != 4 {
+		t.Fatalf("expected 4 got %d err=%v", n, err)
+	}
+	if _, err := Open("oracle", "x"); err == nil {
+		t.Fatalf("expected error for unsupported driver")
+	}
+}
+
+func TestMySQLDSN(t *testing.T) {
+	dsn, err := mysqlDSN("taskmgr:pass@tcp(localhost:3306)/taskmgr")
+	if err != nil {
+		t.Fatalf("dsn: %v", err)
+	}
+	if !strings.Contains(dsn, "parseTime=true") || !strings.Contains(dsn, "multiStatements=true") {
+		t.Fatalf("expected parseTime and multiStatements in %q", dsn)
+	}
+	if _, err := mysqlDSN("not a dsn"); err == nil {
+		t.Fatalf("expected error for malformed DSN")
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	db, err := OpenLazy(SQLite, ":memory:", Instrument)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	ctx := WithOperation(context.Background(), "tasks", "create")
+	errs := testutil.ToFloat64(metric.DBQueryErrors.WithLabelValues("tasks", "create"))
+	if _, err := db.ExecContext(ctx, "CREATE TABLE t (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO missing VALUES (1)"); err == nil {
+		t.Fatalf("expected an error for a missing table")
+	}
+	var n int
+	if err := db.GetContext(context.Background(), &n, "SELECT count(*) FROM t"); err != nil || n != 0 {
+		t.Fatalf("count = %d, %v", n, err)
+	}
+
+	if got := histogramCount(t, metric.DBQueryDuration.WithLabelValues("tasks", "create")); got < 2 {
+		t.Fatalf("expected both statements timed, got %d", got)
+	}
+	if got := histogramCount(t, metric.DBQueryDuration.WithLabelValues("other", "other")); got < 1 {
+		t.Fatalf("expected the unlabeled query under other, got %d", got)
+	}
+	if v := testutil.ToFloat64(metric.DBQueryErrors.WithLabelValues("tasks", "create")); v != errs+1 {
+		t.Fatalf("expected one error, got %v", v-errs)
+	}
+}
+
+func histogramCount(t *testing.T, o prometheus.Observer) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := o.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("write metric: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestOpenRotating_SQLite(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "a.db")
+	db, err := OpenRotating(SQLite, func() string { return dsn })
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE marker (name TEXT)"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// once the pool lets the connection go, the next one follows the new DSN
+	dsn = filepath.Join(dir, "b.db")
+	db.SetMaxIdleConns(0)
+	db.SetMaxIdleConns(1)
+	var n int
+	if err := db.Get(&n, "SELECT count(*) FROM sqlite_master WHERE name = 'marker'"); err != nil || n != 0 {
+		t.Fatalf("expected a connection to the new database, got %d tables err=%v", n, err)
+	}
+
+	if _, err := OpenRotating(MySQL, func() string { return "not a dsn" }); err == nil {
+		t.Fatalf("expected error for a malformed initial DSN")
+	}
+}
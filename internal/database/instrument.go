@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	sqldriver "database/sql/driver"
+	"errors"
+	"time"
+
+	"taskmanager/internal/metric"
+)
+
+type operationKey struct{}
+
+type operation struct{ repository, name string }
+
+// WithOperation returns a copy of ctx whose queries are labeled with the
+// repository ("tasks", "views", ...) and operation ("create", "list", "get",
+// "update", "delete", ...) in the db_query_* metrics. Queries without a label
+// are counted as "other".
+func WithOperation(ctx context.Context, repository, name string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation{repository, name})
+}
+
+func operationOf(ctx context.Context) operation {
+	if op, ok := ctx.Value(operationKey{}).(operation); ok {
+		return op
+	}
+	return operation{"other", "other"}
+}
+
+// Instrument wraps a connector so every query and exec records its duration
+// in db_query_duration_seconds and its failures in db_query_errors_total,
+// labeled by WithOperation. A query is timed until its first rows are
+// available, not until they have all been read. Pass it to OpenLazy.
+func Instrument(c sqldriver.Connector) sqldriver.Connector {
+	return &instrumentedConnector{Connector: c}
+}
+
+type instrumentedConnector struct {
+	sqldriver.Connector
+}
+
+func (c *instrumentedConnector) Connect(ctx context.Context) (sqldriver.Conn, error) {
+	dc, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: dc}, nil
+}
+
+// instrumentedConn forwards to the driver's connection like breaker's
+// wrapper: optional interfaces the driver lacks answer with driver.ErrSkip
+// (or the database/sql default).
+type instrumentedConn struct {
+	sqldriver.Conn
+}
+
+func observe(ctx context.Context, start time.Time, err error) {
+	if errors.Is(err, sqldriver.ErrSkip) {
+		return
+	}
+	op := operationOf(ctx)
+	metric.DBQueryDuration.WithLabelValues(op.repository, op.name).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, context.Canceled) {
+		metric.DBQueryErrors.WithLabelValues(op.repository, op.name).Inc()
+	}
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []sqldriver.NamedValue) (sqldriver.Result, error) {
+	e, ok := c.Conn.(sqldriver.ExecerContext)
+	if !ok {
+		return nil, sqldriver.ErrSkip
+	}
+	start := time.Now()
+	res, err := e.ExecContext(ctx, query, args)
+	observe(ctx, start, err)
+	return res, err
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []sqldriver.NamedValue) (sqldriver.Rows, error) {
+	q, ok := c.Conn.(sqldriver.QueryerContext)
+	if !ok {
+		return nil, sqldriver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	observe(ctx, start, err)
+	return rows, err
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (sqldriver.Stmt, error) {
+	if p, ok := c.Conn.(sqldriver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts sqldriver.TxOptions) (sqldriver.Tx, error) {
+	if bt, ok := c.Conn.(sqldriver.ConnBeginTx); ok {
+		return bt.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(sqldriver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) CheckNamedValue(nv *sqldriver.NamedValue) error {
+	if n, ok := c.Conn.(sqldriver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return sqldriver.ErrSkip
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(sqldriver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if v, ok := c.Conn.(sqldriver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/outbox"
+	"taskmanager/internal/problem"
+)
+
+// ActivityFeed is the part of outbox.Feed the activity endpoints need.
+type ActivityFeed interface {
+	Activity(ctx context.Context, aggregateID string, before int64, limit int) ([]outbox.Event, error)
+}
+
+// ActivityHandler serves the activity feed: the outbox read newest first,
+// for all tasks or a single one.
+type ActivityHandler struct {
+	feed ActivityFeed
+}
+
+// NewActivityHandler creates an ActivityHandler reading from feed.
+func NewActivityHandler(feed ActivityFeed) *ActivityHandler {
+	return &ActivityHandler{feed: feed}
+}
+
+// Activity handles GET /activity?before=<id>&limit=50.
+func (h *ActivityHandler) Activity(c *gin.Context) {
+	h.activity(c, "")
+}
+
+// TaskActivity handles GET /tasks/:id/activity?before=<id>&limit=50. A
+// deleted task keeps its history, so an unknown id yields an empty page
+// rather than 404.
+func (h *ActivityHandler) TaskActivity(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	h.activity(c, id)
+}
+
+// activity writes one page of events older than the before cursor.
+// next_before is the cursor for the following page and is omitted once the
+// feed is exhausted.
+func (h *ActivityHandler) activity(c *gin.Context, aggregateID string) {
+	var before int64
+	if s := c.Query("before"); s != "" {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || v < 1 {
+			problem.Abort(c, http.StatusBadRequest, "invalid before query param")
+			return
+		}
+		before = v
+	}
+
+	limit := 50
+	if s := c.Query("limit"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			limit = min(v, 500)
+		}
+	}
+
+	events, err := h.feed.Activity(c.Request.Context(), aggregateID, before, limit)
+	if err != nil {
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to read activity")
+		return
+	}
+
+	resp := gin.H{"items": events}
+	if len(events) == limit {
+		resp["next_before"] = events[len(events)-1].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/outbox"
+)
+
+type fakeActivity struct {
+	aggregateID string
+	before      int64
+	limit       int
+}
+
+func (f *fakeActivity) Activity(_ context.Context, aggregateID string, before int64, limit int) ([]outbox.Event, error) {
+	f.aggregateID, f.before, f.limit = aggregateID, before, limit
+	events := []outbox.Event{{ID: 9, Type: outbox.EventTaskCompleted}, {ID: 4, Type: outbox.EventTaskCreated}}
+	return events[:min(limit, len(events))], nil
+}
+
+func TestActivityHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	feed := &fakeActivity{}
+	h := NewActivityHandler(feed)
+
+	get := func(id, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/activity?"+query, nil)
+		if id == "" {
+			h.Activity(c)
+		} else {
+			c.Params = gin.Params{{Key: "id", Value: id}}
+			h.TaskActivity(c)
+		}
+		return w
+	}
+
+	// a short page is the last one
+	w := get("", "before=10&limit=5000")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "next_before") {
+		t.Fatalf("expected a final page got %d body=%s", w.Code, w.Body.String())
+	}
+	if feed.aggregateID != "" || feed.before != 10 || feed.limit != 500 {
+		t.Fatalf("unexpected feed call %+v", feed)
+	}
+
+	// a full page carries the cursor of its oldest event
+	id := "2f1d8a54-5b8f-4d8e-9a57-0c6b7e1f2a3b"
+	w = get(id, "limit=1")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"next_before":9`) {
+		t.Fatalf("expected a cursor got %d body=%s", w.Code, w.Body.String())
+	}
+	if feed.aggregateID != id || feed.before != 0 {
+		t.Fatalf("unexpected feed call %+v", feed)
+	}
+
+	for _, q := range []string{"before=0", "before=abc"} {
+		if w := get("", q); w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s got %d", q, w.Code)
+		}
+	}
+	if w := get("not-a-uuid", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad id got %d", w.Code)
+	}
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/config"
+	"taskmanager/internal/logging"
+	"taskmanager/internal/metric"
+	dtos "taskmanager/internal/model/DTOs"
+	"taskmanager/internal/problem"
+	"taskmanager/internal/retention"
+)
+
+// RetentionRunner runs the retention policy on demand; *retention.Worker
+// implements it.
+type RetentionRunner interface {
+	RunOnce(ctx context.Context, trigger string) (retention.Result, error)
+}
+
+// CacheFlusher drops every cached task entry; the database-backed task
+// repository implements it.
+type CacheFlusher interface {
+	FlushCache(ctx context.Context) (int, error)
+}
+
+// TaskCounter counts all tasks, to re-sync the tasks_count gauge.
+type TaskCounter interface {
+	Count(ctx context.Context) (int, error)
+}
+
+// ConfigReloader reloads the configuration of the running service;
+// *config.Store implements it.
+type ConfigReloader interface {
+	Reload() (config.ReloadResult, error)
+}
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit"`
+	GoVersion string    `json:"go_version"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// AdminHandler serves the operational endpoints under /admin, so operators
+// don't need direct access to the database or Redis. Routes are expected to
+// sit behind middleware.RequireAdmin.
+type AdminHandler struct {
+	build     BuildInfo
+	cache     CacheFlusher
+	tasks     TaskCounter
+	retention RetentionRunner
+	readOnly  *atomic.Bool
+	logs      *logging.Runtime
+	config    ConfigReloader
+}
+
+// MaxLogBodiesFor bounds the body logging window PUT /admin/log-level opens,
+// so a forgotten debugging session doesn't keep payloads flowing into the logs.
+const MaxLogBodiesFor = time.Hour
+
+// NewAdminHandler creates an AdminHandler. cache may be nil when there is no
+// cache to flush; readOnly is the flag middleware.ReadOnly checks, logs
+// the runtime of the service's logger and cfg the service's configuration.
+func NewAdminHandler(build BuildInfo, cache CacheFlusher, tasks TaskCounter, retention RetentionRunner, readOnly *atomic.Bool, logs *logging.Runtime, cfg ConfigReloader) *AdminHandler {
+	return &AdminHandler{build: build, cache: cache, tasks: tasks, retention: retention, readOnly: readOnly, logs: logs, config: cfg}
+}
+
+// Build handles GET /admin/build
+func (h *AdminHandler) Build(c *gin.Context) {
+	c.JSON(http.StatusOK, h.build)
+}
+
+// FlushCache handles POST /admin/cache/flush
+// Drops the cached task lists and items locally and in Redis.
+func (h *AdminHandler) FlushCache(c *gin.Context) {
+	if h.cache == nil {
+		c.JSON(http.StatusOK, gin.H{"flushed": 0})
+		return
+	}
+	n, err := h.cache.FlushCache(c.Request.Context())
+	if err != nil {
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusBadGateway, "failed to flush the cache: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"flushed": n})
+}
+
+// ResyncTaskCount handles POST /admin/tasks-count/resync
+// Recounts the tasks and sets the tasks_count gauge now rather than at the
+// next periodic refresh (see metric.TaskGauges).
+func (h *AdminHandler) ResyncTaskCount(c *gin.Context) {
+	n, err := h.tasks.Count(c.Request.Context())
+	if err != nil {
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to count tasks")
+		return
+	}
+	metric.SetTasksCount(n)
+	c.JSON(http.StatusOK, gin.H{"tasks_count": n})
+}
+
+// RunRetention handles POST /admin/retention/run
+// Applies the retention policy now rather than waiting for the schedule.
+func (h *AdminHandler) RunRetention(c *gin.Context) {
+	res, err := h.retention.RunOnce(c.Request.Context(), "manual")
+	if err != nil {
+		if errors.Is(err, retention.ErrRunning) {
+			problem.Abort(c, http.StatusConflict, "a retention run is already in progress")
+			return
+		}
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "retention run failed")
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ReadOnly handles GET /admin/read-only
+func (h *AdminHandler) ReadOnly(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"read_only": h.readOnly.Load()})
+}
+
+// SetReadOnly handles PUT /admin/read-only with {"read_only": true|false}
+// While on, /api/v1 rejects writes with 503 (see middleware.ReadOnly). The
+// flag is per instance and resets on restart.
+func (h *AdminHandler) SetReadOnly(c *gin.Context) {
+	var dto dtos.SetReadOnlyDTO
+	if !bindJSON(c, &dto) {
+		return
+	}
+	h.readOnly.Store(*dto.ReadOnly)
+	logging.FromContext(c.Request.Context()).Warn("read-only mode changed", "read_only", *dto.ReadOnly)
+	c.JSON(http.StatusOK, gin.H{"read_only": *dto.ReadOnly})
+}
+
+// LogLevel handles GET /admin/log-level
+func (h *AdminHandler) LogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, h.logLevel())
+}
+
+// SetLogLevel handles PUT /admin/log-level with {"level": "debug",
+// "log_bodies_for": "10m"}
+// Like read-only mode the change is per instance; a restart or SIGHUP goes
+// back to the configured level and turns body logging off.
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	var dto dtos.SetLogLevelDTO
+	if !bindJSON(c, &dto) {
+		return
+	}
+	var bodiesFor time.Duration
+	if dto.LogBodiesFor != "" {
+		v, err := time.ParseDuration(dto.LogBodiesFor)
+		if err != nil || v < 0 || v > MaxLogBodiesFor {
+			problem.Abort(c, http.StatusBadRequest, "invalid log_bodies_for: expected a duration up to "+MaxLogBodiesFor.String())
+			return
+		}
+		bodiesFor = v
+	}
+	lvl, _ := logging.ParseLevel(dto.Level)
+	h.logs.SetLevel(lvl)
+	var until time.Time
+	if bodiesFor > 0 {
+		until = time.Now().Add(bodiesFor)
+	}
+	h.logs.LogBodiesUntil(until)
+	state := h.logLevel()
+	logging.FromContext(c.Request.Context()).Warn("log level changed", "log_level", state["level"], "log_bodies_until", state["log_bodies_until"])
+	c.JSON(http.StatusOK, state)
+}
+
+// ReloadConfig handles POST /admin/config/reload
+// Re-reads the configuration file and environment like SIGHUP does and
+// applies the reloadable settings (see config.Reloadable). A configuration
+// that fails validation is answered with 422 and its problems, and nothing
+// changes.
+func (h *AdminHandler) ReloadConfig(c *gin.Context) {
+	res, err := h.config.Reload()
+	if err != nil {
+		var verr *config.ValidationError
+		if errors.As(err, &verr) {
+			problem.Render(c, problem.New(http.StatusUnprocessableEntity, "the configuration is invalid; nothing was reloaded").
+				With("problems", verr.Problems))
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to reload the configuration: "+err.Error())
+		return
+	}
+	logging.FromContext(c.Request.Context()).Warn("configuration reloaded", "applied", res.Applied, "restart_required", res.RestartRequired)
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *AdminHandler) logLevel() gin.H {
+	state := gin.H{"level": logging.LevelName(h.logs.Level()), "log_bodies_until": nil}
+	if until := h.logs.BodiesUntil(); !until.IsZero() {
+		state["log_bodies_until"] = until.UTC()
+	}
+	return state
+}
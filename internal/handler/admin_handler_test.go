@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"taskmanager/internal/config"
+	"taskmanager/internal/logging"
+	"taskmanager/internal/metric"
+	"taskmanager/internal/middleware"
+	"taskmanager/internal/retention"
+)
+
+type fakeRetention struct {
+	trigger string
+	err     error
+}
+
+func (f *fakeRetention) RunOnce(_ context.Context, trigger string) (retention.Result, error) {
+	f.trigger = trigger
+	return retention.Result{PurgedCompleted: 4}, f.err
+}
+
+type fakeFlusher struct{ err error }
+
+func (f fakeFlusher) FlushCache(context.Context) (int, error) { return 7, f.err }
+
+type fakeCounter int
+
+func (f fakeCounter) Count(context.Context) (int, error) { return int(f), nil }
+
+type fakeReloader struct {
+	res config.ReloadResult
+	err error
+}
+
+func (f *fakeReloader) Reload() (config.ReloadResult, error) { return f.res, f.err }
+
+func TestAdminHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	runner := &fakeRetention{}
+	flusher := &fakeFlusher{}
+	var readOnly atomic.Bool
+	logs := &logging.Runtime{}
+	reloader := &fakeReloader{}
+	h := NewAdminHandler(BuildInfo{Version: "1.2.3", Commit: "abc"}, flusher, fakeCounter(12), runner, &readOnly, logs, reloader)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.IsAdminKey, c.GetHeader("X-Admin") == "yes")
+	})
+	admin := r.Group("/admin", middleware.RequireAdmin())
+	admin.GET("/build", h.Build)
+	admin.POST("/cache/flush", h.FlushCache)
+	admin.POST("/tasks-count/resync", h.ResyncTaskCount)
+	admin.POST("/retention/run", h.RunRetention)
+	admin.GET("/read-only", h.ReadOnly)
+	admin.PUT("/read-only", h.SetReadOnly)
+	admin.GET("/log-level", h.LogLevel)
+	admin.PUT("/log-level", h.SetLogLevel)
+	admin.POST("/config/reload", h.ReloadConfig)
+	do := func(method, path, body, isAdmin string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Admin", isAdmin)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("RequiresAdmin", func(t *testing.T) {
+		if w := do(http.MethodPost, "/admin/retention/run", "", "no"); w.Code != http.StatusForbidden || runner.trigger != "" {
+			t.Fatalf("expected 403 without running got %d trigger=%q", w.Code, runner.trigger)
+		}
+	})
+
+	t.Run("Build", func(t *testing.T) {
+		if w := do(http.MethodGet, "/admin/build", "", "yes"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"version":"1.2.3"`) {
+			t.Fatalf("unexpected build info %d body=%s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("FlushCache", func(t *testing.T) {
+		if w := do(http.MethodPost, "/admin/cache/flush", "", "yes"); w.Code != http.StatusOK || w.Body.String() != `{"flushed":7}` {
+			t.Fatalf("unexpected flush %d body=%s", w.Code, w.Body.String())
+		}
+		flusher.err = errors.New("redis down")
+		if w := do(http.MethodPost, "/admin/cache/flush", "", "yes"); w.Code != http.StatusBadGateway {
+			t.Fatalf("expected 502 when Redis fails got %d", w.Code)
+		}
+	})
+
+	t.Run("ResyncTaskCount", func(t *testing.T) {
+		metric.SetTasksCount(3)
+		if w := do(http.MethodPost, "/admin/tasks-count/resync", "", "yes"); w.Code != http.StatusOK || w.Body.String() != `{"tasks_count":12}` {
+			t.Fatalf("unexpected resync %d body=%s", w.Code, w.Body.String())
+		}
+		if v := testutil.ToFloat64(metric.TasksCount); v != 12 {
+			t.Fatalf("expected tasks_count 12 got %v", v)
+		}
+	})
+
+	t.Run("RunRetention", func(t *testing.T) {
+		if w := do(http.MethodPost, "/admin/retention/run", "", "yes"); w.Code != http.StatusOK || w.Body.String() != `{"purged_completed":4,"purged_tombstones":0}` || runner.trigger != "manual" {
+			t.Fatalf("unexpected response %d body=%s trigger=%q", w.Code, w.Body.String(), runner.trigger)
+		}
+		runner.err = retention.ErrRunning
+		if w := do(http.MethodPost, "/admin/retention/run", "", "yes"); w.Code != http.StatusConflict {
+			t.Fatalf("expected 409 while a run is in progress got %d", w.Code)
+		}
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		if w := do(http.MethodPut, "/admin/read-only", `{}`, "yes"); w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 without read_only got %d", w.Code)
+		}
+		if w := do(http.MethodPut, "/admin/read-only", `{"read_only":true}`, "yes"); w.Code != http.StatusOK || !readOnly.Load() {
+			t.Fatalf("expected read-only on got %d body=%s", w.Code, w.Body.String())
+		}
+		if w := do(http.MethodGet, "/admin/read-only", "", "yes"); w.Body.String() != `{"read_only":true}` {
+			t.Fatalf("unexpected read-only state %s", w.Body.String())
+		}
+	})
+
+	t.Run("LogLevel", func(t *testing.T) {
+		if w := do(http.MethodGet, "/admin/log-level", "", "yes"); w.Body.String() != `{"level":"info","log_bodies_until":null}` {
+			t.Fatalf("unexpected log level state %s", w.Body.String())
+		}
+		for _, body := range []string{`{"level":"loud"}`, `{"level":"debug","log_bodies_for":"2h"}`, `{"level":"debug","log_bodies_for":"soon"}`} {
+			if w := do(http.MethodPut, "/admin/log-level", body, "yes"); w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400 for %s got %d", body, w.Code)
+			}
+		}
+		w := do(http.MethodPut, "/admin/log-level", `{"level":"debug","log_bodies_for":"10m"}`, "yes")
+		if w.Code != http.StatusOK || logs.Level() != slog.LevelDebug || !strings.Contains(w.Body.String(), `"level":"debug"`) {
+			t.Fatalf("expected debug got %d body=%s", w.Code, w.Body.String())
+		}
+		if until := logs.BodiesUntil(); time.Until(until) < 9*time.Minute || time.Until(until) > 10*time.Minute {
+			t.Fatalf("expected bodies logged for 10m, until %v", until)
+		}
+		if w := do(http.MethodPut, "/admin/log-level", `{"level":"warn"}`, "yes"); w.Body.String() != `{"level":"warn","log_bodies_until":null}` || !logs.BodiesUntil().IsZero() {
+			t.Fatalf("expected warn without bodies got %s", w.Body.String())
+		}
+	})
+
+	t.Run("ReloadConfig", func(t *testing.T) {
+		reloader.res = config.ReloadResult{Applied: []string{"cache.list_ttl"}, RestartRequired: []string{"server.port"}}
+		w := do(http.MethodPost, "/admin/config/reload", "", "yes")
+		if w.Code != http.StatusOK || w.Body.String() != `{"applied":["cache.list_ttl"],"restart_required":["server.port"]}` {
+			t.Fatalf("unexpected reload result %d body=%s", w.Code, w.Body.String())
+		}
+		reloader.err = &config.ValidationError{Problems: []string{"cache.list_ttl must not be negative"}}
+		w = do(http.MethodPost, "/admin/config/reload", "", "yes")
+		if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), `"problems":["cache.list_ttl must not be negative"]`) {
+			t.Fatalf("expected 422 with the problems got %d body=%s", w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	dtos "taskmanager/internal/model/DTOs"
+	"taskmanager/internal/problem"
+	"taskmanager/internal/service"
+)
+
+// MaxBatchOperations is the largest number of operations POST /batch accepts.
+const MaxBatchOperations = 100
+
+// batchHeaders are the response headers reported per operation.
+var batchHeaders = []string{"ETag", "Location", "Link", "X-Total-Count", "Retry-After"}
+
+// txServiceKey carries the TaskService of an atomic batch's transaction in
+// the context of its operations.
+type txServiceKey struct{}
+
+// service returns the TaskService for the request: the transaction's when
+// the request is an operation of an atomic batch, else h.svc.
+func (h *TaskHandler) service(c *gin.Context) service.TaskService {
+	if s, ok := c.Request.Context().Value(txServiceKey{}).(service.TaskService); ok {
+		return s
+	}
+	return h.svc
+}
+
+// BatchHandler serves POST /batch, which runs several API requests in one
+// round trip, e.g. for a mobile client syncing its offline changes.
+type BatchHandler struct {
+	routes http.Handler
+	prefix string
+	tasks  service.TaskService
+}
+
+// NewBatchHandler creates a BatchHandler that runs operations through
+// routes, with their paths under prefix (the API's base path). Atomic
+// batches need tasks to implement service.Transactor.
+func NewBatchHandler(routes http.Handler, prefix string, tasks service.TaskService) *BatchHandler {
+	return &BatchHandler{routes: routes, prefix: prefix, tasks: tasks}
+}
+
+// batchResult is the outcome of one operation.
+type batchResult struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// errBatchFailed rolls back an atomic batch after a failed operation.
+var errBatchFailed = errors.New("batch operation failed")
+
+// Batch handles POST /batch. The operations run in order through the same
+// routes and middleware as separate requests, with the batch request's
+// headers (credentials included), and the response lists their status,
+// headers and body. By default every operation runs whatever the others
+// return. An atomic batch runs them in one transaction and stops at the
+// first that fails (status 400 or above): the operations before it are
+// rolled back and those after it are reported with status 424. Atomic
+// batches only take the task routes below /tasks, with tasks addressed by
+// id, since the other routes don't share the transaction.
+func (h *BatchHandler) Batch(c *gin.Context) {
+	var dto dtos.BatchDTO
+	if !bindJSON(c, &dto) {
+		return
+	}
+	if len(dto.Operations) > MaxBatchOperations {
+		problem.Abort(c, http.StatusBadRequest, fmt.Sprintf("invalid input: at most %d operations are allowed", MaxBatchOperations))
+		return
+	}
+	for i, op := range dto.Operations {
+		if err := checkBatchPath(op.Path, dto.Atomic); err != nil {
+			problem.Abort(c, http.StatusBadRequest, fmt.Sprintf("invalid operations[%d].path: %v", i, err))
+			return
+		}
+	}
+
+	results := make([]batchResult, len(dto.Operations))
+	if !dto.Atomic {
+		for i, op := range dto.Operations {
+			results[i] = h.run(c, c.Request.Context(), op)
+		}
+		c.JSON(http.StatusOK, gin.H{"results": results})
+		return
+	}
+
+	tx, ok := h.tasks.(service.Transactor)
+	if !ok {
+		problem.Abort(c, http.StatusBadRequest, "atomic batches are not supported by this backend")
+		return
+	}
+	failed := -1
+	err := tx.InTx(c.Request.Context(), func(svc service.TaskService) error {
+		ctx := context.WithValue(c.Request.Context(), txServiceKey{}, svc)
+		for i, op := range dto.Operations {
+			results[i] = h.run(c, ctx, op)
+			if results[i].Status >= http.StatusBadRequest {
+				failed = i
+				return errBatchFailed
+			}
+		}
+		return nil
+	})
+	if err != nil && failed < 0 {
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to commit the batch")
+		return
+	}
+	if failed >= 0 {
+		for i := failed + 1; i < len(results); i++ {
+			results[i] = batchResult{Status: http.StatusFailedDependency}
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"committed": failed < 0, "results": results})
+}
+
+// checkBatchPath validates the path of an operation: an absolute, clean path
+// below the API root other than /batch itself and the streamed export, and
+// for atomic batches one of the task routes that run on the transaction.
+func checkBatchPath(p string, atomic bool) error {
+	u, err := url.Parse(p)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/") || path.Clean(u.Path) != u.Path {
+		return errors.New("expected a path below the API root, e.g. /tasks/{id}")
+	}
+	if u.Path == "/batch" {
+		return errors.New("batches cannot be nested")
+	}
+	if u.Path == "/tasks/export" {
+		return errors.New("the export streams its response and cannot be batched")
+	}
+	if atomic && !atomicBatchPath(u) {
+		return errors.New("atomic batches only take /tasks routes with tasks addressed by id, without include")
+	}
+	return nil
+}
+
+// atomicBatchPath reports whether u is served by TaskHandler alone: the
+// other routes, task keys and include read outside the transaction, which
+// would wait for it on SQLite and in memory.
+func atomicBatchPath(u *url.URL) bool {
+	if u.Query().Has("include") {
+		return false
+	}
+	switch u.Path {
+	case "/tasks", "/tasks/stats", "/tasks/reassign", "/tasks/batch-get":
+		return true
+	}
+	rest, ok := strings.CutPrefix(u.Path, "/tasks/")
+	if !ok {
+		return false
+	}
+	id, action, _ := strings.Cut(rest, "/")
+	if _, err := uuid.Parse(id); err != nil || len(id) != 36 {
+		return false
+	}
+	switch action {
+	case "", "complete", "reopen", "duplicate", "move":
+		return true
+	}
+	return false
+}
+
+// run serves one operation with ctx and records its response.
+func (h *BatchHandler) run(c *gin.Context, ctx context.Context, op dtos.BatchOperationDTO) batchResult {
+	var body io.Reader
+	if len(op.Body) > 0 {
+		body = bytes.NewReader(op.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, op.Method, h.prefix+op.Path, body)
+	if err != nil {
+		return batchResult{Status: http.StatusBadRequest}
+	}
+	req.RemoteAddr = c.Request.RemoteAddr
+	req.TLS = c.Request.TLS
+	for k, v := range c.Request.Header {
+		req.Header[k] = v
+	}
+	req.Header.Del("Content-Length")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req.Header.Del("Content-Type")
+	}
+	for k, v := range op.Headers {
+		req.Header.Set(k, v)
+	}
+
+	rec := &batchRecorder{header: http.Header{}}
+	h.routes.ServeHTTP(rec, req)
+	return rec.result()
+}
+
+// batchRecorder is the http.ResponseWriter of one operation.
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *batchRecorder) Header() http.Header { return r.header }
+
+func (r *batchRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *batchRecorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
+
+// result reports the recorded response; a body that isn't JSON becomes a
+// JSON string.
+func (r *batchRecorder) result() batchResult {
+	res := batchResult{Status: r.status}
+	if res.Status == 0 {
+		res.Status = http.StatusOK
+	}
+	for _, name := range batchHeaders {
+		if v := r.header.Get(name); v != "" {
+			if res.Headers == nil {
+				res.Headers = map[string]string{}
+			}
+			res.Headers[name] = v
+		}
+	}
+	if b := r.body.Bytes(); len(b) > 0 {
+		if json.Valid(b) {
+			res.Body = b
+		} else {
+			res.Body, _ = json.Marshal(string(b))
+		}
+	}
+	return res
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/model"
+	"taskmanager/internal/repositories"
+	"taskmanager/internal/service"
+)
+
+func TestBatchHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	tasks := repositories.NewMemoryTaskRepository()
+	existing := &model.Task{Title: "existing"}
+	if err := tasks.Create(ctx, existing); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	svc := service.NewTaskService(tasks)
+	h := NewTaskHandler(svc)
+	r := gin.New()
+	api := r.Group("/api/v1")
+	api.POST("/tasks", h.CreateTask)
+	api.GET("/tasks", h.ListTasks)
+	api.GET("/tasks/:id", h.GetTask)
+	api.PUT("/tasks/:id", h.UpdateTask)
+	api.POST("/tasks/:id/complete", h.CompleteTask)
+	api.POST("/batch", NewBatchHandler(r, api.BasePath(), svc).Batch)
+
+	type result struct {
+		Status  int               `json:"status"`
+		Headers map[string]string `json:"headers"`
+		Body    json.RawMessage   `json:"body"`
+	}
+	type response struct {
+		Committed *bool    `json:"committed"`
+		Results   []result `json:"results"`
+	}
+	batch := func(body string) (*httptest.ResponseRecorder, response) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		var resp response
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v body=%s", err, w.Body.String())
+			}
+		}
+		return w, resp
+	}
+	count := func() int {
+		n, err := svc.Count(ctx)
+		if err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		return n
+	}
+
+	// sequential: every operation runs, failures included
+	w, resp := batch(`{"operations":[
+		{"method":"POST","path":"/tasks","body":{"title":"first"}},
+		{"method":"GET","path":"/tasks/00000000-0000-0000-0000-000000000000"},
+		{"method":"POST","path":"/tasks/` + existing.ID + `/complete"},
+		{"method":"GET","path":"/tasks?completed=true&fields=title"}]}`)
+	if w.Code != http.StatusOK || len(resp.Results) != 4 || resp.Committed != nil {
+		t.Fatalf("expected 4 results got %d body=%s", w.Code, w.Body.String())
+	}
+	for i, want := range []int{http.StatusCreated, http.StatusNotFound, http.StatusOK, http.StatusOK} {
+		if resp.Results[i].Status != want {
+			t.Fatalf("operation %d: expected %d got %+v", i, want, resp.Results[i])
+		}
+	}
+	var created model.Task
+	if err := json.Unmarshal(resp.Results[0].Body, &created); err != nil || created.Title != "first" {
+		t.Fatalf("expected the created task got %s", resp.Results[0].Body)
+	}
+	if resp.Results[3].Headers["ETag"] == "" {
+		t.Fatalf("expected the ETag of the list got %+v", resp.Results[3].Headers)
+	}
+	if !strings.Contains(string(resp.Results[3].Body), `"existing"`) {
+		t.Fatalf("expected the completed task in the list got %s", resp.Results[3].Body)
+	}
+
+	// atomic: all operations commit together
+	w, resp = batch(`{"atomic":true,"operations":[
+		{"method":"POST","path":"/tasks","body":{"title":"second"}},
+		{"method":"PUT","path":"/tasks/` + created.ID + `","body":{"title":"renamed"}}]}`)
+	if w.Code != http.StatusOK || resp.Committed == nil || !*resp.Committed {
+		t.Fatalf("expected a committed batch got %d body=%s", w.Code, w.Body.String())
+	}
+	if n := count(); n != 3 {
+		t.Fatalf("expected 3 tasks got %d", n)
+	}
+	if got, _ := svc.GetByID(ctx, created.ID); got == nil || got.Title != "renamed" {
+		t.Fatalf("expected the renamed task got %+v", got)
+	}
+
+	// atomic: a failure rolls back the operations before it and skips the rest
+	w, resp = batch(`{"atomic":true,"operations":[
+		{"method":"POST","path":"/tasks","body":{"title":"rolled back"}},
+		{"method":"POST","path":"/tasks","body":{}},
+		{"method":"POST","path":"/tasks","body":{"title":"skipped"}}]}`)
+	if w.Code != http.StatusOK || resp.Committed == nil || *resp.Committed {
+		t.Fatalf("expected a rolled back batch got %d body=%s", w.Code, w.Body.String())
+	}
+	for i, want := range []int{http.StatusCreated, http.StatusBadRequest, http.StatusFailedDependency} {
+		if resp.Results[i].Status != want {
+			t.Fatalf("operation %d: expected %d got %+v", i, want, resp.Results[i])
+		}
+	}
+	if n := count(); n != 3 {
+		t.Fatalf("expected the rollback to leave 3 tasks got %d", n)
+	}
+
+	for name, body := range map[string]string{
+		"empty":          `{"operations":[]}`,
+		"method":         `{"operations":[{"method":"PATCH","path":"/tasks"}]}`,
+		"absolute url":   `{"operations":[{"method":"GET","path":"http://example.com/tasks"}]}`,
+		"unclean path":   `{"operations":[{"method":"GET","path":"/views/../tasks"}]}`,
+		"nested":         `{"operations":[{"method":"POST","path":"/batch","body":{"operations":[]}}]}`,
+		"export":         `{"operations":[{"method":"GET","path":"/tasks/export"}]}`,
+		"atomic views":   `{"atomic":true,"operations":[{"method":"GET","path":"/views"}]}`,
+		"atomic key":     `{"atomic":true,"operations":[{"method":"GET","path":"/tasks/TM-1"}]}`,
+		"atomic include": `{"atomic":true,"operations":[{"method":"GET","path":"/tasks?include=watchers"}]}`,
+	} {
+		if w, _ := batch(body); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400 got %d body=%s", name, w.Code, w.Body.String())
+		}
+	}
+	if w, _ := batch(`{"operations":[` + strings.Repeat(`{"method":"GET","path":"/tasks"},`, MaxBatchOperations) + `{"method":"GET","path":"/tasks"}]}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for too many operations got %d", w.Code)
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/outbox"
+
+	"taskmanager/internal/problem"
+)
+
+// MaxChangesWait caps the wait query parameter of GET /changes.
+const MaxChangesWait = 60 * time.Second
+
+// ChangeFeed is the part of outbox.Feed the changes endpoint needs.
+type ChangeFeed interface {
+	Wait(ctx context.Context, seq int64, limit int, wait time.Duration) ([]outbox.Event, error)
+}
+
+// ChangesHandler serves GET /changes, a long-poll change log for sync
+// clients that can't hold an SSE or WebSocket connection.
+type ChangesHandler struct {
+	feed ChangeFeed
+}
+
+// NewChangesHandler creates a ChangesHandler reading from feed.
+func NewChangesHandler(feed ChangeFeed) *ChangesHandler {
+	return &ChangesHandler{feed: feed}
+}
+
+// Changes handles GET /changes?since=<seq>&wait=30s&limit=100.
+// It returns the events after since, waiting up to wait for one to arrive.
+// The wait ends early at the request deadline (server.request_timeout), so
+// an empty page just means "poll again with the same since".
+func (h *ChangesHandler) Changes(c *gin.Context) {
+	var since int64
+	if s := c.Query("since"); s != "" {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || v < 0 {
+			problem.Abort(c, http.StatusBadRequest, "invalid since query param")
+			return
+		}
+		since = v
+	}
+
+	var wait time.Duration
+	if s := c.Query("wait"); s != "" {
+		v, err := time.ParseDuration(s)
+		if err != nil || v < 0 || v > MaxChangesWait {
+			problem.Abort(c, http.StatusBadRequest, "invalid wait query param: expected a duration up to "+MaxChangesWait.String())
+			return
+		}
+		wait = v
+	}
+
+	limit := 100
+	if s := c.Query("limit"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			limit = min(v, 1000)
+		}
+	}
+
+	events, err := h.feed.Wait(c.Request.Context(), since, limit, wait)
+	if err != nil {
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to read changes")
+		return
+	}
+
+	next := since
+	if len(events) > 0 {
+		next = events[len(events)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{"items": events, "next_since": next})
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/outbox"
+)
+
+type fakeFeed struct {
+	seq   int64
+	limit int
+	wait  time.Duration
+}
+
+func (f *fakeFeed) Wait(_ context.Context, seq int64, limit int, wait time.Duration) ([]outbox.Event, error) {
+	f.seq, f.limit, f.wait = seq, limit, wait
+	if seq >= 7 {
+		return []outbox.Event{}, nil
+	}
+	return []outbox.Event{{ID: 6, Type: outbox.EventTaskCreated}, {ID: 7, Type: outbox.EventTaskUpdated}}, nil
+}
+
+func TestChangesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	feed := &fakeFeed{}
+	h := NewChangesHandler(feed)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/changes?"+query, nil)
+		h.Changes(c)
+		return w
+	}
+
+	w := get("since=5&wait=30s&limit=5000")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"next_since":7`) {
+		t.Fatalf("expected events up to 7 got %d body=%s", w.Code, w.Body.String())
+	}
+	if feed.seq != 5 || feed.limit != 1000 || feed.wait != 30*time.Second {
+		t.Fatalf("unexpected feed call %+v", feed)
+	}
+
+	// nothing new: the cursor stays where it was
+	if w := get("since=7"); w.Code != http.StatusOK || w.Body.String() != `{"items":[],"next_since":7}` {
+		t.Fatalf("unexpected empty page %d body=%s", w.Code, w.Body.String())
+	}
+
+	for _, q := range []string{"since=-1", "since=abc", "wait=forever", "wait=2m"} {
+		if w := get(q); w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s got %d", q, w.Code)
+		}
+	}
+}
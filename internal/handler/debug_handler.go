@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	// /debug/vars already carries cmdline and memstats
+	expvar.Publish("goroutines", expvar.Func(func() any { return runtime.NumGoroutine() }))
+}
+
+// RegisterDebug serves the runtime diagnostics under g: net/http/pprof at
+// /debug/pprof/, expvar at /debug/vars and a runtime summary at
+// /debug/runtime. g must only be reachable by operators (the admin listener,
+// or a group behind middleware.RequireAdmin).
+func RegisterDebug(g *gin.RouterGroup) {
+	d := g.Group("/debug")
+	d.GET("/pprof/", gin.WrapF(pprof.Index))
+	d.GET("/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+	d.GET("/pprof/profile", gin.WrapF(pprof.Profile))
+	d.GET("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	d.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	d.GET("/pprof/trace", gin.WrapF(pprof.Trace))
+	// named profiles (heap, goroutine, allocs, block, mutex, threadcreate);
+	// pprof.Index only finds them under the default /debug/pprof/ prefix
+	d.GET("/pprof/:profile", func(c *gin.Context) {
+		pprof.Handler(c.Param("profile")).ServeHTTP(c.Writer, c.Request)
+	})
+	d.GET("/vars", gin.WrapH(expvar.Handler()))
+	d.GET("/runtime", RuntimeStats)
+}
+
+// RuntimeStats handles GET /debug/runtime
+// Goroutine count and the memory and GC figures most useful when chasing a
+// latency spike.
+func RuntimeStats(c *gin.Context) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	var lastGC *time.Time
+	if m.LastGC > 0 {
+		t := time.Unix(0, int64(m.LastGC)).UTC()
+		lastGC = &t
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"goroutines": runtime.NumGoroutine(),
+		"gomaxprocs": runtime.GOMAXPROCS(0),
+		"num_cpu":    runtime.NumCPU(),
+		"go_version": runtime.Version(),
+		"memory": gin.H{
+			"heap_alloc_bytes":  m.HeapAlloc,
+			"heap_inuse_bytes":  m.HeapInuse,
+			"heap_objects":      m.HeapObjects,
+			"stack_inuse_bytes": m.StackInuse,
+			"sys_bytes":         m.Sys,
+			"total_alloc_bytes": m.TotalAlloc,
+			"next_gc_bytes":     m.NextGC,
+		},
+		"gc": gin.H{
+			"num_gc":          m.NumGC,
+			"pause_total_ms":  float64(m.PauseTotalNs) / 1e6,
+			"last_pause_ms":   float64(m.PauseNs[(m.NumGC+255)%256]) / 1e6,
+			"last_gc":         lastGC,
+			"gc_cpu_fraction": m.GCCPUFraction,
+		},
+	})
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterDebug(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterDebug(r.Group("/admin"))
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	if w := get("/admin/debug/pprof/"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine") {
+		t.Fatalf("unexpected pprof index %d", w.Code)
+	}
+	if w := get("/admin/debug/pprof/goroutine?debug=1"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine profile") {
+		t.Fatalf("expected the goroutine profile under a custom prefix got %d", w.Code)
+	}
+	if w := get("/admin/debug/vars"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"goroutines"`) || !strings.Contains(w.Body.String(), `"memstats"`) {
+		t.Fatalf("unexpected expvar output %d", w.Code)
+	}
+
+	w := get("/admin/debug/runtime")
+	var body struct {
+		Goroutines int `json:"goroutines"`
+		GC         struct {
+			NumGC *uint32 `json:"num_gc"`
+		} `json:"gc"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusOK || body.Goroutines < 1 || body.GC.NumGC == nil {
+		t.Fatalf("unexpected runtime stats %d %s err=%v", w.Code, w.Body.String(), err)
+	}
+}
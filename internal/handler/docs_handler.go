@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocsHandler serves the OpenAPI spec and the Swagger UI page. Both contain
+// absolute links (/api/v1, /docs/openapi.yaml) that are rewritten once at
+// startup so the docs keep working when mounted under a base path.
+type DocsHandler struct {
+	spec []byte
+	ui   []byte
+}
+
+// NewDocsHandler loads openapi.yaml and swagger.html from dir.
+func NewDocsHandler(dir, basePath string) (*DocsHandler, error) {
+	spec, err := os.ReadFile(filepath.Join(dir, "openapi.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	ui, err := os.ReadFile(filepath.Join(dir, "swagger.html"))
+	if err != nil {
+		return nil, err
+	}
+	if basePath != "" {
+		spec = bytes.ReplaceAll(spec, []byte("url: /api/v1"), []byte("url: "+basePath+"/api/v1"))
+		ui = bytes.ReplaceAll(ui, []byte("'/docs/openapi.yaml'"), []byte("'"+basePath+"/docs/openapi.yaml'"))
+	}
+	return &DocsHandler{spec: spec, ui: ui}, nil
+}
+
+// Spec handles GET /docs/openapi.yaml
+func (h *DocsHandler) Spec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/yaml", h.spec)
+}
+
+// UI handles GET /docs
+func (h *DocsHandler) UI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", h.ui)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDocsHandler_RewritesBasePath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "openapi.yaml"), []byte("servers:\n  - url: /api/v1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "swagger.html"), []byte("SwaggerUIBundle({ url: '/docs/openapi.yaml' })"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewDocsHandler(dir, "/taskmanager")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/taskmanager/docs/openapi.yaml", nil)
+	h.Spec(c)
+	if !strings.Contains(w.Body.String(), "url: /taskmanager/api/v1") {
+		t.Fatalf("spec not rewritten: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/taskmanager/docs", nil)
+	h.UI(c)
+	if !strings.Contains(w.Body.String(), "'/taskmanager/docs/openapi.yaml'") {
+		t.Fatalf("ui not rewritten: %s", w.Body.String())
+	}
+
+	if _, err := NewDocsHandler(t.TempDir(), ""); err == nil {
+		t.Fatalf("expected error for missing docs")
+	}
+}
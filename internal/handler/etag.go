@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/problem"
+)
+
+// writeETagJSON writes v as a 200 JSON response with an ETag derived from the
+// body, or an empty 304 when the request's If-None-Match already names it.
+// Hashing the body rather than updated_at also covers list pages, whose
+// content changes when other tasks are added or removed.
+func writeETagJSON(c *gin.Context, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		_ = c.Error(err)
+		problem.Abort(c, http.StatusInternalServerError, "failed to encode response")
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// etagMatches reports whether the If-None-Match header value names etag,
+// using the weak comparison RFC 9110 prescribes for it.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/logging"
+	"taskmanager/internal/model"
+	"taskmanager/internal/problem"
+)
+
+// exportFlushRows is how many tasks are written between flushes of the
+// export stream.
+const exportFlushRows = 500
+
+// TaskExporter streams every task; both task repositories implement it.
+type TaskExporter interface {
+	Export(ctx context.Context, fn func(model.Task) error) error
+}
+
+// ExportHandler serves GET /tasks/export, a dump of the whole dataset for
+// backups. The route is exempt from server.request_timeout.
+type ExportHandler struct {
+	tasks TaskExporter
+	// idle is the server's write timeout, renewed on every flush so it
+	// bounds a stalled client rather than the whole export
+	idle time.Duration
+}
+
+// NewExportHandler creates an ExportHandler reading from tasks.
+// writeTimeout is server.write_timeout (0 for none).
+func NewExportHandler(tasks TaskExporter, writeTimeout time.Duration) *ExportHandler {
+	return &ExportHandler{tasks: tasks, idle: writeTimeout}
+}
+
+// Export handles GET /tasks/export?format=ndjson. It streams one task per
+// line, oldest first, with chunked transfer encoding and gzip when the
+// client accepts it, flushing as it goes so memory stays flat however many
+// tasks there are. A failure before the first task is a regular error
+// response; after it the connection is aborted, so the client sees a
+// truncated transfer rather than a short export that looks complete.
+func (h *ExportHandler) Export(c *gin.Context) {
+	if f := c.DefaultQuery("format", "ndjson"); f != "ndjson" {
+		problem.Abort(c, http.StatusBadRequest, "invalid format query param: expected ndjson")
+		return
+	}
+	gz := strings.Contains(c.GetHeader("Accept-Encoding"), "gzip")
+	rc := http.NewResponseController(c.Writer)
+	h.extendDeadline(rc)
+
+	var (
+		out  io.Writer
+		zw   *gzip.Writer
+		enc  *json.Encoder
+		rows int
+	)
+	// start sends the headers; it is deferred to the first task so an error
+	// reading the first one can still be reported properly
+	start := func() {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="tasks-`+time.Now().UTC().Format("20060102T150405Z")+`.ndjson"`)
+		c.Header("Vary", "Accept-Encoding")
+		out = c.Writer
+		if gz {
+			c.Header("Content-Encoding", "gzip")
+			zw = gzip.NewWriter(c.Writer)
+			out = zw
+		}
+		c.Status(http.StatusOK)
+		enc = json.NewEncoder(out)
+	}
+	flush := func() error {
+		if zw != nil {
+			if err := zw.Flush(); err != nil {
+				return err
+			}
+		}
+		h.extendDeadline(rc)
+		return rc.Flush()
+	}
+
+	err := h.tasks.Export(c.Request.Context(), func(t model.Task) error {
+		if enc == nil {
+			start()
+		}
+		if err := enc.Encode(t); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushRows == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if enc == nil {
+			if writeTimeout(c, err) {
+				return
+			}
+			problem.Abort(c, http.StatusInternalServerError, "failed to export tasks")
+			return
+		}
+		logging.FromContext(c.Request.Context()).Warn("task export aborted", "rows", rows, "err", err)
+		panic(http.ErrAbortHandler)
+	}
+	if enc == nil {
+		start()
+	}
+	if zw != nil {
+		_ = zw.Close()
+	}
+	_ = rc.Flush()
+}
+
+// extendDeadline moves the connection's write deadline h.idle ahead; it is
+// a no-op on writers without deadlines, such as test recorders.
+func (h *ExportHandler) extendDeadline(rc *http.ResponseController) {
+	if h.idle > 0 {
+		_ = rc.SetWriteDeadline(time.Now().Add(h.idle))
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/model"
+	"taskmanager/internal/repositories"
+)
+
+type failingExporter struct{}
+
+func (failingExporter) Export(context.Context, func(model.Task) error) error {
+	return errors.New("boom")
+}
+
+func TestExportHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	tasks := repositories.NewMemoryTaskRepository()
+	r := gin.New()
+	r.GET("/tasks/export", NewExportHandler(tasks.(TaskExporter), 0).Export)
+	r.GET("/broken/export", NewExportHandler(failingExporter{}, 0).Export)
+	get := func(target, encoding string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if encoding != "" {
+			req.Header.Set("Accept-Encoding", encoding)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(body io.Reader) []model.Task {
+		var out []model.Task
+		s := bufio.NewScanner(body)
+		for s.Scan() {
+			var task model.Task
+			if err := json.Unmarshal(s.Bytes(), &task); err != nil {
+				t.Fatalf("decode line %q: %v", s.Text(), err)
+			}
+			out = append(out, task)
+		}
+		return out
+	}
+
+	// an empty dataset is an empty stream
+	w := get("/tasks/export", "")
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("expected an empty ndjson stream got %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	ids := map[string]bool{}
+	for _, title := range []string{"one", "two", "three"} {
+		task := &model.Task{Title: title}
+		if err := tasks.Create(ctx, task); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		ids[task.ID] = true
+	}
+	check := func(got []model.Task) {
+		t.Helper()
+		if len(got) != len(ids) {
+			t.Fatalf("expected %d tasks got %d", len(ids), len(got))
+		}
+		for i, task := range got {
+			if !ids[task.ID] {
+				t.Fatalf("unexpected task %+v", task)
+			}
+			if i > 0 && task.CreatedAt.Before(got[i-1].CreatedAt) {
+				t.Fatalf("expected the oldest first got %+v", got)
+			}
+		}
+	}
+
+	w = get("/tasks/export?format=ndjson", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("expected a plain stream got %d %v", w.Code, w.Header())
+	}
+	check(decode(w.Body))
+
+	w = get("/tasks/export", "gzip, deflate")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("expected a gzip stream got %d %v", w.Code, w.Header())
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	check(decode(zr))
+
+	if w := get("/tasks/export?format=csv", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for csv got %d", w.Code)
+	}
+	// nothing was sent yet, so the failure is a regular error response
+	if w := get("/broken/export", "gzip"); w.Code != http.StatusInternalServerError || w.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("expected a plain 500 got %d %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/model"
+	"taskmanager/internal/problem"
+	"taskmanager/internal/repositories"
+)
+
+// taskFields reads the fields query param (see repositories.ParseFields). It
+// writes a 400 and returns ok=false for an unknown field; nil means the whole
+// task.
+func taskFields(c *gin.Context) (fields []string, ok bool) {
+	s := c.Query("fields")
+	if s == "" {
+		return nil, true
+	}
+	fields, err := repositories.ParseFields(s)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "invalid fields query param: "+err.Error())
+		return nil, false
+	}
+	return fields, true
+}
+
+// projectTask renders t with only the given JSON fields.
+func projectTask(t *model.Task, fields []string) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+	out := make(map[string]json.RawMessage, len(fields))
+	for _, f := range fields {
+		if v, ok := all[f]; ok {
+			out[f] = v
+		}
+	}
+	return out, nil
+}
+
+// projectTasks is projectTask for a page of tasks; nil fields returns the
+// tasks unchanged.
+func projectTasks(tasks []model.Task, fields []string) (interface{}, error) {
+	if fields == nil {
+		return tasks, nil
+	}
+	out := make([]map[string]json.RawMessage, len(tasks))
+	for i := range tasks {
+		p, err := projectTask(&tasks[i], fields)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = p
+	}
+	return out, nil
+}
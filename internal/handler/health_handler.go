@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DependencyCheck probes a single downstream dependency for readiness.
+// A failing Required dependency makes the instance unready; optional ones
+// only degrade the reported status.
+type DependencyCheck struct {
+	Name     string
+	Required bool
+	Check    func(ctx context.Context) error
+}
+
+// HealthHandler serves the liveness and readiness probes.
+type HealthHandler struct {
+	checks        []DependencyCheck
+	schemaVersion func(ctx context.Context) (int, error)
+	timeout       time.Duration
+	shuttingDown  atomic.Bool
+}
+
+// NewHealthHandler creates a HealthHandler. schemaVersion may be nil.
+func NewHealthHandler(timeout time.Duration, schemaVersion func(ctx context.Context) (int, error), checks ...DependencyCheck) *HealthHandler {
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	return &HealthHandler{checks: checks, schemaVersion: schemaVersion, timeout: timeout}
+}
+
+// SetShuttingDown marks the instance as draining; readiness fails from then on.
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+type dependencyStatus struct {
+	Status    string `json:"status"`
+	Required  bool   `json:"required"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Livez handles GET /livez. It only reports that the process is serving requests.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz handles GET /readyz
+// Pings every dependency concurrently with a short timeout and returns 503
+// when a required dependency is down or shutdown has begun.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	status, results := h.evaluate(ctx)
+
+	body := gin.H{"status": status, "checks": results}
+	if h.schemaVersion != nil {
+		if v, err := h.schemaVersion(ctx); err == nil {
+			body["schema_version"] = v
+		} else {
+			body["schema_version"] = nil
+		}
+	}
+
+	code := http.StatusOK
+	if status == "unavailable" || status == "shutting_down" {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, body)
+}
+
+// evaluate runs every dependency check concurrently and derives the overall
+// status: ok, degraded, unavailable or shutting_down.
+func (h *HealthHandler) evaluate(ctx context.Context) (string, map[string]dependencyStatus) {
+	results := make(map[string]dependencyStatus, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, chk := range h.checks {
+		wg.Add(1)
+		go func(chk DependencyCheck) {
+			defer wg.Done()
+			start := time.Now()
+			err := chk.Check(ctx)
+			st := dependencyStatus{Status: "up", Required: chk.Required, LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				st.Status = "down"
+				st.Error = err.Error()
+			}
+			mu.Lock()
+			results[chk.Name] = st
+			mu.Unlock()
+		}(chk)
+	}
+	wg.Wait()
+
+	status := "ok"
+	for _, st := range results {
+		if st.Status == "up" {
+			continue
+		}
+		if st.Required {
+			status = "unavailable"
+			break
+		}
+		status = "degraded"
+	}
+	if h.shuttingDown.Load() {
+		status = "shutting_down"
+	}
+	return status, results
+}
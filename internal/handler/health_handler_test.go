@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func readyz(t *testing.T, h *HealthHandler) (int, map[string]interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	h.Readyz(c)
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	return w.Code, body
+}
+
+func TestHealthHandler_Readyz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("connection refused") }
+	version := func(ctx context.Context) (int, error) { return 2, nil }
+
+	t.Run("AllUp", func(t *testing.T) {
+		h := NewHealthHandler(time.Second, version,
+			DependencyCheck{Name: "postgres", Required: true, Check: up},
+			DependencyCheck{Name: "redis", Check: up})
+		code, body := readyz(t, h)
+		if code != http.StatusOK || body["status"] != "ok" {
+			t.Fatalf("expected 200 ok got %d %v", code, body)
+		}
+		if body["schema_version"] != float64(2) {
+			t.Fatalf("expected schema_version 2 got %v", body["schema_version"])
+		}
+	})
+
+	t.Run("OptionalDown_Degraded", func(t *testing.T) {
+		h := NewHealthHandler(time.Second, version,
+			DependencyCheck{Name: "postgres", Required: true, Check: up},
+			DependencyCheck{Name: "redis", Check: down})
+		code, body := readyz(t, h)
+		if code != http.StatusOK || body["status"] != "degraded" {
+			t.Fatalf("expected 200 degraded got %d %v", code, body)
+		}
+	})
+
+	t.Run("RequiredDown_Unavailable", func(t *testing.T) {
+		h := NewHealthHandler(time.Second, version,
+			DependencyCheck{Name: "postgres", Required: true, Check: down})
+		code, body := readyz(t, h)
+		if code != http.StatusServiceUnavailable || body["status"] != "unavailable" {
+			t.Fatalf("expected 503 unavailable got %d %v", code, body)
+		}
+	})
+
+	t.Run("ShuttingDown", func(t *testing.T) {
+		h := NewHealthHandler(time.Second, nil, DependencyCheck{Name: "postgres", Required: true, Check: up})
+		h.SetShuttingDown()
+		code, _ := readyz(t, h)
+		if code != http.StatusServiceUnavailable {
+			t.Fatalf("expected 503 during shutdown got %d", code)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/model"
+	"taskmanager/internal/problem"
+	"taskmanager/internal/service"
+)
+
+// SetRelatedLoader enables the include query param of GetTask and ListTasks,
+// which embeds the tasks' related collections loaded by l. Without a loader
+// include is rejected. Call it before serving requests.
+func (h *TaskHandler) SetRelatedLoader(l *service.RelatedLoader) {
+	h.related = l
+}
+
+// taskIncludes reads the include query param (see service.ParseIncludes). It
+// writes a 400 and returns ok=false for an unknown collection; nil means none.
+func (h *TaskHandler) taskIncludes(c *gin.Context) (include []string, ok bool) {
+	s := c.Query("include")
+	if s == "" {
+		return nil, true
+	}
+	include, err := service.ParseIncludes(s)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "invalid include query param: "+err.Error())
+		return nil, false
+	}
+	if len(include) > 0 && h.related == nil {
+		problem.Abort(c, http.StatusBadRequest, "invalid include query param: related collections are not available")
+		return nil, false
+	}
+	return include, true
+}
+
+// withRelated renders tasks, restricted to fields when not nil, with the
+// included collections embedded. It loads the collections of the whole page
+// at once.
+func (h *TaskHandler) withRelated(c *gin.Context, tasks []model.Task, fields, include []string) ([]map[string]any, error) {
+	ids := make([]string, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].ID
+	}
+	related, err := h.related.Load(c.Request.Context(), ids, include)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]map[string]any, len(tasks))
+	for i := range tasks {
+		b, err := json.Marshal(&tasks[i])
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(b, &all); err != nil {
+			return nil, err
+		}
+		m := make(map[string]any, len(all)+len(include))
+		if fields == nil {
+			for k, v := range all {
+				m[k] = v
+			}
+		} else {
+			for _, f := range fields {
+				if v, ok := all[f]; ok {
+					m[f] = v
+				}
+			}
+		}
+		for k, v := range related[tasks[i].ID] {
+			m[k] = v
+		}
+		out[i] = m
+	}
+	return out, nil
+}
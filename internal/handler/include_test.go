@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/model"
+	"taskmanager/internal/repositories"
+	"taskmanager/internal/service"
+)
+
+// countingWatchers counts the batch lookups, to catch N+1 queries.
+type countingWatchers struct {
+	repositories.WatcherRepository
+	calls int
+}
+
+func (c *countingWatchers) ListMany(ctx context.Context, taskIDs []string) (map[string][]model.Watcher, error) {
+	c.calls++
+	return c.WatcherRepository.ListMany(ctx, taskIDs)
+}
+
+func TestTaskHandler_Include(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	tasks := repositories.NewMemoryTaskRepository()
+	var ids []string
+	for _, title := range []string{"one", "two", "three"} {
+		task := &model.Task{Title: title}
+		task.SetAssignee("alice")
+		if err := tasks.Create(ctx, task); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		ids = append(ids, task.ID)
+	}
+	watchers := &countingWatchers{WatcherRepository: repositories.NewMemoryWatcherRepository(tasks)}
+	entries := repositories.NewMemoryTimeEntryRepository(tasks)
+	for _, name := range []string{"bob", "alice"} {
+		if _, _, err := watchers.Add(ctx, ids[0], name); err != nil {
+			t.Fatalf("watch: %v", err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	if _, err := entries.AddEntry(ctx, ids[1], start, start.Add(30*time.Minute)); err != nil {
+		t.Fatalf("time entry: %v", err)
+	}
+
+	h := NewTaskHandler(service.NewTaskService(tasks))
+	h.SetRelatedLoader(service.NewRelatedLoader(watchers, entries))
+	r := gin.New()
+	r.GET("/tasks", h.ListTasks)
+	r.GET("/tasks/:id", h.GetTask)
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	type related struct {
+		ID          string            `json:"id"`
+		Title       string            `json:"title"`
+		Watchers    []model.Watcher   `json:"watchers"`
+		TimeEntries []model.TimeEntry `json:"time_entries"`
+	}
+	w := get("/tasks?include=watchers,time_entries&sort=title+asc")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+	}
+	var page struct{ Items []related }
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(page.Items) != 3 || watchers.calls != 1 {
+		t.Fatalf("expected 3 tasks from one watcher lookup got %d tasks, %d lookups", len(page.Items), watchers.calls)
+	}
+	byID := map[string]related{}
+	for _, it := range page.Items {
+		byID[it.ID] = it
+	}
+	if ws := byID[ids[0]].Watchers; len(ws) != 2 || ws[0].Watcher != "alice" || ws[1].Watcher != "bob" {
+		t.Fatalf("expected the sorted watchers of the first task got %+v", ws)
+	}
+	if es := byID[ids[1]].TimeEntries; len(es) != 1 || es[0].Seconds != 1800 {
+		t.Fatalf("expected the time entry of the second task got %+v", es)
+	}
+	// empty collections render as [] rather than being left out
+	if !strings.Contains(w.Body.String(), `"watchers":[]`) || !strings.Contains(w.Body.String(), `"time_entries":[]`) {
+		t.Fatalf("expected empty collections as [] got %s", w.Body.String())
+	}
+
+	w = get("/tasks/" + ids[0] + "?include=watchers&fields=title")
+	var one map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &one); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+	}
+	if len(one) != 3 || one["watchers"] == nil || one["title"] == nil {
+		t.Fatalf("expected id, title and watchers got %s", w.Body.String())
+	}
+	if w := get("/tasks/" + ids[0]); strings.Contains(w.Body.String(), "watchers") {
+		t.Fatalf("expected no collections without include got %s", w.Body.String())
+	}
+
+	for _, target := range []string{"/tasks?include=comments", "/tasks/" + ids[0] + "?include=watchers,tags"} {
+		if w := get(target); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "include") {
+			t.Fatalf("%s: expected 400 got %d body=%s", target, w.Code, w.Body.String())
+		}
+	}
+}
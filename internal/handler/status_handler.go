@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/model"
+	dtos "taskmanager/internal/model/DTOs"
+	"taskmanager/internal/repositories"
+
+	"taskmanager/internal/problem"
+)
+
+// incidentWindow is how far back resolved incidents are shown on /statusz.
+const incidentWindow = 7 * 24 * time.Hour
+
+// healthSample is one point of the dependency health history.
+type healthSample struct {
+	At     time.Time         `json:"at"`
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+
+	// cumulative availability counters at sampling time
+	good, total float64
+}
+
+// StatusHandler serves GET /statusz, the data behind a simple status page:
+// recent availability, dependency health history and incident annotations.
+type StatusHandler struct {
+	health       *HealthHandler
+	incidents    repositories.IncidentRepository
+	availability func() (good, total float64)
+	size         int
+
+	mu      sync.Mutex
+	history []healthSample
+}
+
+// NewStatusHandler creates a StatusHandler keeping the last size health samples.
+// availability returns the cumulative good/total request counters.
+func NewStatusHandler(health *HealthHandler, incidents repositories.IncidentRepository, availability func() (good, total float64), size int) *StatusHandler {
+	if size <= 0 {
+		size = 120
+	}
+	return &StatusHandler{health: health, incidents: incidents, availability: availability, size: size}
+}
+
+// Run samples dependency health and availability every interval until ctx is cancelled.
+func (h *StatusHandler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.Sample(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sample records one history point.
+func (h *StatusHandler) Sample(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, h.health.timeout)
+	defer cancel()
+	status, results := h.health.evaluate(ctx)
+
+	s := healthSample{At: time.Now().UTC(), Status: status, Checks: make(map[string]string, len(results))}
+	for name, st := range results {
+		s.Checks[name] = st.Status
+	}
+	if h.availability != nil {
+		s.good, s.total = h.availability()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.history = append(h.history, s)
+	if len(h.history) > h.size {
+		h.history = h.history[len(h.history)-h.size:]
+	}
+}
+
+// Statusz handles GET /statusz
+// Availability is computed over the sampled window (oldest to newest sample);
+// ratio is null when no requests were served in it.
+func (h *StatusHandler) Statusz(c *gin.Context) {
+	h.mu.Lock()
+	history := append([]healthSample(nil), h.history...)
+	h.mu.Unlock()
+
+	status := "unknown"
+	availability := gin.H{"window_seconds": 0, "requests": 0, "ratio": nil}
+	if n := len(history); n > 0 {
+		first, last := history[0], history[n-1]
+		status = last.Status
+		total := last.total - first.total
+		availability["window_seconds"] = int64(last.At.Sub(first.At).Seconds())
+		availability["requests"] = total
+		if total > 0 {
+			availability["ratio"] = (last.good - first.good) / total
+		}
+	}
+
+	incidents := []model.Incident{}
+	if h.incidents != nil {
+		var err error
+		incidents, err = h.incidents.ListSince(c.Request.Context(), time.Now().Add(-incidentWindow))
+		if err != nil {
+			// the status page must still render when the database is down
+			slog.WarnContext(c.Request.Context(), "failed to load incidents", "err", err)
+			incidents = []model.Incident{}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":       status,
+		"availability": availability,
+		"history":      history,
+		"incidents":    incidents,
+	})
+}
+
+// CreateIncident handles POST /incidents
+func (h *StatusHandler) CreateIncident(c *gin.Context) {
+	var dto dtos.CreateIncidentDTO
+	if !bindJSON(c, &dto) {
+		return
+	}
+	inc := &model.Incident{Title: dto.Title, Description: dto.Description, Severity: dto.Severity}
+	if dto.StartedAt != nil {
+		inc.StartedAt = dto.StartedAt.UTC()
+	}
+	if err := h.incidents.Create(c.Request.Context(), inc); err != nil {
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to create incident")
+		return
+	}
+	c.JSON(http.StatusCreated, inc)
+}
+
+// ResolveIncident handles POST /incidents/:id/resolve
+func (h *StatusHandler) ResolveIncident(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "invalid incident id")
+		return
+	}
+	inc, err := h.incidents.Resolve(c.Request.Context(), id, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, repositories.ErrIncidentNotFound) {
+			problem.Abort(c, http.StatusNotFound, "incident not found")
+			return
+		}
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to resolve incident")
+		return
+	}
+	c.JSON(http.StatusOK, inc)
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"taskmanager/internal/model"
+	"taskmanager/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeIncidents implements repositories.IncidentRepository for handler tests.
+type fakeIncidents struct {
+	items   []model.Incident
+	listErr error
+}
+
+func (f *fakeIncidents) Create(_ context.Context, inc *model.Incident) error {
+	inc.ID = int64(len(f.items) + 1)
+	f.items = append(f.items, *inc)
+	return nil
+}
+func (f *fakeIncidents) Resolve(_ context.Context, id int64, at time.Time) (*model.Incident, error) {
+	for i := range f.items {
+		if f.items[i].ID == id {
+			f.items[i].ResolvedAt = &at
+			return &f.items[i], nil
+		}
+	}
+	return nil, repositories.ErrIncidentNotFound
+}
+func (f *fakeIncidents) ListSince(_ context.Context, _ time.Time) ([]model.Incident, error) {
+	return f.items, f.listErr
+}
+
+func TestStatusHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	redisUp := true
+	health := NewHealthHandler(time.Second, nil,
+		DependencyCheck{Name: "postgres", Required: true, Check: func(ctx context.Context) error { return nil }},
+		DependencyCheck{Name: "redis", Check: func(ctx context.Context) error {
+			if redisUp {
+				return nil
+			}
+			return errors.New("down")
+		}})
+
+	var good, total float64
+	incidents := &fakeIncidents{}
+	h := NewStatusHandler(health, incidents, func() (float64, float64) { return good, total }, 2)
+
+	statusz := func() map[string]interface{} {
+		t.Helper()
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/statusz", nil)
+		h.Statusz(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200 got %d", w.Code)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return body
+	}
+
+	t.Run("NoSamples", func(t *testing.T) {
+		body := statusz()
+		if body["status"] != "unknown" || body["availability"].(map[string]interface{})["ratio"] != nil {
+			t.Fatalf("unexpected body %v", body)
+		}
+	})
+
+	t.Run("AvailabilityOverWindow", func(t *testing.T) {
+		good, total = 100, 100
+		h.Sample(context.Background())
+		good, total = 190, 200 // 90 good out of 100 since the first sample
+		redisUp = false
+		h.Sample(context.Background())
+
+		body := statusz()
+		if body["status"] != "degraded" {
+			t.Fatalf("expected degraded got %v", body["status"])
+		}
+		if ratio := body["availability"].(map[string]interface{})["ratio"]; ratio != 0.9 {
+			t.Fatalf("expected ratio 0.9 got %v", ratio)
+		}
+		// history is capped to the configured size
+		h.Sample(context.Background())
+		if n := len(statusz()["history"].([]interface{})); n != 2 {
+			t.Fatalf("expected 2 samples got %d", n)
+		}
+	})
+
+	t.Run("CreateAndResolveIncident", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/incidents", strings.NewReader(`{"title":"Redis outage","severity":"major"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.CreateIncident(c)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201 got %d body=%s", w.Code, w.Body.String())
+		}
+
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request = httptest.NewRequest(http.MethodPost, "/incidents/1/resolve", nil)
+		h.ResolveIncident(c)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"resolved_at":"`) {
+			t.Fatalf("expected resolved incident got %d body=%s", w.Code, w.Body.String())
+		}
+
+		if n := len(statusz()["incidents"].([]interface{})); n != 1 {
+			t.Fatalf("expected 1 incident got %d", n)
+		}
+	})
+
+	t.Run("InvalidSeverity", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/incidents", strings.NewReader(`{"title":"x","severity":"apocalyptic"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.CreateIncident(c)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 got %d", w.Code)
+		}
+	})
+
+	t.Run("IncidentsUnavailable", func(t *testing.T) {
+		incidents.listErr = errors.New("db down")
+		if n := len(statusz()["incidents"].([]interface{})); n != 0 {
+			t.Fatalf("expected empty incidents got %d", n)
+		}
+	})
+}
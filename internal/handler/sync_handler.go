@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/model"
+	"taskmanager/internal/outbox"
+	"taskmanager/internal/problem"
+	"taskmanager/internal/repositories"
+	"taskmanager/internal/service"
+)
+
+// SyncFeed is the part of outbox.Feed the sync endpoint needs.
+type SyncFeed interface {
+	Since(ctx context.Context, seq int64, limit int) ([]outbox.Event, error)
+	SeqAt(ctx context.Context, t time.Time) (int64, error)
+}
+
+// TombstoneLookup is the part of repositories.TombstoneRepository the sync
+// endpoint needs.
+type TombstoneLookup interface {
+	GetMany(ctx context.Context, taskIDs []string) (map[string]model.Tombstone, error)
+}
+
+// SyncHandler serves GET /tasks/changes, the delta an offline-first client
+// applies to its local copy: the tasks created, updated and deleted since
+// its last checkpoint. Unlike GET /changes it returns the current state of
+// each task once rather than every event.
+type SyncHandler struct {
+	feed       SyncFeed
+	tasks      service.TaskService
+	tombstones TombstoneLookup
+}
+
+// NewSyncHandler creates a SyncHandler reading events from feed, the
+// current tasks from tasks and when and by whom they were deleted from
+// tombstones.
+func NewSyncHandler(feed SyncFeed, tasks service.TaskService, tombstones TombstoneLookup) *SyncHandler {
+	return &SyncHandler{feed: feed, tasks: tasks, tombstones: tombstones}
+}
+
+// syncDeleted is a deleted task in the delta. DeletedAt and Actor come from
+// its tombstone and are left out once that has been purged.
+type syncDeleted struct {
+	ID        string     `json:"id"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Actor     *string    `json:"actor,omitempty"`
+}
+
+// syncIgnored are the events that don't change the task payload.
+var syncIgnored = map[string]bool{
+	outbox.EventTaskWatched:   true,
+	outbox.EventTaskUnwatched: true,
+}
+
+// TaskChanges handles GET /tasks/changes?since=<cursor|timestamp>&limit=100.
+// since is the next_since of the previous response, or an RFC 3339
+// timestamp or date for the first sync; without it the whole history is
+// replayed. The page covers up to limit events: tasks created since the
+// checkpoint are listed under created, changed ones under updated, both
+// with their current state, and those that no longer exist under deleted
+// with their tombstone. has_more means another page is waiting at
+// next_since.
+func (h *SyncHandler) TaskChanges(c *gin.Context) {
+	fields, ok := taskFields(c)
+	if !ok {
+		return
+	}
+	limit := 100
+	if s := c.Query("limit"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			limit = min(v, service.MaxBatchGet)
+		}
+	}
+
+	var since int64
+	if s := c.Query("since"); s != "" {
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			if v < 0 {
+				problem.Abort(c, http.StatusBadRequest, "invalid since query param")
+				return
+			}
+			since = v
+		} else {
+			t, err := parseTimestamp(s)
+			if err != nil {
+				problem.Abort(c, http.StatusBadRequest, "invalid since query param: expected a cursor or an RFC 3339 timestamp")
+				return
+			}
+			if since, err = h.feed.SeqAt(c.Request.Context(), t); err != nil {
+				h.fail(c, err)
+				return
+			}
+		}
+	}
+
+	events, err := h.feed.Since(c.Request.Context(), since, limit)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	// the tasks in the order of their last event, and whether the page saw
+	// them being created or deleted
+	var ids []string
+	seen := map[string]bool{}
+	created := map[string]bool{}
+	deleted := map[string]bool{}
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if syncIgnored[e.Type] {
+			continue
+		}
+		switch e.Type {
+		case outbox.EventTaskCreated:
+			created[e.AggregateID] = true
+		case outbox.EventTaskDeleted:
+			deleted[e.AggregateID] = true
+		}
+		if !seen[e.AggregateID] {
+			seen[e.AggregateID] = true
+			ids = append(ids, e.AggregateID)
+		}
+	}
+	slices.Reverse(ids)
+
+	var live []string
+	gone := []string{}
+	for _, id := range ids {
+		if deleted[id] {
+			gone = append(gone, id)
+		} else {
+			live = append(live, id)
+		}
+	}
+	newTasks, changed := []model.Task{}, []model.Task{}
+	var missing []string
+	if len(live) > 0 {
+		// the events come from the primary, so a replica may not have
+		// these tasks yet
+		tasks, notFound, err := h.tasks.BatchGet(repositories.WithPrimary(c.Request.Context()), live)
+		if err != nil {
+			h.fail(c, err)
+			return
+		}
+		for _, t := range tasks {
+			if created[t.ID] {
+				newTasks = append(newTasks, t)
+			} else {
+				changed = append(changed, t)
+			}
+		}
+		missing = notFound
+	}
+	deletedOut := []syncDeleted{}
+	if len(gone)+len(missing) > 0 {
+		tombstones, err := h.tombstones.GetMany(c.Request.Context(), append(slices.Clone(gone), missing...))
+		if err != nil {
+			h.fail(c, err)
+			return
+		}
+		for _, id := range gone {
+			d := syncDeleted{ID: id}
+			if t, ok := tombstones[id]; ok {
+				d.DeletedAt = &t.DeletedAt
+				d.Actor = t.Actor
+			}
+			deletedOut = append(deletedOut, d)
+		}
+		// deleted after the page's last event; without a tombstone there is
+		// no proof of that, and the task.deleted event of a later page
+		// reports it instead
+		for _, id := range missing {
+			if t, ok := tombstones[id]; ok {
+				deletedOut = append(deletedOut, syncDeleted{ID: id, DeletedAt: &t.DeletedAt, Actor: t.Actor})
+			}
+		}
+	}
+	createdOut, err := projectTasks(newTasks, fields)
+	if err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "failed to encode tasks")
+		return
+	}
+	updatedOut, err := projectTasks(changed, fields)
+	if err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "failed to encode tasks")
+		return
+	}
+
+	next := since
+	if len(events) > 0 {
+		next = events[len(events)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"created":    createdOut,
+		"updated":    updatedOut,
+		"deleted":    deletedOut,
+		"next_since": next,
+		"has_more":   len(events) == limit,
+	})
+}
+
+func (h *SyncHandler) fail(c *gin.Context, err error) {
+	if writeTimeout(c, err) {
+		return
+	}
+	problem.Abort(c, http.StatusInternalServerError, "failed to read changes")
+}
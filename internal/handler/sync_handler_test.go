@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/logging"
+	"taskmanager/internal/model"
+	"taskmanager/internal/outbox"
+	"taskmanager/internal/repositories"
+	"taskmanager/internal/service"
+)
+
+// fakeSyncFeed serves events from memory; SeqAt maps every timestamp to at.
+type fakeSyncFeed struct {
+	events []outbox.Event
+	at     int64
+}
+
+func (f *fakeSyncFeed) Since(_ context.Context, seq int64, limit int) ([]outbox.Event, error) {
+	out := []outbox.Event{}
+	for _, e := range f.events {
+		if e.ID > seq && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSyncFeed) SeqAt(context.Context, time.Time) (int64, error) {
+	return f.at, nil
+}
+
+func TestSyncHandler_TaskChanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	tasks := repositories.NewMemoryTaskRepository()
+	var ids []string
+	for _, title := range []string{"old", "new", "later gone"} {
+		task := &model.Task{Title: title}
+		if err := tasks.Create(ctx, task); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		ids = append(ids, task.ID)
+	}
+	if _, err := tasks.Delete(logging.WithActor(ctx, "k1"), ids[2]); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	const removed = "00000000-0000-4000-8000-000000000001"
+	feed := &fakeSyncFeed{at: 2, events: []outbox.Event{
+		{ID: 1, Type: outbox.EventTaskCreated, AggregateID: ids[0]},
+		{ID: 2, Type: outbox.EventTaskCreated, AggregateID: removed},
+		{ID: 3, Type: outbox.EventTaskUpdated, AggregateID: ids[0]},
+		{ID: 4, Type: outbox.EventTaskCreated, AggregateID: ids[1]},
+		{ID: 5, Type: outbox.EventTaskWatched, AggregateID: ids[0]},
+		{ID: 6, Type: outbox.EventTaskDeleted, AggregateID: removed},
+		{ID: 7, Type: outbox.EventTaskCompleted, AggregateID: ids[2]},
+	}}
+	h := NewSyncHandler(feed, service.NewTaskService(tasks), repositories.NewMemoryTombstoneRepository(tasks))
+	r := gin.New()
+	r.GET("/tasks/changes", h.TaskChanges)
+
+	type page struct {
+		Created   []model.Task  `json:"created"`
+		Updated   []model.Task  `json:"updated"`
+		Deleted   []syncDeleted `json:"deleted"`
+		NextSince int64         `json:"next_since"`
+		HasMore   bool          `json:"has_more"`
+	}
+	get := func(target string) (*httptest.ResponseRecorder, page) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		var p page
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+		}
+		return w, p
+	}
+
+	// the whole history collapses to the current state of each task
+	w, p := get("/tasks/changes")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+	}
+	if len(p.Created) != 2 || p.Created[0].ID != ids[0] || p.Created[1].ID != ids[1] || len(p.Updated) != 0 {
+		t.Fatalf("expected the two live tasks as created got %s", w.Body.String())
+	}
+	// the task deleted in the page's window has no tombstone here; the
+	// other one has, with its actor
+	if len(p.Deleted) != 2 || p.Deleted[0].ID != removed || p.Deleted[0].DeletedAt != nil || p.Deleted[1].ID != ids[2] {
+		t.Fatalf("expected both deleted ids got %s", w.Body.String())
+	}
+	if d := p.Deleted[1]; d.DeletedAt == nil || d.Actor == nil || *d.Actor != "k1" {
+		t.Fatalf("expected the tombstone of the deleted task got %s", w.Body.String())
+	}
+	if p.NextSince != 7 || p.HasMore {
+		t.Fatalf("expected next_since 7 and no more got %d %v", p.NextSince, p.HasMore)
+	}
+
+	// from a checkpoint, tasks created before it are updates; the watch
+	// event alone doesn't count as one
+	_, p = get("/tasks/changes?since=3&limit=2")
+	if len(p.Created) != 1 || p.Created[0].ID != ids[1] || len(p.Updated) != 0 || len(p.Deleted) != 0 {
+		t.Fatalf("expected only the second task got %+v", p)
+	}
+	if p.NextSince != 5 || !p.HasMore {
+		t.Fatalf("expected next_since 5 with more got %d %v", p.NextSince, p.HasMore)
+	}
+
+	// a timestamp is resolved to the cursor at that time
+	w, p = get("/tasks/changes?since=2026-01-02T15:04:05Z&fields=title")
+	if len(p.Updated) != 1 || p.Updated[0].ID != ids[0] || p.Updated[0].Title != "old" || len(p.Created) != 1 {
+		t.Fatalf("expected the first task as updated got %s", w.Body.String())
+	}
+	var raw struct{ Updated []map[string]any }
+	if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil || len(raw.Updated[0]) != 2 {
+		t.Fatalf("expected only id and title got %s", w.Body.String())
+	}
+
+	// nothing new: empty collections and the same cursor
+	w, _ = get("/tasks/changes?since=7")
+	if w.Body.String() != `{"created":[],"deleted":[],"has_more":false,"next_since":7,"updated":[]}` {
+		t.Fatalf("expected an empty page got %s", w.Body.String())
+	}
+
+	for _, target := range []string{"/tasks/changes?since=-1", "/tasks/changes?since=yesterday", "/tasks/changes?fields=nope"} {
+		if w, _ := get(target); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400 got %d", target, w.Code)
+		}
+	}
+}
+
+func TestSyncHandler_TaskChanges_MissingTaskWithoutTombstone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tasks := repositories.NewMemoryTaskRepository()
+	// an update of a task the read doesn't find, e.g. on a lagging
+	// replica: without a tombstone or task.deleted event it isn't deleted
+	const unseen = "00000000-0000-4000-8000-000000000002"
+	feed := &fakeSyncFeed{events: []outbox.Event{
+		{ID: 1, Type: outbox.EventTaskUpdated, AggregateID: unseen},
+	}}
+	h := NewSyncHandler(feed, service.NewTaskService(tasks), repositories.NewMemoryTombstoneRepository(tasks))
+	r := gin.New()
+	r.GET("/tasks/changes", h.TaskChanges)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/changes", nil))
+	if w.Body.String() != `{"created":[],"deleted":[],"has_more":false,"next_since":1,"updated":[]}` {
+		t.Fatalf("expected the missing task left out got %s", w.Body.String())
+	}
+}
@@ -1,20 +1,41 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
 
+	"taskmanager/internal/breaker"
+	"taskmanager/internal/middleware"
+	"taskmanager/internal/model"
 	dtos "taskmanager/internal/model/DTOs"
 	"taskmanager/internal/repositories"
 	"taskmanager/internal/service"
+
+	"taskmanager/internal/problem"
 )
 
 // TaskHandler holds dependencies for HTTP handlers.
 type TaskHandler struct {
 	svc service.TaskService
+	// bareLists makes ListTasks return a plain array with pagination in
+	// headers only, for legacy clients.
+	bareLists atomic.Bool
+	// related loads the collections of the include query param; nil
+	// disables it.
+	related *service.RelatedLoader
 }
 
 // NewTaskHandler creates a new TaskHandler.
@@ -22,26 +43,40 @@ func NewTaskHandler(s service.TaskService) *TaskHandler {
 	return &TaskHandler{svc: s}
 }
 
+// SetBareListResponses switches ListTasks between the {items,limit,offset,total}
+// envelope (false, the default) and a bare array with header-only pagination.
+func (h *TaskHandler) SetBareListResponses(on bool) {
+	h.bareLists.Store(on)
+}
+
 // CreateTask handles POST /tasks
 func (h *TaskHandler) CreateTask(c *gin.Context) {
 	var dto dtos.CreateTaskDTO
-	if err := c.ShouldBindJSON(&dto); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+	if !bindJSON(c, &dto) {
 		return
 	}
 
 	// Convert DTO to model and then call service
 	tmodel := dto.ToModel()
 
-	ctx := c.Request.Context()
-	task, err := h.svc.Create(ctx, tmodel)
+	ctx, ok := wipContext(c)
+	if !ok {
+		return
+	}
+	task, err := h.service(c).Create(ctx, tmodel)
 	if err != nil {
 		// service returns ErrInvalidInput for validation problems
 		if errors.Is(err, service.ErrInvalidInput) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+			problem.Abort(c, http.StatusBadRequest, "invalid input")
+			return
+		}
+		if writeWIPLimit(c, err) {
+			return
+		}
+		if writeTimeout(c, err) {
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create task"})
+		problem.Abort(c, http.StatusInternalServerError, "failed to create task")
 		return
 	}
 
@@ -49,8 +84,22 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 }
 
 // ListTasks handles GET /tasks
-// Supports query params: limit, offset, completed, assignee
+// Supports query params: limit, offset and the filters read by
+// parseListFilters. Pages carry an ETag and honor If-None-Match.
 func (h *TaskHandler) ListTasks(c *gin.Context) {
+	f, err := parseListFilters(c.Request.URL.Query())
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.listTasks(c, f)
+}
+
+// listTasks writes the page of tasks matching the filter and sort of f
+// selected by the limit and offset query params, restricted to the fields
+// query param when present. The count query param (see
+// repositories.ParseCountMode) picks how the total is computed.
+func (h *TaskHandler) listTasks(c *gin.Context, f repositories.ListOptions) {
 	limit := 100
 	offset := 0
 
@@ -65,111 +114,763 @@ func (h *TaskHandler) ListTasks(c *gin.Context) {
 		}
 	}
 
-	var completed *bool
-	if s := c.Query("completed"); s != "" {
-		if v, err := strconv.ParseBool(s); err == nil {
-			completed = &v
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid completed query param"})
-			return
-		}
+	fields, ok := taskFields(c)
+	if !ok {
+		return
 	}
-
-	var assignee *string
-	if s := c.Query("assignee"); s != "" {
-		assignee = &s
+	include, ok := h.taskIncludes(c)
+	if !ok {
+		return
+	}
+	count, err := repositories.ParseCountMode(c.Query("count"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "invalid count query param: "+err.Error())
+		return
 	}
 
+	opts := service.ListOptions{ListOptions: f, Count: count, Include: include}
+	opts.Limit, opts.Offset, opts.Fields = limit, offset, fields
 	ctx := c.Request.Context()
-	items, total, err := h.svc.List(ctx, limit, offset, completed, assignee)
+	items, total, err := h.service(c).List(ctx, opts)
+	if err != nil {
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to list tasks")
+		return
+	}
+	if items == nil {
+		items = []model.Task{}
+	}
+	var page interface{}
+	if opts.Include != nil {
+		page, err = h.withRelated(c, items, fields, opts.Include)
+	} else {
+		page, err = projectTasks(items, fields)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list tasks"})
+		if writeTimeout(c, err) {
+			return
+		}
+		_ = c.Error(err)
+		problem.Abort(c, http.StatusInternalServerError, "failed to encode tasks")
 		return
 	}
 
 	// Include pagination metadata in the response and X-Total-Count header for clients.
-	c.Header("X-Total-Count", strconv.Itoa(total))
-	c.JSON(http.StatusOK, gin.H{
-		"items":  items,
+	counted := count != repositories.CountNone
+	if counted {
+		c.Header("X-Total-Count", strconv.Itoa(total))
+	} else {
+		// no total for the Link header: a full page implies a next one
+		total = offset + len(items)
+		if len(items) == limit {
+			total++
+		}
+	}
+	if h.bareLists.Load() {
+		c.Header("X-Limit", strconv.Itoa(limit))
+		c.Header("X-Offset", strconv.Itoa(offset))
+		if link := paginationLinks(c.Request.URL, limit, offset, total); link != "" {
+			c.Header("Link", link)
+		}
+		writeETagJSON(c, page)
+		return
+	}
+	resp := gin.H{
+		"items":  page,
 		"limit":  limit,
 		"offset": offset,
-		"total":  total,
-	})
+	}
+	if counted {
+		resp["total"] = total
+	}
+	writeETagJSON(c, resp)
 }
 
-// GetTask handles GET /tasks/:id
-func (h *TaskHandler) GetTask(c *gin.Context) {
+// TaskStats handles GET /tasks/stats: counts and effort totals of the tasks
+// matching the GET /tasks filters, overall and per assignee. sort, limit and
+// offset are accepted and ignored.
+func (h *TaskHandler) TaskStats(c *gin.Context) {
+	f, err := parseListFilters(c.Request.URL.Query())
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	stats, err := h.service(c).Stats(c.Request.Context(), f.Filter)
+	if err != nil {
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to compute task stats")
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// taskID returns the :id path parameter. Task ids are UUIDs; anything else
+// gets a 400 here rather than a cast error from PostgreSQL.
+func taskID(c *gin.Context) (string, bool) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing id"})
+		problem.Abort(c, http.StatusBadRequest, "missing id")
+		return "", false
+	}
+	// uuid.Parse also takes the braced, urn: and unhyphenated forms; only the
+	// canonical one matches the stored ids on every backend
+	if _, err := uuid.Parse(id); err != nil || len(id) != 36 {
+		problem.Abort(c, http.StatusBadRequest, "invalid id: expected a UUID")
+		return "", false
+	}
+	return id, true
+}
+
+// maxAssigneeFilter caps the number of assignee values in one list query.
+const maxAssigneeFilter = 50
+
+// maxTitleQuery caps the length of the q (title substring) list filter.
+const maxTitleQuery = 200
+
+// parseListFilters reads the filter and sort of a task list from query
+// params: completed, assignee (repeatable, "none" for unassigned tasks), the
+// created_*/updated_*/due_* date ranges, q (case-insensitive substring of the
+// title) and sort (see repositories.ParseSort; the configured default when
+// absent). The page and fields are left to the caller. Errors describe the
+// offending param for a 400.
+func parseListFilters(values url.Values) (repositories.ListOptions, error) {
+	var f repositories.ListOptions
+	if s := values.Get("completed"); s != "" {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return f, errors.New("invalid completed query param")
+		}
+		f.Filter.Completed = &v
+	}
+
+	var err error
+	if f.Filter.Assignee, err = parseAssigneeFilter(values); err != nil {
+		return f, err
+	}
+	if f.Filter.Dates, err = parseDateRange(values); err != nil {
+		return f, err
+	}
+
+	f.Filter.Title = strings.TrimSpace(values.Get("q"))
+	if utf8.RuneCountInString(f.Filter.Title) > maxTitleQuery {
+		return f, fmt.Errorf("q query param is too long (max %d characters)", maxTitleQuery)
+	}
+
+	if s := values.Get("sort"); s != "" {
+		if f.Sort, err = repositories.ParseSort(s); err != nil {
+			return f, fmt.Errorf("invalid sort query param: %v", err)
+		}
+	}
+	return f, nil
+}
+
+// parseAssigneeFilter reads the repeatable assignee query param.
+func parseAssigneeFilter(q url.Values) (repositories.AssigneeFilter, error) {
+	return assigneeFilter(q["assignee"])
+}
+
+// assigneeFilter selects the tasks of any of the assignees in values. The
+// value "none" selects tasks without an assignee.
+func assigneeFilter(values []string) (repositories.AssigneeFilter, error) {
+	var f repositories.AssigneeFilter
+	if len(values) > maxAssigneeFilter {
+		return f, fmt.Errorf("too many assignee values (max %d)", maxAssigneeFilter)
+	}
+	for _, v := range values {
+		switch v {
+		case "":
+		case "none":
+			f.Unassigned = true
+		default:
+			f.Names = append(f.Names, v)
+		}
+	}
+	return f, nil
+}
+
+// parseTimestamp parses an RFC 3339 timestamp or a plain date (midnight UTC).
+func parseTimestamp(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+// parseDateRange reads the created_*/updated_*/due_* query params. Values
+// are RFC 3339 timestamps or plain dates (midnight UTC).
+func parseDateRange(q url.Values) (repositories.DateRange, error) {
+	var r repositories.DateRange
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_after", &r.CreatedAfter},
+		{"created_before", &r.CreatedBefore},
+		{"updated_after", &r.UpdatedAfter},
+		{"updated_before", &r.UpdatedBefore},
+		{"due_after", &r.DueAfter},
+		{"due_before", &r.DueBefore},
+	} {
+		s := q.Get(p.name)
+		if s == "" {
+			continue
+		}
+		t, err := parseTimestamp(s)
+		if err != nil {
+			return r, fmt.Errorf("invalid %s query param: expected RFC 3339 timestamp or YYYY-MM-DD", p.name)
+		}
+		*p.dst = &t
+	}
+	if r.CreatedAfter != nil && r.CreatedBefore != nil && !r.CreatedAfter.Before(*r.CreatedBefore) {
+		return r, errors.New("created_after must be before created_before")
+	}
+	if r.UpdatedAfter != nil && r.UpdatedBefore != nil && !r.UpdatedAfter.Before(*r.UpdatedBefore) {
+		return r, errors.New("updated_after must be before updated_before")
+	}
+	if r.DueAfter != nil && r.DueBefore != nil && !r.DueAfter.Before(*r.DueBefore) {
+		return r, errors.New("due_after must be before due_before")
+	}
+	return r, nil
+}
+
+// paginationLinks builds an RFC 8288 Link header with next/prev pages,
+// keeping the request's other query parameters.
+func paginationLinks(u *url.URL, limit, offset, total int) string {
+	page := func(off int, rel string) string {
+		q := u.Query()
+		q.Set("limit", strconv.Itoa(limit))
+		q.Set("offset", strconv.Itoa(off))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, u.Path, q.Encode(), rel)
+	}
+	var links []string
+	if offset+limit < total {
+		links = append(links, page(offset+limit, "next"))
+	}
+	if offset > 0 {
+		links = append(links, page(max(offset-limit, 0), "prev"))
+	}
+	return strings.Join(links, ", ")
+}
+
+// BatchGetTasks handles POST /tasks/batch-get
+// Fetches up to service.MaxBatchGet tasks in one query, in request order, and
+// reports the ids that do not exist.
+func (h *TaskHandler) BatchGetTasks(c *gin.Context) {
+	var dto dtos.BatchGetTasksDTO
+	if !bindJSON(c, &dto) {
+		return
+	}
+
+	tasks, notFound, err := h.service(c).BatchGet(c.Request.Context(), dto.IDs)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			problem.Abort(c, http.StatusBadRequest, fmt.Sprintf("invalid input: between 1 and %d ids are required", service.MaxBatchGet))
+			return
+		}
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to fetch tasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": tasks, "not_found": notFound})
+}
+
+// ReassignTasks handles POST /tasks/reassign
+// Moves every task matching the filter to a new assignee in one transaction.
+func (h *TaskHandler) ReassignTasks(c *gin.Context) {
+	var dto dtos.ReassignTasksDTO
+	if !bindJSON(c, &dto) {
+		return
+	}
+	completed, err := dto.Filter.CompletedFilter()
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+	f := dto.Filter
+	assignee, err := assigneeFilter(f.Assignee)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+	filter := repositories.TaskFilter{
+		Completed: completed,
+		Assignee:  assignee,
+		Dates: repositories.DateRange{
+			CreatedAfter: f.CreatedAfter, CreatedBefore: f.CreatedBefore,
+			UpdatedAfter: f.UpdatedAfter, UpdatedBefore: f.UpdatedBefore,
+			DueAfter: f.DueAfter, DueBefore: f.DueBefore,
+		},
+		Title: strings.TrimSpace(f.Q),
+	}
+
+	ctx, ok := wipContext(c)
+	if !ok {
+		return
+	}
+	ids, err := h.service(c).Reassign(ctx, filter, dto.Assignee)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			problem.Abort(c, http.StatusBadRequest, "invalid input: a target assignee and at least one filter are required")
+			return
+		}
+		if writeWIPLimit(c, err) {
+			return
+		}
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to reassign tasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reassigned": len(ids), "ids": ids, "assignee": dto.Assignee})
+}
+
+// GetTask handles GET /tasks/:id; like ListTasks it accepts fields and
+// answers a matching If-None-Match with 304.
+func (h *TaskHandler) GetTask(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	fields, ok := taskFields(c)
+	if !ok {
+		return
+	}
+	include, ok := h.taskIncludes(c)
+	if !ok {
 		return
 	}
 
 	ctx := c.Request.Context()
-	t, err := h.svc.GetByID(ctx, id)
+	t, err := h.service(c).GetByID(ctx, id)
 	if err != nil {
 		// map repository not-found to 404
 		if errors.Is(err, repositories.ErrNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			problem.Abort(c, http.StatusNotFound, "task not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch task"})
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to fetch task")
 		return
 	}
-	c.JSON(http.StatusOK, t)
+	if include != nil {
+		p, err := h.withRelated(c, []model.Task{*t}, fields, include)
+		if err != nil {
+			if writeTimeout(c, err) {
+				return
+			}
+			problem.Abort(c, http.StatusInternalServerError, "failed to fetch related collections")
+			return
+		}
+		writeETagJSON(c, p[0])
+		return
+	}
+	if fields == nil {
+		writeETagJSON(c, t)
+		return
+	}
+	p, err := projectTask(t, fields)
+	if err != nil {
+		problem.Abort(c, http.StatusInternalServerError, "failed to encode task")
+		return
+	}
+	writeETagJSON(c, p)
 }
 
 // UpdateTask handles PUT /tasks/:id
-// Now accepts partial update for assignee as well.
+// With ?dry_run=true the update is validated and the would-be task plus a
+// field diff is returned without persisting anything.
 func (h *TaskHandler) UpdateTask(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing id"})
+	id, ok := taskID(c)
+	if !ok {
 		return
 	}
 	var dto dtos.UpdateTaskDTO
-	if err := c.ShouldBindJSON(&dto); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+	if !bindJSON(c, &dto) {
+		return
+	}
+
+	dryRun, ok := parseDryRun(c)
+	if !ok {
 		return
 	}
 
 	// Convert DTO to model and call service.Update with the partial Task.
 	tmodel := dto.ToModel(id)
 	ctx := c.Request.Context()
-	updated, err := h.svc.Update(ctx, tmodel)
+	if dryRun {
+		before, after, err := h.service(c).PreviewUpdate(ctx, tmodel)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidInput) {
+				problem.Abort(c, http.StatusBadRequest, "invalid input")
+				return
+			}
+			if errors.Is(err, repositories.ErrNotFound) {
+				problem.Abort(c, http.StatusNotFound, "task not found")
+				return
+			}
+			if writeTimeout(c, err) {
+				return
+			}
+			problem.Abort(c, http.StatusInternalServerError, "failed to update task")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"dry_run": true, "task": after, "changes": before.Diff(after)})
+		return
+	}
+
+	updated, err := h.service(c).Update(ctx, tmodel)
 	if err != nil {
 		if errors.Is(err, service.ErrInvalidInput) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+			problem.Abort(c, http.StatusBadRequest, "invalid input")
 			return
 		}
 		if errors.Is(err, repositories.ErrNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			problem.Abort(c, http.StatusNotFound, "task not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update task"})
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to update task")
 		return
 	}
 
 	c.JSON(http.StatusOK, updated)
 }
 
+// CompleteTask handles POST /tasks/:id/complete. Completing an already
+// completed task is a no-op that returns it unchanged.
+func (h *TaskHandler) CompleteTask(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	t, err := h.service(c).Complete(c.Request.Context(), id)
+	h.writeCompletion(c, t, err)
+}
+
+// ReopenTask handles POST /tasks/:id/reopen. The reopened task counts
+// against its assignee's WIP limit (see override_wip_limit).
+func (h *TaskHandler) ReopenTask(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	ctx, ok := wipContext(c)
+	if !ok {
+		return
+	}
+	t, err := h.service(c).Reopen(ctx, id)
+	h.writeCompletion(c, t, err)
+}
+
+func (h *TaskHandler) writeCompletion(c *gin.Context, t *model.Task, err error) {
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			problem.Abort(c, http.StatusNotFound, "task not found")
+			return
+		}
+		if writeWIPLimit(c, err) {
+			return
+		}
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to update task")
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// MoveTask handles POST /tasks/:id/move {"after_id": ...}: it places the task
+// right after after_id in the manual order, or first when after_id is null.
+func (h *TaskHandler) MoveTask(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	var dto dtos.MoveTaskDTO
+	if !bindJSON(c, &dto) {
+		return
+	}
+	afterID := ""
+	if dto.AfterID != nil {
+		afterID = *dto.AfterID
+	}
+
+	t, err := h.service(c).Move(c.Request.Context(), id, afterID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			problem.Abort(c, http.StatusBadRequest, "a task cannot be moved after itself")
+			return
+		}
+		if errors.Is(err, repositories.ErrMoveAnchorNotFound) {
+			problem.Render(c, problem.New(http.StatusBadRequest, "invalid input").
+				WithErrors(problem.FieldError{Field: "after_id", Rule: "exists", Message: "no task with this id"}))
+			return
+		}
+		if errors.Is(err, repositories.ErrNotFound) {
+			problem.Abort(c, http.StatusNotFound, "task not found")
+			return
+		}
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to move task")
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// DuplicateTask handles POST /tasks/:id/duplicate: it creates an open copy of
+// the task and returns it with 201. Like CreateTask the copy is subject to the
+// assignee's WIP limit (see override_wip_limit).
+func (h *TaskHandler) DuplicateTask(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	ctx, ok := wipContext(c)
+	if !ok {
+		return
+	}
+	t, err := h.service(c).Duplicate(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			problem.Abort(c, http.StatusNotFound, "task not found")
+			return
+		}
+		if writeWIPLimit(c, err) {
+			return
+		}
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to duplicate task")
+		return
+	}
+	c.JSON(http.StatusCreated, t)
+}
+
 // DeleteTask handles DELETE /tasks/:id
+// With ?dry_run=true the task that would be deleted is returned instead.
 func (h *TaskHandler) DeleteTask(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing id"})
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+
+	dryRun, ok := parseDryRun(c)
+	if !ok {
 		return
 	}
 
 	ctx := c.Request.Context()
-	if err := h.svc.Delete(ctx, id); err != nil {
+	if dryRun {
+		t, err := h.service(c).PreviewDelete(ctx, id)
+		if err != nil {
+			if errors.Is(err, repositories.ErrNotFound) {
+				problem.Abort(c, http.StatusNotFound, "task not found")
+				return
+			}
+			if writeTimeout(c, err) {
+				return
+			}
+			problem.Abort(c, http.StatusInternalServerError, "failed to delete task")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"dry_run": true, "task": t})
+		return
+	}
+
+	if err := h.service(c).Delete(ctx, id); err != nil {
 		if errors.Is(err, repositories.ErrNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			problem.Abort(c, http.StatusNotFound, "task not found")
+			return
+		}
+		if writeTimeout(c, err) {
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete task"})
+		problem.Abort(c, http.StatusInternalServerError, "failed to delete task")
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// DeleteCompletedTasks handles DELETE /tasks?completed=true&before=<date>
+// Deletes every completed task (completed before the optional RFC 3339
+// timestamp or date) in one statement; admin only. With dry_run=true it
+// only reports how many tasks would be deleted.
+func (h *TaskHandler) DeleteCompletedTasks(c *gin.Context) {
+	if c.Query("completed") != "true" {
+		problem.Abort(c, http.StatusBadRequest, "bulk delete requires completed=true")
+		return
+	}
+	var before *time.Time
+	if s := c.Query("before"); s != "" {
+		t, err := parseTimestamp(s)
+		if err != nil {
+			problem.Abort(c, http.StatusBadRequest, "invalid before query param")
+			return
+		}
+		before = &t
+	}
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+	if !c.GetBool(middleware.IsAdminKey) {
+		problem.Abort(c, http.StatusForbidden, "bulk delete requires an admin key")
+		return
+	}
+
+	ctx := c.Request.Context()
+	var n int
+	var err error
+	if dryRun {
+		n, err = h.service(c).PreviewDeleteCompleted(ctx, before)
+	} else {
+		n, err = h.service(c).DeleteCompleted(ctx, before)
+	}
+	if err != nil {
+		if writeTimeout(c, err) {
+			return
+		}
+		problem.Abort(c, http.StatusInternalServerError, "failed to delete tasks")
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{"dry_run": true, "count": n})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": n})
+}
+
+// bindJSON decodes the JSON body into dst and writes the error response itself
+// when decoding fails: 413 if the body exceeded the size limit, 400 otherwise.
+// Fields that fail validation or have the wrong type are listed in the
+// problem's errors; under middleware.StrictJSON so are unknown fields.
+func bindJSON(c *gin.Context, dst interface{}) bool {
+	var err error
+	if c.GetBool(middleware.StrictJSONKey) {
+		err = decodeStrict(c.Request, dst)
+	} else {
+		err = c.ShouldBindJSON(dst)
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem.Abort(c, http.StatusRequestEntityTooLarge, "request body too large")
+			return false
+		}
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			field = strings.Trim(field, `"`)
+			problem.Render(c, problem.New(http.StatusBadRequest, fmt.Sprintf("invalid request: unknown field %q", field)).
+				WithErrors(problem.FieldError{Field: field, Rule: "unknown", Message: "unknown field"}))
+			return false
+		}
+		if errs := fieldErrors(err); errs != nil {
+			problem.Render(c, problem.New(http.StatusBadRequest, "invalid request: see errors for the invalid fields").WithErrors(errs...))
+			return false
+		}
+		problem.Abort(c, http.StatusBadRequest, "invalid request: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// decodeStrict is ShouldBindJSON with DisallowUnknownFields, followed by the
+// same binding validation.
+func decodeStrict(r *http.Request, dst interface{}) error {
+	if r.Body == nil {
+		return errors.New("empty request body")
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(dst)
+}
+
+// writeTimeout responds with 408 when err was caused by the request deadline
+// expiring (see middleware.Timeout), or with 503 when the database's circuit
+// breaker is open, and reports whether it did so. Any other err is attached
+// to c for the access log and error tracking, as the caller answers 500.
+func writeTimeout(c *gin.Context, err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		problem.Abort(c, http.StatusRequestTimeout, "request timed out")
+		return true
+	}
+	if errors.Is(err, breaker.ErrOpen) {
+		c.Header("Retry-After", "10")
+		problem.Abort(c, http.StatusServiceUnavailable, "the database is unavailable, try again later")
+		return true
+	}
+	_ = c.Error(err)
+	return false
+}
+
+// wipContext returns the request context, marked to bypass the WIP limit
+// when an admin sends override_wip_limit=true. It writes a 400/403 and
+// returns ok=false for an invalid or unauthorized override.
+func wipContext(c *gin.Context) (context.Context, bool) {
+	ctx := c.Request.Context()
+	s := c.Query("override_wip_limit")
+	if s == "" {
+		return ctx, true
+	}
+	override, err := strconv.ParseBool(s)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "invalid override_wip_limit query param")
+		return nil, false
+	}
+	if !override {
+		return ctx, true
+	}
+	if !c.GetBool(middleware.IsAdminKey) {
+		problem.Abort(c, http.StatusForbidden, "override_wip_limit requires an admin key")
+		return nil, false
+	}
+	return service.WithWIPOverride(ctx), true
+}
+
+// writeWIPLimit answers 409 when err is a WIP limit violation.
+func writeWIPLimit(c *gin.Context, err error) bool {
+	var wip *service.WIPLimitError
+	if !errors.As(err, &wip) {
+		return false
+	}
+	problem.Render(c, problem.New(http.StatusConflict, err.Error()).
+		With("assignee", wip.Assignee).
+		With("open", wip.Open).
+		With("limit", wip.Limit))
+	return true
+}
+
+// parseDryRun reads the optional dry_run query param. It writes a 400 and
+// returns ok=false when the value is not a boolean.
+func parseDryRun(c *gin.Context) (dryRun bool, ok bool) {
+	s := c.Query("dry_run")
+	if s == "" {
+		return false, true
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "invalid dry_run query param")
+		return false, false
+	}
+	return v, true
+}
@@ -2,13 +2,20 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"taskmanager/internal/breaker"
+	"taskmanager/internal/middleware"
 	"taskmanager/internal/model"
+	"taskmanager/internal/problem"
 	"taskmanager/internal/repositories"
+	"taskmanager/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -16,12 +23,20 @@ import (
 
 // fakeService implements service.TaskService for handler tests.
 type fakeService struct {
-	createFn func(ctx context.Context, task *model.Task) (*model.Task, error)
-	listFn   func(ctx context.Context, limit, offset int, completed *bool, assignee *string) ([]model.Task, int, error)
-	getFn    func(ctx context.Context, id string) (*model.Task, error)
-	updateFn func(ctx context.Context, task *model.Task) (*model.Task, error)
-	deleteFn func(ctx context.Context, id string) error
-	countFn  func(ctx context.Context) (int, error)
+	createFn   func(ctx context.Context, task *model.Task) (*model.Task, error)
+	listFn     func(ctx context.Context, opts service.ListOptions) ([]model.Task, int, error)
+	getFn      func(ctx context.Context, id string) (*model.Task, error)
+	batchGetFn func(ctx context.Context, ids []string) ([]model.Task, []string, error)
+	updateFn   func(ctx context.Context, task *model.Task) (*model.Task, error)
+	previewFn  func(ctx context.Context, task *model.Task) (*model.Task, *model.Task, error)
+	deleteFn   func(ctx context.Context, id string) error
+	setDoneFn  func(ctx context.Context, id string, completed bool) (*model.Task, error)
+	dupFn      func(ctx context.Context, id string) (*model.Task, error)
+	moveFn     func(ctx context.Context, id, afterID string) (*model.Task, error)
+	countFn    func(ctx context.Context) (int, error)
+	statsFn    func(ctx context.Context, f repositories.TaskFilter) (*model.TaskStats, error)
+	reassignFn func(ctx context.Context, filter repositories.TaskFilter, to string) ([]string, error)
+	bulkDelFn  func(ctx context.Context, before *time.Time, dryRun bool) (int, error)
 }
 
 func (f *fakeService) Create(ctx context.Context, task *model.Task) (*model.Task, error) {
@@ -30,15 +45,51 @@ func (f *fakeService) Create(ctx context.Context, task *model.Task) (*model.Task
 func (f *fakeService) GetByID(ctx context.Context, id string) (*model.Task, error) {
 	return f.getFn(ctx, id)
 }
-func (f *fakeService) List(ctx context.Context, limit, offset int, completed *bool, assignee *string) ([]model.Task, int, error) {
-	return f.listFn(ctx, limit, offset, completed, assignee)
+func (f *fakeService) BatchGet(ctx context.Context, ids []string) ([]model.Task, []string, error) {
+	return f.batchGetFn(ctx, ids)
+}
+func (f *fakeService) List(ctx context.Context, opts service.ListOptions) ([]model.Task, int, error) {
+	return f.listFn(ctx, opts)
 }
 func (f *fakeService) Update(ctx context.Context, task *model.Task) (*model.Task, error) {
 	return f.updateFn(ctx, task)
 }
+func (f *fakeService) PreviewUpdate(ctx context.Context, task *model.Task) (*model.Task, *model.Task, error) {
+	return f.previewFn(ctx, task)
+}
+func (f *fakeService) Complete(ctx context.Context, id string) (*model.Task, error) {
+	return f.setDoneFn(ctx, id, true)
+}
+func (f *fakeService) Reopen(ctx context.Context, id string) (*model.Task, error) {
+	return f.setDoneFn(ctx, id, false)
+}
+func (f *fakeService) Duplicate(ctx context.Context, id string) (*model.Task, error) {
+	return f.dupFn(ctx, id)
+}
+func (f *fakeService) Move(ctx context.Context, id, afterID string) (*model.Task, error) {
+	return f.moveFn(ctx, id, afterID)
+}
 func (f *fakeService) Delete(ctx context.Context, id string) error { return f.deleteFn(ctx, id) }
-func (f *fakeService) Count(ctx context.Context) (int, error)      { return f.countFn(ctx) }
-func (f *fakeService) SetCacheClient(_ *redis.Client)              {}
+func (f *fakeService) PreviewDelete(ctx context.Context, id string) (*model.Task, error) {
+	return f.getFn(ctx, id)
+}
+func (f *fakeService) DeleteCompleted(ctx context.Context, before *time.Time) (int, error) {
+	return f.bulkDelFn(ctx, before, false)
+}
+func (f *fakeService) PreviewDeleteCompleted(ctx context.Context, before *time.Time) (int, error) {
+	return f.bulkDelFn(ctx, before, true)
+}
+func (f *fakeService) Count(ctx context.Context) (int, error) { return f.countFn(ctx) }
+func (f *fakeService) Stats(ctx context.Context, filter repositories.TaskFilter) (*model.TaskStats, error) {
+	return f.statsFn(ctx, filter)
+}
+func (f *fakeService) Reassign(ctx context.Context, filter repositories.TaskFilter, to string) ([]string, error) {
+	return f.reassignFn(ctx, filter, to)
+}
+func (f *fakeService) SetCacheClient(_ *redis.Client) {}
+
+// testTaskID is a well-formed task id for the :id routes.
+const testTaskID = "3f2b8a4e-6c1d-4e0a-9b7f-2d5c8e1a0b4c"
 
 func TestTaskHandler_Group(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -48,7 +99,7 @@ func TestTaskHandler_Group(t *testing.T) {
 			task.ID = "id-1"
 			return task, nil
 		},
-		listFn: func(ctx context.Context, limit, offset int, completed *bool, assignee *string) ([]model.Task, int, error) {
+		listFn: func(ctx context.Context, opts service.ListOptions) ([]model.Task, int, error) {
 			return []model.Task{{ID: "id-1", Title: "t1"}}, 1, nil
 		},
 		getFn: func(ctx context.Context, id string) (*model.Task, error) {
@@ -57,6 +108,9 @@ func TestTaskHandler_Group(t *testing.T) {
 		updateFn: func(ctx context.Context, task *model.Task) (*model.Task, error) {
 			return &model.Task{ID: task.ID, Title: "updated"}, nil
 		},
+		previewFn: func(ctx context.Context, task *model.Task) (*model.Task, *model.Task, error) {
+			return &model.Task{ID: task.ID, Title: "old"}, &model.Task{ID: task.ID, Title: task.Title}, nil
+		},
 		deleteFn: func(ctx context.Context, id string) error { return nil },
 		countFn:  func(ctx context.Context) (int, error) { return 1, nil },
 	}
@@ -75,28 +129,478 @@ func TestTaskHandler_Group(t *testing.T) {
 		}
 	})
 
+	t.Run("Create_FieldErrors", func(t *testing.T) {
+		post := func(body string) problem.Problem {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			h.CreateTask(c)
+			var p problem.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil || w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400 problem for %s got %d body=%s", body, w.Code, w.Body.String())
+			}
+			return p
+		}
+		rules := func(p problem.Problem) map[string]string {
+			m := map[string]string{}
+			for _, e := range p.Errors {
+				if e.Message == "" {
+					t.Fatalf("missing message for %+v", e)
+				}
+				m[e.Field] = e.Rule
+			}
+			return m
+		}
+
+		got := rules(post(`{"title":"` + strings.Repeat("x", 201) + `","due_date":"1990-01-01T00:00:00Z","estimate_minutes":-1,"remaining_minutes":-1}`))
+		want := map[string]string{"title": "max", "due_date": "recent", "estimate_minutes": "min", "remaining_minutes": "min"}
+		if len(got) != len(want) {
+			t.Fatalf("expected %v got %v", want, got)
+		}
+		for f, r := range want {
+			if got[f] != r {
+				t.Fatalf("expected %v got %v", want, got)
+			}
+		}
+		if got := rules(post(`{}`)); got["title"] != "required" {
+			t.Fatalf("expected title required got %v", got)
+		}
+		if got := rules(post(`{"title":5}`)); got["title"] != "type" {
+			t.Fatalf("expected a title type error got %v", got)
+		}
+	})
+
+	t.Run("Create_WIPLimit", func(t *testing.T) {
+		h := NewTaskHandler(&fakeService{
+			createFn: func(ctx context.Context, task *model.Task) (*model.Task, error) {
+				return nil, &service.WIPLimitError{Assignee: "alice", Limit: 3, Open: 3}
+			},
+		})
+		post := func(query string, admin bool) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/tasks"+query, strings.NewReader(`{"title":"t","assignee":"alice"}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set(middleware.IsAdminKey, admin)
+			h.CreateTask(c)
+			return w
+		}
+		if w := post("", false); w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"limit":3`) {
+			t.Fatalf("expected 409 with the limit got %d body=%s", w.Code, w.Body.String())
+		}
+		if w := post("?override_wip_limit=true", false); w.Code != http.StatusForbidden {
+			t.Fatalf("expected 403 for a non-admin override got %d", w.Code)
+		}
+		if w := post("?override_wip_limit=maybe", true); w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for an invalid flag got %d", w.Code)
+		}
+	})
+
 	t.Run("List_Success", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
 		c.Request = httptest.NewRequest(http.MethodGet, "/tasks", nil)
 		h.ListTasks(c)
+		if w.Code != http.StatusOK || w.Header().Get("ETag") == "" {
+			t.Fatalf("expected 200 with an ETag got %d", w.Code)
+		}
+
+		w2 := httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w2)
+		c.Request = httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		c.Request.Header.Set("If-None-Match", w.Header().Get("ETag"))
+		h.ListTasks(c)
+		if w2.Code != http.StatusNotModified {
+			t.Fatalf("expected 304 for an unchanged page got %d", w2.Code)
+		}
+	})
+
+	t.Run("List_BareArray", func(t *testing.T) {
+		h := NewTaskHandler(&fakeService{
+			listFn: func(ctx context.Context, opts service.ListOptions) ([]model.Task, int, error) {
+				return []model.Task{{ID: "id-2", Title: "t2"}}, 5, nil
+			},
+		})
+		h.SetBareListResponses(true)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/tasks?limit=1&offset=1&assignee=bob", nil)
+		h.ListTasks(c)
+		var items []model.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil || len(items) != 1 {
+			t.Fatalf("expected a bare array got %s", w.Body.String())
+		}
+		if w.Header().Get("X-Total-Count") != "5" || w.Header().Get("X-Limit") != "1" || w.Header().Get("X-Offset") != "1" {
+			t.Fatalf("unexpected pagination headers %v", w.Header())
+		}
+		want := `</tasks?assignee=bob&limit=1&offset=2>; rel="next", </tasks?assignee=bob&limit=1&offset=0>; rel="prev"`
+		if got := w.Header().Get("Link"); got != want {
+			t.Fatalf("unexpected Link header %q", got)
+		}
+	})
+
+	t.Run("List_AssigneeSet", func(t *testing.T) {
+		var got repositories.AssigneeFilter
+		h := NewTaskHandler(&fakeService{
+			listFn: func(ctx context.Context, opts service.ListOptions) ([]model.Task, int, error) {
+				got = opts.Filter.Assignee
+				return nil, 0, nil
+			},
+		})
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/tasks?assignee=alice&assignee=none&assignee=bob&assignee=", nil)
+		h.ListTasks(c)
+		if w.Code != http.StatusOK || !got.Unassigned || len(got.Names) != 2 || got.Names[0] != "alice" || got.Names[1] != "bob" {
+			t.Fatalf("unexpected assignee filter %+v (status %d)", got, w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/tasks?"+strings.Repeat("assignee=x&", maxAssigneeFilter+1), nil)
+		h.ListTasks(c)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for too many assignees got %d", w.Code)
+		}
+	})
+
+	t.Run("List_DateRange", func(t *testing.T) {
+		var got repositories.DateRange
+		h := NewTaskHandler(&fakeService{
+			listFn: func(ctx context.Context, opts service.ListOptions) ([]model.Task, int, error) {
+				got = opts.Filter.Dates
+				return nil, 0, nil
+			},
+		})
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/tasks?created_after=2024-01-01&updated_before=2024-02-01T12:00:00%2B02:00", nil)
+		h.ListTasks(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+		}
+		if got.CreatedAfter == nil || !got.CreatedAfter.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) ||
+			got.UpdatedBefore == nil || !got.UpdatedBefore.Equal(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)) ||
+			got.CreatedBefore != nil || got.UpdatedAfter != nil {
+			t.Fatalf("unexpected date range %+v", got)
+		}
+
+		for _, q := range []string{"created_before=yesterday", "updated_after=2024-02-01&updated_before=2024-01-01"} {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/tasks?"+q, nil)
+			h.ListTasks(c)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400 for %s got %d", q, w.Code)
+			}
+		}
+	})
+
+	t.Run("List_TitleQuery", func(t *testing.T) {
+		var got string
+		h := NewTaskHandler(&fakeService{
+			listFn: func(ctx context.Context, opts service.ListOptions) ([]model.Task, int, error) {
+				got = opts.Filter.Title
+				return nil, 0, nil
+			},
+		})
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/tasks?q=+deploy%20api+", nil)
+		h.ListTasks(c)
+		if w.Code != http.StatusOK || got != "deploy api" {
+			t.Fatalf("expected 200 with q=%q got %d q=%q", "deploy api", w.Code, got)
+		}
+
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/tasks?q="+strings.Repeat("x", maxTitleQuery+1), nil)
+		h.ListTasks(c)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for an overlong q got %d", w.Code)
+		}
+	})
+
+	t.Run("List_Fields", func(t *testing.T) {
+		var got []string
+		h := NewTaskHandler(&fakeService{
+			listFn: func(ctx context.Context, opts service.ListOptions) ([]model.Task, int, error) {
+				got = opts.Fields
+				return []model.Task{{ID: testTaskID, Number: 3, Title: "t"}}, 1, nil
+			},
+		})
+		list := func(query string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/tasks?"+query, nil)
+			h.ListTasks(c)
+			return w
+		}
+		w := list("fields=title,key")
+		want := `{"items":[{"id":"` + testTaskID + `","key":"TM-3","title":"t"}],"limit":100,"offset":0,"total":1}`
+		if w.Code != http.StatusOK || w.Body.String() != want {
+			t.Fatalf("expected %s got %d body=%s", want, w.Code, w.Body.String())
+		}
+		if strings.Join(got, ",") != "id,key,title" {
+			t.Fatalf("expected the fields to reach the service got %v", got)
+		}
+		if w := list(""); w.Code != http.StatusOK || got != nil || !strings.Contains(w.Body.String(), `"completed":false`) {
+			t.Fatalf("expected whole tasks without fields got %d fields=%v body=%s", w.Code, got, w.Body.String())
+		}
+		if w := list("fields=title,password"); w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for an unknown field got %d", w.Code)
+		}
+	})
+
+	t.Run("List_CountModes", func(t *testing.T) {
+		var got repositories.CountMode
+		h := NewTaskHandler(&fakeService{
+			listFn: func(ctx context.Context, opts service.ListOptions) ([]model.Task, int, error) {
+				got = opts.Count
+				return []model.Task{{ID: "a"}, {ID: "b"}}, 40, nil
+			},
+		})
+		list := func(query string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/tasks?"+query, nil)
+			h.ListTasks(c)
+			return w
+		}
+		if w := list("limit=2"); got != repositories.CountExact || w.Header().Get("X-Total-Count") != "40" || !strings.Contains(w.Body.String(), `"total":40`) {
+			t.Fatalf("expected an exact total by default got mode=%q body=%s", got, w.Body.String())
+		}
+		if w := list("limit=2&count=estimate"); got != repositories.CountEstimate || w.Header().Get("X-Total-Count") != "40" {
+			t.Fatalf("expected an estimated total got mode=%q", got)
+		}
+		w := list("limit=2&count=none")
+		if got != repositories.CountNone || w.Header().Get("X-Total-Count") != "" || strings.Contains(w.Body.String(), `"total"`) {
+			t.Fatalf("expected no total got mode=%q headers=%v body=%s", got, w.Header(), w.Body.String())
+		}
+		if w := list("count=approx"); w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for an unknown count mode got %d", w.Code)
+		}
+
+		// bare lists without a total link to the next page after a full one
+		h.SetBareListResponses(true)
+		w = list("limit=2&count=none")
+		if link := w.Header().Get("Link"); !strings.Contains(link, `offset=2>; rel="next"`) {
+			t.Fatalf("expected a next link got %q", link)
+		}
+		if link := list("limit=3&count=none").Header().Get("Link"); link != "" {
+			t.Fatalf("expected no next link after a short page got %q", link)
+		}
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		var gotCompleted *bool
+		var gotAssignee repositories.AssigneeFilter
+		alice := "alice"
+		h := NewTaskHandler(&fakeService{
+			statsFn: func(ctx context.Context, f repositories.TaskFilter) (*model.TaskStats, error) {
+				gotCompleted, gotAssignee = f.Completed, f.Assignee
+				totals := model.TaskTotals{Count: 2, EstimateMinutes: 90, RemainingMinutes: 30}
+				return &model.TaskStats{TaskTotals: totals, ByAssignee: []model.AssigneeStats{{Assignee: &alice, TaskTotals: totals}}}, nil
+			},
+		})
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/tasks/stats?completed=false&assignee=alice", nil)
+		h.TaskStats(c)
 		if w.Code != http.StatusOK {
 			t.Fatalf("expected 200 got %d", w.Code)
 		}
+		if gotCompleted == nil || *gotCompleted || len(gotAssignee.Names) != 1 {
+			t.Fatalf("expected the list filters to be passed on got completed=%v assignee=%+v", gotCompleted, gotAssignee)
+		}
+		for _, want := range []string{`"count":2`, `"estimate_minutes":90`, `"by_assignee":[{"assignee":"alice"`} {
+			if !strings.Contains(w.Body.String(), want) {
+				t.Fatalf("expected %s in %s", want, w.Body.String())
+			}
+		}
+
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/tasks/stats?completed=maybe", nil)
+		h.TaskStats(c)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for a bad filter got %d", w.Code)
+		}
+	})
+
+	t.Run("BatchGet", func(t *testing.T) {
+		svc.batchGetFn = func(ctx context.Context, ids []string) ([]model.Task, []string, error) {
+			return []model.Task{{ID: "id-1", Title: "t1"}}, []string{"id-9"}, nil
+		}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/tasks/batch-get", strings.NewReader(`{"ids":["id-1","id-9"]}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.BatchGetTasks(c)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"not_found":["id-9"]`) {
+			t.Fatalf("expected 200 with id-9 missing got %d body=%s", w.Code, w.Body.String())
+		}
+
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/tasks/batch-get", strings.NewReader(`{"ids":[]}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.BatchGetTasks(c)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for empty ids got %d", w.Code)
+		}
+	})
+
+	t.Run("Reassign_StatusOpen", func(t *testing.T) {
+		svc.reassignFn = func(ctx context.Context, f repositories.TaskFilter, to string) ([]string, error) {
+			if f.Completed == nil || *f.Completed || !slices.Equal(f.Assignee.Names, []string{"alice"}) || to != "bob" {
+				t.Fatalf("unexpected filter %+v to=%q", f, to)
+			}
+			if f.Title != "docs" || f.Dates.DueBefore == nil || !f.Dates.DueBefore.Equal(time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Fatalf("expected the title and due date filters got %+v", f)
+			}
+			return []string{"id-1", "id-2"}, nil
+		}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body := `{"filter":{"assignee":"alice","status":"open","q":" docs ","due_before":"2026-05-01T00:00:00Z"},"assignee":"bob"}`
+		c.Request = httptest.NewRequest(http.MethodPost, "/tasks/reassign", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.ReassignTasks(c)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"reassigned":2`) {
+			t.Fatalf("expected 200 with 2 reassigned got %d body=%s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Reassign_SeveralAssignees", func(t *testing.T) {
+		svc.reassignFn = func(ctx context.Context, f repositories.TaskFilter, to string) ([]string, error) {
+			if !slices.Equal(f.Assignee.Names, []string{"alice", "carol"}) || !f.Assignee.Unassigned {
+				t.Fatalf("unexpected assignee filter %+v", f.Assignee)
+			}
+			return []string{"id-1"}, nil
+		}
+		reassign := func(body string) int {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/tasks/reassign", strings.NewReader(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			h.ReassignTasks(c)
+			return w.Code
+		}
+		if code := reassign(`{"filter":{"assignee":["alice","none","carol"]},"assignee":"bob"}`); code != http.StatusOK {
+			t.Fatalf("expected 200 got %d", code)
+		}
+		if code := reassign(`{"filter":{"assignee":7},"assignee":"bob"}`); code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for a numeric assignee got %d", code)
+		}
+		if code := reassign(`{"filter":{"assignee":["` + strings.Repeat(`a","`, maxAssigneeFilter) + `a"]},"assignee":"bob"}`); code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for too many assignees got %d", code)
+		}
+	})
+
+	t.Run("DeleteCompleted", func(t *testing.T) {
+		var gotBefore *time.Time
+		svc.bulkDelFn = func(ctx context.Context, before *time.Time, dryRun bool) (int, error) {
+			gotBefore = before
+			if dryRun {
+				return 3, nil
+			}
+			return 2, nil
+		}
+		del := func(query string, admin bool) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodDelete, "/tasks"+query, nil)
+			c.Set(middleware.IsAdminKey, admin)
+			h.DeleteCompletedTasks(c)
+			return w
+		}
+		if w := del("?completed=true", false); w.Code != http.StatusForbidden {
+			t.Fatalf("expected 403 without an admin key got %d", w.Code)
+		}
+		for _, q := range []string{"", "?completed=false", "?completed=true&before=yesterday", "?completed=true&dry_run=maybe"} {
+			if w := del(q, true); w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400 for %q got %d", q, w.Code)
+			}
+		}
+		if w := del("?completed=true&before=2024-01-01&dry_run=true", true); w.Code != http.StatusOK || w.Body.String() != `{"count":3,"dry_run":true}` {
+			t.Fatalf("expected dry run count got %d body=%s", w.Code, w.Body.String())
+		}
+		if gotBefore == nil || !gotBefore.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("unexpected before %v", gotBefore)
+		}
+		if w := del("?completed=true", true); w.Code != http.StatusOK || w.Body.String() != `{"deleted":2}` || gotBefore != nil {
+			t.Fatalf("expected 2 deleted got %d body=%s before=%v", w.Code, w.Body.String(), gotBefore)
+		}
 	})
 
 	t.Run("Get_NotFound", func(t *testing.T) {
 		svc.getFn = func(ctx context.Context, id string) (*model.Task, error) { return nil, repositories.ErrNotFound }
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
-		c.Params = gin.Params{{Key: "id", Value: "missing"}}
-		c.Request = httptest.NewRequest(http.MethodGet, "/tasks/missing", nil)
+		c.Params = gin.Params{{Key: "id", Value: testTaskID}}
+		c.Request = httptest.NewRequest(http.MethodGet, "/tasks/"+testTaskID, nil)
 		h.GetTask(c)
 		if w.Code != http.StatusNotFound {
 			t.Fatalf("expected 404 got %d", w.Code)
 		}
 	})
 
+	t.Run("Get_ETag", func(t *testing.T) {
+		task := &model.Task{ID: testTaskID, Title: "t", UpdatedAt: time.Now()}
+		svc.getFn = func(ctx context.Context, id string) (*model.Task, error) { return task, nil }
+		get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: testTaskID}}
+			c.Request = httptest.NewRequest(http.MethodGet, "/tasks/"+testTaskID, nil)
+			if ifNoneMatch != "" {
+				c.Request.Header.Set("If-None-Match", ifNoneMatch)
+			}
+			h.GetTask(c)
+			return w
+		}
+		w := get("")
+		etag := w.Header().Get("ETag")
+		if w.Code != http.StatusOK || etag == "" || !strings.Contains(w.Body.String(), `"title":"t"`) {
+			t.Fatalf("expected 200 with an ETag got %d etag=%q body=%s", w.Code, etag, w.Body.String())
+		}
+		for _, inm := range []string{etag, `"other", W/` + etag, "*"} {
+			if w := get(inm); w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != etag {
+				t.Fatalf("expected 304 for %s got %d body=%s", inm, w.Code, w.Body.String())
+			}
+		}
+		task.Title = "changed"
+		if w := get(etag); w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+			t.Fatalf("expected a new ETag after a change got %d etag=%q", w.Code, w.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("Get_DeadlineExceeded", func(t *testing.T) {
+		svc.getFn = func(ctx context.Context, id string) (*model.Task, error) { return nil, context.DeadlineExceeded }
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: testTaskID}}
+		c.Request = httptest.NewRequest(http.MethodGet, "/tasks/"+testTaskID, nil)
+		h.GetTask(c)
+		if w.Code != http.StatusRequestTimeout {
+			t.Fatalf("expected 408 got %d", w.Code)
+		}
+	})
+
+	t.Run("Get_CircuitOpen", func(t *testing.T) {
+		svc.getFn = func(ctx context.Context, id string) (*model.Task, error) { return nil, breaker.ErrOpen }
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: testTaskID}}
+		c.Request = httptest.NewRequest(http.MethodGet, "/tasks/"+testTaskID, nil)
+		h.GetTask(c)
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+			t.Fatalf("expected 503 with Retry-After got %d", w.Code)
+		}
+	})
+
 	t.Run("Update_BadID", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -108,14 +612,227 @@ func TestTaskHandler_Group(t *testing.T) {
 		}
 	})
 
+	t.Run("Get_InvalidID", func(t *testing.T) {
+		svc.getFn = func(ctx context.Context, id string) (*model.Task, error) {
+			t.Fatalf("malformed ids must not reach the service")
+			return nil, nil
+		}
+		for _, id := range []string{"not-a-uuid", "{" + testTaskID + "}", "3f2b8a4e6c1d4e0a9b7f2d5c8e1a0b4c"} {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: id}}
+			c.Request = httptest.NewRequest(http.MethodGet, "/tasks/x", nil)
+			h.GetTask(c)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400 for %q got %d", id, w.Code)
+			}
+		}
+	})
+
+	t.Run("Update_DryRun", func(t *testing.T) {
+		svc.updateFn = func(ctx context.Context, task *model.Task) (*model.Task, error) {
+			t.Fatalf("dry run must not call Update")
+			return nil, nil
+		}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: testTaskID}}
+		c.Request = httptest.NewRequest(http.MethodPut, "/tasks/"+testTaskID+"?dry_run=true", strings.NewReader(`{"title":"new"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.UpdateTask(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+		}
+		var body struct {
+			DryRun  bool                         `json:"dry_run"`
+			Changes map[string]model.FieldChange `json:"changes"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if !body.DryRun || body.Changes["title"].To != "new" {
+			t.Fatalf("unexpected dry run body %s", w.Body.String())
+		}
+	})
+
+	t.Run("Update_DescriptionAndDueDate", func(t *testing.T) {
+		var got *model.Task
+		svc.updateFn = func(ctx context.Context, task *model.Task) (*model.Task, error) {
+			got = task
+			return task, nil
+		}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: testTaskID}}
+		c.Request = httptest.NewRequest(http.MethodPut, "/tasks/"+testTaskID, strings.NewReader(`{"description":"","due_date":"2030-01-02T00:00:00Z"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.UpdateTask(c)
+		if w.Code != http.StatusOK || got == nil || !got.Description.Valid || got.Description.String != "" || !got.DueDate.Valid {
+			t.Fatalf("expected description and due date passed on got %d %+v", w.Code, got)
+		}
+	})
+
+	t.Run("Delete_DryRun_NotFound", func(t *testing.T) {
+		svc.deleteFn = func(ctx context.Context, id string) error {
+			t.Fatalf("dry run must not call Delete")
+			return nil
+		}
+		svc.getFn = func(ctx context.Context, id string) (*model.Task, error) { return nil, repositories.ErrNotFound }
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: testTaskID}}
+		c.Request = httptest.NewRequest(http.MethodDelete, "/tasks/"+testTaskID+"?dry_run=1", nil)
+		h.DeleteTask(c)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 got %d", w.Code)
+		}
+		svc.deleteFn = func(ctx context.Context, id string) error { return nil }
+	})
+
+	t.Run("CompleteAndReopen", func(t *testing.T) {
+		svc.setDoneFn = func(ctx context.Context, id string, completed bool) (*model.Task, error) {
+			if id != testTaskID {
+				return nil, repositories.ErrNotFound
+			}
+			return &model.Task{ID: id, Completed: completed}, nil
+		}
+		call := func(fn gin.HandlerFunc, id string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: id}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/tasks/"+id+"/complete", nil)
+			fn(c)
+			return w
+		}
+		if w := call(h.CompleteTask, testTaskID); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"completed":true`) {
+			t.Fatalf("expected 200 with a completed task got %d body=%s", w.Code, w.Body.String())
+		}
+		if w := call(h.ReopenTask, testTaskID); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"completed":false`) {
+			t.Fatalf("expected 200 with an open task got %d body=%s", w.Code, w.Body.String())
+		}
+		if w := call(h.CompleteTask, "0b0e5c4f-1d7a-4c55-a0a3-7b1b2c3d4e5f"); w.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 got %d", w.Code)
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		svc.dupFn = func(ctx context.Context, id string) (*model.Task, error) {
+			if id != testTaskID {
+				return nil, repositories.ErrNotFound
+			}
+			return &model.Task{ID: "copy", Title: "t"}, nil
+		}
+		call := func(id string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: id}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/tasks/"+id+"/duplicate", nil)
+			h.DuplicateTask(c)
+			return w
+		}
+		if w := call(testTaskID); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"id":"copy"`) {
+			t.Fatalf("expected 201 with the copy got %d body=%s", w.Code, w.Body.String())
+		}
+		if w := call("0b0e5c4f-1d7a-4c55-a0a3-7b1b2c3d4e5f"); w.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 got %d", w.Code)
+		}
+		if w := call("nope"); w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for a malformed id got %d", w.Code)
+		}
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		const other = "0b0e5c4f-1d7a-4c55-a0a3-7b1b2c3d4e5f"
+		svc.moveFn = func(ctx context.Context, id, afterID string) (*model.Task, error) {
+			switch afterID {
+			case "", testTaskID:
+				return &model.Task{ID: id, Position: 1536}, nil
+			case id:
+				return nil, service.ErrInvalidInput
+			}
+			return nil, repositories.ErrMoveAnchorNotFound
+		}
+		call := func(body string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: other}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/tasks/"+other+"/move", strings.NewReader(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			h.MoveTask(c)
+			return w
+		}
+		if w := call(`{"after_id":"` + testTaskID + `"}`); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"position":1536`) {
+			t.Fatalf("expected 200 with the moved task got %d body=%s", w.Code, w.Body.String())
+		}
+		if w := call(`{"after_id":null}`); w.Code != http.StatusOK {
+			t.Fatalf("expected 200 for a move to the top got %d", w.Code)
+		}
+		if w := call(`{"after_id":"nope"}`); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"rule":"task_ref"`) {
+			t.Fatalf("expected 400 for a malformed after_id got %d body=%s", w.Code, w.Body.String())
+		}
+		// a key passes validation and is resolved by the service
+		if w := call(`{"after_id":"TM-7"}`); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"rule":"exists"`) {
+			t.Fatalf("expected the key to reach the service got %d body=%s", w.Code, w.Body.String())
+		}
+		if w := call(`{"after_id":"` + other + `"}`); w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for a move after itself got %d", w.Code)
+		}
+		if w := call(`{"after_id":"9c1d0e2f-3a4b-4c5d-8e6f-7a8b9c0d1e2f"}`); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"after_id"`) {
+			t.Fatalf("expected 400 naming after_id for an unknown anchor got %d body=%s", w.Code, w.Body.String())
+		}
+	})
+
 	t.Run("Delete_Success", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
-		c.Params = gin.Params{{Key: "id", Value: "id-1"}}
-		c.Request = httptest.NewRequest(http.MethodDelete, "/tasks/id-1", nil)
+		c.Params = gin.Params{{Key: "id", Value: testTaskID}}
+		c.Request = httptest.NewRequest(http.MethodDelete, "/tasks/"+testTaskID, nil)
 		h.DeleteTask(c)
 		if w.Code != http.StatusNoContent && w.Code != http.StatusOK {
 			t.Fatalf("expected 204 or 200 got %d", w.Code)
 		}
 	})
 }
+
+func TestBindJSON_StrictRejectsUnknownFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewTaskHandler(&fakeService{
+		createFn: func(ctx context.Context, task *model.Task) (*model.Task, error) { return task, nil },
+	})
+	newRouter := func(strict bool) *gin.Engine {
+		r := gin.New()
+		if strict {
+			r.Use(middleware.StrictJSON())
+		}
+		r.POST("/tasks", h.CreateTask)
+		return r
+	}
+	post := func(r *gin.Engine, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	typo := `{"title":"t","assginee":"bob"}`
+	if w := post(newRouter(false), typo); w.Code != http.StatusCreated {
+		t.Fatalf("lenient mode should ignore unknown fields, got %d", w.Code)
+	}
+
+	strict := newRouter(true)
+	w := post(strict, typo)
+	var body problem.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusBadRequest || len(body.Errors) != 1 || body.Errors[0].Field != "assginee" {
+		t.Fatalf("expected 400 naming assginee got %d body=%s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+		t.Fatalf("expected %s got %s", problem.ContentType, ct)
+	}
+	if w := post(strict, `{"assignee":"bob"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("strict mode must still validate required fields, got %d", w.Code)
+	}
+	if w := post(strict, `{"title":"t","assignee":"bob"}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for a valid body got %d body=%s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/model"
+	"taskmanager/internal/problem"
+	"taskmanager/internal/repositories"
+)
+
+// TaskKeyLookup finds a task by the number in its key.
+type TaskKeyLookup interface {
+	GetByNumber(ctx context.Context, number int64) (*model.Task, error)
+}
+
+// ResolveTaskKey lets routes with an :id task parameter take the task's key
+// (TM-1042) in place of its UUID: the key is looked up and the parameter
+// rewritten to the id before the handler runs. Anything that is not a key is
+// left for the handler to validate.
+func ResolveTaskKey(tasks TaskKeyLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		number, ok := model.ParseKey(c.Param("id"))
+		if !ok {
+			c.Next()
+			return
+		}
+		t, err := tasks.GetByNumber(c.Request.Context(), number)
+		if err != nil {
+			if errors.Is(err, repositories.ErrNotFound) {
+				problem.Abort(c, http.StatusNotFound, "task not found")
+				return
+			}
+			if writeTimeout(c, err) {
+				return
+			}
+			problem.Abort(c, http.StatusInternalServerError, "failed to resolve task key")
+			return
+		}
+		for i := range c.Params {
+			if c.Params[i].Key == "id" {
+				c.Params[i].Value = t.ID
+			}
+		}
+		c.Next()
+	}
+}
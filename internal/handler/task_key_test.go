@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/model"
+	"taskmanager/internal/repositories"
+)
+
+func TestResolveTaskKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := repositories.NewMemoryTaskRepository()
+	task := &model.Task{Title: "first"}
+	if err := repo.Create(context.Background(), task); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	r := gin.New()
+	r.GET("/tasks/:id", ResolveTaskKey(repo), func(c *gin.Context) {
+		c.String(http.StatusOK, c.Param("id"))
+	})
+	get := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/"+id, nil))
+		return w
+	}
+
+	for _, key := range []string{"TM-1", "tm-1"} {
+		if w := get(key); w.Code != http.StatusOK || w.Body.String() != task.ID {
+			t.Fatalf("expected %s to resolve to %s got %d body=%s", key, task.ID, w.Code, w.Body.String())
+		}
+	}
+	if w := get("TM-2"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown key got %d", w.Code)
+	}
+	// not keys: passed through for the handler to validate
+	for _, id := range []string{task.ID, "TM-01", "TM-", "TM-1x", "PR-1"} {
+		if w := get(id); w.Code != http.StatusOK || w.Body.String() != id {
+			t.Fatalf("expected %s to pass through got %d body=%s", id, w.Code, w.Body.String())
+		}
+	}
+}
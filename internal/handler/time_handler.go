@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	dtos "taskmanager/internal/model/DTOs"
+	"taskmanager/internal/problem"
+	"taskmanager/internal/repositories"
+)
+
+// TimeHandler serves the time tracking endpoints: the timer and time entries
+// of a task and the time report.
+type TimeHandler struct {
+	repo repositories.TimeEntryRepository
+}
+
+// NewTimeHandler creates a TimeHandler.
+func NewTimeHandler(repo repositories.TimeEntryRepository) *TimeHandler {
+	return &TimeHandler{repo: repo}
+}
+
+// StartTimer handles POST /tasks/:id/timer/start. It answers 409 when the
+// task's timer is already running.
+func (h *TimeHandler) StartTimer(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	e, err := h.repo.StartTimer(c.Request.Context(), id)
+	if err != nil {
+		writeTimeError(c, err, "failed to start timer")
+		return
+	}
+	c.JSON(http.StatusCreated, e)
+}
+
+// StopTimer handles POST /tasks/:id/timer/stop and returns the closed entry.
+// It answers 409 when no timer is running.
+func (h *TimeHandler) StopTimer(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	e, err := h.repo.StopTimer(c.Request.Context(), id)
+	if err != nil {
+		writeTimeError(c, err, "failed to stop timer")
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
+
+// ListTimeEntries handles GET /tasks/:id/time-entries
+func (h *TimeHandler) ListTimeEntries(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	entries, err := h.repo.List(c.Request.Context(), id)
+	if err != nil {
+		writeTimeError(c, err, "failed to list time entries")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": entries})
+}
+
+// AddTimeEntry handles POST /tasks/:id/time-entries, recording finished work
+// manually. The span must end after it starts and not in the future.
+func (h *TimeHandler) AddTimeEntry(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	var dto dtos.LogTimeDTO
+	if !bindJSON(c, &dto) {
+		return
+	}
+	var fe *problem.FieldError
+	switch {
+	case !dto.EndedAt.After(dto.StartedAt):
+		fe = &problem.FieldError{Field: "ended_at", Rule: "gtfield", Message: "must be after started_at"}
+	case dto.EndedAt.After(time.Now()):
+		fe = &problem.FieldError{Field: "ended_at", Rule: "past", Message: "must not be in the future"}
+	}
+	if fe != nil {
+		problem.Render(c, problem.New(http.StatusBadRequest, "invalid input").WithErrors(*fe))
+		return
+	}
+	e, err := h.repo.AddEntry(c.Request.Context(), id, dto.StartedAt, dto.EndedAt)
+	if err != nil {
+		writeTimeError(c, err, "failed to add time entry")
+		return
+	}
+	c.JSON(http.StatusCreated, e)
+}
+
+// TimeReport handles GET /reports/time?from=&to=, the finished time per
+// assignee for entries started in [from, to). Both bounds are optional and
+// take the same formats as the list date filters.
+func (h *TimeHandler) TimeReport(c *gin.Context) {
+	q := c.Request.URL.Query()
+	from, err := parseTimeParam(q, "from")
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	to, err := parseTimeParam(q, "to")
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		problem.Abort(c, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	rows, err := h.repo.Report(c.Request.Context(), from, to)
+	if err != nil {
+		writeTimeError(c, err, "failed to build time report")
+		return
+	}
+	var total int64
+	for _, r := range rows {
+		total += r.Seconds
+	}
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "items": rows, "total_seconds": total})
+}
+
+// parseTimeParam reads an optional RFC 3339 timestamp or YYYY-MM-DD query
+// param.
+func parseTimeParam(q url.Values, name string) (*time.Time, error) {
+	s := q.Get(name)
+	if s == "" {
+		return nil, nil
+	}
+	t, err := parseTimestamp(s)
+	if err != nil {
+		return nil, errors.New("invalid " + name + " query param: expected RFC 3339 timestamp or YYYY-MM-DD")
+	}
+	return &t, nil
+}
+
+func writeTimeError(c *gin.Context, err error, detail string) {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		problem.Abort(c, http.StatusNotFound, "task not found")
+		return
+	case errors.Is(err, repositories.ErrTimerRunning), errors.Is(err, repositories.ErrTimerNotRunning):
+		problem.Abort(c, http.StatusConflict, err.Error())
+		return
+	}
+	if writeTimeout(c, err) {
+		return
+	}
+	problem.Abort(c, http.StatusInternalServerError, detail)
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/model"
+	"taskmanager/internal/repositories"
+)
+
+func TestTimeHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tasks := repositories.NewMemoryTaskRepository()
+	task := &model.Task{ID: testTaskID, Title: "t"}
+	task.SetAssignee("alice")
+	if err := tasks.Create(context.Background(), task); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	h := NewTimeHandler(repositories.NewMemoryTimeEntryRepository(tasks))
+	r := gin.New()
+	r.POST("/tasks/:id/timer/start", h.StartTimer)
+	r.POST("/tasks/:id/timer/stop", h.StopTimer)
+	r.GET("/tasks/:id/time-entries", h.ListTimeEntries)
+	r.POST("/tasks/:id/time-entries", h.AddTimeEntry)
+	r.GET("/reports/time", h.TimeReport)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+	base := "/tasks/" + testTaskID
+
+	if w := do(http.MethodPost, base+"/timer/stop", ""); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 stopping an idle timer got %d", w.Code)
+	}
+	if w := do(http.MethodPost, base+"/timer/start", ""); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"ended_at":null`) {
+		t.Fatalf("expected 201 with a running entry got %d body=%s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, base+"/timer/start", ""); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 starting a running timer got %d", w.Code)
+	}
+	if w := do(http.MethodPost, base+"/timer/stop", ""); w.Code != http.StatusOK || strings.Contains(w.Body.String(), `"ended_at":null`) {
+		t.Fatalf("expected 200 with a closed entry got %d body=%s", w.Code, w.Body.String())
+	}
+
+	start := time.Now().Add(-2 * time.Hour).UTC().Truncate(time.Second)
+	body := `{"started_at":"` + start.Format(time.RFC3339) + `","ended_at":"` + start.Add(90*time.Minute).Format(time.RFC3339) + `"}`
+	w := do(http.MethodPost, base+"/time-entries", body)
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"seconds":5400`) || !strings.Contains(w.Body.String(), `"assignee":"alice"`) {
+		t.Fatalf("expected 201 with 5400s for alice got %d body=%s", w.Code, w.Body.String())
+	}
+	if got, _ := tasks.GetByID(context.Background(), testTaskID); got.TimeSpentSeconds < 5400 {
+		t.Fatalf("expected the task to carry the logged time got %d", got.TimeSpentSeconds)
+	}
+
+	for name, body := range map[string]string{
+		"inverted": `{"started_at":"2025-01-02T10:00:00Z","ended_at":"2025-01-02T09:00:00Z"}`,
+		"future":   `{"started_at":"2025-01-02T10:00:00Z","ended_at":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`,
+		"missing":  `{"started_at":"2025-01-02T10:00:00Z"}`,
+	} {
+		if w := do(http.MethodPost, base+"/time-entries", body); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"ended_at"`) {
+			t.Errorf("%s: expected 400 on ended_at got %d body=%s", name, w.Code, w.Body.String())
+		}
+	}
+
+	w = do(http.MethodGet, base+"/time-entries", "")
+	var list struct{ Items []model.TimeEntry }
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.Items) != 2 || !list.Items[0].StartedAt.After(list.Items[1].StartedAt) {
+		t.Fatalf("expected two entries newest first got %s err=%v", w.Body.String(), err)
+	}
+
+	w = do(http.MethodGet, "/reports/time?from="+start.Add(-time.Minute).Format(time.RFC3339)+"&to="+start.Add(time.Minute).Format(time.RFC3339), "")
+	var report struct {
+		Items        []model.TimeReportRow
+		TotalSeconds int64 `json:"total_seconds"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || len(report.Items) != 1 || report.TotalSeconds != 5400 || *report.Items[0].Assignee != "alice" {
+		t.Fatalf("expected only the manual entry in range got %s err=%v", w.Body.String(), err)
+	}
+	if w := do(http.MethodGet, "/reports/time?from=2025-02-01&to=2025-01-01", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an inverted range got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/tasks/00000000-0000-0000-0000-000000000000/time-entries", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown task got %d", w.Code)
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	dtos "taskmanager/internal/model/DTOs"
+	"taskmanager/internal/problem"
+)
+
+// fieldErrors translates binding failures into per-field problem details:
+// validator rule violations and JSON values of the wrong type. It returns nil
+// for errors that don't concern a particular field (e.g. malformed JSON).
+func fieldErrors(err error) []problem.FieldError {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		out := make([]problem.FieldError, len(verrs))
+		for i, fe := range verrs {
+			out[i] = problem.FieldError{Field: fieldPath(fe), Rule: fe.Tag(), Message: ruleMessage(fe)}
+		}
+		return out
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []problem.FieldError{{Field: typeErr.Field, Rule: "type", Message: "must be " + jsonType(typeErr.Type)}}
+	}
+	return nil
+}
+
+// fieldPath is the JSON path of the field, e.g. "filter.assignee"; the
+// namespace starts with the Go name of the DTO, which clients don't know.
+func fieldPath(fe validator.FieldError) string {
+	_, path, ok := strings.Cut(fe.Namespace(), ".")
+	if !ok {
+		return fe.Field()
+	}
+	return path
+}
+
+// ruleMessage describes a failed rule for humans; clients should switch on
+// the rule name instead.
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "max":
+		bound := "at least"
+		if fe.Tag() == "max" {
+			bound = "at most"
+		}
+		switch fe.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("must be %s %s characters long", bound, fe.Param())
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fmt.Sprintf("must have %s %s items", bound, fe.Param())
+		}
+		return fmt.Sprintf("must be %s %s", bound, fe.Param())
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "uuid":
+		return "must be a UUID"
+	case "task_ref":
+		return "must be a task id or key"
+	case "recent":
+		return fmt.Sprintf("must not be more than %d years in the past", dtos.MaxDueDateAge/(365*24*time.Hour))
+	}
+	return fmt.Sprintf("failed the %s rule", fe.Tag())
+}
+
+// jsonType names the JSON type a Go type is decoded from.
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return "an RFC 3339 timestamp"
+		}
+	}
+	return "an object"
+}
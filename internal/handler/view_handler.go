@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/model"
+	dtos "taskmanager/internal/model/DTOs"
+	"taskmanager/internal/problem"
+	"taskmanager/internal/repositories"
+)
+
+// ViewHandler serves /views, the saved task list queries. A view's filter is
+// run through the same parsing as the GET /tasks query params, so the two
+// accept exactly the same filters.
+type ViewHandler struct {
+	repo  repositories.ViewRepository
+	tasks *TaskHandler
+}
+
+// NewViewHandler creates a ViewHandler listing tasks through tasks.
+func NewViewHandler(repo repositories.ViewRepository, tasks *TaskHandler) *ViewHandler {
+	return &ViewHandler{repo: repo, tasks: tasks}
+}
+
+// CreateView handles POST /views.
+func (h *ViewHandler) CreateView(c *gin.Context) {
+	var dto dtos.CreateViewDTO
+	if !bindJSON(c, &dto) {
+		return
+	}
+	filter, err := dto.Filter.ToModel()
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+	if _, err := parseListFilters(viewQuery(filter)); err != nil {
+		problem.Abort(c, http.StatusBadRequest, "invalid filter: "+err.Error())
+		return
+	}
+	v := model.View{Name: dto.Name, Filter: filter}
+	if err := h.repo.Create(c.Request.Context(), &v); err != nil {
+		writeViewError(c, err, "failed to create view")
+		return
+	}
+	c.Header("Location", "/api/v1/views/"+v.ID)
+	c.JSON(http.StatusCreated, v)
+}
+
+// ListViews handles GET /views
+func (h *ViewHandler) ListViews(c *gin.Context) {
+	views, err := h.repo.List(c.Request.Context())
+	if err != nil {
+		writeViewError(c, err, "failed to list views")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": views})
+}
+
+// GetView handles GET /views/:id
+func (h *ViewHandler) GetView(c *gin.Context) {
+	v, ok := h.view(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
+
+// DeleteView handles DELETE /views/:id
+func (h *ViewHandler) DeleteView(c *gin.Context) {
+	// view ids are UUIDs, like task ids
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	if err := h.repo.Delete(c.Request.Context(), id); err != nil {
+		writeViewError(c, err, "failed to delete view")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListViewTasks handles GET /views/:id/tasks. It answers like GET /tasks with
+// the view's filter; limit and offset come from the request.
+func (h *ViewHandler) ListViewTasks(c *gin.Context) {
+	v, ok := h.view(c)
+	if !ok {
+		return
+	}
+	f, err := parseListFilters(viewQuery(v.Filter))
+	if err != nil {
+		// stored before a rule tightened; the view has to be recreated
+		problem.Abort(c, http.StatusUnprocessableEntity, "view filter is no longer valid: "+err.Error())
+		return
+	}
+	h.tasks.listTasks(c, f)
+}
+
+// view loads the view named by the :id path parameter, writing the error
+// response when it can't.
+func (h *ViewHandler) view(c *gin.Context) (*model.View, bool) {
+	id, ok := taskID(c)
+	if !ok {
+		return nil, false
+	}
+	v, err := h.repo.Get(c.Request.Context(), id)
+	if err != nil {
+		writeViewError(c, err, "failed to get view")
+		return nil, false
+	}
+	return v, true
+}
+
+// viewQuery renders f as the GET /tasks query params it stands for.
+func viewQuery(f model.ViewFilter) url.Values {
+	q := url.Values{}
+	if f.Completed != nil {
+		q.Set("completed", strconv.FormatBool(*f.Completed))
+	}
+	for _, a := range f.Assignee {
+		q.Add("assignee", a)
+	}
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{
+		{"created_after", f.CreatedAfter},
+		{"created_before", f.CreatedBefore},
+		{"updated_after", f.UpdatedAfter},
+		{"updated_before", f.UpdatedBefore},
+		{"due_after", f.DueAfter},
+		{"due_before", f.DueBefore},
+	} {
+		if p.t != nil {
+			q.Set(p.name, p.t.Format(time.RFC3339Nano))
+		}
+	}
+	if f.Q != "" {
+		q.Set("q", f.Q)
+	}
+	if f.Sort != "" {
+		q.Set("sort", f.Sort)
+	}
+	return q
+}
+
+func writeViewError(c *gin.Context, err error, detail string) {
+	if errors.Is(err, repositories.ErrViewNotFound) {
+		problem.Abort(c, http.StatusNotFound, "view not found")
+		return
+	}
+	if writeTimeout(c, err) {
+		return
+	}
+	problem.Abort(c, http.StatusInternalServerError, detail)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/model"
+	"taskmanager/internal/repositories"
+	"taskmanager/internal/service"
+)
+
+func TestViewHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	type listCall struct {
+		limit, offset int
+		completed     *bool
+		assignee      repositories.AssigneeFilter
+		dates         repositories.DateRange
+		sort          []repositories.SortField
+	}
+	var got listCall
+	tasks := NewTaskHandler(&fakeService{
+		listFn: func(ctx context.Context, opts service.ListOptions) ([]model.Task, int, error) {
+			f := opts.Filter
+			got = listCall{opts.Limit, opts.Offset, f.Completed, f.Assignee, f.Dates, opts.Sort}
+			return []model.Task{{ID: testTaskID, Title: "t"}}, 1, nil
+		},
+	})
+	h := NewViewHandler(repositories.NewMemoryViewRepository(), tasks)
+	r := gin.New()
+	r.POST("/views", h.CreateView)
+	r.GET("/views", h.ListViews)
+	r.GET("/views/:id", h.GetView)
+	r.DELETE("/views/:id", h.DeleteView)
+	r.GET("/views/:id/tasks", h.ListViewTasks)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/views", `{"name":"my overdue","filter":{"status":"open","assignee":["bob","none"],"due_before":"2026-01-01T00:00:00Z","sort":"due_date asc nulls last, created_at desc"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d body=%s", w.Code, w.Body.String())
+	}
+	var v model.View
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if v.ID == "" || v.Filter.Completed == nil || *v.Filter.Completed {
+		t.Fatalf("expected an id and status resolved to completed=false got %+v", v)
+	}
+
+	if w := do(http.MethodGet, "/views/"+v.ID+"/tasks?limit=5&offset=10&completed=true", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d body=%s", w.Code, w.Body.String())
+	}
+	if got.limit != 5 || got.offset != 10 {
+		t.Fatalf("expected limit/offset from the request got %d/%d", got.limit, got.offset)
+	}
+	if got.completed == nil || *got.completed {
+		t.Fatal("expected the view's completed=false, not the query param")
+	}
+	if !got.assignee.Unassigned || len(got.assignee.Names) != 1 || got.assignee.Names[0] != "bob" {
+		t.Fatalf("unexpected assignee filter %+v", got.assignee)
+	}
+	if got.dates.DueBefore == nil || got.dates.DueBefore.Year() != 2026 {
+		t.Fatalf("unexpected dates %+v", got.dates)
+	}
+	if len(got.sort) != 2 || got.sort[0].Column != "due_date" || !got.sort[1].Desc {
+		t.Fatalf("unexpected sort %+v", got.sort)
+	}
+
+	for name, body := range map[string]string{
+		"missing name":    `{"filter":{}}`,
+		"bad sort":        `{"name":"x","filter":{"sort":"nope"}}`,
+		"bad status":      `{"name":"x","filter":{"status":"done"}}`,
+		"conflict":        `{"name":"x","filter":{"status":"open","completed":true}}`,
+		"inverted due":    `{"name":"x","filter":{"due_after":"2026-02-01T00:00:00Z","due_before":"2026-01-01T00:00:00Z"}}`,
+		"long title text": `{"name":"x","filter":{"q":"` + strings.Repeat("a", maxTitleQuery+1) + `"}}`,
+	} {
+		if w := do(http.MethodPost, "/views", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400 got %d body=%s", name, w.Code, w.Body.String())
+		}
+	}
+
+	if w := do(http.MethodGet, "/views", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"name":"my overdue"`) {
+		t.Fatalf("expected the view in the list got %d body=%s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodGet, "/views/not-a-uuid", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a malformed id got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/views/"+v.ID, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d", w.Code)
+	}
+	for _, path := range []string{"/views/" + v.ID, "/views/" + v.ID + "/tasks"} {
+		if w := do(http.MethodGet, path, ""); w.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404 after delete got %d", path, w.Code)
+		}
+	}
+}
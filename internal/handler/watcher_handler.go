@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	dtos "taskmanager/internal/model/DTOs"
+	"taskmanager/internal/problem"
+	"taskmanager/internal/repositories"
+)
+
+// WatcherHandler serves /tasks/:id/watchers.
+type WatcherHandler struct {
+	repo repositories.WatcherRepository
+}
+
+// NewWatcherHandler creates a WatcherHandler.
+func NewWatcherHandler(repo repositories.WatcherRepository) *WatcherHandler {
+	return &WatcherHandler{repo: repo}
+}
+
+// ListWatchers handles GET /tasks/:id/watchers
+func (h *WatcherHandler) ListWatchers(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	watchers, err := h.repo.List(c.Request.Context(), id)
+	if err != nil {
+		writeWatcherError(c, err, "failed to list watchers")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": watchers})
+}
+
+// AddWatcher handles POST /tasks/:id/watchers {"watcher": "alice"}. It answers
+// 201 when the watcher was added and 200 when it already watched the task.
+func (h *WatcherHandler) AddWatcher(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	var dto dtos.WatchTaskDTO
+	if !bindJSON(c, &dto) {
+		return
+	}
+	name, ok := watcherName(c, dto.Watcher)
+	if !ok {
+		return
+	}
+	w, added, err := h.repo.Add(c.Request.Context(), id, name)
+	if err != nil {
+		writeWatcherError(c, err, "failed to add watcher")
+		return
+	}
+	status := http.StatusOK
+	if added {
+		status = http.StatusCreated
+	}
+	c.JSON(status, w)
+}
+
+// RemoveWatcher handles DELETE /tasks/:id/watchers?watcher=alice. Removing
+// someone who doesn't watch the task is not an error.
+func (h *WatcherHandler) RemoveWatcher(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	name, ok := watcherName(c, c.Query("watcher"))
+	if !ok {
+		return
+	}
+	if _, err := h.repo.Remove(c.Request.Context(), id, name); err != nil {
+		writeWatcherError(c, err, "failed to remove watcher")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// watcherName trims name and writes a 400 when nothing is left.
+func watcherName(c *gin.Context, name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		problem.Render(c, problem.New(http.StatusBadRequest, "invalid input").
+			WithErrors(problem.FieldError{Field: "watcher", Rule: "required", Message: "is required"}))
+		return "", false
+	}
+	return name, true
+}
+
+func writeWatcherError(c *gin.Context, err error, detail string) {
+	if errors.Is(err, repositories.ErrNotFound) {
+		problem.Abort(c, http.StatusNotFound, "task not found")
+		return
+	}
+	if writeTimeout(c, err) {
+		return
+	}
+	problem.Abort(c, http.StatusInternalServerError, detail)
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/model"
+	"taskmanager/internal/repositories"
+)
+
+func TestWatcherHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tasks := repositories.NewMemoryTaskRepository()
+	task := &model.Task{ID: testTaskID, Title: "t"}
+	if err := tasks.Create(context.Background(), task); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	h := NewWatcherHandler(repositories.NewMemoryWatcherRepository(tasks))
+	r := gin.New()
+	r.GET("/tasks/:id/watchers", h.ListWatchers)
+	r.POST("/tasks/:id/watchers", h.AddWatcher)
+	r.DELETE("/tasks/:id/watchers", h.RemoveWatcher)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+	list := func() []string {
+		w := do(http.MethodGet, "/tasks/"+testTaskID+"/watchers", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200 got %d", w.Code)
+		}
+		var body struct{ Items []model.Watcher }
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		names := []string{}
+		for _, it := range body.Items {
+			names = append(names, it.Watcher)
+		}
+		return names
+	}
+
+	path := "/tasks/" + testTaskID + "/watchers"
+	if w := do(http.MethodPost, path, `{"watcher":" bob "}`); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"watcher":"bob"`) {
+		t.Fatalf("expected 201 with the trimmed watcher got %d body=%s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, path, `{"watcher":"bob"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 when already watching got %d", w.Code)
+	}
+	do(http.MethodPost, path, `{"watcher":"alice"}`)
+	if got := strings.Join(list(), ","); got != "alice,bob" {
+		t.Fatalf("expected alice,bob got %s", got)
+	}
+
+	if w := do(http.MethodPost, path, `{"watcher":"  "}`); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"watcher"`) {
+		t.Fatalf("expected 400 for a blank watcher got %d body=%s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodDelete, path, ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without ?watcher got %d", w.Code)
+	}
+
+	if w := do(http.MethodDelete, path+"?watcher=bob", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, path+"?watcher=bob", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected removing twice to be a no-op got %d", w.Code)
+	}
+	if got := strings.Join(list(), ","); got != "alice" {
+		t.Fatalf("expected alice got %s", got)
+	}
+
+	missing := "/tasks/0b0e5c4f-1d7a-4c55-a0a3-7b1b2c3d4e5f/watchers"
+	if w := do(http.MethodPost, missing, `{"watcher":"bob"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown task got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/tasks/nope/watchers", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a malformed id got %d", w.Code)
+	}
+
+	if _, err := tasks.Delete(context.Background(), testTaskID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if w := do(http.MethodGet, path, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 once the task is gone got %d", w.Code)
+	}
+}
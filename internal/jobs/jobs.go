@@ -0,0 +1,93 @@
+// Package jobs is a small database-backed queue for asynchronous work. Jobs
+// are rows of the jobs table; a Worker claims due jobs, runs the handler
+// registered for their kind and retries failures with backoff until the
+// kind's RetryPolicy gives up, when the job moves to dead_jobs.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+)
+
+// Job is a single row of the jobs table.
+type Job struct {
+	ID        int64           `db:"id" json:"id"`
+	Kind      string          `db:"kind" json:"kind"`
+	Payload   json.RawMessage `db:"payload" json:"payload"`
+	Attempts  int             `db:"attempts" json:"attempts"`
+	RunAt     time.Time       `db:"run_at" json:"run_at"`
+	LastError *string         `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
+
+// Enqueue adds a job of the given kind that is due immediately. Like
+// outbox.Insert it takes any executor, so a job can be enqueued in the
+// transaction of the change that caused it.
+func Enqueue(ctx context.Context, exec sqlx.ExtContext, kind string, payload interface{}) error {
+	return EnqueueAt(ctx, exec, kind, payload, time.Now())
+}
+
+// EnqueueAt adds a job that becomes due at runAt.
+func EnqueueAt(ctx context.Context, exec sqlx.ExtContext, kind string, payload interface{}, runAt time.Time) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	query := database.For(exec).Rebind("INSERT INTO jobs (kind, payload, run_at) VALUES ($1, $2, $3)")
+	_, err = exec.ExecContext(ctx, query, kind, b, runAt.UTC())
+	return err
+}
+
+// RetryPolicy decides how often a failing job is retried. The delay before
+// attempt n+1 is Backoff doubled n-1 times, capped at MaxBackoff.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// DefaultRetryPolicy is used for kinds registered without one.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 5, Backoff: 10 * time.Second, MaxBackoff: 10 * time.Minute}
+
+// delay returns how long to wait before retrying after the given attempt.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// Counts returns the number of queued and dead-lettered jobs per kind, for
+// metric.RegisterJobQueue.
+func Counts(ctx context.Context, db sqlx.QueryerContext) (queued, dead map[string]int64, err error) {
+	count := func(table string) (map[string]int64, error) {
+		var rows []struct {
+			Kind  string `db:"kind"`
+			Count int64  `db:"count"`
+		}
+		if err := sqlx.SelectContext(ctx, db, &rows, "SELECT kind, count(1) AS count FROM "+table+" GROUP BY kind"); err != nil {
+			return nil, err
+		}
+		out := make(map[string]int64, len(rows))
+		for _, r := range rows {
+			out[r.Kind] = r.Count
+		}
+		return out, nil
+	}
+	if queued, err = count("jobs"); err != nil {
+		return nil, nil, err
+	}
+	if dead, err = count("dead_jobs"); err != nil {
+		return nil, nil, err
+	}
+	return queued, dead, nil
+}
@@ -0,0 +1,216 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+	"taskmanager/internal/metric"
+)
+
+// HandlerFunc runs one job. A nil error completes the job; any other error
+// schedules a retry according to the kind's RetryPolicy.
+type HandlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+type handler struct {
+	fn     HandlerFunc
+	policy RetryPolicy
+}
+
+// Worker runs the jobs of the kinds registered with Handle. Jobs are claimed
+// with FOR UPDATE SKIP LOCKED (on SQLite, which serializes writers, a single
+// worker is expected) and leased by moving run_at forward by the lease, so a
+// job whose worker died becomes due again once the lease runs out.
+type Worker struct {
+	db          *sqlx.DB
+	d           database.Dialect
+	concurrency int
+	interval    time.Duration
+	lease       time.Duration
+	handlers    map[string]handler
+	now         func() time.Time
+}
+
+// NewWorker creates a Worker running up to concurrency jobs at a time and
+// polling for due jobs every interval while idle.
+func NewWorker(db *sqlx.DB, concurrency int, interval time.Duration) *Worker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &Worker{
+		db:          db,
+		d:           database.For(db),
+		concurrency: concurrency,
+		interval:    interval,
+		lease:       5 * time.Minute,
+		handlers:    map[string]handler{},
+		now:         time.Now,
+	}
+}
+
+// Handle registers fn for jobs of kind. A zero policy uses
+// DefaultRetryPolicy. Register every kind before calling Run.
+func (w *Worker) Handle(kind string, policy RetryPolicy, fn HandlerFunc) {
+	if policy.MaxAttempts < 1 {
+		policy = DefaultRetryPolicy
+	}
+	w.handlers[kind] = handler{fn: fn, policy: policy}
+}
+
+// Kinds returns the registered kinds, sorted.
+func (w *Worker) Kinds() []string {
+	kinds := make([]string, 0, len(w.handlers))
+	for k := range w.handlers {
+		kinds = append(kinds, k)
+	}
+	slices.Sort(kinds)
+	return kinds
+}
+
+// Run processes jobs with the configured concurrency until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range w.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		// drain due jobs before waiting for the next tick
+		for {
+			ran, err := w.RunOnce(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Error("job worker failed", "err", err)
+				}
+				break
+			}
+			if !ran {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce claims and runs a single due job. It reports false when no job of
+// a registered kind was due. A failing handler is not an error of RunOnce;
+// the job is rescheduled or moved to dead_jobs instead.
+func (w *Worker) RunOnce(ctx context.Context) (bool, error) {
+	job, err := w.claim(ctx)
+	if err != nil || job == nil {
+		return false, err
+	}
+	h := w.handlers[job.Kind]
+
+	start := w.now()
+	runCtx, cancel := context.WithTimeout(ctx, w.lease)
+	runErr := safeRun(runCtx, h.fn, job.Payload)
+	cancel()
+	metric.JobDuration.WithLabelValues(job.Kind).Observe(w.now().Sub(start).Seconds())
+
+	switch {
+	case runErr == nil:
+		_, err = w.db.ExecContext(ctx, w.d.Rebind("DELETE FROM jobs WHERE id = $1"), job.ID)
+		metric.JobsProcessed.WithLabelValues(job.Kind, "ok").Inc()
+	case job.Attempts >= h.policy.MaxAttempts:
+		err = w.bury(ctx, job, runErr)
+		metric.JobsProcessed.WithLabelValues(job.Kind, "dead").Inc()
+		slog.Error("job failed permanently", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "err", runErr)
+	default:
+		retryAt := w.now().Add(h.policy.delay(job.Attempts)).UTC()
+		_, err = w.db.ExecContext(ctx, w.d.Rebind("UPDATE jobs SET run_at = $1, last_error = $2 WHERE id = $3"), retryAt, runErr.Error(), job.ID)
+		metric.JobsProcessed.WithLabelValues(job.Kind, "retry").Inc()
+		slog.Warn("job failed, retrying", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "retry_at", retryAt, "err", runErr)
+	}
+	return true, err
+}
+
+// safeRun turns a panicking handler into a failed attempt.
+func safeRun(ctx context.Context, fn HandlerFunc, payload json.RawMessage) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return fn(ctx, payload)
+}
+
+// claim picks the oldest due job of a registered kind, counts the attempt
+// and leases it. It returns nil when nothing is due.
+func (w *Worker) claim(ctx context.Context) (*Job, error) {
+	kinds := w.Kinds()
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := w.now().UTC()
+	query, args, err := sqlx.In(`SELECT id, kind, payload, attempts, run_at, last_error, created_at
+FROM jobs WHERE run_at <= ? AND kind IN (?) ORDER BY run_at, id LIMIT 1`, now, kinds)
+	if err != nil {
+		return nil, err
+	}
+	if !w.d.SQLite() {
+		query += " FOR UPDATE SKIP LOCKED"
+	}
+	var jobs []Job
+	if err := tx.SelectContext(ctx, &jobs, tx.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	job := jobs[0]
+	job.Attempts++
+	if _, err := tx.ExecContext(ctx, w.d.Rebind("UPDATE jobs SET attempts = $1, run_at = $2 WHERE id = $3"), job.Attempts, now.Add(w.lease), job.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// bury moves a job that exhausted its retries to dead_jobs.
+func (w *Worker) bury(ctx context.Context, job *Job, cause error) error {
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, w.d.Rebind(`INSERT INTO dead_jobs (id, kind, payload, attempts, last_error, created_at, failed_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`), job.ID, job.Kind, []byte(job.Payload), job.Attempts, cause.Error(), job.CreatedAt, w.now().UTC()); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, w.d.Rebind("DELETE FROM jobs WHERE id = $1"), job.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,134 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"taskmanager/internal/metric"
+)
+
+func jobRows(attempts int) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows([]string{"id", "kind", "payload", "attempts", "run_at", "last_error", "created_at"}).
+		AddRow(7, "test.kind", []byte(`{"n":1}`), attempts, now, nil, now)
+}
+
+func newTestWorker(t *testing.T, fn HandlerFunc) (*Worker, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	w := NewWorker(sqlx.NewDb(db, "sqlmock"), 1, time.Second)
+	w.Handle("test.kind", RetryPolicy{MaxAttempts: 3, Backoff: time.Second}, fn)
+	return w, mock
+}
+
+func expectClaim(mock sqlmock.Sqlmock, attempts int) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, kind, payload, attempts, run_at, last_error, created_at\s+FROM jobs WHERE run_at <= \? AND kind IN \(\?\) ORDER BY run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED`).
+		WillReturnRows(jobRows(attempts))
+	mock.ExpectExec(`UPDATE jobs SET attempts = \$1, run_at = \$2 WHERE id = \$3`).
+		WithArgs(attempts+1, sqlmock.AnyArg(), 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func TestRunOnce_CompletesJob(t *testing.T) {
+	var got json.RawMessage
+	w, mock := newTestWorker(t, func(_ context.Context, payload json.RawMessage) error {
+		got = payload
+		return nil
+	})
+	before := testutil.ToFloat64(metric.JobsProcessed.WithLabelValues("test.kind", "ok"))
+
+	expectClaim(mock, 0)
+	mock.ExpectExec(`DELETE FROM jobs WHERE id = \$1`).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ran, err := w.RunOnce(context.Background())
+	if err != nil || !ran {
+		t.Fatalf("expected a job to run, ran=%v err=%v", ran, err)
+	}
+	if string(got) != `{"n":1}` {
+		t.Fatalf("unexpected payload %s", got)
+	}
+	if v := testutil.ToFloat64(metric.JobsProcessed.WithLabelValues("test.kind", "ok")); v != before+1 {
+		t.Fatalf("expected ok counter to grow, got %v", v)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestRunOnce_RetriesFailure(t *testing.T) {
+	w, mock := newTestWorker(t, func(context.Context, json.RawMessage) error {
+		return errors.New("boom")
+	})
+
+	expectClaim(mock, 1)
+	mock.ExpectExec(`UPDATE jobs SET run_at = \$1, last_error = \$2 WHERE id = \$3`).
+		WithArgs(sqlmock.AnyArg(), "boom", 7).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if ran, err := w.RunOnce(context.Background()); err != nil || !ran {
+		t.Fatalf("expected a job to run, ran=%v err=%v", ran, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestRunOnce_DeadLettersAfterMaxAttempts(t *testing.T) {
+	w, mock := newTestWorker(t, func(context.Context, json.RawMessage) error {
+		panic("handler bug")
+	})
+	before := testutil.ToFloat64(metric.JobsProcessed.WithLabelValues("test.kind", "dead"))
+
+	expectClaim(mock, 2)
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO dead_jobs`).
+		WithArgs(7, "test.kind", sqlmock.AnyArg(), 3, "job panicked: handler bug", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM jobs WHERE id = \$1`).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if ran, err := w.RunOnce(context.Background()); err != nil || !ran {
+		t.Fatalf("expected a job to run, ran=%v err=%v", ran, err)
+	}
+	if v := testutil.ToFloat64(metric.JobsProcessed.WithLabelValues("test.kind", "dead")); v != before+1 {
+		t.Fatalf("expected dead counter to grow, got %v", v)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestRunOnce_NothingDue(t *testing.T) {
+	w, mock := newTestWorker(t, func(context.Context, json.RawMessage) error { return nil })
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, kind, payload`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	if ran, err := w.RunOnce(context.Background()); err != nil || ran {
+		t.Fatalf("expected nothing to run, ran=%v err=%v", ran, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, Backoff: 10 * time.Second, MaxBackoff: time.Minute}
+	for attempt, want := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second, 4: time.Minute, 9: time.Minute} {
+		if got := p.delay(attempt); got != want {
+			t.Fatalf("delay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
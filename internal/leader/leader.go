@@ -0,0 +1,147 @@
+// Package leader elects one instance among the replicas sharing a database
+// to run the periodic schedulers and the outbox relay, which must not run
+// once per replica. The leader holds a session-level database lock
+// (pg_try_advisory_lock on PostgreSQL, GET_LOCK on MySQL) on a dedicated
+// connection; when that instance dies its connection closes, the database
+// drops the lock and another instance takes over on its next attempt.
+// SQLite databases are local to one process, so the only instance is
+// always the leader.
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+	"taskmanager/internal/metric"
+)
+
+// Elector competes for the lock called name.
+type Elector struct {
+	db       *sqlx.DB
+	d        database.Dialect
+	name     string
+	interval time.Duration
+	leader   atomic.Bool
+	conn     *sql.Conn
+}
+
+// New creates an Elector for the lock name. Every interval a follower tries
+// to take the lock and the leader checks that its connection still holds it,
+// so failover takes up to one interval after the old leader's connection is
+// gone.
+func New(db *sqlx.DB, name string, interval time.Duration) *Elector {
+	return &Elector{db: db, d: database.For(db), name: name, interval: interval}
+}
+
+// IsLeader reports whether this instance currently holds the lock.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run campaigns until ctx is cancelled, then releases the lock.
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		e.Step(ctx)
+		select {
+		case <-ctx.Done():
+			e.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Step tries to become leader, or, while leader, checks the connection
+// holding the lock is alive, and reports whether this instance is leader
+// afterwards. Only Run (or a test) calls it.
+func (e *Elector) Step(ctx context.Context) bool {
+	if e.d.SQLite() {
+		e.set(true)
+		return true
+	}
+	if e.conn != nil {
+		if _, err := e.conn.ExecContext(ctx, "SELECT 1"); err == nil {
+			return true
+		} else if ctx.Err() == nil {
+			slog.Warn("leader lock connection lost", "lock", e.name, "err", err)
+		}
+		e.drop()
+	}
+
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		slog.Error("leader election failed", "lock", e.name, "err", err)
+		return false
+	}
+	var acquired sql.NullBool
+	if e.d.MySQL() {
+		err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", e.name).Scan(&acquired)
+	} else {
+		err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.key()).Scan(&acquired)
+	}
+	if err != nil || !acquired.Bool {
+		if err != nil {
+			slog.Error("leader election failed", "lock", e.name, "err", err)
+		}
+		conn.Close()
+		return false
+	}
+	e.conn = conn
+	e.set(true)
+	slog.Info("became leader", "lock", e.name)
+	return true
+}
+
+// resign releases the lock so another instance can take over right away
+// instead of after its connection times out.
+func (e *Elector) resign() {
+	if e.conn == nil {
+		e.set(false)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var err error
+	if e.d.MySQL() {
+		_, err = e.conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", e.name)
+	} else {
+		_, err = e.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", e.key())
+	}
+	if err != nil {
+		slog.Warn("leader lock release failed", "lock", e.name, "err", err)
+	}
+	e.drop()
+}
+
+// drop forgets the lock connection; closing it releases the lock if the
+// database still holds it.
+func (e *Elector) drop() {
+	e.conn.Close()
+	e.conn = nil
+	e.set(false)
+}
+
+func (e *Elector) set(leader bool) {
+	e.leader.Store(leader)
+	v := 0.0
+	if leader {
+		v = 1
+	}
+	metric.IsLeader.WithLabelValues(e.name).Set(v)
+}
+
+// key maps the lock name to the bigint PostgreSQL advisory locks take.
+func (e *Elector) key() int64 {
+	h := fnv.New64a()
+	h.Write([]byte("taskmanager:" + e.name))
+	return int64(h.Sum64())
+}
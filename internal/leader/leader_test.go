@@ -0,0 +1,89 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"taskmanager/internal/metric"
+)
+
+func TestElector_AcquiresKeepsAndLosesLock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	e := New(sqlx.NewDb(db, "sqlmock"), "test", time.Second)
+	ctx := context.Background()
+
+	// another instance holds the lock
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).WithArgs(e.key()).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+	if e.Step(ctx) || e.IsLeader() {
+		t.Fatalf("expected to stay a follower")
+	}
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).WithArgs(e.key()).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	if !e.Step(ctx) || !e.IsLeader() {
+		t.Fatalf("expected to become leader")
+	}
+	if v := testutil.ToFloat64(metric.IsLeader.WithLabelValues("test")); v != 1 {
+		t.Fatalf("expected leader gauge 1 got %v", v)
+	}
+
+	mock.ExpectExec(`SELECT 1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	if !e.Step(ctx) {
+		t.Fatalf("expected to keep the lock while the connection is alive")
+	}
+
+	// the lock connection breaks: leadership is lost and campaigned for again
+	mock.ExpectExec(`SELECT 1`).WillReturnError(errors.New("connection reset"))
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).WillReturnError(errors.New("connection refused"))
+	if e.Step(ctx) || e.IsLeader() {
+		t.Fatalf("expected to lose leadership")
+	}
+	if v := testutil.ToFloat64(metric.IsLeader.WithLabelValues("test")); v != 0 {
+		t.Fatalf("expected leader gauge 0 got %v", v)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestElector_ResignReleasesLock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	e := New(sqlx.NewDb(db, "sqlmock"), "test", time.Hour)
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock`).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(e.key()).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx)
+		close(done)
+	}()
+	for !e.IsLeader() {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	if e.IsLeader() {
+		t.Fatalf("expected to resign on shutdown")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
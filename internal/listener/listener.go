@@ -0,0 +1,83 @@
+// Package listener opens the network listener the HTTP server accepts on.
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// systemd passes inherited sockets starting at this descriptor.
+const listenFDsStart = 3
+
+// Listen opens a listener for spec:
+//
+//	":8080", "tcp:host:8080"  TCP address
+//	"unix:/run/app.sock"      unix domain socket (a stale socket file is replaced)
+//	"systemd"                 first socket passed by systemd socket activation
+func Listen(spec string) (net.Listener, error) {
+	switch {
+	case spec == "systemd":
+		return systemdListener()
+	case strings.HasPrefix(spec, "unix:"):
+		return unixListener(strings.TrimPrefix(spec, "unix:"))
+	default:
+		return net.Listen("tcp", strings.TrimPrefix(spec, "tcp:"))
+	}
+}
+
+// Valid reports whether spec is a form accepted by Listen, without opening it.
+func Valid(spec string) error {
+	switch {
+	case spec == "systemd":
+		return nil
+	case strings.HasPrefix(spec, "unix:"):
+		if strings.TrimPrefix(spec, "unix:") == "" {
+			return errors.New("unix socket path is empty")
+		}
+		return nil
+	default:
+		_, _, err := net.SplitHostPort(strings.TrimPrefix(spec, "tcp:"))
+		return err
+	}
+}
+
+func unixListener(path string) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("unix socket path is empty")
+	}
+	// remove a socket left behind by a previous run; refuse to touch other files
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// systemdListener implements the sd_listen_fds protocol: LISTEN_PID must
+// match this process and LISTEN_FDS gives the number of inherited sockets.
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errors.New("no sockets passed by systemd (LISTEN_PID not set for this process)")
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, errors.New("no sockets passed by systemd (LISTEN_FDS)")
+	}
+	// don't leak the variables to child processes
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(uintptr(listenFDsStart), "systemd-socket")
+	defer f.Close()
+	return net.FileListener(f)
+}
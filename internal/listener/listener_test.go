@@ -0,0 +1,73 @@
+package listener
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListen_TCP(t *testing.T) {
+	ln, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer ln.Close()
+	if ln.Addr().Network() != "tcp" {
+		t.Fatalf("expected tcp listener got %s", ln.Addr().Network())
+	}
+}
+
+func TestListen_UnixReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+
+	// leave a stale socket file behind
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := Listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer ln.Close()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	conn.Close()
+}
+
+func TestListen_UnixRefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen("unix:" + path); err == nil {
+		t.Fatalf("expected error for regular file")
+	}
+}
+
+func TestListen_SystemdWithoutSockets(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	if _, err := Listen("systemd"); err == nil {
+		t.Fatalf("expected error when systemd passed no sockets")
+	}
+}
+
+func TestValid(t *testing.T) {
+	for _, spec := range []string{":8080", "tcp:localhost:8080", "unix:/run/app.sock", "systemd"} {
+		if err := Valid(spec); err != nil {
+			t.Fatalf("expected %q to be valid: %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"8080", "unix:"} {
+		if err := Valid(spec); err == nil {
+			t.Fatalf("expected %q to be invalid", spec)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is read from incoming requests and echoed on every response.
+const RequestIDHeader = "X-Request-ID"
+
+// IncidentIDKey is the gin context key holding the incident id of a 5xx
+// response (see problem.Render); the access log line carries it too.
+const IncidentIDKey = "incident_id"
+
+// ClientIdentityKey is the gin context key holding the identity of the
+// request's client certificate (see middleware.ClientCert); the access log
+// line carries it too.
+const ClientIdentityKey = "client_identity"
+
+type ctxKey struct{}
+
+type requestIDKey struct{}
+
+type actorKey struct{}
+
+// MaxLoggedBody bounds the part of a request or response body written to the
+// access log while body logging is on.
+const MaxLoggedBody = 4 << 10
+
+// Runtime holds the logging settings that can change while the service runs:
+// the level of the logger returned by New, and a window during which the
+// access log carries request and response bodies. The zero value logs at info
+// without bodies.
+type Runtime struct {
+	level slog.LevelVar
+	// bodiesUntil is the end of the body logging window in Unix nanoseconds;
+	// 0 when off.
+	bodiesUntil atomic.Int64
+}
+
+// New returns a JSON logger writing to w at the given level
+// ("debug", "info", "warn" or "error"), and the Runtime that changes it.
+func New(w io.Writer, level string) (*slog.Logger, *Runtime, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, nil, err
+	}
+	rt := &Runtime{}
+	rt.level.Set(lvl)
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: &rt.level})), rt, nil
+}
+
+// Level returns the current level.
+func (rt *Runtime) Level() slog.Level {
+	return rt.level.Level()
+}
+
+// SetLevel changes the level of the logger and every logger derived from it.
+func (rt *Runtime) SetLevel(l slog.Level) {
+	rt.level.Set(l)
+}
+
+// LogBodiesUntil makes the access log carry request and response bodies
+// until t; the zero time turns body logging off.
+func (rt *Runtime) LogBodiesUntil(t time.Time) {
+	if t.IsZero() {
+		rt.bodiesUntil.Store(0)
+		return
+	}
+	rt.bodiesUntil.Store(t.UnixNano())
+}
+
+// BodiesUntil returns the end of the body logging window, or the zero time
+// when body logging is off or the window has passed.
+func (rt *Runtime) BodiesUntil() time.Time {
+	if rt == nil {
+		return time.Time{}
+	}
+	n := rt.bodiesUntil.Load()
+	if n == 0 || time.Now().UnixNano() >= n {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// LevelName returns the lower-case name of l as ParseLevel accepts it.
+func LevelName(l slog.Level) string {
+	return strings.ToLower(l.String())
+}
+
+// ParseLevel converts a level name into a slog.Level.
+func ParseLevel(s string) (slog.Level, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q (want debug, info, warn or error)", s)
+	}
+	return lvl, nil
+}
+
+// WithLogger returns a copy of ctx carrying l.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the request-scoped logger stored in ctx, or the default
+// logger when there is none. Safe to call with a nil context.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+			return l
+		}
+	}
+	return slog.Default()
+}
+
+// WithRequestID returns a copy of ctx carrying the request id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request id stored in ctx, or "" when there is none.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithActor returns a copy of ctx carrying the actor: who made the request,
+// as recorded with the changes it makes.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the actor stored in ctx, or "" when there is none.
+func Actor(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// Middleware assigns each request an id (taken from X-Request-ID when it looks
+// sane, generated otherwise), stores a logger annotated with it in the request
+// context and writes one access log line per request. While rt's body logging
+// window is open the line also carries the first MaxLoggedBody bytes of the
+// request and response bodies; rt may be nil.
+func Middleware(base *slog.Logger, rt *Runtime) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		logBodies := !rt.BodiesUntil().IsZero()
+		var reqBody []byte
+		var resBody *bodyRecorder
+		if logBodies {
+			reqBody = peekBody(c.Request)
+			resBody = &bodyRecorder{ResponseWriter: c.Writer}
+			c.Writer = resBody
+		}
+
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.New().String()
+		}
+		c.Header(RequestIDHeader, id)
+
+		l := base.With("request_id", id)
+		ctx := WithRequestID(c.Request.Context(), id)
+		c.Request = c.Request.WithContext(WithLogger(ctx, l))
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		attrs := []any{
+			"method", c.Request.Method,
+			"route", route,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+		if identity := c.GetString(ClientIdentityKey); identity != "" {
+			attrs = append(attrs, "client_identity", identity)
+		}
+		if incident := c.GetString(IncidentIDKey); incident != "" {
+			attrs = append(attrs, "incident_id", incident)
+		}
+		if logBodies {
+			attrs = append(attrs, "request_body", string(reqBody), "response_body", resBody.buf.String())
+		}
+
+		switch status := c.Writer.Status(); {
+		case status >= 500:
+			l.Error("request", attrs...)
+		case status >= 400:
+			l.Warn("request", attrs...)
+		default:
+			l.Info("request", attrs...)
+		}
+	}
+}
+
+// validRequestID accepts client supplied ids of reasonable length made of
+// printable ASCII, so they can't be used to forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	return strings.IndexFunc(id, func(r rune) bool { return r < 0x21 || r > 0x7e }) < 0
+}
+
+// peekBody returns the first MaxLoggedBody bytes of r's body, leaving the body
+// intact for the handler.
+func peekBody(r *http.Request) []byte {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	head, _ := io.ReadAll(io.LimitReader(r.Body, MaxLoggedBody))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	return head
+}
+
+// bodyRecorder keeps a copy of the first MaxLoggedBody bytes written to the
+// response.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.record(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. to extend
+// the write deadline of a streamed response.
+func (w *bodyRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *bodyRecorder) record(b []byte) {
+	if room := MaxLoggedBody - w.buf.Len(); room > 0 {
+		w.buf.Write(b[:min(len(b), room)])
+	}
+}
@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware_RequestIDAndAccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger, _, err := New(&buf, "info")
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(Middleware(logger, nil))
+	var seenID string
+	r.GET("/tasks/:id", func(c *gin.Context) {
+		seenID = RequestID(c.Request.Context())
+		FromContext(c.Request.Context()).Info("inside handler")
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("KeepsIncomingID", func(t *testing.T) {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+		req.Header.Set(RequestIDHeader, "abc-123")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if got := w.Header().Get(RequestIDHeader); got != "abc-123" || seenID != "abc-123" {
+			t.Fatalf("expected request id to be propagated, header=%q ctx=%q", got, seenID)
+		}
+
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		if len(lines) != 2 {
+			t.Fatalf("expected handler line and access line, got %q", buf.String())
+		}
+		for _, line := range lines {
+			var entry map[string]interface{}
+			if err := json.Unmarshal(line, &entry); err != nil {
+				t.Fatalf("log line is not JSON: %s", line)
+			}
+			if entry["request_id"] != "abc-123" {
+				t.Fatalf("expected request_id on every line, got %s", line)
+			}
+		}
+		var access map[string]interface{}
+		_ = json.Unmarshal(lines[1], &access)
+		if access["route"] != "/tasks/:id" || access["status"] != float64(200) {
+			t.Fatalf("unexpected access log %s", lines[1])
+		}
+	})
+
+	t.Run("ReplacesInvalidID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+		req.Header.Set(RequestIDHeader, "bad id\nforged")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		got := w.Header().Get(RequestIDHeader)
+		if got == "" || got == "bad id\nforged" {
+			t.Fatalf("expected a generated request id, got %q", got)
+		}
+	})
+}
+
+func TestMiddleware_LogsBodiesWhileEnabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger, rt, err := New(&buf, "info")
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	r := gin.New()
+	r.Use(Middleware(logger, rt))
+	r.POST("/tasks", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusCreated, "application/json", body)
+	})
+	payload := `{"title":"` + strings.Repeat("x", MaxLoggedBody) + `"}`
+	post := func() map[string]interface{} {
+		buf.Reset()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(payload)))
+		if w.Body.String() != payload {
+			t.Fatalf("the handler must see the whole body, echoed %d bytes", w.Body.Len())
+		}
+		var access map[string]interface{}
+		_ = json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &access)
+		return access
+	}
+
+	if access := post(); access["request_body"] != nil || access["response_body"] != nil {
+		t.Fatalf("bodies logged while off: %v", access)
+	}
+	rt.LogBodiesUntil(time.Now().Add(time.Minute))
+	access := post()
+	if access["request_body"] != payload[:MaxLoggedBody] || access["response_body"] != payload[:MaxLoggedBody] {
+		t.Fatalf("expected both bodies cut to %d bytes, got %v", MaxLoggedBody, access)
+	}
+	rt.LogBodiesUntil(time.Now().Add(-time.Second))
+	if access := post(); access["request_body"] != nil {
+		t.Fatalf("bodies logged after the window: %v", access)
+	}
+
+	rt.SetLevel(slog.LevelWarn)
+	if post() != nil {
+		t.Fatalf("expected no access line at warn, got %q", buf.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if _, err := ParseLevel("warn"); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Fatalf("expected error for unknown level")
+	}
+}
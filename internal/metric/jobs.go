@@ -0,0 +1,57 @@
+package metric
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// jobQueueTimeout bounds the queries behind one scrape of the job queue
+// gauges.
+const jobQueueTimeout = 5 * time.Second
+
+// RegisterJobQueue exports jobs_queue_depth and jobs_dead, the number of
+// queued and dead-lettered jobs per kind. fn returns both, keyed by kind,
+// and is called on every scrape.
+func RegisterJobQueue(fn func(ctx context.Context) (queued, dead map[string]int64, err error)) {
+	prometheus.MustRegister(NewJobQueueCollector(fn))
+}
+
+// JobQueueCollector is a prometheus.Collector reading the job queue sizes on
+// scrape.
+type JobQueueCollector struct {
+	fn    func(ctx context.Context) (queued, dead map[string]int64, err error)
+	depth *prometheus.Desc
+	dead  *prometheus.Desc
+}
+
+// NewJobQueueCollector creates a collector for the counts returned by fn.
+func NewJobQueueCollector(fn func(ctx context.Context) (queued, dead map[string]int64, err error)) *JobQueueCollector {
+	return &JobQueueCollector{
+		fn:    fn,
+		depth: prometheus.NewDesc("jobs_queue_depth", "Jobs waiting to run or running, labeled by kind", []string{"kind"}, nil),
+		dead:  prometheus.NewDesc("jobs_dead", "Jobs that exhausted their retries and sit in dead_jobs, labeled by kind", []string{"kind"}, nil),
+	}
+}
+
+func (c *JobQueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+	ch <- c.dead
+}
+
+func (c *JobQueueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobQueueTimeout)
+	defer cancel()
+	queued, dead, err := c.fn(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.depth, err)
+		return
+	}
+	for kind, n := range queued {
+		ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(n), kind)
+	}
+	for kind, n := range dead {
+		ch <- prometheus.MustNewConstMetric(c.dead, prometheus.GaugeValue, float64(n), kind)
+	}
+}
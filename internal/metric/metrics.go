@@ -2,12 +2,14 @@ package metric
 
 import (
 	"net/http"
+	"runtime"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 )
 
 var (
@@ -19,15 +21,185 @@ var (
 		[]string{"method", "path", "status"},
 	)
 
-	RequestLatency = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "request_latency_seconds",
-			Help:    "Histogram of request latencies labeled by method and path",
-			Buckets: prometheus.DefBuckets,
+	RequestLatency = newRequestLatency(prometheus.DefBuckets)
+
+	// AvailabilityTotal and AvailabilityGood form the per-route availability SLI:
+	// good/total is the success ratio, where any non-5xx response counts as good.
+	AvailabilityTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "availability_requests_total",
+			Help: "Total number of requests counted towards the availability SLI, labeled by method and path",
 		},
 		[]string{"method", "path"},
 	)
 
+	AvailabilityGood = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "availability_requests_good_total",
+			Help: "Number of requests that did not fail with a 5xx status, labeled by method and path",
+		},
+		[]string{"method", "path"},
+	)
+
+	BuildInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "build_info",
+			Help: "Always 1; labels carry the version, commit and Go version of the running binary",
+		},
+		[]string{"version", "commit", "goversion"},
+	)
+
+	// Cache effectiveness for the repository cache-aside layer, labeled by cache
+	// name (e.g. "list"). hit ratio = hits / (hits + misses).
+	CacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_hits_total",
+			Help: "Number of cache lookups served from Redis, labeled by cache",
+		},
+		[]string{"cache"},
+	)
+
+	CacheMisses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_misses_total",
+			Help: "Number of cache lookups that fell through to the database, labeled by cache",
+		},
+		[]string{"cache"},
+	)
+
+	CacheSets = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_sets_total",
+			Help: "Number of entries written to the cache, labeled by cache",
+		},
+		[]string{"cache"},
+	)
+
+	CacheInvalidations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_invalidations_total",
+			Help: "Number of cache keys removed by invalidation, labeled by cache",
+		},
+		[]string{"cache"},
+	)
+
+	CacheEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "Number of entries evicted from a size-bounded cache to make room, labeled by cache",
+		},
+		[]string{"cache"},
+	)
+
+	RedisLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "redis_operation_duration_seconds",
+			Help:    "Latency of Redis commands issued by the repository layer, labeled by operation",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		},
+		[]string{"operation"},
+	)
+
+	// DBQueryDuration and DBQueryErrors are recorded by database.Instrument,
+	// labeled by the repository and operation that issued the query.
+	DBQueryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Latency of SQL queries and statements, labeled by repository and operation",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"repository", "operation"},
+	)
+
+	DBQueryErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_query_errors_total",
+			Help: "SQL queries and statements that failed, labeled by repository and operation",
+		},
+		[]string{"repository", "operation"},
+	)
+
+	WIPLimitViolations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wip_limit_violations_total",
+			Help: "Assignments that would exceed the per-assignee WIP limit, labeled by outcome (rejected or overridden)",
+		},
+		[]string{"outcome"},
+	)
+
+	TaskStateChanges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "task_state_changes_total",
+			Help: "Tasks marked completed or reopened through the action endpoints, labeled by action (complete or reopen)",
+		},
+		[]string{"action"},
+	)
+
+	TaskCycleTime = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "task_cycle_time_seconds",
+			Help:    "Time from creation to completion (completed_at - created_at) of tasks as they are completed",
+			Buckets: []float64{3600, 4 * 3600, 8 * 3600, 86400, 2 * 86400, 3 * 86400, 7 * 86400, 14 * 86400, 30 * 86400, 90 * 86400},
+		},
+	)
+
+	RetentionRows = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "retention_rows_total",
+			Help: "Rows removed by the retention policy, labeled by action (purge_completed or purge_tombstones)",
+		},
+		[]string{"action"},
+	)
+
+	RetentionRuns = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "retention_runs_total",
+			Help: "Retention policy runs, labeled by trigger (schedule or manual) and result (ok or error)",
+		},
+		[]string{"trigger", "result"},
+	)
+
+	JobsProcessed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jobs_processed_total",
+			Help: "Background job attempts, labeled by kind and result (ok, retry or dead)",
+		},
+		[]string{"kind", "result"},
+	)
+
+	JobDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "job_duration_seconds",
+			Help:    "Run time of background job attempts, labeled by kind",
+			Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+		},
+		[]string{"kind"},
+	)
+
+	IsLeader = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "leader_is_leader",
+			Help: "1 while this instance holds the named leader lock, 0 otherwise",
+		},
+		[]string{"name"},
+	)
+
+	CircuitBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "Circuit breaker state per dependency: 0 closed, 1 half-open, 2 open",
+		},
+		[]string{"name"},
+	)
+
+	CircuitBreakerRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "circuit_breaker_rejections_total",
+			Help: "Calls failed fast because the dependency's circuit breaker was open",
+		},
+		[]string{"name"},
+	)
+
 	TasksCount = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "tasks_count",
@@ -36,13 +208,43 @@ var (
 	)
 )
 
+// UnmatchedRoute is the path label of requests that matched no route, so
+// scans of random URLs cannot grow the label sets without bound.
+const UnmatchedRoute = "unmatched"
+
+func newRequestLatency(buckets []float64) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "request_latency_seconds",
+			Help:    "Histogram of request latencies labeled by method and path",
+			Buckets: buckets,
+		},
+		[]string{"method", "path"},
+	)
+}
+
+// SetLatencyBuckets replaces the request_latency_seconds buckets (upper
+// bounds in seconds). Call it before InitMetrics.
+func SetLatencyBuckets(buckets []float64) {
+	RequestLatency = newRequestLatency(buckets)
+}
+
 // InitMetrics registers the Prometheus metrics. Call once at program startup.
 func InitMetrics() {
-	prometheus.MustRegister(RequestsTotal, RequestLatency, TasksCount)
+	prometheus.MustRegister(
+		RequestsTotal, RequestLatency, AvailabilityTotal, AvailabilityGood, BuildInfo, TasksCount,
+		CacheHits, CacheMisses, CacheSets, CacheInvalidations, CacheEvictions, RedisLatency,
+		DBQueryDuration, DBQueryErrors,
+		WIPLimitViolations, TaskStateChanges, TaskCycleTime, RetentionRows, RetentionRuns,
+		JobsProcessed, JobDuration, IsLeader, CircuitBreakerState, CircuitBreakerRejections,
+		TasksByStatus, OverdueTasks,
+	)
 }
 
 // PrometheusMiddleware returns a Gin middleware that instruments requests.
 // It records request count and latency (method + path + status labels).
+// Requests that matched no route are labeled UnmatchedRoute, and methods
+// other than the standard ones "OTHER".
 func PrometheusMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -52,12 +254,59 @@ func PrometheusMiddleware() gin.HandlerFunc {
 		status := strconv.Itoa(c.Writer.Status())
 		route := c.FullPath()
 		if route == "" {
-			route = c.Request.URL.Path
+			route = UnmatchedRoute
 		}
+		method := methodLabel(c.Request.Method)
+
+		RequestsTotal.WithLabelValues(method, route, status).Inc()
+		RequestLatency.WithLabelValues(method, route).Observe(duration)
+
+		AvailabilityTotal.WithLabelValues(method, route).Inc()
+		if c.Writer.Status() < http.StatusInternalServerError {
+			AvailabilityGood.WithLabelValues(method, route).Inc()
+		}
+	}
+}
 
-		RequestsTotal.WithLabelValues(c.Request.Method, route, status).Inc()
-		RequestLatency.WithLabelValues(c.Request.Method, route).Observe(duration)
+func methodLabel(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return m
 	}
+	return "OTHER"
+}
+
+// AvailabilityCounts returns the availability SLI counters summed over all
+// routes since the process started.
+func AvailabilityCounts() (good, total float64) {
+	return sumCounters(AvailabilityGood), sumCounters(AvailabilityTotal)
+}
+
+func sumCounters(c prometheus.Collector) float64 {
+	ch := make(chan prometheus.Metric, 64)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	var sum float64
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err == nil && pb.Counter != nil {
+			sum += pb.Counter.GetValue()
+		}
+	}
+	return sum
+}
+
+// SetBuildInfo publishes the build_info gauge for the running binary.
+func SetBuildInfo(version, commit string) {
+	BuildInfo.WithLabelValues(version, commit, runtime.Version()).Set(1)
+}
+
+// ObserveRedis records the latency of a Redis operation started at start.
+func ObserveRedis(operation string, start time.Time) {
+	RedisLatency.WithLabelValues(operation).Observe(time.Since(start).Seconds())
 }
 
 // PromhttpHandler returns the standard promhttp handler to expose /metrics.
@@ -65,18 +314,8 @@ func PromhttpHandler() http.Handler {
 	return promhttp.Handler()
 }
 
-// SetTasksCount sets the tasks_count gauge to the provided value.
-// Exported so application code can update the metric after DB changes.
+// SetTasksCount sets the tasks_count gauge to the provided value. The gauge
+// is kept up to date by TaskGauges.
 func SetTasksCount(n int) {
 	TasksCount.Set(float64(n))
 }
-
-// IncTaskCount increments the tasks_count gauge by 1.
-func IncTaskCount() {
-	TasksCount.Add(1)
-}
-
-// DecTaskCount decrements the tasks_count gauge by 1.
-func DecTaskCount() {
-	TasksCount.Sub(1)
-}
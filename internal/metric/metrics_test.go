@@ -0,0 +1,60 @@
+package metric
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestPrometheusMiddleware_CollapsesUnmatchedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(PrometheusMiddleware())
+	r.GET("/tasks/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	matched := testutil.ToFloat64(RequestsTotal.WithLabelValues(http.MethodGet, "/tasks/:id", "200"))
+	unmatched := testutil.ToFloat64(RequestsTotal.WithLabelValues(http.MethodGet, UnmatchedRoute, "404"))
+	other := testutil.ToFloat64(RequestsTotal.WithLabelValues("OTHER", UnmatchedRoute, "404"))
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/tasks/1", nil),
+		httptest.NewRequest(http.MethodGet, "/wp-login.php", nil),
+		httptest.NewRequest(http.MethodGet, "/.env", nil),
+		httptest.NewRequest("PROPFIND", "/", nil),
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if v := testutil.ToFloat64(RequestsTotal.WithLabelValues(http.MethodGet, "/tasks/:id", "200")); v != matched+1 {
+		t.Fatalf("expected the route template as path, got %v", v-matched)
+	}
+	if v := testutil.ToFloat64(RequestsTotal.WithLabelValues(http.MethodGet, UnmatchedRoute, "404")); v != unmatched+2 {
+		t.Fatalf("expected both scans under %q, got %v", UnmatchedRoute, v-unmatched)
+	}
+	if v := testutil.ToFloat64(RequestsTotal.WithLabelValues("OTHER", UnmatchedRoute, "404")); v != other+1 {
+		t.Fatalf("expected a nonstandard method as OTHER, got %v", v-other)
+	}
+	if n := testutil.CollectAndCount(RequestsTotal, "requests_total"); n != 3 {
+		t.Fatalf("expected 3 series, got %d", n)
+	}
+}
+
+func TestSetLatencyBuckets(t *testing.T) {
+	defer SetLatencyBuckets(prometheus.DefBuckets)
+	SetLatencyBuckets([]float64{0.1, 1})
+	h := RequestLatency.WithLabelValues(http.MethodGet, "/")
+	h.Observe(0.5)
+
+	var m dto.Metric
+	if err := h.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	b := m.GetHistogram().GetBucket()
+	if len(b) != 2 || b[0].GetUpperBound() != 0.1 || b[1].GetUpperBound() != 1 || b[1].GetCumulativeCount() != 1 {
+		t.Fatalf("unexpected buckets %v", b)
+	}
+}
@@ -0,0 +1,65 @@
+package metric
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/redis/go-redis/v9"
+)
+
+// RegisterDBStats exports sql.DBStats (open/in-use/idle connections, wait
+// count and duration, ...) as go_sql_* metrics labeled db_name=name.
+// The values are read from the pool on every scrape.
+func RegisterDBStats(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterRedisPoolStats exports the go-redis connection pool stats.
+func RegisterRedisPoolStats(rdb *redis.Client) {
+	prometheus.MustRegister(NewRedisPoolCollector(rdb))
+}
+
+// RedisPoolCollector is a prometheus.Collector reading redis.PoolStats on scrape.
+type RedisPoolCollector struct {
+	rdb *redis.Client
+
+	hits, misses, timeouts, waits, waitDuration *prometheus.Desc
+	total, idle, stale                          *prometheus.Desc
+}
+
+// NewRedisPoolCollector creates a collector for rdb's connection pool.
+func NewRedisPoolCollector(rdb *redis.Client) *RedisPoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("redis_pool_"+name, help, nil, nil)
+	}
+	return &RedisPoolCollector{
+		rdb:          rdb,
+		hits:         desc("hits_total", "Number of times a free connection was found in the pool"),
+		misses:       desc("misses_total", "Number of times a free connection was not found in the pool"),
+		timeouts:     desc("timeouts_total", "Number of times a wait for a connection timed out"),
+		waits:        desc("waits_total", "Number of times a caller waited for a connection"),
+		waitDuration: desc("wait_duration_seconds_total", "Total time spent waiting for a connection"),
+		total:        desc("connections", "Number of connections in the pool"),
+		idle:         desc("idle_connections", "Number of idle connections in the pool"),
+		stale:        desc("stale_connections_total", "Number of stale connections removed from the pool"),
+	}
+}
+
+func (c *RedisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.hits, c.misses, c.timeouts, c.waits, c.waitDuration, c.total, c.idle, c.stale} {
+		ch <- d
+	}
+}
+
+func (c *RedisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.rdb.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(s.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.waits, prometheus.CounterValue, float64(s.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, float64(s.WaitDurationNs)/1e9)
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.stale, prometheus.CounterValue, float64(s.StaleConns))
+}
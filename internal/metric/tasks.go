@@ -0,0 +1,122 @@
+package metric
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// taskGaugesTimeout bounds the queries behind one refresh of the task gauges.
+const taskGaugesTimeout = 10 * time.Second
+
+// Assignee labels of overdue_tasks that are not assignee names.
+const (
+	UnassignedLabel     = "(unassigned)"
+	OtherAssigneesLabel = "(other)"
+)
+
+var (
+	TasksByStatus = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "tasks_by_status",
+			Help: "Current number of tasks in the database, labeled by status (open or completed)",
+		},
+		[]string{"status"},
+	)
+
+	OverdueTasks = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "overdue_tasks",
+			Help: "Open tasks past their due date, labeled by assignee; only the assignees with the most are named, the rest are summed under (other)",
+		},
+		[]string{"assignee"},
+	)
+)
+
+// TaskFigures are the values behind the task gauges.
+type TaskFigures struct {
+	Open, Completed int
+	// Overdue counts the open tasks past their due date per assignee; ""
+	// stands for the unassigned ones.
+	Overdue map[string]int
+}
+
+// TaskGauges recomputes the task gauges (tasks_count, tasks_by_status and
+// overdue_tasks) from the database every interval instead of following
+// creates and deletes, so bulk changes, crashes and writes made by other
+// replicas cannot make them drift; every replica reports the same figures.
+type TaskGauges struct {
+	figures  func(ctx context.Context) (TaskFigures, error)
+	interval time.Duration
+	// topAssignees limits the assignee labels of overdue_tasks.
+	topAssignees int
+}
+
+// NewTaskGauges creates a refresher that sets the gauges from figures,
+// naming at most topAssignees assignees in overdue_tasks.
+func NewTaskGauges(figures func(ctx context.Context) (TaskFigures, error), interval time.Duration, topAssignees int) *TaskGauges {
+	return &TaskGauges{figures: figures, interval: interval, topAssignees: topAssignees}
+}
+
+// Refresh recomputes the gauges once. On error they keep their last values.
+func (g *TaskGauges) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, taskGaugesTimeout)
+	defer cancel()
+	f, err := g.figures(ctx)
+	if err != nil {
+		return err
+	}
+	SetTasksCount(f.Open + f.Completed)
+	TasksByStatus.WithLabelValues("open").Set(float64(f.Open))
+	TasksByStatus.WithLabelValues("completed").Set(float64(f.Completed))
+
+	type overdue struct {
+		assignee string
+		n        int
+	}
+	byCount := make([]overdue, 0, len(f.Overdue))
+	for a, n := range f.Overdue {
+		byCount = append(byCount, overdue{a, n})
+	}
+	slices.SortFunc(byCount, func(a, b overdue) int {
+		return cmp.Or(b.n-a.n, cmp.Compare(a.assignee, b.assignee))
+	})
+	// assignees drop out of the top, so the old series are removed
+	OverdueTasks.Reset()
+	other := 0
+	for i, o := range byCount {
+		switch {
+		case i >= g.topAssignees:
+			other += o.n
+		case o.assignee == "":
+			OverdueTasks.WithLabelValues(UnassignedLabel).Set(float64(o.n))
+		default:
+			OverdueTasks.WithLabelValues(o.assignee).Set(float64(o.n))
+		}
+	}
+	if other > 0 {
+		OverdueTasks.WithLabelValues(OtherAssigneesLabel).Set(float64(other))
+	}
+	return nil
+}
+
+// Run refreshes the gauges now and then every interval until ctx is
+// cancelled.
+func (g *TaskGauges) Run(ctx context.Context) {
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+	for {
+		if err := g.Refresh(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("task gauges refresh failed", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTaskGauges_Refresh(t *testing.T) {
+	f := TaskFigures{Open: 5, Completed: 2, Overdue: map[string]int{"alice": 3, "bob": 1, "carol": 1, "": 2}}
+	var fail bool
+	g := NewTaskGauges(func(ctx context.Context) (TaskFigures, error) {
+		if fail {
+			return TaskFigures{}, errors.New("db down")
+		}
+		return f, nil
+	}, time.Minute, 2)
+
+	SetTasksCount(3)
+	if err := g.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if v := testutil.ToFloat64(TasksCount); v != 7 {
+		t.Fatalf("expected tasks_count 7, got %v", v)
+	}
+	if o, c := testutil.ToFloat64(TasksByStatus.WithLabelValues("open")), testutil.ToFloat64(TasksByStatus.WithLabelValues("completed")); o != 5 || c != 2 {
+		t.Fatalf("unexpected tasks_by_status open=%v completed=%v", o, c)
+	}
+	// top 2: alice (3) and the unassigned (2); bob and carol are summed
+	want := map[string]float64{"alice": 3, UnassignedLabel: 2, OtherAssigneesLabel: 2}
+	if n := testutil.CollectAndCount(OverdueTasks); n != len(want) {
+		t.Fatalf("expected %d overdue_tasks series, got %d", len(want), n)
+	}
+	for a, n := range want {
+		if v := testutil.ToFloat64(OverdueTasks.WithLabelValues(a)); v != n {
+			t.Fatalf("overdue_tasks{assignee=%q} = %v, want %v", a, v, n)
+		}
+	}
+
+	// alice's series goes away once she has nothing overdue
+	f = TaskFigures{Open: 1, Overdue: map[string]int{"bob": 1}}
+	if err := g.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if n := testutil.CollectAndCount(OverdueTasks); n != 1 {
+		t.Fatalf("expected only bob's series, got %d", n)
+	}
+
+	fail = true
+	if err := g.Refresh(context.Background()); err == nil {
+		t.Fatalf("expected the figures error")
+	}
+	if v := testutil.ToFloat64(TasksCount); v != 1 {
+		t.Fatalf("expected tasks_count to keep 1 on error, got %v", v)
+	}
+}
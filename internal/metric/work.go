@@ -0,0 +1,54 @@
+package metric
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// remainingWorkTimeout bounds the query behind one scrape of
+// task_remaining_minutes.
+const remainingWorkTimeout = 5 * time.Second
+
+// RegisterRemainingWork exports task_remaining_minutes, the remaining effort
+// of open tasks per assignee, for capacity dashboards. fn returns the minutes
+// keyed by assignee and is called on every scrape.
+func RegisterRemainingWork(fn func(ctx context.Context) (map[string]int64, error)) {
+	prometheus.MustRegister(NewRemainingWorkCollector(fn))
+}
+
+// RemainingWorkCollector is a prometheus.Collector reading the remaining work
+// per assignee on scrape.
+type RemainingWorkCollector struct {
+	fn   func(ctx context.Context) (map[string]int64, error)
+	desc *prometheus.Desc
+}
+
+// NewRemainingWorkCollector creates a collector for the minutes returned by fn.
+func NewRemainingWorkCollector(fn func(ctx context.Context) (map[string]int64, error)) *RemainingWorkCollector {
+	return &RemainingWorkCollector{
+		fn: fn,
+		desc: prometheus.NewDesc("task_remaining_minutes",
+			`Sum of remaining_minutes over open tasks, labeled by assignee ("none" for unassigned tasks)`,
+			[]string{"assignee"}, nil),
+	}
+}
+
+func (c *RemainingWorkCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *RemainingWorkCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), remainingWorkTimeout)
+	defer cancel()
+	minutes, err := c.fn(ctx)
+	if err != nil {
+		// fails the scrape of this metric only
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for assignee, m := range minutes {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(m), assignee)
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/logging"
+	"taskmanager/internal/problem"
+)
+
+// IsAdminKey is the gin context key set to true when the request was
+// authenticated with one of the admin keys.
+const IsAdminKey = "is_admin"
+
+// KeyIDKey is the gin context key holding a fingerprint of the request's API
+// key (the first 8 bytes of its SHA-256, in hex), which identifies the
+// caller in error reports without revealing the key. It is also the
+// request's actor (see logging.WithActor).
+const KeyIDKey = "api_key_id"
+
+// APIKeyAuth rejects requests that do not carry one of the given keys or
+// admin keys, either as "Authorization: Bearer <key>" or in the X-API-Key
+// header. Requests using an admin key are marked with IsAdminKey. A request
+// without a key that presented a verified client certificate (see
+// ClientCert) is authenticated by it instead.
+func APIKeyAuth(keys, adminKeys []string) gin.HandlerFunc {
+	return APIKeyAuthFrom(func() ([]string, []string) { return keys, adminKeys })
+}
+
+// APIKeyAuthFrom is APIKeyAuth with the keys read from source on every
+// request, so they can be rotated while the service runs.
+func APIKeyAuthFrom(source func() (keys, adminKeys []string)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys, adminKeys := source()
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+				key = strings.TrimPrefix(auth, "Bearer ")
+			}
+		}
+		if key == "" && ClientIdentity(c) != "" {
+			c.Next()
+			return
+		}
+		admin := key != "" && matchKey(adminKeys, key)
+		if key == "" || (!admin && !matchKey(keys, key)) {
+			problem.Abort(c, http.StatusUnauthorized, "missing or invalid API key")
+			return
+		}
+		c.Set(IsAdminKey, admin)
+		sum := sha256.Sum256([]byte(key))
+		keyID := hex.EncodeToString(sum[:8])
+		c.Set(KeyIDKey, keyID)
+		c.Request = c.Request.WithContext(logging.WithActor(c.Request.Context(), keyID))
+		c.Next()
+	}
+}
+
+func matchKey(keys []string, key string) bool {
+	ok := false
+	for _, k := range keys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
+
+// RequireAdmin rejects requests that were not authenticated with an admin
+// key (see APIKeyAuth) or admin client certificate (see ClientCert) with 403.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool(IsAdminKey) {
+			problem.Abort(c, http.StatusForbidden, "this endpoint requires an admin key")
+			return
+		}
+		c.Next()
+	}
+}
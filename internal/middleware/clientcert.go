@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"crypto/x509"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/logging"
+)
+
+// ClientIdentity returns the identity of the client certificate the request
+// was made with (see ClientCert), or "" when there is none.
+func ClientIdentity(c *gin.Context) string {
+	return c.GetString(logging.ClientIdentityKey)
+}
+
+// ClientCert records the identity of a verified client certificate (mutual
+// TLS, see server.tls_client_ca_file) under logging.ClientIdentityKey, where
+// the access log and APIKeyAuth find it, and as the request's actor (see
+// logging.WithActor). Identities listed in
+// adminIdentities are marked with IsAdminKey like admin keys. Requests
+// without a verified certificate pass through unchanged.
+func ClientCert(adminIdentities []string) gin.HandlerFunc {
+	admins := make(map[string]bool, len(adminIdentities))
+	for _, id := range adminIdentities {
+		admins[id] = true
+	}
+	return func(c *gin.Context) {
+		if tls := c.Request.TLS; tls != nil && len(tls.VerifiedChains) > 0 && len(tls.VerifiedChains[0]) > 0 {
+			if id := certIdentity(tls.VerifiedChains[0][0]); id != "" {
+				c.Set(logging.ClientIdentityKey, id)
+				c.Set(IsAdminKey, admins[id])
+				c.Request = c.Request.WithContext(logging.WithActor(c.Request.Context(), id))
+			}
+		}
+		c.Next()
+	}
+}
+
+// certIdentity names the subject of cert: its common name, or else the
+// first URI (such as a SPIFFE id), DNS name or email address among its
+// subject alternative names.
+func certIdentity(cert *x509.Certificate) string {
+	switch {
+	case cert.Subject.CommonName != "":
+		return cert.Subject.CommonName
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	case len(cert.EmailAddresses) > 0:
+		return cert.EmailAddresses[0]
+	}
+	return ""
+}
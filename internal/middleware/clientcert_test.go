@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientCert_AuthenticatesWithoutAKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ClientCert([]string{"ops-bot"}), APIKeyAuth([]string{"k"}, nil))
+	r.GET("/tasks", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"identity": ClientIdentity(c), "admin": c.GetBool(IsAdminKey)})
+	})
+
+	do := func(cert *x509.Certificate, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		if cert != nil {
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	spiffe, _ := url.Parse("spiffe://cluster.local/ns/jobs/sa/importer")
+	for _, tc := range []struct {
+		cert *x509.Certificate
+		body string
+	}{
+		{&x509.Certificate{Subject: pkix.Name{CommonName: "billing"}, DNSNames: []string{"billing.svc"}}, `{"admin":false,"identity":"billing"}`},
+		{&x509.Certificate{URIs: []*url.URL{spiffe}, DNSNames: []string{"importer.svc"}}, `{"admin":false,"identity":"spiffe://cluster.local/ns/jobs/sa/importer"}`},
+		{&x509.Certificate{Subject: pkix.Name{CommonName: "ops-bot"}}, `{"admin":true,"identity":"ops-bot"}`},
+	} {
+		if w := do(tc.cert, ""); w.Code != http.StatusOK || w.Body.String() != tc.body {
+			t.Fatalf("unexpected response %d %s, want %s", w.Code, w.Body.String(), tc.body)
+		}
+	}
+
+	if w := do(nil, ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a certificate or key got %d", w.Code)
+	}
+	// a key that is sent must be valid even with a certificate
+	if w := do(&x509.Certificate{Subject: pkix.Name{CommonName: "billing"}}, "wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong key got %d", w.Code)
+	}
+	// an unverified certificate (no chain) identifies nobody
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "ops-bot"}}}}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for an unverified certificate got %d", w.Code)
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORS returns a middleware that answers preflight requests and sets the
+// Access-Control-* headers for the allowed origins. An origin of "*" allows any.
+func CORS(origins, methods, headers []string) gin.HandlerFunc {
+	return CORSFrom(func() ([]string, []string, []string) { return origins, methods, headers })
+}
+
+// CORSFrom is CORS with the settings read from source on every request, so
+// they can be changed while the service runs. No origins disables CORS.
+func CORSFrom(source func() (origins, methods, headers []string)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origins, methods, headers := source()
+		origin := c.GetHeader("Origin")
+		if origin == "" || !(slices.Contains(origins, "*") || slices.Contains(origins, origin)) {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Add("Vary", "Origin")
+		h.Set("Access-Control-Expose-Headers", "X-Total-Count, ETag")
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			h.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+			h.Set("Access-Control-Max-Age", "600")
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
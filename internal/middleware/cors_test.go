@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSFrom(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var origins []string
+	r := gin.New()
+	r.Use(CORSFrom(func() ([]string, []string, []string) {
+		return origins, []string{"GET", "POST"}, []string{"Content-Type"}
+	}))
+	r.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/tasks", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := preflight("https://app.example"); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected CORS off without origins, got %v", w.Header())
+	}
+	origins = []string{"https://app.example"}
+	w := preflight("https://app.example")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example" || w.Header().Get("Access-Control-Allow-Methods") != "GET, POST" {
+		t.Fatalf("unexpected preflight answer %d %v", w.Code, w.Header())
+	}
+	if w := preflight("https://evil.example"); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected other origins refused, got %v", w.Header())
+	}
+	origins = []string{"*"}
+	if w := preflight("https://evil.example"); w.Header().Get("Access-Control-Allow-Origin") != "https://evil.example" {
+		t.Fatalf("expected any origin allowed, got %v", w.Header())
+	}
+}
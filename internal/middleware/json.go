@@ -0,0 +1,17 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// StrictJSONKey is the gin context key StrictJSON sets; handlers that decode
+// request bodies check it.
+const StrictJSONKey = "strict_json"
+
+// StrictJSON makes handlers in the group reject request bodies with fields
+// the endpoint does not know (e.g. a misspelled "assginee") instead of
+// silently ignoring them.
+func StrictJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(StrictJSONKey, true)
+		c.Next()
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/problem"
+)
+
+// RequestTimeoutHeader lets clients shorten (never extend) the request
+// deadline, as a Go duration ("1.5s", "300ms") or a number of seconds.
+const RequestTimeoutHeader = "X-Request-Timeout"
+
+// Timeout attaches a deadline to the request context so DB and Redis calls
+// made while handling the request are cancelled once it passes. The budget is
+// d, or the client's X-Request-Timeout when shorter; reserve is taken off it
+// so the handler still has time to write a response after a downstream call
+// times out. If the deadline expired and the handler wrote nothing, a 408 is
+// returned.
+func Timeout(d, reserve time.Duration) gin.HandlerFunc {
+	return TimeoutFrom(func() (time.Duration, time.Duration) { return d, reserve })
+}
+
+// TimeoutFrom is Timeout with the budget and reserve read from source on
+// every request, so they can be changed while the service runs. Routes
+// whose full path starts with one of the exempt prefixes run without a
+// deadline; they are the streaming ones and end when the client goes away.
+func TimeoutFrom(source func() (d, reserve time.Duration), exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, prefix := range exempt {
+			if strings.HasPrefix(c.FullPath(), prefix) {
+				c.Next()
+				return
+			}
+		}
+		budget, reserve := source()
+		if h := c.GetHeader(RequestTimeoutHeader); h != "" {
+			v, err := parseRequestTimeout(h)
+			if err != nil {
+				problem.Abort(c, http.StatusBadRequest, "invalid "+RequestTimeoutHeader+" header")
+				return
+			}
+			budget = min(budget, v)
+		}
+		if reserve >= budget {
+			reserve = budget / 10
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), budget-reserve)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			problem.Abort(c, http.StatusRequestTimeout, "request timed out")
+		}
+	}
+}
+
+func parseRequestTimeout(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		secs, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil {
+			return 0, err
+		}
+		d = time.Duration(secs * float64(time.Second))
+	}
+	if d <= 0 {
+		return 0, errors.New("timeout must be positive")
+	}
+	return d, nil
+}
+
+// BodyLimit caps request bodies at n bytes. Requests that declare a larger
+// Content-Length are rejected with 413 up front; bodies that turn out larger
+// while streaming fail with *http.MaxBytesError when read.
+func BodyLimit(n int64) gin.HandlerFunc {
+	return BodyLimitFrom(func() int64 { return n })
+}
+
+// BodyLimitFrom is BodyLimit with the cap read from source on every request.
+func BodyLimitFrom(source func() int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		n := source()
+		if c.Request.ContentLength > n {
+			problem.Abort(c, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, n)
+		}
+		c.Next()
+	}
+}
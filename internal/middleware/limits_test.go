@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeout_Returns408WhenHandlerWritesNothing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Timeout(10*time.Millisecond, 0))
+	r.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusRequestTimeout {
+		t.Fatalf("expected 408 got %d", w.Code)
+	}
+}
+
+func TestTimeout_LeavesFastResponsesAlone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Timeout(time.Second, 0))
+	r.GET("/fast", func(c *gin.Context) {
+		if _, ok := c.Request.Context().Deadline(); !ok {
+			t.Errorf("expected a deadline on the request context")
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d", w.Code)
+	}
+}
+
+func TestTimeout_ClientDeadlineAndReserve(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Timeout(10*time.Second, 100*time.Millisecond))
+	var remaining time.Duration
+	r.GET("/budget", func(c *gin.Context) {
+		deadline, _ := c.Request.Context().Deadline()
+		remaining = time.Until(deadline)
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, tc := range []struct {
+		header   string
+		code     int
+		min, max time.Duration
+	}{
+		{"", http.StatusNoContent, 9 * time.Second, 9900 * time.Millisecond},
+		{"2s", http.StatusNoContent, 1800 * time.Millisecond, 1900 * time.Millisecond},
+		{"1.5", http.StatusNoContent, 1300 * time.Millisecond, 1400 * time.Millisecond},
+		{"60s", http.StatusNoContent, 9 * time.Second, 9900 * time.Millisecond}, // cannot extend
+		{"50ms", http.StatusNoContent, 0, 45 * time.Millisecond},                // reserve shrinks to fit
+		{"", http.StatusNoContent, 9 * time.Second, 9900 * time.Millisecond},    // ...for that request only
+		{"soon", http.StatusBadRequest, 0, 0},
+		{"-1s", http.StatusBadRequest, 0, 0},
+	} {
+		remaining = 0
+		req := httptest.NewRequest(http.MethodGet, "/budget", nil)
+		if tc.header != "" {
+			req.Header.Set(RequestTimeoutHeader, tc.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Fatalf("%q: expected %d got %d", tc.header, tc.code, w.Code)
+		}
+		if tc.code == http.StatusNoContent && (remaining <= tc.min || remaining > tc.max) {
+			t.Fatalf("%q: remaining budget %v outside (%v, %v]", tc.header, remaining, tc.min, tc.max)
+		}
+	}
+}
+
+func TestTimeoutFrom_ReadsSourcePerRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	budget := time.Second
+	r := gin.New()
+	r.Use(TimeoutFrom(func() (time.Duration, time.Duration) { return budget, 0 }))
+	var remaining time.Duration
+	r.GET("/budget", func(c *gin.Context) {
+		deadline, _ := c.Request.Context().Deadline()
+		remaining = time.Until(deadline)
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, d := range []time.Duration{time.Second, 5 * time.Second} {
+		budget = d
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/budget", nil))
+		if remaining <= d-100*time.Millisecond || remaining > d {
+			t.Fatalf("expected a budget of %v, %v remaining", d, remaining)
+		}
+	}
+}
+
+func TestTimeoutFrom_ExemptRoutesHaveNoDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(TimeoutFrom(func() (time.Duration, time.Duration) { return time.Second, 0 }, "/stream"))
+	deadlines := map[string]bool{}
+	for _, path := range []string{"/stream", "/tasks"} {
+		r.GET(path, func(c *gin.Context) {
+			_, deadlines[path] = c.Request.Context().Deadline()
+			c.Status(http.StatusNoContent)
+		})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if deadlines["/stream"] || !deadlines["/tasks"] {
+		t.Fatalf("expected a deadline on /tasks only got %v", deadlines)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(BodyLimit(8))
+	r.POST("/echo", func(c *gin.Context) {
+		_, err := io.ReadAll(c.Request.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("DeclaredLengthTooLarge", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("0123456789")))
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("expected 413 got %d", w.Code)
+		}
+	})
+
+	t.Run("StreamedBodyTooLarge", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("0123456789"))
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("expected 413 got %d", w.Code)
+		}
+	})
+
+	t.Run("WithinLimit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("0123")))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200 got %d", w.Code)
+		}
+	})
+}
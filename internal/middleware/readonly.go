@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/problem"
+)
+
+// ReadOnly rejects writes (any method but GET, HEAD and OPTIONS) with 503
+// while on is set, e.g. during a maintenance window. Routes whose path
+// starts with one of the exempt prefixes, such as the admin routes that turn
+// the mode off again, are always let through.
+func ReadOnly(on *atomic.Bool, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !on.Load() {
+			c.Next()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		for _, prefix := range exempt {
+			if strings.HasPrefix(c.FullPath(), prefix) {
+				c.Next()
+				return
+			}
+		}
+		c.Header("Retry-After", "60")
+		problem.Abort(c, http.StatusServiceUnavailable, "the service is in read-only mode")
+	}
+}
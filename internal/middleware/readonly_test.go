@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadOnly_RejectsWritesWhileOn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var on atomic.Bool
+	r := gin.New()
+	api := r.Group("/api", ReadOnly(&on, "/api/admin"))
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	api.GET("/tasks", ok)
+	api.POST("/tasks", ok)
+	api.PUT("/admin/read-only", ok)
+
+	do := func(method, path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w.Code
+	}
+	if code := do(http.MethodPost, "/api/tasks"); code != http.StatusNoContent {
+		t.Fatalf("expected writes while off got %d", code)
+	}
+	on.Store(true)
+	if code := do(http.MethodPost, "/api/tasks"); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for a write while on got %d", code)
+	}
+	if code := do(http.MethodGet, "/api/tasks"); code != http.StatusNoContent {
+		t.Fatalf("expected reads while on got %d", code)
+	}
+	if code := do(http.MethodPut, "/api/admin/read-only"); code != http.StatusNoContent {
+		t.Fatalf("expected exempt routes while on got %d", code)
+	}
+}
@@ -0,0 +1,21 @@
+package dtos
+
+import "encoding/json"
+
+// BatchDTO is the body of POST /batch.
+type BatchDTO struct {
+	// Atomic runs the operations in one transaction: the first that fails
+	// rolls back those before it and the rest are not run.
+	Atomic     bool                `json:"atomic"`
+	Operations []BatchOperationDTO `json:"operations" binding:"required,min=1,dive"`
+}
+
+// BatchOperationDTO is one request of a batch. Path is relative to the API
+// root (/api/v1) and may carry a query string; Headers are added to those of
+// the batch request.
+type BatchOperationDTO struct {
+	Method  string            `json:"method" binding:"required,oneof=GET POST PUT DELETE"`
+	Path    string            `json:"path" binding:"required"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
@@ -0,0 +1,6 @@
+package dtos
+
+// BatchGetTasksDTO is the body of POST /tasks/batch-get.
+type BatchGetTasksDTO struct {
+	IDs []string `json:"ids" binding:"required,min=1"`
+}
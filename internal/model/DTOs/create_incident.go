@@ -0,0 +1,10 @@
+package dtos
+
+import "time"
+
+type CreateIncidentDTO struct {
+	Title       string     `json:"title" binding:"required"`
+	Description string     `json:"description"`
+	Severity    string     `json:"severity" binding:"omitempty,oneof=minor major critical"`
+	StartedAt   *time.Time `json:"started_at"`
+}
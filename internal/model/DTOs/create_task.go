@@ -6,10 +6,14 @@ import (
 )
 
 type CreateTaskDTO struct {
-	Title       string     `json:"title" binding:"required"`
-	Description *string    `json:"description,omitempty"`
+	Title       string     `json:"title" binding:"required,max=200"`
+	Description *string    `json:"description,omitempty" binding:"omitempty,max=10000"`
 	Assignee    *string    `json:"assignee,omitempty"`
-	DueDate     *time.Time `json:"due_date,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty" binding:"omitempty,recent"`
+	// EstimateMinutes is the original estimate of the effort.
+	EstimateMinutes *int64 `json:"estimate_minutes,omitempty" binding:"omitempty,min=0"`
+	// RemainingMinutes is the assignee's estimate of the effort left.
+	RemainingMinutes *int64 `json:"remaining_minutes,omitempty" binding:"omitempty,min=0"`
 }
 
 // ToModel converts the DTO into a domain Task ready to be used by services or repos.
@@ -26,5 +30,11 @@ func (d *CreateTaskDTO) ToModel() *model.Task {
 	if d.DueDate != nil {
 		t.SetDueDate(*d.DueDate)
 	}
+	if d.EstimateMinutes != nil {
+		t.SetEstimateMinutes(*d.EstimateMinutes)
+	}
+	if d.RemainingMinutes != nil {
+		t.SetRemainingMinutes(*d.RemainingMinutes)
+	}
 	return t
 }
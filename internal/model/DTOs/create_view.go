@@ -0,0 +1,50 @@
+package dtos
+
+import (
+	"time"
+
+	"taskmanager/internal/model"
+)
+
+// CreateViewDTO is the body of POST /views.
+type CreateViewDTO struct {
+	Name   string        `json:"name" binding:"required,max=200"`
+	Filter ViewFilterDTO `json:"filter"`
+}
+
+// ViewFilterDTO takes the GET /tasks filter params. Status is a convenience
+// alias for Completed, as in ReassignFilterDTO.
+type ViewFilterDTO struct {
+	Completed     *bool      `json:"completed,omitempty"`
+	Status        *string    `json:"status,omitempty" binding:"omitempty,oneof=open completed"`
+	Assignee      []string   `json:"assignee,omitempty"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	UpdatedAfter  *time.Time `json:"updated_after,omitempty"`
+	UpdatedBefore *time.Time `json:"updated_before,omitempty"`
+	DueAfter      *time.Time `json:"due_after,omitempty"`
+	DueBefore     *time.Time `json:"due_before,omitempty"`
+	Q             string     `json:"q,omitempty"`
+	Sort          string     `json:"sort,omitempty"`
+}
+
+// ToModel resolves Status into Completed. It fails when both are set and
+// disagree.
+func (f *ViewFilterDTO) ToModel() (model.ViewFilter, error) {
+	completed, err := (&ReassignFilterDTO{Completed: f.Completed, Status: f.Status}).CompletedFilter()
+	if err != nil {
+		return model.ViewFilter{}, err
+	}
+	return model.ViewFilter{
+		Completed:     completed,
+		Assignee:      f.Assignee,
+		CreatedAfter:  f.CreatedAfter,
+		CreatedBefore: f.CreatedBefore,
+		UpdatedAfter:  f.UpdatedAfter,
+		UpdatedBefore: f.UpdatedBefore,
+		DueAfter:      f.DueAfter,
+		DueBefore:     f.DueBefore,
+		Q:             f.Q,
+		Sort:          f.Sort,
+	}, nil
+}
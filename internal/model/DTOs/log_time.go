@@ -0,0 +1,9 @@
+package dtos
+
+import "time"
+
+// LogTimeDTO is the body of POST /tasks/:id/time-entries.
+type LogTimeDTO struct {
+	StartedAt time.Time `json:"started_at" binding:"required"`
+	EndedAt   time.Time `json:"ended_at" binding:"required"`
+}
@@ -0,0 +1,7 @@
+package dtos
+
+// MoveTaskDTO is the body of POST /tasks/:id/move. AfterID is a task id or
+// key; a missing or null one moves the task to the top of the manual order.
+type MoveTaskDTO struct {
+	AfterID *string `json:"after_id" binding:"omitempty,task_ref"`
+}
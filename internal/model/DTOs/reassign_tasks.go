@@ -0,0 +1,67 @@
+package dtos
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type ReassignTasksDTO struct {
+	Filter   ReassignFilterDTO `json:"filter"`
+	Assignee string            `json:"assignee" binding:"required"`
+}
+
+// ReassignFilterDTO selects the tasks to reassign with the GET /tasks
+// filters. Status is a convenience alias for Completed: "open" (not
+// completed) or "completed".
+type ReassignFilterDTO struct {
+	Assignee      Assignees  `json:"assignee,omitempty"`
+	Completed     *bool      `json:"completed,omitempty"`
+	Status        *string    `json:"status,omitempty"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	UpdatedAfter  *time.Time `json:"updated_after,omitempty"`
+	UpdatedBefore *time.Time `json:"updated_before,omitempty"`
+	DueAfter      *time.Time `json:"due_after,omitempty"`
+	DueBefore     *time.Time `json:"due_before,omitempty"`
+	Q             string     `json:"q,omitempty" binding:"max=200"`
+}
+
+// CompletedFilter resolves Completed/Status into a single completion filter.
+func (f *ReassignFilterDTO) CompletedFilter() (*bool, error) {
+	if f.Status == nil {
+		return f.Completed, nil
+	}
+	var v bool
+	switch *f.Status {
+	case "open":
+		v = false
+	case "completed":
+		v = true
+	default:
+		return nil, errors.New(`status must be "open" or "completed"`)
+	}
+	if f.Completed != nil && *f.Completed != v {
+		return nil, errors.New("status and completed filters conflict")
+	}
+	return &v, nil
+}
+
+// Assignees is the assignee filter: one name or a list of names, matching
+// tasks of any of them, with "none" for unassigned tasks as in GET /tasks.
+type Assignees []string
+
+// UnmarshalJSON accepts a single name as well as a list.
+func (a *Assignees) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*a = Assignees{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return errors.New("assignee must be a string or a list of strings")
+	}
+	*a = many
+	return nil
+}
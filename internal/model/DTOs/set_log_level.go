@@ -0,0 +1,9 @@
+package dtos
+
+// SetLogLevelDTO is the body of PUT /admin/log-level.
+type SetLogLevelDTO struct {
+	Level string `json:"level" binding:"required,oneof=debug info warn error"`
+	// LogBodiesFor is how long the access log carries request and response
+	// bodies, as a Go duration ("10m"); empty or "0s" turns that off.
+	LogBodiesFor string `json:"log_bodies_for,omitempty"`
+}
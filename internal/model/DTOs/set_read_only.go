@@ -0,0 +1,6 @@
+package dtos
+
+// SetReadOnlyDTO is the body of PUT /admin/read-only.
+type SetReadOnlyDTO struct {
+	ReadOnly *bool `json:"read_only" binding:"required"`
+}
@@ -6,26 +6,27 @@ import (
 )
 
 type UpdateTaskDTO struct {
-	Title       *string    `json:"title,omitempty"`
-	Description *string    `json:"description,omitempty"`
+	Title       *string    `json:"title,omitempty" binding:"omitempty,max=200"`
+	Description *string    `json:"description,omitempty" binding:"omitempty,max=10000"`
 	Assignee    *string    `json:"assignee,omitempty"`
 	Completed   *bool      `json:"completed,omitempty"`
-	DueDate     *time.Time `json:"due_date,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty" binding:"omitempty,recent"`
+	// EstimateMinutes is the original estimate of the effort.
+	EstimateMinutes *int64 `json:"estimate_minutes,omitempty" binding:"omitempty,min=0"`
+	// RemainingMinutes is the assignee's estimate of the effort left.
+	RemainingMinutes *int64 `json:"remaining_minutes,omitempty" binding:"omitempty,min=0"`
 }
 
 // Only fields that are non-nil in the DTO will be applied on the returned Task (nullable
-// fields are represented using model helpers).
+// fields are represented using model helpers). An empty description is kept
+// as a valid empty string, which the service reads as clearing it.
 func (d *UpdateTaskDTO) ToModel(id string) *model.Task {
 	t := &model.Task{ID: id}
 	if d.Title != nil {
 		t.Title = *d.Title
 	}
 	if d.Description != nil {
-		if *d.Description == "" {
-			t.ClearDescription()
-		} else {
-			t.SetDescription(*d.Description)
-		}
+		t.SetDescription(*d.Description)
 	}
 	if d.Assignee != nil {
 		if *d.Assignee != "" {
@@ -38,5 +39,11 @@ func (d *UpdateTaskDTO) ToModel(id string) *model.Task {
 	if d.DueDate != nil {
 		t.SetDueDate(*d.DueDate)
 	}
+	if d.EstimateMinutes != nil {
+		t.SetEstimateMinutes(*d.EstimateMinutes)
+	}
+	if d.RemainingMinutes != nil {
+		t.SetRemainingMinutes(*d.RemainingMinutes)
+	}
 	return t
 }
@@ -0,0 +1,49 @@
+package dtos
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"taskmanager/internal/model"
+)
+
+// MaxDueDateAge bounds how far in the past a due date may lie (the "recent"
+// rule); older dates are typos or zero values such as 0001-01-01.
+const MaxDueDateAge = 10 * 365 * 24 * time.Hour
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		RegisterValidations(v)
+	}
+}
+
+// RegisterValidations adds the custom rules the DTOs use to v and makes it
+// report fields by their JSON names. It runs on gin's validator at init.
+func RegisterValidations(v *validator.Validate) {
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	// Registration only fails for an empty tag or a nil func.
+	_ = v.RegisterValidation("recent", func(fl validator.FieldLevel) bool {
+		t, ok := fl.Field().Interface().(time.Time)
+		return !ok || !t.Before(time.Now().Add(-MaxDueDateAge))
+	})
+	// task_ref: a task's canonical UUID or its key (TM-1042)
+	_ = v.RegisterValidation("task_ref", func(fl validator.FieldLevel) bool {
+		s := fl.Field().String()
+		if _, ok := model.ParseKey(s); ok {
+			return true
+		}
+		_, err := uuid.Parse(s)
+		return err == nil && len(s) == 36
+	})
+}
@@ -0,0 +1,6 @@
+package dtos
+
+// WatchTaskDTO is the body of POST /tasks/:id/watchers.
+type WatchTaskDTO struct {
+	Watcher string `json:"watcher" binding:"required,max=255"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Incident is an operator supplied annotation shown on the status page.
+// ResolvedAt is nil while the incident is ongoing.
+type Incident struct {
+	ID          int64      `db:"id" json:"id"`
+	Title       string     `db:"title" json:"title"`
+	Description string     `db:"description" json:"description"`
+	Severity    string     `db:"severity" json:"severity"`
+	StartedAt   time.Time  `db:"started_at" json:"started_at"`
+	ResolvedAt  *time.Time `db:"resolved_at" json:"resolved_at"`
+}
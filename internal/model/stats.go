@@ -0,0 +1,33 @@
+package model
+
+// TaskTotals aggregates a set of tasks. The minute sums skip tasks without a
+// value.
+type TaskTotals struct {
+	Count            int   `db:"count" json:"count"`
+	Completed        int   `db:"completed" json:"completed"`
+	EstimateMinutes  int64 `db:"estimate_minutes" json:"estimate_minutes"`
+	RemainingMinutes int64 `db:"remaining_minutes" json:"remaining_minutes"`
+	TimeSpentSeconds int64 `db:"time_spent_seconds" json:"time_spent_seconds"`
+}
+
+// Add adds the totals of o to t.
+func (t *TaskTotals) Add(o TaskTotals) {
+	t.Count += o.Count
+	t.Completed += o.Completed
+	t.EstimateMinutes += o.EstimateMinutes
+	t.RemainingMinutes += o.RemainingMinutes
+	t.TimeSpentSeconds += o.TimeSpentSeconds
+}
+
+// AssigneeStats are the totals of one assignee; a nil Assignee stands for
+// the unassigned tasks.
+type AssigneeStats struct {
+	Assignee *string `db:"assignee" json:"assignee"`
+	TaskTotals
+}
+
+// TaskStats are the totals of a task list, overall and per assignee.
+type TaskStats struct {
+	TaskTotals
+	ByAssignee []AssigneeStats `json:"by_assignee"`
+}
@@ -2,22 +2,78 @@ package model
 
 import (
 	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// KeyPrefix starts every task key (TM-1042).
+const KeyPrefix = "TM-"
+
 // DB tags match the columns in the tasks table.
 // JSON marshaling and unmarshaling are implemented to render nullable DB types
 // (sql.NullString/sql.NullTime) as simple JSON values (string / RFC3339 time)
 // or null when not present.
 type Task struct {
-	ID          string         `db:"id" json:"id"`
+	ID string `db:"id" json:"id"`
+	// Number is the task's sequential number, assigned by the database on
+	// insert and never reused; see Key.
+	Number      int64          `db:"number" json:"number"`
 	Title       string         `db:"title" json:"title"`
 	Description sql.NullString `db:"description" json:"description"`
 	Assignee    sql.NullString `db:"assignee" json:"assignee"`
 	Completed   bool           `db:"completed" json:"completed"`
-	DueDate     sql.NullTime   `db:"due_date" json:"due_date"`
-	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+	// CompletedAt is when the task was last marked completed; null while open.
+	CompletedAt sql.NullTime `db:"completed_at" json:"completed_at"`
+	DueDate     sql.NullTime `db:"due_date" json:"due_date"`
+	// EstimateMinutes is the original estimate of the effort.
+	EstimateMinutes sql.NullInt64 `db:"estimate_minutes" json:"estimate_minutes"`
+	// RemainingMinutes is the effort left as reported by the assignee.
+	RemainingMinutes sql.NullInt64 `db:"remaining_minutes" json:"remaining_minutes"`
+	// Position is the task's rank in the manual order (ascending); only the
+	// order is meaningful, the values change when positions are rebalanced.
+	Position float64 `db:"position" json:"position"`
+	// TimeSpentSeconds is the time tracked on the task by stopped timers and
+	// manual time entries.
+	TimeSpentSeconds int64     `db:"time_spent_seconds" json:"time_spent_seconds"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Key returns the human-readable key of the task (TM-<number>), or "" before
+// the task has been stored.
+func (t Task) Key() string {
+	if t.Number <= 0 {
+		return ""
+	}
+	return KeyPrefix + strconv.FormatInt(t.Number, 10)
+}
+
+// ParseKey returns the number in a task key such as TM-1042, ignoring the
+// case of the prefix. ok is false when s is not a key.
+func ParseKey(s string) (number int64, ok bool) {
+	if len(s) <= len(KeyPrefix) || !strings.EqualFold(s[:len(KeyPrefix)], KeyPrefix) {
+		return 0, false
+	}
+	digits := s[len(KeyPrefix):]
+	if digits[0] < '1' || digits[0] > '9' {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// MarshalJSON adds the derived key to the task's fields.
+func (t Task) MarshalJSON() ([]byte, error) {
+	type task Task
+	return json.Marshal(struct {
+		task
+		Key string `json:"key,omitempty"`
+	}{task(t), t.Key()})
 }
 
 // SetDescription sets the description value and marks it valid.
@@ -49,3 +105,73 @@ func (t *Task) SetDueDate(dt time.Time) {
 func (t *Task) ClearDueDate() {
 	t.DueDate = sql.NullTime{Valid: false}
 }
+
+// SetEstimateMinutes sets the effort estimate and marks it valid.
+func (t *Task) SetEstimateMinutes(m int64) {
+	t.EstimateMinutes = sql.NullInt64{Int64: m, Valid: true}
+}
+
+// SetRemainingMinutes sets the remaining effort and marks it valid.
+func (t *Task) SetRemainingMinutes(m int64) {
+	t.RemainingMinutes = sql.NullInt64{Int64: m, Valid: true}
+}
+
+// ClearRemainingMinutes clears the remaining effort (sets it to null).
+func (t *Task) ClearRemainingMinutes() {
+	t.RemainingMinutes = sql.NullInt64{Valid: false}
+}
+
+// FieldChange describes a single field that differs between two versions of a task.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Diff returns the user-editable fields whose values differ between t and other,
+// keyed by JSON field name. Nullable fields are reported as plain values or nil.
+func (t *Task) Diff(other *Task) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	if t.Title != other.Title {
+		changes["title"] = FieldChange{From: t.Title, To: other.Title}
+	}
+	if t.Description != other.Description {
+		changes["description"] = FieldChange{From: nullStringValue(t.Description), To: nullStringValue(other.Description)}
+	}
+	if t.Assignee != other.Assignee {
+		changes["assignee"] = FieldChange{From: nullStringValue(t.Assignee), To: nullStringValue(other.Assignee)}
+	}
+	if t.Completed != other.Completed {
+		changes["completed"] = FieldChange{From: t.Completed, To: other.Completed}
+	}
+	if t.DueDate.Valid != other.DueDate.Valid || !t.DueDate.Time.Equal(other.DueDate.Time) {
+		changes["due_date"] = FieldChange{From: nullTimeValue(t.DueDate), To: nullTimeValue(other.DueDate)}
+	}
+	if t.EstimateMinutes != other.EstimateMinutes {
+		changes["estimate_minutes"] = FieldChange{From: nullInt64Value(t.EstimateMinutes), To: nullInt64Value(other.EstimateMinutes)}
+	}
+	if t.RemainingMinutes != other.RemainingMinutes {
+		changes["remaining_minutes"] = FieldChange{From: nullInt64Value(t.RemainingMinutes), To: nullInt64Value(other.RemainingMinutes)}
+	}
+	return changes
+}
+
+func nullStringValue(ns sql.NullString) interface{} {
+	if !ns.Valid {
+		return nil
+	}
+	return ns.String
+}
+
+func nullInt64Value(ni sql.NullInt64) interface{} {
+	if !ni.Valid {
+		return nil
+	}
+	return ni.Int64
+}
+
+func nullTimeValue(nt sql.NullTime) interface{} {
+	if !nt.Valid {
+		return nil
+	}
+	return nt.Time
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// TimeEntry is a span of time tracked on a task, either by a timer or
+// entered manually. A running timer has no EndedAt and zero Seconds.
+type TimeEntry struct {
+	ID     string `db:"id" json:"id"`
+	TaskID string `db:"task_id" json:"task_id"`
+	// Assignee is the task's assignee when the entry was recorded.
+	Assignee  *string    `db:"assignee" json:"assignee"`
+	StartedAt time.Time  `db:"started_at" json:"started_at"`
+	EndedAt   *time.Time `db:"ended_at" json:"ended_at"`
+	Seconds   int64      `db:"seconds" json:"seconds"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
+
+// TimeReportRow is the tracked time of one assignee in a time report; a nil
+// Assignee stands for entries recorded on unassigned tasks.
+type TimeReportRow struct {
+	Assignee *string `db:"assignee" json:"assignee"`
+	Seconds  int64   `db:"seconds" json:"seconds"`
+	Entries  int     `db:"entries" json:"entries"`
+}
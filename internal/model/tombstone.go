@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Tombstone records the deletion of a task, so sync clients and webhook
+// replay can tell a deleted task from one they never saw. Actor is who
+// deleted it (see logging.Actor), nil when unknown.
+type Tombstone struct {
+	TaskID    string    `db:"task_id" json:"task_id"`
+	DeletedAt time.Time `db:"deleted_at" json:"deleted_at"`
+	Actor     *string   `db:"actor" json:"actor,omitempty"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// View is a saved task list query; GET /views/:id/tasks runs it.
+type View struct {
+	ID        string     `db:"id" json:"id"`
+	Name      string     `db:"name" json:"name"`
+	Filter    ViewFilter `db:"filter" json:"filter"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// ViewFilter holds the GET /tasks query params of a view under the same
+// names. It is stored as a JSON document.
+type ViewFilter struct {
+	Completed     *bool      `json:"completed,omitempty"`
+	Assignee      []string   `json:"assignee,omitempty"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	UpdatedAfter  *time.Time `json:"updated_after,omitempty"`
+	UpdatedBefore *time.Time `json:"updated_before,omitempty"`
+	DueAfter      *time.Time `json:"due_after,omitempty"`
+	DueBefore     *time.Time `json:"due_before,omitempty"`
+	Q             string     `json:"q,omitempty"`
+	Sort          string     `json:"sort,omitempty"`
+}
+
+// Value implements driver.Valuer.
+func (f ViewFilter) Value() (driver.Value, error) {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (f *ViewFilter) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	}
+	return fmt.Errorf("cannot scan %T into ViewFilter", src)
+}
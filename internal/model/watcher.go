@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// Watcher is someone following the changes of a task. Like Task.Assignee the
+// name is free-form.
+type Watcher struct {
+	TaskID    string    `db:"task_id" json:"task_id"`
+	Watcher   string    `db:"watcher" json:"watcher"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
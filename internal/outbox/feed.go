@@ -0,0 +1,168 @@
+package outbox
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+)
+
+// Feed exposes the outbox as a change log: event ids are a monotonically
+// increasing sequence that clients resume from. Events stay readable after
+// the relay has published them.
+//
+// Ids are assigned at insert time, so a transaction that commits after a
+// later one makes an event with a smaller id appear late, and a client that
+// already resumed past it would never see it. The feed therefore holds back
+// events younger than its lag (see SetLag), which must exceed the time a
+// transaction stays open after writing its events.
+type Feed struct {
+	db       *sqlx.DB
+	interval time.Duration
+	lag      time.Duration
+}
+
+// NewFeed creates a Feed that polls every interval while waiting for changes.
+func NewFeed(db *sqlx.DB, interval time.Duration) *Feed {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &Feed{db: db, interval: interval}
+}
+
+// SetLag holds back events written less than lag ago, by the database's
+// clock. Call it before the feed is used. SQLite serializes writers, so its
+// ids commit in order and no lag is applied there.
+func (f *Feed) SetLag(lag time.Duration) {
+	f.lag = lag
+}
+
+// held is the SQL expression telling whether an event is younger than the
+// lag, taking the lag in microseconds as the placeholder ph. It is empty
+// when nothing is held back.
+func (f *Feed) held(d database.Dialect, ph string) string {
+	switch {
+	case f.lag <= 0 || d.SQLite():
+		return ""
+	case d.MySQL():
+		return "created_at > NOW(6) - INTERVAL " + ph + " MICROSECOND"
+	}
+	return "created_at > clock_timestamp() - " + ph + " * interval '1 microsecond'"
+}
+
+// feedRow is an event and whether it is still held back.
+type feedRow struct {
+	Event
+	Held bool `db:"held"`
+}
+
+// Since returns up to limit events with an id greater than seq, oldest
+// first. The page ends before the first event that is still held back, so
+// it never skips over an id that may yet commit.
+func (f *Feed) Since(ctx context.Context, seq int64, limit int) ([]Event, error) {
+	d := database.For(f.db)
+	held := f.held(d, "$1")
+	if held == "" {
+		query := `SELECT id, event_type, aggregate_id, payload, created_at, request_id
+FROM outbox WHERE id > $1 ORDER BY id LIMIT $2`
+		events := []Event{}
+		if err := f.db.SelectContext(ctx, &events, d.Rebind(query), seq, limit); err != nil {
+			return nil, err
+		}
+		return events, nil
+	}
+
+	query := `SELECT id, event_type, aggregate_id, payload, created_at, request_id, ` + held + ` AS held
+FROM outbox WHERE id > $2 ORDER BY id LIMIT $3`
+	var rows []feedRow
+	if err := f.db.SelectContext(ctx, &rows, d.Rebind(query), f.lag.Microseconds(), seq, limit); err != nil {
+		return nil, err
+	}
+	events := []Event{}
+	for _, r := range rows {
+		if r.Held {
+			break
+		}
+		events = append(events, r.Event)
+	}
+	return events, nil
+}
+
+// SeqAt returns the id of the last event written at or before t, 0 when
+// there is none, so that Since(SeqAt(t)) starts right after t. Events still
+// held back don't count, even when t is later.
+func (f *Feed) SeqAt(ctx context.Context, t time.Time) (int64, error) {
+	d := database.For(f.db)
+	var seq int64
+	query := "SELECT COALESCE(MAX(id), 0) FROM outbox WHERE created_at <= $1"
+	args := []interface{}{t.UTC()}
+	if held := f.held(d, "$2"); held != "" {
+		query += " AND NOT (" + held + ")"
+		args = append(args, f.lag.Microseconds())
+	}
+	if err := f.db.GetContext(ctx, &seq, d.Rebind(query), args...); err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+// Wait is Since, but when nothing newer than seq exists yet it polls until an
+// event arrives or wait has elapsed, in which case it returns no events. The
+// wait is cut short so the last poll still finishes before ctx's deadline.
+func (f *Feed) Wait(ctx context.Context, seq int64, limit int, wait time.Duration) ([]Event, error) {
+	stop := time.Now().Add(wait)
+	if dl, ok := ctx.Deadline(); ok && dl.Add(-f.interval).Before(stop) {
+		stop = dl.Add(-f.interval)
+	}
+	for {
+		events, err := f.Since(ctx, seq, limit)
+		if err != nil || len(events) > 0 {
+			return events, err
+		}
+		left := time.Until(stop)
+		if left <= 0 {
+			return events, nil
+		}
+		t := time.NewTimer(min(f.interval, left))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Activity returns up to limit events older than before, newest first; a
+// before of 0 starts at the latest event. When aggregateID is non-empty only
+// that task's events are returned. Pass the id of the last event as before
+// to read the next page.
+func (f *Feed) Activity(ctx context.Context, aggregateID string, before int64, limit int) ([]Event, error) {
+	d := database.For(f.db)
+	query := "SELECT id, event_type, aggregate_id, payload, created_at, request_id FROM outbox"
+	var conds []string
+	var args []interface{}
+	if aggregateID != "" {
+		args = append(args, aggregateID)
+		conds = append(conds, "aggregate_id = $"+strconv.Itoa(len(args)))
+	}
+	if before > 0 {
+		args = append(args, before)
+		conds = append(conds, "id < $"+strconv.Itoa(len(args)))
+	}
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	args = append(args, limit)
+	query += " ORDER BY id DESC LIMIT $" + strconv.Itoa(len(args))
+
+	events := []Event{}
+	if err := f.db.SelectContext(ctx, &events, d.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,130 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestFeedWait_PollsUntilEventsArrive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	feed := NewFeed(sqlx.NewDb(db, "sqlmock"), 10*time.Millisecond)
+
+	empty := sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "payload", "created_at"})
+	mock.ExpectQuery(`FROM outbox WHERE id > \$1 ORDER BY id LIMIT \$2`).WithArgs(5, 50).WillReturnRows(empty)
+	mock.ExpectQuery(`FROM outbox WHERE id > \$1 ORDER BY id LIMIT \$2`).WithArgs(5, 50).WillReturnRows(outboxRows())
+
+	events, err := feed.Wait(context.Background(), 5, 50, time.Second)
+	if err != nil || len(events) != 2 || events[1].ID != 2 {
+		t.Fatalf("unexpected events %v err=%v", events, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestFeedSince_StopsAtHeldEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	feed := NewFeed(sqlx.NewDb(db, "sqlmock"), time.Second)
+	feed.SetLag(2 * time.Second)
+
+	// 4 is still too young, so 5 may have overtaken an id that hasn't
+	// committed yet and must wait as well
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "payload", "created_at", "held"}).
+		AddRow(3, EventTaskCreated, "t1", []byte(`{}`), now, false).
+		AddRow(4, EventTaskUpdated, "t1", []byte(`{}`), now, true).
+		AddRow(5, EventTaskCreated, "t2", []byte(`{}`), now, false)
+	mock.ExpectQuery(`created_at > clock_timestamp\(\) - \$1 \* interval '1 microsecond' AS held\s+FROM outbox WHERE id > \$2 ORDER BY id LIMIT \$3`).
+		WithArgs(int64(2_000_000), 2, 10).WillReturnRows(rows)
+	mock.ExpectQuery(`FROM outbox WHERE created_at <= \$1 AND NOT \(created_at > clock_timestamp\(\) - \$2 \* interval '1 microsecond'\)`).
+		WithArgs(sqlmock.AnyArg(), int64(2_000_000)).WillReturnRows(sqlmock.NewRows([]string{"seq"}).AddRow(3))
+
+	events, err := feed.Since(context.Background(), 2, 10)
+	if err != nil || len(events) != 1 || events[0].ID != 3 {
+		t.Fatalf("expected only event 3 got %v err=%v", events, err)
+	}
+	if seq, err := feed.SeqAt(context.Background(), now); err != nil || seq != 3 {
+		t.Fatalf("expected 3 got %d err=%v", seq, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestFeedWait_ReturnsEmptyBeforeDeadline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	feed := NewFeed(sqlx.NewDb(db, "sqlmock"), 10*time.Millisecond)
+	for range 20 {
+		mock.ExpectQuery("FROM outbox").WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "payload", "created_at"}))
+	}
+
+	// the deadline is shorter than the requested wait: expect an empty page, not an error
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	events, err := feed.Wait(ctx, 0, 10, time.Minute)
+	if err != nil || len(events) != 0 {
+		t.Fatalf("expected an empty page got %v err=%v", events, err)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("expected Wait to return before the deadline")
+	}
+}
+
+func TestFeedActivity_FiltersAndPagesBackwards(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	feed := NewFeed(sqlx.NewDb(db, "sqlmock"), time.Second)
+
+	mock.ExpectQuery(`FROM outbox ORDER BY id DESC LIMIT \$1`).WithArgs(20).WillReturnRows(outboxRows())
+	mock.ExpectQuery(`FROM outbox WHERE aggregate_id = \$1 AND id < \$2 ORDER BY id DESC LIMIT \$3`).WithArgs("t1", 2, 20).WillReturnRows(outboxRows())
+
+	if _, err := feed.Activity(context.Background(), "", 0, 20); err != nil {
+		t.Fatalf("activity: %v", err)
+	}
+	if _, err := feed.Activity(context.Background(), "t1", 2, 20); err != nil {
+		t.Fatalf("task activity: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestFeedSeqAt(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	feed := NewFeed(sqlx.NewDb(db, "sqlmock"), time.Second)
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(id\), 0\) FROM outbox WHERE created_at <= \$1`).
+		WithArgs(at.UTC()).WillReturnRows(sqlmock.NewRows([]string{"seq"}).AddRow(42))
+
+	seq, err := feed.SeqAt(context.Background(), at)
+	if err != nil || seq != 42 {
+		t.Fatalf("expected 42 got %d err=%v", seq, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+	"taskmanager/internal/logging"
+)
+
+// Domain event types written to the outbox.
+const (
+	EventTaskCreated    = "task.created"
+	EventTaskUpdated    = "task.updated"
+	EventTaskDeleted    = "task.deleted"
+	EventTaskReassigned = "task.reassigned"
+	EventTaskCompleted  = "task.completed"
+	EventTaskReopened   = "task.reopened"
+	EventTaskMoved      = "task.moved"
+	EventTaskWatched    = "task.watched"
+	EventTaskUnwatched  = "task.unwatched"
+	EventTaskTimeLogged = "task.time_logged"
+)
+
+// Event is a single row of the outbox table.
+type Event struct {
+	ID          int64           `db:"id" json:"id"`
+	Type        string          `db:"event_type" json:"type"`
+	AggregateID string          `db:"aggregate_id" json:"aggregate_id"`
+	Payload     json.RawMessage `db:"payload" json:"payload"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+	// RequestID is the X-Request-ID of the API request that caused the
+	// event, nil when it was written outside a request.
+	RequestID *string `db:"request_id" json:"request_id,omitempty"`
+}
+
+// Publisher delivers outbox events to a downstream broker (Kafka, NATS, ...).
+// Publish must only return nil once the broker has accepted the event.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// Insert writes an event to the outbox using the given executor. Pass the
+// transaction that performs the data change so both commit (or roll back) together.
+// The request id in ctx, if any, is recorded with the event.
+func Insert(ctx context.Context, exec sqlx.ExtContext, eventType, aggregateID string, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var requestID *string
+	if id := logging.RequestID(ctx); id != "" {
+		requestID = &id
+	}
+	query := database.For(exec).Rebind("INSERT INTO outbox (event_type, aggregate_id, payload, request_id) VALUES ($1, $2, $3, $4)")
+	_, err = exec.ExecContext(ctx, query, eventType, aggregateID, b, requestID)
+	return err
+}
@@ -0,0 +1,37 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/logging"
+)
+
+func TestInsert_RecordsRequestID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	x := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectExec(`INSERT INTO outbox \(event_type, aggregate_id, payload, request_id\)`).
+		WithArgs(EventTaskDeleted, "t1", []byte(`{"id":"t1"}`), nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := Insert(context.Background(), x, EventTaskDeleted, "t1", map[string]string{"id": "t1"}); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	id := "req-1"
+	mock.ExpectExec(`INSERT INTO outbox`).
+		WithArgs(EventTaskDeleted, "t1", sqlmock.AnyArg(), &id).WillReturnResult(sqlmock.NewResult(2, 1))
+	ctx := logging.WithRequestID(context.Background(), id)
+	if err := Insert(ctx, x, EventTaskDeleted, "t1", map[string]string{"id": "t1"}); err != nil {
+		t.Fatalf("insert with request id: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogPublisher writes events to the default structured logger. Useful for
+// local development when no broker is available.
+type LogPublisher struct{}
+
+func (LogPublisher) Publish(ctx context.Context, e Event) error {
+	slog.InfoContext(ctx, "outbox event",
+		"event_id", e.ID,
+		"type", e.Type,
+		"aggregate_id", e.AggregateID,
+		"payload", e.Payload,
+		"request_id", e.RequestID,
+	)
+	return nil
+}
+
+// NATSPublisher publishes events to NATS core using the plain text protocol.
+// Each event is sent to subject <prefix><event type> (e.g. "taskmanager.task.created")
+// and followed by a PING so Publish only returns once the server has processed it.
+type NATSPublisher struct {
+	addr    string
+	prefix  string
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewNATSPublisher creates a publisher for a server address like
+// "localhost:4222" or "nats://localhost:4222". The connection is opened lazily.
+func NewNATSPublisher(addr, subjectPrefix string) *NATSPublisher {
+	return &NATSPublisher{
+		addr:    strings.TrimPrefix(addr, "nats://"),
+		prefix:  subjectPrefix,
+		timeout: 5 * time.Second,
+	}
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.connect(ctx); err != nil {
+		return err
+	}
+	if err := p.publish(p.prefix+e.Type, b); err != nil {
+		p.closeConn()
+		return err
+	}
+	return nil
+}
+
+// Close closes the underlying connection, if any.
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closeConn()
+	return nil
+}
+
+func (p *NATSPublisher) connect(ctx context.Context) error {
+	if p.conn != nil {
+		return nil
+	}
+	d := net.Dialer{Timeout: p.timeout}
+	conn, err := d.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return fmt.Errorf("nats dial %s: %w", p.addr, err)
+	}
+	p.conn = conn
+	p.r = bufio.NewReader(conn)
+
+	// server greets with INFO {...}
+	_ = conn.SetDeadline(time.Now().Add(p.timeout))
+	line, err := p.r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO") {
+		p.closeConn()
+		return fmt.Errorf("nats handshake: unexpected greeting %q: %v", strings.TrimSpace(line), err)
+	}
+	if _, err := conn.Write([]byte("CONNECT {\"verbose\":false,\"pedantic\":false,\"name\":\"taskmanager-outbox\"}\r\n")); err != nil {
+		p.closeConn()
+		return err
+	}
+	return nil
+}
+
+func (p *NATSPublisher) publish(subject string, data []byte) error {
+	_ = p.conn.SetDeadline(time.Now().Add(p.timeout))
+	msg := fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", subject, len(data), data)
+	if _, err := p.conn.Write([]byte(msg)); err != nil {
+		return err
+	}
+	for {
+		line, err := p.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats: %s", line)
+		}
+	}
+}
+
+func (p *NATSPublisher) closeConn() {
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+	p.conn = nil
+	p.r = nil
+}
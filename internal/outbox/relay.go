@@ -0,0 +1,124 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"taskmanager/internal/database"
+)
+
+// Relay polls the outbox table and hands unpublished events to a Publisher.
+// Rows are claimed with FOR UPDATE SKIP LOCKED, so relays running side by
+// side never deliver the same event twice, but they publish different
+// batches in no set order. Events are published in id order only while a
+// single relay runs: with several replicas, gate Run on a leader election
+// (see SetLeader), and expect a batch still in flight at a leader change to
+// overlap the new leader's. On SQLite, which serializes writers, a single
+// relay is expected.
+type Relay struct {
+	db        *sqlx.DB
+	pub       Publisher
+	interval  time.Duration
+	batchSize int
+	// leader reports whether this instance may publish; nil means always
+	leader func() bool
+}
+
+// NewRelay creates a Relay that polls every interval.
+func NewRelay(db *sqlx.DB, pub Publisher, interval time.Duration) *Relay {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &Relay{db: db, pub: pub, interval: interval, batchSize: 100}
+}
+
+// SetLeader makes Run skip its ticks unless isLeader reports true, so with
+// several replicas one relay publishes at a time. Call it before Run.
+func (r *Relay) SetLeader(isLeader func() bool) {
+	r.leader = isLeader
+}
+
+// Run relays events until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		// drain the backlog before waiting for the next tick
+		for r.leader == nil || r.leader() {
+			n, err := r.RelayOnce(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Error("outbox relay failed", "err", err)
+				}
+				break
+			}
+			if n < r.batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce publishes a single batch of events in id order and marks the
+// delivered ones as published. It stops at the first publish failure so the
+// batch's later events aren't published ahead of it; the failed event is
+// retried on the next call.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	d := database.For(r.db)
+	query := `SELECT id, event_type, aggregate_id, payload, created_at, request_id
+FROM outbox WHERE published_at IS NULL ORDER BY id LIMIT $1`
+	if !d.SQLite() {
+		query += " FOR UPDATE SKIP LOCKED"
+	}
+	var events []Event
+	if err := tx.SelectContext(ctx, &events, d.Rebind(query), r.batchSize); err != nil {
+		return 0, err
+	}
+
+	ids := make([]int64, 0, len(events))
+	var pubErr error
+	for _, e := range events {
+		if pubErr = r.pub.Publish(ctx, e); pubErr != nil {
+			break
+		}
+		ids = append(ids, e.ID)
+	}
+
+	if len(ids) > 0 {
+		if err := markPublished(ctx, tx, d, ids); err != nil {
+			return 0, err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), pubErr
+}
+
+func markPublished(ctx context.Context, tx *sqlx.Tx, d database.Dialect, ids []int64) error {
+	if d.Postgres() {
+		_, err := tx.ExecContext(ctx, "UPDATE outbox SET published_at = now() WHERE id = ANY($1)", pq.Array(ids))
+		return err
+	}
+	query, args, err := sqlx.In("UPDATE outbox SET published_at = "+d.Now()+" WHERE id IN (?)", ids)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, tx.Rebind(query), args...)
+	return err
+}
@@ -0,0 +1,130 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+type fakePublisher struct {
+	published []Event
+	failOn    int64
+}
+
+func (f *fakePublisher) Publish(_ context.Context, e Event) error {
+	if e.ID == f.failOn {
+		return errors.New("broker down")
+	}
+	f.published = append(f.published, e)
+	return nil
+}
+
+func outboxRows() *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows([]string{"id", "event_type", "aggregate_id", "payload", "created_at"}).
+		AddRow(1, EventTaskCreated, "t1", []byte(`{"id":"t1"}`), now).
+		AddRow(2, EventTaskUpdated, "t1", []byte(`{"id":"t1"}`), now)
+}
+
+func TestRelayOnce_PublishesAndMarks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+
+	pub := &fakePublisher{}
+	relay := NewRelay(sqlx.NewDb(db, "sqlmock"), pub, time.Second)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, event_type, aggregate_id, payload, created_at").WillReturnRows(outboxRows())
+	mock.ExpectExec("UPDATE outbox SET published_at").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	n, err := relay.RelayOnce(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if n != 2 || len(pub.published) != 2 {
+		t.Fatalf("expected 2 published got n=%d published=%d", n, len(pub.published))
+	}
+	if string(pub.published[0].Payload) != `{"id":"t1"}` {
+		t.Fatalf("unexpected payload %s", pub.published[0].Payload)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestRelayOnce_StopsAtFirstFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+
+	pub := &fakePublisher{failOn: 2}
+	relay := NewRelay(sqlx.NewDb(db, "sqlmock"), pub, time.Second)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, event_type, aggregate_id, payload, created_at").WillReturnRows(outboxRows())
+	mock.ExpectExec("UPDATE outbox SET published_at").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err := relay.RelayOnce(context.Background())
+	if err == nil {
+		t.Fatalf("expected publish error")
+	}
+	if n != 1 {
+		t.Fatalf("expected only the first event marked, got %d", n)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestRelayRun_OnlyOnLeader(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+
+	pub := &fakePublisher{}
+	relay := NewRelay(sqlx.NewDb(db, "sqlmock"), pub, 10*time.Millisecond)
+	var leader atomic.Bool
+	relay.SetLeader(leader.Load)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, event_type, aggregate_id, payload, created_at").WillReturnRows(outboxRows())
+	mock.ExpectExec("UPDATE outbox SET published_at").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		relay.Run(ctx)
+		close(done)
+	}()
+	// a follower leaves the outbox alone
+	time.Sleep(50 * time.Millisecond)
+	if err := mock.ExpectationsWereMet(); err == nil {
+		t.Fatalf("expected no query while not the leader")
+	}
+
+	leader.Store(true)
+	deadline := time.Now().Add(time.Second)
+	for mock.ExpectationsWereMet() != nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+// Package problem renders error responses as RFC 7807 problem details
+// (application/problem+json).
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"taskmanager/internal/logging"
+)
+
+// ContentType is the media type of problem responses.
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	// Type identifies the problem type; "about:blank" means the status code
+	// says it all and Title is its reason phrase.
+	Type     string
+	Title    string
+	Status   int
+	Detail   string
+	Instance string
+	// Errors lists per-field validation failures.
+	Errors []FieldError
+	// Extensions are extra members serialized next to the standard ones.
+	Extensions map[string]interface{}
+}
+
+// FieldError describes one invalid request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+// New returns an "about:blank" problem for status with a human-readable
+// detail.
+func New(status int, detail string) *Problem {
+	return &Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail}
+}
+
+// With sets the extension member key and returns p.
+func (p *Problem) With(key string, v interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = map[string]interface{}{}
+	}
+	p.Extensions[key] = v
+	return p
+}
+
+// WithErrors attaches field errors and returns p.
+func (p *Problem) WithErrors(errs ...FieldError) *Problem {
+	p.Errors = append(p.Errors, errs...)
+	return p
+}
+
+// members are the standard problem members in their RFC 7807 order.
+type members struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// MarshalJSON writes the standard members followed by the extensions.
+// Extensions named like a standard member are dropped.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(members{p.Type, p.Title, p.Status, p.Detail, p.Instance, p.Errors})
+	if err != nil {
+		return nil, err
+	}
+	ext := make(map[string]interface{}, len(p.Extensions))
+	for k, v := range p.Extensions {
+		switch k {
+		case "type", "title", "status", "detail", "instance", "errors":
+		default:
+			ext[k] = v
+		}
+	}
+	if len(ext) == 0 {
+		return b, nil
+	}
+	e, err := json.Marshal(ext)
+	if err != nil {
+		return nil, err
+	}
+	// splice {"a":1} and {"b":2} into {"a":1,"b":2}
+	return append(append(b[:len(b)-1], ','), e[1:]...), nil
+}
+
+// UnmarshalJSON is the inverse of MarshalJSON: unknown members land in
+// Extensions.
+func (p *Problem) UnmarshalJSON(b []byte) error {
+	var m members
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return err
+	}
+	*p = Problem{Type: m.Type, Title: m.Title, Status: m.Status, Detail: m.Detail, Instance: m.Instance, Errors: m.Errors}
+	for k, raw := range all {
+		switch k {
+		case "type", "title", "status", "detail", "instance", "errors":
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		p.With(k, v)
+	}
+	return nil
+}
+
+// Render writes p and aborts the handler chain. Instance defaults to the
+// request path, and the request id (see logging.Middleware) is added as the
+// request_id extension so a reported error can be found in the logs. A 5xx
+// problem also gets an incident_id, the id it is logged and reported to
+// error tracking under.
+func Render(c *gin.Context, p *Problem) {
+	if c.Request != nil {
+		if p.Instance == "" {
+			p.Instance = c.Request.URL.Path
+		}
+		if id := logging.RequestID(c.Request.Context()); id != "" {
+			p.With("request_id", id)
+		}
+	}
+	if p.Status >= http.StatusInternalServerError {
+		p.With("incident_id", IncidentID(c))
+	}
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// IncidentID returns the incident id of the request, assigning one (in the
+// 32 hex digit form of a Sentry event id) on first use.
+func IncidentID(c *gin.Context) string {
+	id := c.GetString(logging.IncidentIDKey)
+	if id == "" {
+		id = strings.ReplaceAll(uuid.NewString(), "-", "")
+		c.Set(logging.IncidentIDKey, id)
+	}
+	return id
+}
+
+// Abort writes an "about:blank" problem for status and aborts the handler
+// chain.
+func Abort(c *gin.Context, status int, detail string) {
+	Render(c, New(status, detail))
+}
@@ -0,0 +1,79 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/logging"
+)
+
+func TestRender(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/tasks?x=1", nil)
+
+	Render(c, New(http.StatusConflict, "alice already has 3 open tasks").
+		With("limit", 3).
+		With("status", "ignored").
+		WithErrors(FieldError{Field: "assignee", Rule: "wip_limit", Message: "too many open tasks"}))
+
+	if w.Code != http.StatusConflict || w.Header().Get("Content-Type") != ContentType || !c.IsAborted() {
+		t.Fatalf("unexpected response %d %q aborted=%v", w.Code, w.Header().Get("Content-Type"), c.IsAborted())
+	}
+	want := `{"type":"about:blank","title":"Conflict","status":409,"detail":"alice already has 3 open tasks","instance":"/api/v1/tasks",` +
+		`"errors":[{"field":"assignee","rule":"wip_limit","message":"too many open tasks"}],"limit":3}`
+	if w.Body.String() != want {
+		t.Fatalf("unexpected body\n got %s\nwant %s", w.Body.String(), want)
+	}
+
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if p.Status != http.StatusConflict || p.Instance != "/api/v1/tasks" || len(p.Errors) != 1 || p.Extensions["limit"] != 3.0 {
+		t.Fatalf("unexpected round trip %+v", p)
+	}
+}
+
+func TestRender_RequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/tasks/x", nil)
+	c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), "req-1"))
+
+	Abort(c, http.StatusNotFound, "task not found")
+
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if p.Extensions["request_id"] != "req-1" {
+		t.Fatalf("expected request_id in %s", w.Body.String())
+	}
+}
+
+func TestRender_IncidentID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for status, want := range map[int]bool{http.StatusNotFound: false, http.StatusInternalServerError: true, http.StatusServiceUnavailable: true} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/tasks/x", nil)
+
+		Abort(c, status, "failed")
+
+		var p Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		id, ok := p.Extensions["incident_id"]
+		if ok != want || (want && id != c.GetString(logging.IncidentIDKey)) {
+			t.Fatalf("status %d: unexpected incident_id in %s", status, w.Body.String())
+		}
+	}
+}
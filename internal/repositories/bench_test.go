@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"taskmanager/internal/database"
+	"taskmanager/internal/model"
+	"taskmanager/migrations"
+)
+
+// The list and create benchmarks run on a SQLite file, uncached, with the L1
+// cache, and with Redis at REDIS_TEST_ADDR (which they flush) when set:
+//
+//	go test ./internal/repositories -run '^$' -bench . -benchmem
+//
+// Compare runs with benchstat to catch regressions in the query builder or
+// the cache.
+
+// benchTasks is the number of tasks the list benchmarks query.
+const benchTasks = 1000
+
+// benchRepo returns a repository on a migrated SQLite database holding n
+// tasks, and the Redis client of REDIS_TEST_ADDR (nil when not set).
+func benchRepo(b *testing.B, n int) (TaskRepository, *redis.Client) {
+	b.Helper()
+	ctx := context.Background()
+	db, err := database.Open(database.SQLite, filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatalf("open: %v", err)
+	}
+	b.Cleanup(func() { db.Close() })
+	m, err := migrations.New(db)
+	if err != nil {
+		b.Fatalf("migrations: %v", err)
+	}
+	if err := m.Up(ctx); err != nil {
+		b.Fatalf("migrate: %v", err)
+	}
+	repo := NewTaskRepository(db)
+	due := time.Now().Add(24 * time.Hour)
+	for i := range n {
+		t := &model.Task{Title: fmt.Sprintf("task %d", i), Completed: i%3 == 0}
+		if i%4 != 0 {
+			t.SetAssignee([]string{"alice", "bob", "carol"}[i%3])
+		}
+		if i%2 == 0 {
+			t.SetDueDate(due.Add(time.Duration(i) * time.Hour))
+		}
+		if err := repo.Create(ctx, t); err != nil {
+			b.Fatalf("create: %v", err)
+		}
+	}
+
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		return repo, nil
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	b.Cleanup(func() {
+		rdb.FlushDB(context.Background())
+		rdb.Close()
+	})
+	if err := rdb.FlushDB(ctx).Err(); err != nil {
+		b.Fatalf("redis: %v", err)
+	}
+	return repo, rdb
+}
+
+// cacheMode is one cache setup of the benchmarks.
+type cacheMode struct {
+	name  string
+	setup func()
+}
+
+// cacheModes returns the cache setups of repo to benchmark.
+func cacheModes(repo TaskRepository, rdb *redis.Client) []cacheMode {
+	r := repo.(*taskRepo)
+	modes := []cacheMode{
+		{"uncached", func() {
+			r.SetCacheClient(nil)
+			r.SetCacheOptions(CacheOptions{})
+		}},
+		{"l1", func() {
+			r.SetCacheClient(nil)
+			r.SetCacheOptions(CacheOptions{LocalMaxEntries: 1000, LocalTTL: time.Minute})
+		}},
+	}
+	if rdb != nil {
+		modes = append(modes, cacheMode{"redis", func() {
+			r.SetCacheOptions(CacheOptions{})
+			r.SetCacheClient(rdb)
+		}})
+	}
+	return modes
+}
+
+func BenchmarkList(b *testing.B) {
+	repo, rdb := benchRepo(b, benchTasks)
+	ctx := context.Background()
+	open := false
+	filters := []struct {
+		name      string
+		completed *bool
+		assignee  AssigneeFilter
+		title     string
+	}{
+		{"all", nil, AssigneeFilter{}, ""},
+		{"filtered", &open, AssigneeFilter{Names: []string{"alice", "bob"}, Unassigned: true}, "task 1"},
+	}
+	for _, mode := range cacheModes(repo, rdb) {
+		mode.setup()
+		for _, f := range filters {
+			b.Run(mode.name+"/"+f.name, func(b *testing.B) {
+				for b.Loop() {
+					if _, err := repo.List(ctx, ListOptions{Filter: TaskFilter{Completed: f.completed, Assignee: f.assignee, Title: f.title}, Limit: 50}); err != nil {
+						b.Fatalf("list: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkCreate includes the invalidation of the cached lists.
+func BenchmarkCreate(b *testing.B) {
+	repo, rdb := benchRepo(b, 0)
+	ctx := context.Background()
+	for _, mode := range cacheModes(repo, rdb) {
+		mode.setup()
+		b.Run(mode.name, func(b *testing.B) {
+			for b.Loop() {
+				// a cached page for the create to invalidate
+				if _, err := repo.List(ctx, ListOptions{Limit: 10}); err != nil {
+					b.Fatalf("list: %v", err)
+				}
+				t := &model.Task{Title: "bench"}
+				t.SetAssignee("alice")
+				if err := repo.Create(ctx, t); err != nil {
+					b.Fatalf("create: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkListQuery measures building the SQL and the cache key of a list
+// with every filter set.
+func BenchmarkListQuery(b *testing.B) {
+	r := &taskRepo{d: database.Dialect{}} // PostgreSQL
+	open := false
+	now := time.Now()
+	dates := DateRange{CreatedAfter: &now, DueBefore: &now}
+	assignee := AssigneeFilter{Names: []string{"alice", "bob"}, Unassigned: true}
+	sort, _ := ParseSort("due_date asc nulls last, created_at desc")
+	for b.Loop() {
+		qb := &queryBuilder{d: r.d}
+		TaskFilter{Completed: &open, Assignee: assignee, Dates: dates, Title: "report"}.apply(qb)
+		_ = r.d.Rebind(selectTaskFields(nil) + qb.WhereClause() + orderByClause(sort, r.d) + " LIMIT " + qb.Arg(50) + " OFFSET " + qb.Arg(0))
+		_ = r.cacheKeyForList(ListOptions{Filter: TaskFilter{Completed: &open, Assignee: assignee, Dates: dates, Title: "report"}, Sort: sort, Limit: 50})
+	}
+}
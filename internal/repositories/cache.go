@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"taskmanager/internal/logging"
+	"taskmanager/internal/metric"
+	"taskmanager/internal/model"
+)
+
+// defaultListTTL is used for cached list pages when no TTL was configured.
+const defaultListTTL = 60 * time.Second
+
+// defaultItemTTL is used for cached single tasks when no TTL was configured.
+const defaultItemTTL = 60 * time.Second
+
+// notFoundMarker is cached under tasks:id:<id> for recently missed ids
+// (negative caching).
+const notFoundMarker = "!notfound"
+
+// CacheOptions controls the Redis cache-aside layer of the task repository.
+type CacheOptions struct {
+	ListTTL time.Duration
+	ItemTTL time.Duration
+	// NegativeTTL > 0 enables caching of GetByID misses.
+	NegativeTTL time.Duration
+	// Jitter adds a random extra TTL in [0, Jitter) to every entry so keys
+	// written together don't expire together.
+	Jitter time.Duration
+	// StaleTTL > 0 keeps list pages for this long past their TTL; stale pages
+	// are served while being refreshed in the background.
+	StaleTTL time.Duration
+	// LocalMaxEntries > 0 enables the in-process L1 cache in front of Redis
+	// (also used alone while Redis is unavailable). Entries live for at most
+	// LocalTTL, which bounds how stale other instances' writes can appear.
+	LocalMaxEntries int
+	LocalTTL        time.Duration
+	// PubSub publishes every invalidation on Redis so other instances drop
+	// their L1 copies right away instead of after LocalTTL (see
+	// ListenInvalidations).
+	PubSub bool
+}
+
+func (o CacheOptions) listTTL() time.Duration {
+	ttl := o.ListTTL
+	if ttl <= 0 {
+		ttl = defaultListTTL
+	}
+	return ttl + o.jitter()
+}
+
+func (o CacheOptions) itemTTL() time.Duration {
+	ttl := o.ItemTTL
+	if ttl <= 0 {
+		ttl = defaultItemTTL
+	}
+	return ttl + o.jitter()
+}
+
+func (o CacheOptions) jitter() time.Duration {
+	if o.Jitter <= 0 {
+		return 0
+	}
+	return rand.N(o.Jitter)
+}
+
+// cachedList is the list cache format used when stale serving is enabled.
+type cachedList struct {
+	FreshUntil time.Time    `json:"fresh_until"`
+	Items      []model.Task `json:"items"`
+}
+
+func (c cachedList) stale() bool {
+	return !c.FreshUntil.IsZero() && time.Now().After(c.FreshUntil)
+}
+
+// decodeCachedList accepts both the envelope and the plain items array.
+func decodeCachedList(s string) (cachedList, bool) {
+	var c cachedList
+	if strings.HasPrefix(s, "{") {
+		err := json.Unmarshal([]byte(s), &c)
+		return c, err == nil
+	}
+	err := json.Unmarshal([]byte(s), &c.Items)
+	return c, err == nil
+}
+
+// cacheGet looks key up in the local cache, then in Redis; a Redis hit is
+// copied into the local cache. name labels the metrics ("list" or "item").
+func (r *taskRepo) cacheGet(ctx context.Context, key, name string) (string, bool) {
+	if r.local != nil {
+		if v, ok := r.local.Get(key); ok {
+			metric.CacheHits.WithLabelValues("local").Inc()
+			return v, true
+		}
+		metric.CacheMisses.WithLabelValues("local").Inc()
+	}
+	rdb := r.cacheClient()
+	if rdb == nil {
+		return "", false
+	}
+	start := time.Now()
+	s, err := rdb.Get(ctx, key).Result()
+	metric.ObserveRedis("get", start)
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logging.FromContext(ctx).Warn(name+" cache read failed", "key", key, "err", err)
+		}
+		metric.CacheMisses.WithLabelValues(name).Inc()
+		return "", false
+	}
+	metric.CacheHits.WithLabelValues(name).Inc()
+	if r.local != nil {
+		r.local.Set(key, s, r.cacheOptions().LocalTTL)
+	}
+	return s, true
+}
+
+// cacheSet writes key to the local cache (capped at LocalTTL) and to Redis.
+func (r *taskRepo) cacheSet(ctx context.Context, key, val string, ttl time.Duration, name string) {
+	if r.local != nil {
+		r.local.Set(key, val, min(ttl, r.cacheOptions().LocalTTL))
+	}
+	rdb := r.cacheClient()
+	if rdb == nil {
+		return
+	}
+	start := time.Now()
+	err := rdb.Set(ctx, key, val, ttl).Err()
+	metric.ObserveRedis("set", start)
+	if err != nil {
+		logging.FromContext(ctx).Warn(name+" cache write failed", "key", key, "err", err)
+		return
+	}
+	metric.CacheSets.WithLabelValues(name).Inc()
+}
+
+// FlushCache drops every cached task entry, lists and items, from the local
+// cache and from Redis, and returns the number of Redis keys removed. Unlike
+// invalidation it reports Redis errors, since an operator asked for it.
+func (r *taskRepo) FlushCache(ctx context.Context) (int, error) {
+	if r.local != nil {
+		r.local.DeletePrefix("tasks:")
+	}
+	r.publishInvalidation(ctx, invalidationMessage{All: true})
+	rdb := r.cacheClient()
+	if rdb == nil {
+		return 0, nil
+	}
+	start := time.Now()
+	defer metric.ObserveRedis("scan", start)
+	var n int64
+	iter := rdb.Scan(ctx, 0, "tasks:*", 100).Iterator()
+	for iter.Next(ctx) {
+		deleted, err := rdb.Del(ctx, iter.Val()).Result()
+		if err != nil {
+			return int(n), err
+		}
+		n += deleted
+	}
+	return int(n), iter.Err()
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+)
+
+// CountMode selects how the total of a task list is computed.
+type CountMode string
+
+const (
+	// CountExact runs COUNT over the matching rows (the default).
+	CountExact CountMode = "exact"
+	// CountEstimate uses the query planner's row estimate (see EstimateCount).
+	CountEstimate CountMode = "estimate"
+	// CountNone skips the count entirely.
+	CountNone CountMode = "none"
+)
+
+// ParseCountMode parses the count query param; "" is CountExact.
+func ParseCountMode(s string) (CountMode, error) {
+	switch m := CountMode(s); m {
+	case "":
+		return CountExact, nil
+	case CountExact, CountEstimate, CountNone:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown count mode %q: expected none, exact or estimate", s)
+}
+
+// EstimateCount implements TaskRepository. On PostgreSQL the unfiltered
+// total is pg_class.reltuples and a filtered one the row estimate of the
+// plan for the count query, so neither scans the table; both are only as
+// fresh as the last ANALYZE. Other databases, and a table that has never
+// been analyzed, fall back to CountFiltered.
+func (r *taskRepo) EstimateCount(ctx context.Context, f TaskFilter) (int, error) {
+	ctx = database.WithOperation(ctx, "tasks", "count")
+	if !r.d.Postgres() {
+		return r.CountFiltered(ctx, f)
+	}
+	b := &queryBuilder{d: r.d}
+	f.apply(b)
+
+	if b.WhereClause() == "" {
+		var reltuples float64
+		if err := sqlx.GetContext(ctx, r.conn(ctx), &reltuples, "SELECT reltuples FROM pg_class WHERE oid = 'tasks'::regclass"); err != nil {
+			return 0, err
+		}
+		if reltuples < 0 {
+			// -1: never vacuumed or analyzed
+			return r.CountFiltered(ctx, f)
+		}
+		return int(reltuples), nil
+	}
+
+	var plan []byte
+	if err := sqlx.GetContext(ctx, r.conn(ctx), &plan, r.d.Rebind("EXPLAIN (FORMAT JSON) SELECT 1 FROM tasks"+b.WhereClause()), b.Args()...); err != nil {
+		return 0, err
+	}
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explained); err != nil || len(explained) == 0 {
+		return 0, fmt.Errorf("unexpected EXPLAIN output: %s", plan)
+	}
+	return int(explained[0].Plan.Rows), nil
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// taskFieldColumns maps the JSON fields of a task that can be requested on
+// their own to the column they are read from.
+var taskFieldColumns = map[string]string{
+	"id":                 "id",
+	"number":             "number",
+	"key":                "number",
+	"title":              "title",
+	"description":        "description",
+	"assignee":           "assignee",
+	"completed":          "completed",
+	"completed_at":       "completed_at",
+	"due_date":           "due_date",
+	"estimate_minutes":   "estimate_minutes",
+	"remaining_minutes":  "remaining_minutes",
+	"position":           "position",
+	"time_spent_seconds": "time_spent_seconds",
+	"created_at":         "created_at",
+	"updated_at":         "updated_at",
+}
+
+// ParseFields parses a sparse fieldset like "id,title,due_date" into the
+// sorted, de-duplicated list of task JSON field names. id is always part of
+// the result so every item stays addressable.
+func ParseFields(spec string) ([]string, error) {
+	fields := []string{"id"}
+	for _, part := range strings.Split(spec, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" {
+			continue
+		}
+		if _, ok := taskFieldColumns[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q", strings.TrimSpace(part))
+		}
+		fields = append(fields, name)
+	}
+	slices.Sort(fields)
+	return slices.Compact(fields), nil
+}
+
+// selectTaskFields is selectTask restricted to the columns behind fields;
+// no fields selects every column.
+func selectTaskFields(fields []string) string {
+	if len(fields) == 0 {
+		return selectTask
+	}
+	cols := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if col := taskFieldColumns[f]; !slices.Contains(cols, col) {
+			cols = append(cols, col)
+		}
+	}
+	return "SELECT " + strings.Join(cols, ", ") + " FROM tasks"
+}
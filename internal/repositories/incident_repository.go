@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+	"taskmanager/internal/model"
+)
+
+// ErrIncidentNotFound is returned when an incident id does not exist.
+var ErrIncidentNotFound = errors.New("incident not found")
+
+// IncidentRepository stores the incident annotations shown on the status page.
+type IncidentRepository interface {
+	Create(ctx context.Context, inc *model.Incident) error
+	// Resolve sets resolved_at on an open incident.
+	Resolve(ctx context.Context, id int64, at time.Time) (*model.Incident, error)
+	// ListSince returns incidents that are still open or started after since,
+	// newest first.
+	ListSince(ctx context.Context, since time.Time) ([]model.Incident, error)
+}
+
+type incidentRepo struct {
+	db *sqlx.DB
+	d  database.Dialect
+}
+
+// NewIncidentRepository creates an IncidentRepository backed by sqlx.DB.
+func NewIncidentRepository(db *sqlx.DB) IncidentRepository {
+	return &incidentRepo{db: db, d: database.For(db)}
+}
+
+func (r *incidentRepo) Create(ctx context.Context, inc *model.Incident) error {
+	ctx = database.WithOperation(ctx, "incidents", "create")
+	if inc.Severity == "" {
+		inc.Severity = "minor"
+	}
+	if inc.StartedAt.IsZero() {
+		inc.StartedAt = time.Now().UTC()
+	}
+	query := r.d.Rebind("INSERT INTO incidents (title, description, severity, started_at) VALUES ($1, $2, $3, $4)")
+	if r.d.MySQL() {
+		// no RETURNING; the AUTO_INCREMENT id comes back with the result
+		res, err := r.db.ExecContext(ctx, query, inc.Title, inc.Description, inc.Severity, inc.StartedAt)
+		if err != nil {
+			return err
+		}
+		inc.ID, err = res.LastInsertId()
+		return err
+	}
+	return r.db.GetContext(ctx, &inc.ID, query+" RETURNING id",
+		inc.Title, inc.Description, inc.Severity, inc.StartedAt)
+}
+
+func (r *incidentRepo) Resolve(ctx context.Context, id int64, at time.Time) (*model.Incident, error) {
+	ctx = database.WithOperation(ctx, "incidents", "update")
+	var inc model.Incident
+	const columns = "id, title, description, severity, started_at, resolved_at"
+	update := r.d.Rebind("UPDATE incidents SET resolved_at = COALESCE(resolved_at, $1) WHERE id = $2")
+	var err error
+	if r.d.MySQL() {
+		// no RETURNING: update, then read the row back
+		if _, err = r.db.ExecContext(ctx, update, at, id); err == nil {
+			err = r.db.GetContext(ctx, &inc, r.d.Rebind("SELECT "+columns+" FROM incidents WHERE id = $1"), id)
+		}
+	} else {
+		err = r.db.GetContext(ctx, &inc, update+"\nRETURNING "+columns, at, id)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrIncidentNotFound
+		}
+		return nil, err
+	}
+	return &inc, nil
+}
+
+func (r *incidentRepo) ListSince(ctx context.Context, since time.Time) ([]model.Incident, error) {
+	ctx = database.WithOperation(ctx, "incidents", "list")
+	incidents := []model.Incident{}
+	err := r.db.SelectContext(ctx, &incidents, r.d.Rebind(`SELECT id, title, description, severity, started_at, resolved_at
+FROM incidents WHERE resolved_at IS NULL OR started_at >= $1 ORDER BY started_at DESC, id DESC`), since)
+	return incidents, err
+}
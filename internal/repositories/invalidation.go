@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"taskmanager/internal/logging"
+	"taskmanager/internal/metric"
+)
+
+// invalidationChannel is the Redis Pub/Sub channel instances announce their
+// cache invalidations on, so the others can drop their L1 copies.
+const invalidationChannel = "tasks:invalidate"
+
+// invalidationMessage is published after every write when
+// CacheOptions.PubSub is set.
+type invalidationMessage struct {
+	// Origin identifies the publishing instance, which ignores its own messages.
+	Origin string   `json:"origin"`
+	List   bool     `json:"list,omitempty"`
+	IDs    []string `json:"ids,omitempty"`
+	// All drops every cached task entry (see FlushCache).
+	All bool `json:"all,omitempty"`
+}
+
+// InvalidationListener is implemented by repositories whose L1 cache follows
+// the invalidations published by other instances.
+type InvalidationListener interface {
+	ListenInvalidations(ctx context.Context, rdb *redis.Client)
+}
+
+// invalidate drops the caches after a committed write and tells the other
+// instances to do the same.
+func (r *taskRepo) invalidate(ctx context.Context, list bool, ids ...string) {
+	if list {
+		r.invalidateListCache(ctx)
+	}
+	r.invalidateItems(ctx, ids...)
+	r.publishInvalidation(ctx, invalidationMessage{List: list, IDs: ids})
+}
+
+// publishInvalidation announces msg on invalidationChannel. A lost message
+// only leaves other instances' L1 entries stale until LocalTTL, so failures
+// are logged and otherwise ignored.
+func (r *taskRepo) publishInvalidation(ctx context.Context, msg invalidationMessage) {
+	rdb := r.cacheClient()
+	if !r.cacheOptions().PubSub || rdb == nil || (!msg.List && !msg.All && len(msg.IDs) == 0) {
+		return
+	}
+	msg.Origin = r.originID()
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	start := time.Now()
+	err = rdb.Publish(ctx, invalidationChannel, b).Err()
+	metric.ObserveRedis("publish", start)
+	if err != nil {
+		logging.FromContext(ctx).Warn("cache invalidation publish failed", "err", err)
+	}
+}
+
+// ListenInvalidations applies the invalidations other instances publish to
+// the local cache until ctx is cancelled. While the subscription is broken
+// messages may be missed, so the local cache is cleared when it fails.
+func (r *taskRepo) ListenInvalidations(ctx context.Context, rdb *redis.Client) {
+	if r.local == nil {
+		return
+	}
+	sub := rdb.Subscribe(ctx, invalidationChannel)
+	defer sub.Close()
+	for {
+		msg, err := sub.ReceiveMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("cache invalidation subscription failed", "err", err)
+			r.local.DeletePrefix("tasks:")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		r.applyInvalidation(msg.Payload)
+	}
+}
+
+// applyInvalidation drops the local entries named by a published message,
+// unless this instance sent it.
+func (r *taskRepo) applyInvalidation(payload string) {
+	var msg invalidationMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		slog.Warn("invalid cache invalidation message", "err", err)
+		return
+	}
+	if msg.Origin == r.originID() {
+		return
+	}
+	if msg.All {
+		r.local.DeletePrefix("tasks:")
+		metric.CacheInvalidations.WithLabelValues("remote").Inc()
+		return
+	}
+	if msg.List {
+		r.local.DeletePrefix("tasks:list:")
+	}
+	keys := make([]string, len(msg.IDs))
+	for i, id := range msg.IDs {
+		keys[i] = cacheKeyForItem(id)
+	}
+	r.local.Delete(keys...)
+	metric.CacheInvalidations.WithLabelValues("remote").Inc()
+}
+
+// originID returns the id this repository publishes invalidations under.
+func (r *taskRepo) originID() string {
+	r.originOnce.Do(func() {
+		if r.origin == "" {
+			r.origin = uuid.NewString()
+		}
+	})
+	return r.origin
+}
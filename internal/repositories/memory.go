@@ -0,0 +1,926 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"taskmanager/internal/model"
+)
+
+// memoryStore is the state shared by a memory repository and the
+// repositories WithTx hands out for it.
+type memoryStore struct {
+	mu    sync.RWMutex
+	tasks map[string]model.Task
+	// number is the last task number handed out
+	number int64
+	// tombstones of the deleted tasks, by task id
+	tombstones map[string]model.Tombstone
+}
+
+// memoryRepo is a TaskRepository kept entirely in process memory, for demos
+// and for embedding the service without a database. Data is lost on exit and
+// no outbox events are recorded.
+type memoryRepo struct {
+	s    *memoryStore
+	sort []SortField
+	// inTx is set on repositories handed out by WithTx, which already hold
+	// the store's write lock
+	inTx bool
+}
+
+// NewMemoryTaskRepository creates an empty in-memory TaskRepository.
+func NewMemoryTaskRepository() TaskRepository {
+	return &memoryRepo{s: &memoryStore{tasks: make(map[string]model.Task), tombstones: make(map[string]model.Tombstone)}}
+}
+
+// SetDefaultSort sets the ordering used by List (see ParseSort).
+func (r *memoryRepo) SetDefaultSort(fields []SortField) {
+	r.sort = fields
+}
+
+// SetCacheClient is a no-op: the data already lives in memory.
+func (r *memoryRepo) SetCacheClient(_ *redis.Client) {}
+
+// Reset removes every task (used by sandbox mode).
+func (r *memoryRepo) Reset() {
+	defer r.lock()()
+	r.s.tasks = make(map[string]model.Task)
+	r.s.tombstones = make(map[string]model.Tombstone)
+}
+
+func (r *memoryRepo) lock() func() {
+	if r.inTx {
+		return func() {}
+	}
+	r.s.mu.Lock()
+	return r.s.mu.Unlock
+}
+
+func (r *memoryRepo) rlock() func() {
+	if r.inTx {
+		return func() {}
+	}
+	r.s.mu.RLock()
+	return r.s.mu.RUnlock
+}
+
+func (r *memoryRepo) Create(_ context.Context, task *model.Task) error {
+	if task == nil {
+		return errors.New("task is nil")
+	}
+	defer r.lock()()
+	if task.ID == "" {
+		task.ID = uuid.New().String()
+	}
+	if _, ok := r.s.tasks[task.ID]; ok {
+		return errors.New("duplicate task id")
+	}
+	now := time.Now().UTC()
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	task.CompletedAt = sql.NullTime{Time: now, Valid: task.Completed}
+	var last *float64
+	for _, t := range r.s.tasks {
+		if last == nil || t.Position > *last {
+			last = &t.Position
+		}
+	}
+	task.Position, _ = positionBetween(last, nil)
+	r.s.number++
+	task.Number = r.s.number
+	r.s.tasks[task.ID] = *task
+	return nil
+}
+
+func (r *memoryRepo) GetByID(_ context.Context, id string) (*model.Task, error) {
+	defer r.rlock()()
+	t, ok := r.s.tasks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &t, nil
+}
+
+func (r *memoryRepo) GetByNumber(_ context.Context, number int64) (*model.Task, error) {
+	defer r.rlock()()
+	for _, t := range r.s.tasks {
+		if t.Number == number {
+			return &t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryRepo) GetMany(_ context.Context, ids []string) ([]model.Task, error) {
+	defer r.rlock()()
+	tasks := []model.Task{}
+	for _, id := range ids {
+		if t, ok := r.s.tasks[id]; ok {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+func (r *memoryRepo) GetManyByNumber(_ context.Context, numbers []int64) ([]model.Task, error) {
+	defer r.rlock()()
+	tasks := []model.Task{}
+	for _, t := range r.s.tasks {
+		if slices.Contains(numbers, t.Number) {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+func (r *memoryRepo) List(_ context.Context, opts ListOptions) ([]model.Task, error) {
+	limit, offset := opts.Limit, opts.Offset
+	if limit <= 0 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	defer r.rlock()()
+	tasks := r.matching(opts.Filter)
+	fields := opts.Sort
+	if len(fields) == 0 {
+		fields = r.sort
+	}
+	if len(fields) == 0 {
+		fields = DefaultSort
+	}
+	slices.SortFunc(tasks, func(a, b model.Task) int { return compareTasks(a, b, fields) })
+	if offset >= len(tasks) {
+		return []model.Task{}, nil
+	}
+	return tasks[offset:min(offset+limit, len(tasks))], nil
+}
+
+// Export calls fn with every task, oldest first. The tasks are copied under
+// the read lock so a slow fn doesn't hold up writers.
+func (r *memoryRepo) Export(ctx context.Context, fn func(model.Task) error) error {
+	unlock := r.rlock()
+	tasks := r.matching(TaskFilter{})
+	unlock()
+	slices.SortFunc(tasks, func(a, b model.Task) int {
+		return compareTasks(a, b, []SortField{{Column: "created_at"}})
+	})
+	for _, t := range tasks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepo) Update(_ context.Context, task *model.Task) error {
+	if task == nil {
+		return errors.New("task is nil")
+	}
+	defer r.lock()()
+	cur, ok := r.s.tasks[task.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	// same columns as the SQL UPDATE: assignee and created_at are kept
+	task.UpdatedAt = time.Now()
+	cur.Title = task.Title
+	cur.Description = task.Description
+	switch {
+	case !task.Completed:
+		cur.CompletedAt = sql.NullTime{}
+	case !cur.CompletedAt.Valid:
+		cur.CompletedAt = sql.NullTime{Time: task.UpdatedAt.UTC(), Valid: true}
+	}
+	cur.Completed = task.Completed
+	cur.DueDate = task.DueDate
+	cur.EstimateMinutes = task.EstimateMinutes
+	cur.RemainingMinutes = task.RemainingMinutes
+	cur.UpdatedAt = task.UpdatedAt
+	r.s.tasks[task.ID] = cur
+	return nil
+}
+
+func (r *memoryRepo) SetCompleted(_ context.Context, id string, completed bool) (*model.Task, bool, error) {
+	defer r.lock()()
+	t, ok := r.s.tasks[id]
+	if !ok {
+		return nil, false, ErrNotFound
+	}
+	if t.Completed == completed {
+		return &t, false, nil
+	}
+	now := time.Now().UTC()
+	t.Completed = completed
+	t.CompletedAt = sql.NullTime{Time: now, Valid: completed}
+	t.UpdatedAt = now
+	r.s.tasks[id] = t
+	return &t, true, nil
+}
+
+func (r *memoryRepo) Move(_ context.Context, id, afterID string) (*model.Task, error) {
+	defer r.lock()()
+	t, ok := r.s.tasks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	pos, ok, err := r.positionAfter(id, afterID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		r.rebalance()
+		pos, _, _ = r.positionAfter(id, afterID)
+	}
+	t = r.s.tasks[id]
+	t.Position = pos
+	t.UpdatedAt = time.Now().UTC()
+	r.s.tasks[id] = t
+	return &t, nil
+}
+
+// positionAfter mirrors taskRepo.positionAfter; callers hold the lock.
+func (r *memoryRepo) positionAfter(id, afterID string) (pos float64, ok bool, err error) {
+	var prev, next *float64
+	if afterID != "" {
+		anchor, found := r.s.tasks[afterID]
+		if !found {
+			return 0, false, ErrMoveAnchorNotFound
+		}
+		prev = &anchor.Position
+	}
+	for _, t := range r.s.tasks {
+		if t.ID == id || t.ID == afterID || (prev != nil && t.Position < *prev) {
+			continue
+		}
+		if next == nil || t.Position < *next {
+			next = &t.Position
+		}
+	}
+	pos, ok = positionBetween(prev, next)
+	return pos, ok, nil
+}
+
+// rebalance renumbers every task PositionGap apart in the current order.
+func (r *memoryRepo) rebalance() {
+	tasks := make([]model.Task, 0, len(r.s.tasks))
+	for _, t := range r.s.tasks {
+		tasks = append(tasks, t)
+	}
+	slices.SortFunc(tasks, func(a, b model.Task) int {
+		return compareTasks(a, b, []SortField{{Column: "position"}})
+	})
+	for i, t := range tasks {
+		t.Position = float64(i+1) * PositionGap
+		r.s.tasks[t.ID] = t
+	}
+}
+
+func (r *memoryRepo) Delete(ctx context.Context, id string) (bool, error) {
+	defer r.lock()()
+	if _, ok := r.s.tasks[id]; !ok {
+		return false, nil
+	}
+	delete(r.s.tasks, id)
+	r.s.tombstones[id] = newTombstone(ctx, id)
+	return true, nil
+}
+
+func (r *memoryRepo) DeleteCompleted(ctx context.Context, before *time.Time) ([]string, error) {
+	defer r.lock()()
+	ids := []string{}
+	for id, t := range r.s.tasks {
+		if completedBefore(t, before) {
+			delete(r.s.tasks, id)
+			r.s.tombstones[id] = newTombstone(ctx, id)
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memoryRepo) CountCompleted(_ context.Context, before *time.Time) (int, error) {
+	defer r.rlock()()
+	n := 0
+	for _, t := range r.s.tasks {
+		if completedBefore(t, before) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// completedBefore reports whether t is completed, and before the given time
+// when it is set.
+func completedBefore(t model.Task, before *time.Time) bool {
+	return t.Completed && (before == nil || (t.CompletedAt.Valid && t.CompletedAt.Time.Before(*before)))
+}
+
+func (r *memoryRepo) Count(ctx context.Context) (int, error) {
+	return r.CountFiltered(ctx, TaskFilter{})
+}
+
+func (r *memoryRepo) CountFiltered(_ context.Context, f TaskFilter) (int, error) {
+	defer r.rlock()()
+	return len(r.matching(f)), nil
+}
+
+// EstimateCount is exact: counting in memory is already cheap.
+func (r *memoryRepo) EstimateCount(ctx context.Context, f TaskFilter) (int, error) {
+	return r.CountFiltered(ctx, f)
+}
+
+func (r *memoryRepo) Stats(_ context.Context, f TaskFilter) (*model.TaskStats, error) {
+	defer r.rlock()()
+	groups := map[sql.NullString]*model.AssigneeStats{}
+	for _, t := range r.matching(f) {
+		g, ok := groups[t.Assignee]
+		if !ok {
+			g = &model.AssigneeStats{Assignee: assigneeOf(&t)}
+			groups[t.Assignee] = g
+		}
+		g.Count++
+		if t.Completed {
+			g.Completed++
+		}
+		g.EstimateMinutes += t.EstimateMinutes.Int64
+		g.RemainingMinutes += t.RemainingMinutes.Int64
+		g.TimeSpentSeconds += t.TimeSpentSeconds
+	}
+	out := make([]model.AssigneeStats, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	slices.SortFunc(out, func(a, b model.AssigneeStats) int {
+		if a.Assignee == nil || b.Assignee == nil {
+			return cmp.Compare(boolInt(a.Assignee != nil), boolInt(b.Assignee != nil))
+		}
+		return strings.Compare(*a.Assignee, *b.Assignee)
+	})
+	return statsOf(out), nil
+}
+
+func (r *memoryRepo) Reassign(_ context.Context, f TaskFilter, to string) ([]string, error) {
+	if f.Empty() {
+		return nil, errors.New("reassign requires at least one filter")
+	}
+	defer r.lock()()
+	matched := r.matching(f)
+	ids := make([]string, 0, len(matched))
+	now := time.Now().UTC()
+	for _, t := range matched {
+		t.SetAssignee(to)
+		t.UpdatedAt = now
+		r.s.tasks[t.ID] = t
+		ids = append(ids, t.ID)
+	}
+	return ids, nil
+}
+
+// WithTx holds the store's write lock for the duration of fn and restores
+// the previous contents if fn fails, so other callers never see partial
+// changes.
+func (r *memoryRepo) WithTx(_ context.Context, fn func(repo TaskRepository) error) error {
+	if r.inTx {
+		return fn(r)
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	snapshot := maps.Clone(r.s.tasks)
+	tombstones := maps.Clone(r.s.tombstones)
+	txRepo := &memoryRepo{s: r.s, sort: r.sort, inTx: true}
+	if err := fn(txRepo); err != nil {
+		r.s.tasks = snapshot
+		r.s.tombstones = tombstones
+		return err
+	}
+	return nil
+}
+
+// matching returns copies of the tasks that satisfy f; callers hold the lock.
+func (r *memoryRepo) matching(f TaskFilter) []model.Task {
+	out := []model.Task{}
+	for _, t := range r.s.tasks {
+		if f.Completed != nil && t.Completed != *f.Completed {
+			continue
+		}
+		var assignee *string
+		if t.Assignee.Valid {
+			assignee = &t.Assignee.String
+		}
+		if !f.Assignee.Matches(assignee) {
+			continue
+		}
+		if !f.Dates.Contains(t.CreatedAt, t.UpdatedAt, t.DueDate) {
+			continue
+		}
+		if f.Title != "" && !TitleContains(t.Title, f.Title) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// compareTasks orders a and b like orderByClause does in PostgreSQL: NULLs
+// sort as the largest value unless the field says otherwise, and id breaks
+// ties.
+func compareTasks(a, b model.Task, fields []SortField) int {
+	for _, f := range fields {
+		an, bn, c := compareColumn(a, b, f.Column)
+		nullsFirst := f.Desc
+		if f.Nulls != "" {
+			nullsFirst = f.Nulls == "FIRST"
+		}
+		switch {
+		case an && bn:
+			continue
+		case an || bn:
+			if an == nullsFirst {
+				return -1
+			}
+			return 1
+		}
+		if f.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+// compareColumn compares one sortable column, reporting whether each side is
+// NULL.
+func compareColumn(a, b model.Task, column string) (aNull, bNull bool, c int) {
+	switch column {
+	case "created_at":
+		return false, false, a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return false, false, a.UpdatedAt.Compare(b.UpdatedAt)
+	case "due_date":
+		return !a.DueDate.Valid, !b.DueDate.Valid, a.DueDate.Time.Compare(b.DueDate.Time)
+	case "title":
+		return false, false, strings.Compare(a.Title, b.Title)
+	case "completed":
+		return false, false, cmp.Compare(boolInt(a.Completed), boolInt(b.Completed))
+	case "assignee":
+		return !a.Assignee.Valid, !b.Assignee.Valid, strings.Compare(a.Assignee.String, b.Assignee.String)
+	case "position":
+		return false, false, cmp.Compare(a.Position, b.Position)
+	}
+	return false, false, 0
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// memoryTombstoneRepo is the in-memory TombstoneRepository over the
+// tombstones a memoryRepo records.
+type memoryTombstoneRepo struct {
+	tasks *memoryRepo
+}
+
+// NewMemoryTombstoneRepository creates a TombstoneRepository reading the
+// tombstones of tasks, which must come from NewMemoryTaskRepository.
+func NewMemoryTombstoneRepository(tasks TaskRepository) TombstoneRepository {
+	return &memoryTombstoneRepo{tasks: tasks.(*memoryRepo)}
+}
+
+func (r *memoryTombstoneRepo) GetMany(_ context.Context, taskIDs []string) (map[string]model.Tombstone, error) {
+	defer r.tasks.rlock()()
+	out := map[string]model.Tombstone{}
+	for _, id := range taskIDs {
+		if t, ok := r.tasks.s.tombstones[id]; ok {
+			out[id] = t
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryTombstoneRepo) Purge(_ context.Context, before time.Time) (int, error) {
+	defer r.tasks.lock()()
+	n := 0
+	for id, t := range r.tasks.s.tombstones {
+		if t.DeletedAt.Before(before) {
+			delete(r.tasks.s.tombstones, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// memoryWatcherRepo is the in-memory WatcherRepository used alongside
+// memoryRepo. It checks the task through tasks; the watchers of a deleted task
+// are dropped the next time they are looked at.
+type memoryWatcherRepo struct {
+	tasks    TaskRepository
+	mu       sync.Mutex
+	watchers map[string]map[string]time.Time
+}
+
+// NewMemoryWatcherRepository creates an empty in-memory WatcherRepository for
+// the tasks in tasks.
+func NewMemoryWatcherRepository(tasks TaskRepository) WatcherRepository {
+	return &memoryWatcherRepo{tasks: tasks, watchers: map[string]map[string]time.Time{}}
+}
+
+// check reports ErrNotFound for a missing task and forgets its watchers;
+// callers hold the lock.
+func (r *memoryWatcherRepo) check(ctx context.Context, taskID string) error {
+	if _, err := r.tasks.GetByID(ctx, taskID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			delete(r.watchers, taskID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *memoryWatcherRepo) Add(ctx context.Context, taskID, watcher string) (*model.Watcher, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.check(ctx, taskID); err != nil {
+		return nil, false, err
+	}
+	if at, ok := r.watchers[taskID][watcher]; ok {
+		return &model.Watcher{TaskID: taskID, Watcher: watcher, CreatedAt: at}, false, nil
+	}
+	if r.watchers[taskID] == nil {
+		r.watchers[taskID] = map[string]time.Time{}
+	}
+	now := time.Now().UTC()
+	r.watchers[taskID][watcher] = now
+	return &model.Watcher{TaskID: taskID, Watcher: watcher, CreatedAt: now}, true, nil
+}
+
+func (r *memoryWatcherRepo) Remove(ctx context.Context, taskID, watcher string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.check(ctx, taskID); err != nil {
+		return false, err
+	}
+	if _, ok := r.watchers[taskID][watcher]; !ok {
+		return false, nil
+	}
+	delete(r.watchers[taskID], watcher)
+	return true, nil
+}
+
+func (r *memoryWatcherRepo) List(ctx context.Context, taskID string) ([]model.Watcher, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.check(ctx, taskID); err != nil {
+		return nil, err
+	}
+	out := make([]model.Watcher, 0, len(r.watchers[taskID]))
+	for name, at := range r.watchers[taskID] {
+		out = append(out, model.Watcher{TaskID: taskID, Watcher: name, CreatedAt: at})
+	}
+	slices.SortFunc(out, func(a, b model.Watcher) int { return strings.Compare(a.Watcher, b.Watcher) })
+	return out, nil
+}
+
+func (r *memoryWatcherRepo) ListMany(ctx context.Context, taskIDs []string) (map[string][]model.Watcher, error) {
+	out := map[string][]model.Watcher{}
+	for _, id := range taskIDs {
+		watchers, err := r.List(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(watchers) > 0 {
+			out[id] = watchers
+		}
+	}
+	return out, nil
+}
+
+// logTime adds seconds to the tracked time of the task (see
+// memoryTimeEntryRepo).
+func (r *memoryRepo) logTime(id string, seconds int64) error {
+	defer r.lock()()
+	t, ok := r.s.tasks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	t.TimeSpentSeconds += seconds
+	t.UpdatedAt = time.Now().UTC()
+	r.s.tasks[id] = t
+	return nil
+}
+
+// assigneeOf returns the task's assignee, nil when unassigned.
+func assigneeOf(t *model.Task) *string {
+	if !t.Assignee.Valid {
+		return nil
+	}
+	return &t.Assignee.String
+}
+
+// memoryTimeEntryRepo is the in-memory TimeEntryRepository used alongside
+// memoryRepo. Like memoryWatcherRepo it drops the entries of a deleted task
+// the next time they are looked at.
+type memoryTimeEntryRepo struct {
+	tasks   *memoryRepo
+	mu      sync.Mutex
+	entries map[string][]model.TimeEntry
+}
+
+// NewMemoryTimeEntryRepository creates an empty in-memory TimeEntryRepository
+// for the tasks in tasks, which must come from NewMemoryTaskRepository.
+func NewMemoryTimeEntryRepository(tasks TaskRepository) TimeEntryRepository {
+	return &memoryTimeEntryRepo{tasks: tasks.(*memoryRepo), entries: map[string][]model.TimeEntry{}}
+}
+
+// task returns the task, forgetting its entries when it is gone; callers hold
+// the lock.
+func (r *memoryTimeEntryRepo) task(ctx context.Context, taskID string) (*model.Task, error) {
+	t, err := r.tasks.GetByID(ctx, taskID)
+	if errors.Is(err, ErrNotFound) {
+		delete(r.entries, taskID)
+	}
+	return t, err
+}
+
+// running returns the index of the task's running entry, or -1.
+func (r *memoryTimeEntryRepo) running(taskID string) int {
+	return slices.IndexFunc(r.entries[taskID], func(e model.TimeEntry) bool { return e.EndedAt == nil })
+}
+
+func (r *memoryTimeEntryRepo) StartTimer(ctx context.Context, taskID string) (*model.TimeEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, err := r.task(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if r.running(taskID) >= 0 {
+		return nil, ErrTimerRunning
+	}
+	now := time.Now().UTC()
+	e := model.TimeEntry{ID: uuid.New().String(), TaskID: taskID, Assignee: assigneeOf(t), StartedAt: now, CreatedAt: now}
+	r.entries[taskID] = append(r.entries[taskID], e)
+	return &e, nil
+}
+
+func (r *memoryTimeEntryRepo) StopTimer(ctx context.Context, taskID string) (*model.TimeEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.task(ctx, taskID); err != nil {
+		return nil, err
+	}
+	i := r.running(taskID)
+	if i < 0 {
+		return nil, ErrTimerNotRunning
+	}
+	e := r.entries[taskID][i]
+	now := time.Now().UTC()
+	e.EndedAt = &now
+	e.Seconds = spanSeconds(e.StartedAt, now)
+	if err := r.tasks.logTime(taskID, e.Seconds); err != nil {
+		return nil, err
+	}
+	r.entries[taskID][i] = e
+	return &e, nil
+}
+
+func (r *memoryTimeEntryRepo) AddEntry(ctx context.Context, taskID string, startedAt, endedAt time.Time) (*model.TimeEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, err := r.task(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	startedAt, endedAt = startedAt.UTC(), endedAt.UTC()
+	e := model.TimeEntry{
+		ID:        uuid.New().String(),
+		TaskID:    taskID,
+		Assignee:  assigneeOf(t),
+		StartedAt: startedAt,
+		EndedAt:   &endedAt,
+		Seconds:   spanSeconds(startedAt, endedAt),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := r.tasks.logTime(taskID, e.Seconds); err != nil {
+		return nil, err
+	}
+	r.entries[taskID] = append(r.entries[taskID], e)
+	return &e, nil
+}
+
+func (r *memoryTimeEntryRepo) List(ctx context.Context, taskID string) ([]model.TimeEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.task(ctx, taskID); err != nil {
+		return nil, err
+	}
+	out := slices.Clone(r.entries[taskID])
+	if out == nil {
+		out = []model.TimeEntry{}
+	}
+	slices.SortFunc(out, func(a, b model.TimeEntry) int {
+		if c := b.StartedAt.Compare(a.StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return out, nil
+}
+
+func (r *memoryTimeEntryRepo) ListMany(ctx context.Context, taskIDs []string) (map[string][]model.TimeEntry, error) {
+	out := map[string][]model.TimeEntry{}
+	for _, id := range taskIDs {
+		entries, err := r.List(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			out[id] = entries
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryTimeEntryRepo) Report(ctx context.Context, from, to *time.Time) ([]model.TimeReportRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rows := map[string]*model.TimeReportRow{}
+	for taskID, entries := range r.entries {
+		if _, err := r.task(ctx, taskID); err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.EndedAt == nil || (from != nil && e.StartedAt.Before(*from)) || (to != nil && !e.StartedAt.Before(*to)) {
+				continue
+			}
+			// "" can't be an assignee: names are trimmed and must be non-empty
+			key := ""
+			if e.Assignee != nil {
+				key = *e.Assignee
+			}
+			row, ok := rows[key]
+			if !ok {
+				row = &model.TimeReportRow{Assignee: e.Assignee}
+				rows[key] = row
+			}
+			row.Seconds += e.Seconds
+			row.Entries++
+		}
+	}
+	out := make([]model.TimeReportRow, 0, len(rows))
+	for _, key := range slices.Sorted(maps.Keys(rows)) {
+		out = append(out, *rows[key])
+	}
+	return out, nil
+}
+
+// memoryViewRepo is the in-memory ViewRepository used alongside memoryRepo.
+type memoryViewRepo struct {
+	mu    sync.Mutex
+	views map[string]model.View
+}
+
+// NewMemoryViewRepository creates an empty in-memory ViewRepository.
+func NewMemoryViewRepository() ViewRepository {
+	return &memoryViewRepo{views: map[string]model.View{}}
+}
+
+func (r *memoryViewRepo) Create(_ context.Context, v *model.View) error {
+	v.ID = uuid.New().String()
+	v.CreatedAt = time.Now().UTC()
+	v.UpdatedAt = v.CreatedAt
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.views[v.ID] = *v
+	return nil
+}
+
+func (r *memoryViewRepo) Get(_ context.Context, id string) (*model.View, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.views[id]
+	if !ok {
+		return nil, ErrViewNotFound
+	}
+	return &v, nil
+}
+
+func (r *memoryViewRepo) List(_ context.Context) ([]model.View, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]model.View, 0, len(r.views))
+	for _, v := range r.views {
+		out = append(out, v)
+	}
+	slices.SortFunc(out, func(a, b model.View) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return out, nil
+}
+
+func (r *memoryViewRepo) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.views[id]; !ok {
+		return ErrViewNotFound
+	}
+	delete(r.views, id)
+	return nil
+}
+
+// memoryIncidentRepo is the in-memory IncidentRepository used alongside
+// memoryRepo.
+type memoryIncidentRepo struct {
+	mu        sync.Mutex
+	nextID    int64
+	incidents []model.Incident
+}
+
+// NewMemoryIncidentRepository creates an empty in-memory IncidentRepository.
+func NewMemoryIncidentRepository() IncidentRepository {
+	return &memoryIncidentRepo{}
+}
+
+// Reset removes every incident (used by sandbox mode).
+func (r *memoryIncidentRepo) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.incidents = nil
+}
+
+func (r *memoryIncidentRepo) Create(_ context.Context, inc *model.Incident) error {
+	if inc.Severity == "" {
+		inc.Severity = "minor"
+	}
+	if inc.StartedAt.IsZero() {
+		inc.StartedAt = time.Now().UTC()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	inc.ID = r.nextID
+	r.incidents = append(r.incidents, *inc)
+	return nil
+}
+
+func (r *memoryIncidentRepo) Resolve(_ context.Context, id int64, at time.Time) (*model.Incident, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.incidents {
+		if inc := &r.incidents[i]; inc.ID == id {
+			if inc.ResolvedAt == nil {
+				inc.ResolvedAt = &at
+			}
+			out := *inc
+			return &out, nil
+		}
+	}
+	return nil, ErrIncidentNotFound
+}
+
+func (r *memoryIncidentRepo) ListSince(_ context.Context, since time.Time) ([]model.Incident, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := []model.Incident{}
+	for _, inc := range r.incidents {
+		if inc.ResolvedAt == nil || !inc.StartedAt.Before(since) {
+			out = append(out, inc)
+		}
+	}
+	slices.SortFunc(out, func(a, b model.Incident) int {
+		if c := b.StartedAt.Compare(a.StartedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+	return out, nil
+}
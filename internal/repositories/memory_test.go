@@ -0,0 +1,348 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"taskmanager/internal/logging"
+	"taskmanager/internal/model"
+)
+
+func TestMemoryRepo_FilterPaginateCount(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository()
+	base := time.Now().Add(-time.Hour)
+	for i, title := range []string{"a", "b", "c", "d"} {
+		task := &model.Task{Title: title, Completed: i%2 == 1}
+		if i < 3 {
+			task.SetAssignee("alice")
+		}
+		if err := repo.Create(ctx, task); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		// make creation order deterministic for the default created_at DESC sort
+		stored := repo.(*memoryRepo).s.tasks[task.ID]
+		stored.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		repo.(*memoryRepo).s.tasks[task.ID] = stored
+	}
+
+	alice, open := "alice", false
+	items, err := repo.List(ctx, ListOptions{Filter: TaskFilter{Completed: &open, Assignee: AssigneeIs(&alice)}, Limit: 1, Offset: 1})
+	if err != nil || len(items) != 1 || items[0].Title != "a" {
+		t.Fatalf("expected second open task of alice (a) got %v err=%v", items, err)
+	}
+	if n, _ := repo.CountFiltered(ctx, TaskFilter{Completed: &open, Assignee: AssigneeIs(&alice)}); n != 2 {
+		t.Fatalf("expected 2 open tasks of alice got %d", n)
+	}
+	if n, _ := repo.Count(ctx); n != 4 {
+		t.Fatalf("expected 4 tasks got %d", n)
+	}
+	if items, _ := repo.List(ctx, ListOptions{Limit: 10, Offset: 10}); len(items) != 0 {
+		t.Fatalf("expected empty page past the end got %v", items)
+	}
+
+	if n, _ := repo.CountFiltered(ctx, TaskFilter{Assignee: AssigneeFilter{Unassigned: true}}); n != 1 {
+		t.Fatalf("expected 1 unassigned task got %d", n)
+	}
+	if n, _ := repo.CountFiltered(ctx, TaskFilter{Assignee: AssigneeFilter{Names: []string{"alice", "bob"}, Unassigned: true}}); n != 4 {
+		t.Fatalf("expected alice's and unassigned tasks (4) got %d", n)
+	}
+
+	cutoff := base.Add(90 * time.Second)
+	if n, _ := repo.CountFiltered(ctx, TaskFilter{Dates: DateRange{CreatedAfter: &cutoff}}); n != 2 {
+		t.Fatalf("expected 2 tasks created after the cutoff got %d", n)
+	}
+	if items, _ := repo.List(ctx, ListOptions{Filter: TaskFilter{Dates: DateRange{CreatedBefore: &cutoff}}, Limit: 10}); len(items) != 2 || items[0].Title != "b" {
+		t.Fatalf("expected b, a created before the cutoff got %v", items)
+	}
+
+	if n, _ := repo.CountFiltered(ctx, TaskFilter{Title: "C"}); n != 1 {
+		t.Fatalf("expected 1 task with c in the title got %d", n)
+	}
+
+	repo.(*memoryRepo).SetDefaultSort([]SortField{{Column: "assignee", Nulls: "FIRST"}, {Column: "title", Desc: true}})
+	items, _ = repo.List(ctx, ListOptions{Limit: 10})
+	var got string
+	for _, it := range items {
+		got += it.Title
+	}
+	if got != "dcba" {
+		t.Fatalf("expected order dcba got %s", got)
+	}
+
+	// a per-request sort wins over the default one
+	items, _ = repo.List(ctx, ListOptions{Sort: []SortField{{Column: "title"}}, Limit: 10})
+	if len(items) != 4 || items[0].Title != "a" {
+		t.Fatalf("expected a first with the requested sort got %v", items)
+	}
+
+	// due bounds never match tasks without a due date
+	due := base.Add(24 * time.Hour)
+	b := items[1]
+	b.SetDueDate(due)
+	repo.(*memoryRepo).s.tasks[b.ID] = b
+	after := due.Add(-time.Minute)
+	if items, _ := repo.List(ctx, ListOptions{Filter: TaskFilter{Dates: DateRange{DueAfter: &after}}, Limit: 10}); len(items) != 1 || items[0].Title != "b" {
+		t.Fatalf("expected only b due after the bound got %v", items)
+	}
+	if n, _ := repo.CountFiltered(ctx, TaskFilter{Dates: DateRange{DueBefore: &after}}); n != 0 {
+		t.Fatalf("expected no task due before the bound got %d", n)
+	}
+}
+
+func TestMemoryRepo_Stats(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository()
+	for _, spec := range []struct {
+		assignee            string
+		completed           bool
+		estimate, remaining int64
+	}{
+		{"bob", false, 60, 20},
+		{"alice", false, 30, 30},
+		{"alice", true, 45, 0},
+		{"", false, 10, 10},
+	} {
+		task := &model.Task{Title: "t", Completed: spec.completed}
+		if spec.assignee != "" {
+			task.SetAssignee(spec.assignee)
+		}
+		task.SetEstimateMinutes(spec.estimate)
+		task.SetRemainingMinutes(spec.remaining)
+		if err := repo.Create(ctx, task); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	stats, err := repo.Stats(ctx, TaskFilter{})
+	if err != nil || stats.Count != 4 || stats.Completed != 1 || stats.EstimateMinutes != 145 || stats.RemainingMinutes != 60 {
+		t.Fatalf("unexpected totals %+v err=%v", stats.TaskTotals, err)
+	}
+	var order []string
+	for _, g := range stats.ByAssignee {
+		name := "none"
+		if g.Assignee != nil {
+			name = *g.Assignee
+		}
+		order = append(order, name)
+	}
+	if got := strings.Join(order, ","); got != "none,alice,bob" || stats.ByAssignee[1].Count != 2 || stats.ByAssignee[1].EstimateMinutes != 75 {
+		t.Fatalf("unexpected groups %s %+v", got, stats.ByAssignee)
+	}
+
+	open := false
+	if stats, _ := repo.Stats(ctx, TaskFilter{Completed: &open, Assignee: AssigneeFilter{Names: []string{"alice"}}}); stats.Count != 1 || stats.RemainingMinutes != 30 {
+		t.Fatalf("expected alice's open task only got %+v", stats)
+	}
+}
+
+func TestMemoryRepo_UpdateDeleteReassign(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository()
+	task := &model.Task{Title: "old"}
+	task.SetAssignee("alice")
+	if err := repo.Create(ctx, task); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if err := repo.Update(ctx, &model.Task{ID: task.ID, Title: "new", Completed: true}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, _ := repo.GetByID(ctx, task.ID)
+	if got.Title != "new" || !got.Completed || got.Assignee.String != "alice" || !got.CreatedAt.Equal(task.CreatedAt) || got.Key() != "TM-1" {
+		t.Fatalf("unexpected task after update %+v", got)
+	}
+	if err := repo.Update(ctx, &model.Task{ID: "missing"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found got %v", err)
+	}
+
+	alice := "alice"
+	if ids, err := repo.Reassign(ctx, TaskFilter{Assignee: AssigneeIs(&alice)}, "bob"); err != nil || len(ids) != 1 {
+		t.Fatalf("unexpected reassign ids=%v err=%v", ids, err)
+	}
+	if got, _ := repo.GetByID(ctx, task.ID); got.Assignee.String != "bob" {
+		t.Fatalf("expected bob got %q", got.Assignee.String)
+	}
+
+	if ok, _ := repo.Delete(ctx, task.ID); !ok {
+		t.Fatalf("expected delete to report a row")
+	}
+	if _, err := repo.GetByID(ctx, task.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found got %v", err)
+	}
+	// numbers of deleted tasks are not reused
+	next := &model.Task{Title: "next"}
+	if err := repo.Create(ctx, next); err != nil || next.Key() != "TM-2" {
+		t.Fatalf("expected TM-2 got %q err=%v", next.Key(), err)
+	}
+	if got, err := repo.GetByNumber(ctx, 2); err != nil || got.ID != next.ID {
+		t.Fatalf("unexpected task by number %+v err=%v", got, err)
+	}
+}
+
+func TestMemoryRepo_DeleteCompleted(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository()
+	done, open := &model.Task{Title: "done"}, &model.Task{Title: "open"}
+	for _, task := range []*model.Task{done, open} {
+		if err := repo.Create(ctx, task); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	if _, _, err := repo.SetCompleted(ctx, done.ID, true); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	if n, _ := repo.CountCompleted(ctx, &past); n != 0 {
+		t.Fatalf("expected nothing completed an hour ago got %d", n)
+	}
+	if n, _ := repo.CountCompleted(ctx, nil); n != 1 {
+		t.Fatalf("expected one completed task got %d", n)
+	}
+	if ids, err := repo.DeleteCompleted(ctx, nil); err != nil || len(ids) != 1 || ids[0] != done.ID {
+		t.Fatalf("unexpected deleted ids=%v err=%v", ids, err)
+	}
+	if n, _ := repo.Count(ctx); n != 1 {
+		t.Fatalf("expected the open task to remain got %d tasks", n)
+	}
+}
+
+func TestMemoryRepo_MoveRebalances(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository()
+	repo.(*memoryRepo).SetDefaultSort([]SortField{{Column: "position"}})
+	ids := map[string]string{}
+	for _, title := range []string{"a", "b", "c"} {
+		task := &model.Task{Title: title}
+		if err := repo.Create(ctx, task); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		ids[title] = task.ID
+	}
+	order := func() string {
+		items, _ := repo.List(ctx, ListOptions{Limit: 10})
+		out := ""
+		for i, it := range items {
+			if i > 0 && it.Position == items[i-1].Position {
+				t.Fatalf("expected distinct positions got %v", items)
+			}
+			out += it.Title
+		}
+		return out
+	}
+	if got := order(); got != "abc" {
+		t.Fatalf("expected creation order got %s", got)
+	}
+
+	if _, err := repo.Move(ctx, ids["c"], ""); err != nil || order() != "cab" {
+		t.Fatalf("expected c first got %s err=%v", order(), err)
+	}
+	// each move halves the gap after a; without rebalancing the positions
+	// would collide long before 60 moves
+	for i := 0; i < 60; i++ {
+		moved, want := "c", "acb"
+		if i%2 == 1 {
+			moved, want = "b", "abc"
+		}
+		if _, err := repo.Move(ctx, ids[moved], ids["a"]); err != nil {
+			t.Fatalf("move %d: %v", i, err)
+		}
+		if got := order(); got != want {
+			t.Fatalf("move %d: expected %s got %s", i, want, got)
+		}
+	}
+	if _, err := repo.Move(ctx, ids["a"], "missing"); !errors.Is(err, ErrMoveAnchorNotFound) {
+		t.Fatalf("expected ErrMoveAnchorNotFound got %v", err)
+	}
+	if _, err := repo.Move(ctx, "missing", ids["a"]); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound got %v", err)
+	}
+}
+
+func TestMemoryRepo_WithTxRollsBack(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTaskRepository()
+	boom := errors.New("boom")
+	err := repo.WithTx(ctx, func(tx TaskRepository) error {
+		if err := tx.Create(ctx, &model.Task{Title: "t"}); err != nil {
+			return err
+		}
+		if n, _ := tx.Count(ctx); n != 1 {
+			t.Fatalf("expected the write to be visible inside the transaction")
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom got %v", err)
+	}
+	if n, _ := repo.Count(ctx); n != 0 {
+		t.Fatalf("expected rollback, got %d tasks", n)
+	}
+}
+
+func TestMemoryRepo_Tombstones(t *testing.T) {
+	ctx := logging.WithActor(context.Background(), "k1")
+	repo := NewMemoryTaskRepository()
+	tombstones := NewMemoryTombstoneRepository(repo)
+	var ids []string
+	for _, completed := range []bool{false, true, true} {
+		task := &model.Task{Title: "t", Completed: completed}
+		if err := repo.Create(ctx, task); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		ids = append(ids, task.ID)
+	}
+	if _, err := repo.Delete(ctx, ids[0]); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	// a rolled back delete leaves no tombstone
+	_ = repo.WithTx(ctx, func(tx TaskRepository) error {
+		if _, err := tx.DeleteCompleted(ctx, nil); err != nil {
+			return err
+		}
+		return errors.New("boom")
+	})
+	got, _ := tombstones.GetMany(ctx, ids)
+	if len(got) != 1 || got[ids[0]].Actor == nil || *got[ids[0]].Actor != "k1" || got[ids[0]].DeletedAt.IsZero() {
+		t.Fatalf("expected one tombstone by k1 got %+v", got)
+	}
+
+	if _, err := repo.DeleteCompleted(context.Background(), nil); err != nil {
+		t.Fatalf("delete completed: %v", err)
+	}
+	if got, _ := tombstones.GetMany(ctx, ids); len(got) != 3 || got[ids[1]].Actor != nil {
+		t.Fatalf("expected three tombstones, the new ones without actor, got %+v", got)
+	}
+	if n, _ := tombstones.Purge(ctx, time.Now().Add(-time.Hour)); n != 0 {
+		t.Fatalf("expected nothing older than an hour got %d", n)
+	}
+	if n, _ := tombstones.Purge(ctx, time.Now().Add(time.Second)); n != 3 {
+		t.Fatalf("expected 3 purged got %d", n)
+	}
+}
+
+func TestMemoryIncidentRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryIncidentRepository()
+	old := &model.Incident{Title: "old", StartedAt: time.Now().Add(-48 * time.Hour)}
+	cur := &model.Incident{Title: "current"}
+	for _, inc := range []*model.Incident{old, cur} {
+		if err := repo.Create(ctx, inc); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	if _, err := repo.Resolve(ctx, old.ID, time.Now()); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if _, err := repo.Resolve(ctx, 99, time.Now()); !errors.Is(err, ErrIncidentNotFound) {
+		t.Fatalf("expected not found got %v", err)
+	}
+	list, err := repo.ListSince(ctx, time.Now().Add(-time.Hour))
+	if err != nil || len(list) != 1 || list[0].ID != cur.ID || list[0].Severity != "minor" {
+		t.Fatalf("unexpected incidents %+v err=%v", list, err)
+	}
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import "errors"
+
+// ErrMoveAnchorNotFound is returned by Move when the task to place the moved
+// task after does not exist.
+var ErrMoveAnchorNotFound = errors.New("after task not found")
+
+// PositionGap is the distance between neighbouring positions after an append
+// or a rebalance.
+const PositionGap = 1024
+
+// minPositionGap is the smallest gap between two neighbours that a move still
+// splits; below it the positions are rebalanced first. float64 keeps about 52
+// halvings of PositionGap, so this leaves plenty of room.
+const minPositionGap = 1e-6
+
+// positionBetween returns the position of a task placed between prev and next,
+// nil meaning the start or end of the list. ok is false when the neighbours
+// are too close to split.
+func positionBetween(prev, next *float64) (pos float64, ok bool) {
+	switch {
+	case prev == nil && next == nil:
+		return PositionGap, true
+	case prev == nil:
+		return *next - PositionGap, true
+	case next == nil:
+		return *prev + PositionGap, true
+	}
+	if *next-*prev < minPositionGap {
+		return 0, false
+	}
+	return (*prev + *next) / 2, true
+}
@@ -0,0 +1,245 @@
+package repositories
+
+import (
+	"database/sql"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"taskmanager/internal/database"
+)
+
+// TaskFilter holds the optional predicates shared by List, CountFiltered,
+// Stats and Reassign. Nil (or empty) fields don't constrain the result.
+type TaskFilter struct {
+	Completed *bool
+	Assignee  AssigneeFilter
+	Dates     DateRange
+	// Title matches tasks whose title contains it, ignoring case.
+	Title string
+}
+
+// ListOptions selects a page of tasks for List. New list parameters go here
+// rather than into the signature of every implementation and caller.
+type ListOptions struct {
+	Filter TaskFilter
+	// Sort orders the page; empty uses the default ordering (see ParseSort).
+	Sort []SortField
+	// Limit is the page size (100 when not positive) and Offset the number
+	// of tasks skipped.
+	Limit, Offset int
+	// Fields (see ParseFields) may limit the columns read; the other fields
+	// of the returned tasks are then zero. Nil reads every column.
+	Fields []string
+}
+
+// AssigneeFilter matches tasks assigned to any of Names or, with Unassigned,
+// tasks without an assignee. The zero value matches every task.
+type AssigneeFilter struct {
+	Names      []string
+	Unassigned bool
+}
+
+// AssigneeIs returns the filter for a single optional assignee (nil or ""
+// matches every task).
+func AssigneeIs(name *string) AssigneeFilter {
+	if name == nil || *name == "" {
+		return AssigneeFilter{}
+	}
+	return AssigneeFilter{Names: []string{*name}}
+}
+
+// Empty reports whether the filter matches every task.
+func (a AssigneeFilter) Empty() bool {
+	return len(a.Names) == 0 && !a.Unassigned
+}
+
+// Matches reports whether a task with the given assignee passes the filter.
+func (a AssigneeFilter) Matches(assignee *string) bool {
+	if a.Empty() {
+		return true
+	}
+	if assignee == nil {
+		return a.Unassigned
+	}
+	return slices.Contains(a.Names, *assignee)
+}
+
+// cacheKey renders the filter for list cache keys. A single name keeps the
+// original "assignee=<name>" format; sets are sorted so the same set always
+// maps to the same key.
+func (a AssigneeFilter) cacheKey() string {
+	switch {
+	case a.Empty():
+		return "any"
+	case len(a.Names) == 1 && !a.Unassigned:
+		return a.Names[0]
+	}
+	names := make([]string, len(a.Names))
+	for i, n := range a.Names {
+		names[i] = url.QueryEscape(n)
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+	key := "any:assignees=" + strings.Join(names, ",")
+	if a.Unassigned {
+		key += ":unassigned"
+	}
+	return key
+}
+
+// DateRange restricts created_at/updated_at/due_date. Bounds are exclusive
+// and nil bounds are open; tasks without a due date never match a due bound.
+type DateRange struct {
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	DueAfter      *time.Time
+	DueBefore     *time.Time
+}
+
+// Empty reports whether no bound is set.
+func (r DateRange) Empty() bool {
+	return r.CreatedAfter == nil && r.CreatedBefore == nil && r.UpdatedAfter == nil && r.UpdatedBefore == nil &&
+		r.DueAfter == nil && r.DueBefore == nil
+}
+
+// Contains reports whether a task with the given timestamps is in the range.
+func (r DateRange) Contains(createdAt, updatedAt time.Time, due sql.NullTime) bool {
+	return (r.CreatedAfter == nil || createdAt.After(*r.CreatedAfter)) &&
+		(r.CreatedBefore == nil || createdAt.Before(*r.CreatedBefore)) &&
+		(r.UpdatedAfter == nil || updatedAt.After(*r.UpdatedAfter)) &&
+		(r.UpdatedBefore == nil || updatedAt.Before(*r.UpdatedBefore)) &&
+		(r.DueAfter == nil || (due.Valid && due.Time.After(*r.DueAfter))) &&
+		(r.DueBefore == nil || (due.Valid && due.Time.Before(*r.DueBefore)))
+}
+
+// cacheKey renders the bounds for list cache keys ("any" when unbounded).
+func (r DateRange) cacheKey() string {
+	if r.Empty() {
+		return "any"
+	}
+	bound := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	key := bound(r.CreatedAfter) + "~" + bound(r.CreatedBefore) + "~" + bound(r.UpdatedAfter) + "~" + bound(r.UpdatedBefore)
+	if r.DueAfter != nil || r.DueBefore != nil {
+		// keys without due bounds keep their original format
+		key += "~" + bound(r.DueAfter) + "~" + bound(r.DueBefore)
+	}
+	return key
+}
+
+// TitleContains reports whether title contains q, ignoring case.
+func TitleContains(title, q string) bool {
+	return strings.Contains(strings.ToLower(title), strings.ToLower(q))
+}
+
+// likeEscaper escapes the LIKE wildcards so a term matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Empty reports whether the filter has no predicates.
+func (f TaskFilter) Empty() bool {
+	return f.Completed == nil && f.Assignee.Empty() && f.Dates.Empty() && f.Title == ""
+}
+
+// apply adds the filter's predicates to b.
+func (f TaskFilter) apply(b *queryBuilder) {
+	if f.Completed != nil {
+		b.Where("completed = ?", *f.Completed)
+	}
+	if a := f.Assignee; !a.Empty() {
+		var cond []string
+		switch len(a.Names) {
+		case 0:
+		case 1:
+			cond = append(cond, "assignee = "+b.Arg(a.Names[0]))
+		default:
+			ph := make([]string, len(a.Names))
+			for i, n := range a.Names {
+				ph[i] = b.Arg(n)
+			}
+			cond = append(cond, "assignee IN ("+strings.Join(ph, ", ")+")")
+		}
+		if a.Unassigned {
+			cond = append(cond, "assignee IS NULL")
+		}
+		if len(cond) == 1 {
+			b.Where(cond[0])
+		} else {
+			b.Where("(" + strings.Join(cond, " OR ") + ")")
+		}
+	}
+	if f.Dates.CreatedAfter != nil {
+		b.Where("created_at > ?", f.Dates.CreatedAfter.UTC())
+	}
+	if f.Dates.CreatedBefore != nil {
+		b.Where("created_at < ?", f.Dates.CreatedBefore.UTC())
+	}
+	if f.Dates.UpdatedAfter != nil {
+		b.Where("updated_at > ?", f.Dates.UpdatedAfter.UTC())
+	}
+	if f.Dates.UpdatedBefore != nil {
+		b.Where("updated_at < ?", f.Dates.UpdatedBefore.UTC())
+	}
+	if f.Dates.DueAfter != nil {
+		b.Where("due_date > ?", f.Dates.DueAfter.UTC())
+	}
+	if f.Dates.DueBefore != nil {
+		b.Where("due_date < ?", f.Dates.DueBefore.UTC())
+	}
+	if f.Title != "" {
+		b.Where(b.d.ILike("title", b.Arg("%"+likeEscaper.Replace(f.Title)+"%")))
+	}
+}
+
+// queryBuilder composes a parameterized statement. Values are always passed
+// as arguments; placeholders are numbered ($1, $2, ...) in the order the
+// values are added, so fragments can be combined freely. d selects the
+// dialect of the few predicates that differ between databases.
+type queryBuilder struct {
+	d     database.Dialect
+	args  []interface{}
+	where []string
+}
+
+// Arg registers v and returns its placeholder.
+func (b *queryBuilder) Arg(v interface{}) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// Where adds a predicate, replacing each ? in cond with the next value.
+func (b *queryBuilder) Where(cond string, vals ...interface{}) {
+	var sb strings.Builder
+	for _, v := range vals {
+		i := strings.IndexByte(cond, '?')
+		if i < 0 {
+			break
+		}
+		sb.WriteString(cond[:i])
+		sb.WriteString(b.Arg(v))
+		cond = cond[i+1:]
+	}
+	sb.WriteString(cond)
+	b.where = append(b.where, sb.String())
+}
+
+// WhereClause renders the predicates as " WHERE a AND b", or "" when there are none.
+func (b *queryBuilder) WhereClause() string {
+	if len(b.where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.where, " AND ")
+}
+
+// Args returns the collected argument values.
+func (b *queryBuilder) Args() []interface{} {
+	return b.args
+}
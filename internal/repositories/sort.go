@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+
+	"taskmanager/internal/database"
+)
+
+// sortableColumns lists the task columns that may be used for ordering.
+var sortableColumns = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"due_date":   true,
+	"title":      true,
+	"completed":  true,
+	"assignee":   true,
+	"position":   true,
+}
+
+// SortField is a single ORDER BY term.
+type SortField struct {
+	Column string
+	Desc   bool
+	// Nulls is "", "FIRST" or "LAST".
+	Nulls string
+}
+
+// DefaultSort is the ordering used when none is configured.
+var DefaultSort = []SortField{{Column: "created_at", Desc: true}}
+
+// ParseSort parses a spec like "due_date asc nulls last, created_at desc".
+// Columns are restricted to a whitelist so the result is safe to splice into SQL.
+func ParseSort(spec string) ([]SortField, error) {
+	var fields []SortField
+	for _, part := range strings.Split(spec, ",") {
+		words := strings.Fields(strings.ToLower(part))
+		if len(words) == 0 {
+			continue
+		}
+		f := SortField{Column: words[0]}
+		if !sortableColumns[f.Column] {
+			return nil, fmt.Errorf("unknown sort column %q", words[0])
+		}
+		rest := words[1:]
+		if len(rest) > 0 && (rest[0] == "asc" || rest[0] == "desc") {
+			f.Desc = rest[0] == "desc"
+			rest = rest[1:]
+		}
+		if len(rest) == 2 && rest[0] == "nulls" && (rest[1] == "first" || rest[1] == "last") {
+			f.Nulls = strings.ToUpper(rest[1])
+			rest = rest[2:]
+		}
+		if len(rest) > 0 {
+			return nil, fmt.Errorf("invalid sort term %q", strings.TrimSpace(part))
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty sort specification")
+	}
+	return fields, nil
+}
+
+// String renders the fields back into a canonical spec (used in cache keys).
+func sortSpec(fields []SortField) string {
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		p := f.Column + " asc"
+		if f.Desc {
+			p = f.Column + " desc"
+		}
+		if f.Nulls != "" {
+			p += " nulls " + strings.ToLower(f.Nulls)
+		}
+		parts = append(parts, p)
+	}
+	return strings.Join(parts, ",")
+}
+
+// orderByClause renders the fields as an ORDER BY clause, always ending with
+// id as a tie-breaker so pagination over equal sort keys is deterministic.
+func orderByClause(fields []SortField, d database.Dialect) string {
+	terms := make([]string, 0, len(fields)+1)
+	for _, f := range fields {
+		terms = append(terms, d.OrderBy(f.Column, f.Desc, f.Nulls))
+	}
+	terms = append(terms, "id ASC")
+	return " ORDER BY " + strings.Join(terms, ", ")
+}
@@ -6,78 +6,369 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
 
+	"taskmanager/internal/cache"
+	"taskmanager/internal/database"
+	"taskmanager/internal/logging"
+	"taskmanager/internal/metric"
 	"taskmanager/internal/model"
+	"taskmanager/internal/outbox"
 )
 
 var ErrNotFound = errors.New("task not found")
 
+const selectTask = "SELECT id, number, title, description, assignee, completed, completed_at, due_date, estimate_minutes, remaining_minutes, position, time_spent_seconds, created_at, updated_at FROM tasks"
+
 // TaskRepository defines DB operations for tasks.
 type TaskRepository interface {
-	Create(task *model.Task) error
-	GetByID(id string) (*model.Task, error)
-	List(limit, offset int, completed *bool, assignee *string) ([]model.Task, error)
-	Update(task *model.Task) error
-	Delete(id string) (bool, error)
-	Count() (int, error)
-	// CountFiltered returns the number of tasks matching optional filters.
-	// If both filters are nil/empty, returns the total count (same as Count()).
-	CountFiltered(completed *bool, assignee *string) (int, error)
+	Create(ctx context.Context, task *model.Task) error
+	GetByID(ctx context.Context, id string) (*model.Task, error)
+	// GetByNumber returns the task with the given sequential number (the
+	// digits of its key) or ErrNotFound.
+	GetByNumber(ctx context.Context, number int64) (*model.Task, error)
+	// GetManyByNumber is GetMany for task numbers: one query, no particular
+	// order, unknown numbers absent.
+	GetManyByNumber(ctx context.Context, numbers []int64) ([]model.Task, error)
+	// GetMany returns the tasks with the given ids in one query, in no
+	// particular order; unknown ids are simply absent from the result.
+	GetMany(ctx context.Context, ids []string) ([]model.Task, error)
+	// List returns the page of tasks selected by opts (see ListOptions).
+	List(ctx context.Context, opts ListOptions) ([]model.Task, error)
+	Update(ctx context.Context, task *model.Task) error
+	// SetCompleted marks the task completed (recording completed_at) or open
+	// again with a single guarded UPDATE and records a task.completed or
+	// task.reopened event. changed is false, and nothing is written, when the
+	// task already was in that state.
+	SetCompleted(ctx context.Context, id string, completed bool) (task *model.Task, changed bool, err error)
+	// Move places the task right after afterID in the manual order, or first
+	// when afterID is empty, and records a task.moved event. When the
+	// neighbouring positions are too close to split, every task is renumbered
+	// PositionGap apart first. It fails with ErrMoveAnchorNotFound when
+	// afterID does not exist.
+	Move(ctx context.Context, id, afterID string) (*model.Task, error)
+	Delete(ctx context.Context, id string) (bool, error)
+	// DeleteCompleted deletes every completed task, or only those completed
+	// before the given time, in one statement and records a task.deleted
+	// event per task. It returns the ids of the deleted tasks.
+	DeleteCompleted(ctx context.Context, before *time.Time) ([]string, error)
+	// CountCompleted counts the tasks DeleteCompleted would delete.
+	CountCompleted(ctx context.Context, before *time.Time) (int, error)
+	Count(ctx context.Context) (int, error)
+	// CountFiltered returns the number of tasks matching the filter. An empty
+	// filter counts every task (same as Count()).
+	CountFiltered(ctx context.Context, f TaskFilter) (int, error)
+	// EstimateCount is a cheap approximation of CountFiltered for large
+	// tables; backends without planner statistics count exactly.
+	EstimateCount(ctx context.Context, f TaskFilter) (int, error)
+	// Stats aggregates the tasks matching the filter, overall and per
+	// assignee (unassigned first, then by name).
+	Stats(ctx context.Context, f TaskFilter) (*model.TaskStats, error)
+	// Reassign sets the assignee of every task matching f to `to` in a single
+	// transaction and returns the ids of the reassigned tasks. f must not be
+	// empty.
+	Reassign(ctx context.Context, f TaskFilter, to string) ([]string, error)
+	// WithTx runs fn with a repository bound to a single transaction, committed
+	// when fn returns nil and rolled back otherwise. Cache invalidation for
+	// writes made through it happens only after commit.
+	WithTx(ctx context.Context, fn func(repo TaskRepository) error) error
 
 	// Optional: attach a Redis client for cache-aside behavior
 	SetCacheClient(rdb *redis.Client)
 }
 
 type taskRepo struct {
-	db  *sqlx.DB
-	rdb *redis.Client
+	db *sqlx.DB
+	d  database.Dialect
+	// tx and pending are set on repositories handed out by WithTx
+	tx      *sqlx.Tx
+	pending *pendingInvalidation
+	// rdb may be attached/detached and cache retuned at runtime; read them
+	// through cacheClient and cacheOptions
+	mu    sync.RWMutex
+	rdb   *redis.Client
+	cache CacheOptions
+	// local is the optional in-process L1 cache (nil when disabled)
+	local *cache.LRU
+	sort  []SortField
+
+	// replica serves reads outside transactions when set; for readYourWrites
+	// after lastWrite (unix nanos, shared with WithTx copies) reads stay on
+	// the primary instead.
+	replica        *sqlx.DB
+	readYourWrites time.Duration
+	lastWrite      *atomic.Int64
+
+	// list keys with a background refresh in flight (stale-while-revalidate)
+	refreshing sync.Map
+
+	// origin tags published invalidations (see originID)
+	origin     string
+	originOnce sync.Once
 }
 
 // NewTaskRepository creates a new TaskRepository backed by sqlx.DB.
 func NewTaskRepository(db *sqlx.DB) TaskRepository {
-	return &taskRepo{db: db}
+	return &taskRepo{db: db, d: database.For(db), cache: CacheOptions{ListTTL: defaultListTTL, ItemTTL: defaultItemTTL}}
+}
+
+// SetReadReplica routes GetByID, List, Count and CountFiltered to replica
+// (nil reads from the primary again). For readYourWrites after a write
+// through this repository, reads go to the primary so a client does not miss
+// its own change because of replication lag.
+func (r *taskRepo) SetReadReplica(replica *sqlx.DB, readYourWrites time.Duration) {
+	r.replica = replica
+	r.readYourWrites = readYourWrites
+	if r.lastWrite == nil {
+		r.lastWrite = new(atomic.Int64)
+	}
+}
+
+// markWritten records a committed write for read-your-writes routing.
+func (r *taskRepo) markWritten() {
+	if r.lastWrite != nil {
+		r.lastWrite.Store(time.Now().UnixNano())
+	}
+}
+
+// SetDefaultSort sets the ordering used by List (see ParseSort).
+func (r *taskRepo) SetDefaultSort(fields []SortField) {
+	r.sort = fields
+}
+
+func (r *taskRepo) sortFields() []SortField {
+	if len(r.sort) == 0 {
+		return DefaultSort
+	}
+	return r.sort
+}
+
+// SetCacheOptions configures cache TTLs, jitter and stale serving.
+func (r *taskRepo) SetCacheOptions(o CacheOptions) {
+	r.mu.Lock()
+	r.cache = o
+	r.mu.Unlock()
+	r.local = nil
+	if o.LocalMaxEntries > 0 && o.LocalTTL > 0 {
+		r.local = cache.NewLRU(o.LocalMaxEntries, func() {
+			metric.CacheEvictions.WithLabelValues("local").Inc()
+		})
+	}
+}
+
+// SetCacheTTLs replaces the TTLs, jitter and stale window of the cache while
+// requests are being served. The L1 cache and Pub/Sub settings chosen by
+// SetCacheOptions are kept.
+func (r *taskRepo) SetCacheTTLs(o CacheOptions) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o.LocalMaxEntries, o.LocalTTL, o.PubSub = r.cache.LocalMaxEntries, r.cache.LocalTTL, r.cache.PubSub
+	r.cache = o
+}
+
+func (r *taskRepo) cacheOptions() CacheOptions {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cache
 }
 
 // SetCacheClient attaches a Redis client to the repository to enable cache-aside
 // behavior for List() and invalidation on Create/Update/Delete.
+// A nil client detaches the cache.
 func (r *taskRepo) SetCacheClient(rdb *redis.Client) {
+	r.mu.Lock()
 	r.rdb = rdb
+	r.mu.Unlock()
+}
+
+func (r *taskRepo) cacheClient() *redis.Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rdb
 }
 
-func (r *taskRepo) cacheKeyForList(limit, offset int, completed *bool, assignee *string) string {
+// cacheKeyForList renders opts, whose Sort must already be resolved, as the
+// cache key of the page.
+func (r *taskRepo) cacheKeyForList(opts ListOptions) string {
+	f := opts.Filter
 	compVal := "any"
-	if completed != nil {
-		compVal = fmt.Sprintf("%v", *completed)
+	if f.Completed != nil {
+		compVal = fmt.Sprintf("%v", *f.Completed)
+	}
+	key := fmt.Sprintf("tasks:list:limit=%d:offset=%d:completed=%s:assignee=%s:sort=%s", opts.Limit, opts.Offset, compVal, f.Assignee.cacheKey(), sortSpec(opts.Sort))
+	if !f.Dates.Empty() {
+		// unbounded keys keep their original format
+		key += ":dates=" + f.Dates.cacheKey()
+	}
+	if f.Title != "" {
+		key += ":title=" + url.QueryEscape(f.Title)
+	}
+	if len(opts.Fields) > 0 {
+		key += ":fields=" + strings.Join(opts.Fields, ",")
+	}
+	return key
+}
+
+// pendingInvalidation collects the cache invalidations of a transaction so
+// they run once it has committed.
+type pendingInvalidation struct {
+	list bool
+	ids  []string
+}
+
+// WithTx implements TaskRepository. Nested calls reuse the outer transaction.
+func (r *taskRepo) WithTx(ctx context.Context, fn func(repo TaskRepository) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txRepo := &taskRepo{db: r.db, d: r.d, tx: tx, pending: &pendingInvalidation{}, rdb: r.cacheClient(), cache: r.cacheOptions(), local: r.local, sort: r.sort, lastWrite: r.lastWrite}
+	if err := fn(txRepo); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.markWritten()
+
+	r.invalidate(ctx, txRepo.pending.list, txRepo.pending.ids...)
+	return nil
+}
+
+// inTx runs fn in the bound transaction, or in a new one that is committed
+// when fn succeeds.
+func (r *taskRepo) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.markWritten()
+	return nil
+}
+
+type primaryKey struct{}
+
+// WithPrimary returns a copy of ctx whose reads skip the read replica, for
+// callers that must not see an older state than the primary, such as a
+// sync delta built from the outbox on the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// conn returns the handle reads should use: the bound transaction, else the
+// replica unless ctx asks for the primary (see WithPrimary) or this instance
+// wrote within the read-your-writes window.
+func (r *taskRepo) conn(ctx context.Context) sqlx.QueryerContext {
+	if r.tx != nil {
+		return r.tx
+	}
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		return r.db
+	}
+	if r.replica != nil && time.Since(time.Unix(0, r.lastWrite.Load())) >= r.readYourWrites {
+		return r.replica
+	}
+	return r.db
+}
+
+// invalidateAfterWrite drops the list caches and the item entries for ids,
+// deferring to commit when running inside WithTx.
+func (r *taskRepo) invalidateAfterWrite(ctx context.Context, ids ...string) {
+	if r.pending != nil {
+		r.pending.list = true
+		r.pending.ids = append(r.pending.ids, ids...)
+		return
 	}
-	assVal := "any"
-	if assignee != nil {
-		assVal = *assignee
+	r.invalidate(ctx, true, ids...)
+}
+
+func cacheKeyForItem(id string) string {
+	return "tasks:id:" + id
+}
+
+// invalidateItems removes the cached entries (including negative ones) for ids.
+// Like invalidateListCache it runs after commit and ignores cancellation.
+func (r *taskRepo) invalidateItems(ctx context.Context, ids ...string) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = cacheKeyForItem(id)
+	}
+	if r.local != nil {
+		r.local.Delete(keys...)
 	}
-	return fmt.Sprintf("tasks:list:limit=%d:offset=%d:completed=%s:assignee=%s", limit, offset, compVal, assVal)
+	rdb := r.cacheClient()
+	if rdb == nil || len(ids) == 0 {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	start := time.Now()
+	n, err := rdb.Del(ctx, keys...).Result()
+	metric.ObserveRedis("del", start)
+	if err != nil {
+		logging.FromContext(ctx).Warn("item cache invalidation failed", "err", err)
+		return
+	}
+	metric.CacheInvalidations.WithLabelValues("item").Add(float64(n))
 }
 
 // invalidateListCache removes cached list entries. For simplicity we remove the specific key used,
-// and also attempt a simple pattern delete for task lists. If r.rdb is nil, this is a no-op.
+// and also attempt a simple pattern delete for task lists. If no cache client is attached, this is a no-op.
+// It runs after the DB change has committed, so it must not be skipped when the
+// caller's context is cancelled: cancellation is detached (deadline-free) here.
 func (r *taskRepo) invalidateListCache(ctx context.Context) {
-	if r.rdb == nil {
+	if r.local != nil {
+		r.local.DeletePrefix("tasks:list:")
+	}
+	rdb := r.cacheClient()
+	if rdb == nil {
 		return
 	}
+	ctx = context.WithoutCancel(ctx)
 	// It's expensive to scan by pattern in Redis at scale; for MVP we attempt to delete keys with known prefix.
 	pattern := "tasks:list:*"
-	iter := r.rdb.Scan(ctx, 0, pattern, 100).Iterator()
+	start := time.Now()
+	iter := rdb.Scan(ctx, 0, pattern, 100).Iterator()
 	for iter.Next(ctx) {
-		_ = r.rdb.Del(ctx, iter.Val()).Err()
+		delStart := time.Now()
+		if err := rdb.Del(ctx, iter.Val()).Err(); err == nil {
+			metric.CacheInvalidations.WithLabelValues("list").Inc()
+		}
+		metric.ObserveRedis("del", delStart)
+	}
+	metric.ObserveRedis("scan", start)
+	if err := iter.Err(); err != nil {
+		logging.FromContext(ctx).Warn("list cache invalidation failed", "err", err)
 	}
-	// ignore iter.Err() for now
 }
 
-// Create inserts a new task and invalidates list caches.
-func (r *taskRepo) Create(task *model.Task) error {
+// Create inserts a new task at the end of the manual order together with its
+// task.created outbox event and invalidates list caches.
+func (r *taskRepo) Create(ctx context.Context, task *model.Task) error {
+	ctx = database.WithOperation(ctx, "tasks", "create")
 	if task == nil {
 		return errors.New("task is nil")
 	}
@@ -87,23 +378,88 @@ func (r *taskRepo) Create(task *model.Task) error {
 	now := time.Now().UTC()
 	task.CreatedAt = now
 	task.UpdatedAt = now
+	task.CompletedAt = sql.NullTime{Time: now, Valid: task.Completed}
 
-	query := `INSERT INTO tasks (id, title, description, assignee, completed, due_date, created_at, updated_at)
-VALUES (:id, :title, :description, :assignee, :completed, :due_date, :created_at, :updated_at)`
+	query := `INSERT INTO tasks (id, title, description, assignee, completed, completed_at, due_date, estimate_minutes, remaining_minutes, position, created_at, updated_at)
+VALUES (:id, :title, :description, :assignee, :completed, :completed_at, :due_date, :estimate_minutes, :remaining_minutes, :position, :created_at, :updated_at)`
 
-	_, err := r.db.NamedExec(query, task)
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		// concurrent creates may pick the same position; id breaks the tie
+		// and the next move through it rebalances
+		var last sql.NullFloat64
+		if err := tx.GetContext(ctx, &last, "SELECT MAX(position) FROM tasks"); err != nil {
+			return err
+		}
+		task.Position, _ = positionBetween(nullFloat(last), nil)
+		if _, err := tx.NamedExecContext(ctx, query, task); err != nil {
+			return err
+		}
+		// the number comes from the database (sequence, AUTO_INCREMENT or
+		// SQLite trigger); read it back so the event carries the key
+		if err := tx.GetContext(ctx, &task.Number, r.d.Rebind("SELECT number FROM tasks WHERE id = $1"), task.ID); err != nil {
+			return err
+		}
+		return outbox.Insert(ctx, tx, outbox.EventTaskCreated, task.ID, task)
+	})
 	if err != nil {
 		return err
 	}
 
-	// invalidate list cache after create
-	r.invalidateListCache(context.Background())
+	// invalidate list cache after create; the id may have been negatively cached
+	r.invalidateAfterWrite(ctx, task.ID)
 	return nil
 }
 
-func (r *taskRepo) GetByID(id string) (*model.Task, error) {
+// GetByID reads through the per-item cache (tasks:id:<id>). Misses are
+// cached as well when negative caching is enabled. Inside WithTx the cache
+// is bypassed and the row is locked for the rest of the transaction.
+func (r *taskRepo) GetByID(ctx context.Context, id string) (*model.Task, error) {
+	ctx = database.WithOperation(ctx, "tasks", "get")
+	if r.tx != nil {
+		var t model.Task
+		if err := r.tx.GetContext(ctx, &t, r.d.Rebind(selectTask+" WHERE id = $1")+r.d.ForUpdate(), id); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, ErrNotFound
+			}
+			return nil, err
+		}
+		return &t, nil
+	}
+
+	cacheKey := cacheKeyForItem(id)
+	if s, ok := r.cacheGet(ctx, cacheKey, "item"); ok {
+		if s == notFoundMarker {
+			return nil, ErrNotFound
+		}
+		var cached model.Task
+		if jerr := json.Unmarshal([]byte(s), &cached); jerr == nil {
+			return &cached, nil
+		}
+	}
+
 	var t model.Task
-	err := r.db.Get(&t, "SELECT id, title, description, assignee, completed, due_date, created_at, updated_at FROM tasks WHERE id = $1", id)
+	err := sqlx.GetContext(ctx, r.conn(ctx), &t, r.d.Rebind(selectTask+" WHERE id = $1"), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if ttl := r.cacheOptions().NegativeTTL; ttl > 0 {
+				r.cacheSet(ctx, cacheKey, notFoundMarker, ttl, "item")
+			}
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if b, merr := json.Marshal(&t); merr == nil {
+		r.cacheSet(ctx, cacheKey, string(b), r.cacheOptions().itemTTL(), "item")
+	}
+	return &t, nil
+}
+
+// GetByNumber implements TaskRepository. It bypasses the cache; callers use
+// it to resolve a key and then read the task by id.
+func (r *taskRepo) GetByNumber(ctx context.Context, number int64) (*model.Task, error) {
+	ctx = database.WithOperation(ctx, "tasks", "get")
+	var t model.Task
+	err := sqlx.GetContext(ctx, r.conn(ctx), &t, r.d.Rebind(selectTask+" WHERE number = $1"), number)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
@@ -115,19 +471,40 @@ func (r *taskRepo) GetByID(id string) (*model.Task, error) {
 
 // List attempts to return a cached result (if Redis client provided) using cache-aside pattern.
 // If cache miss or no Redis configured, it queries DB and populates cache.
-func (r *taskRepo) List(limit, offset int, completed *bool, assignee *string) ([]model.Task, error) {
-	// Attempt cache read first (cache-aside). If Redis client not configured or cache miss,
-	// fall back to DB and then populate cache.
-	cacheKey := r.cacheKeyForList(limit, offset, completed, assignee)
-	if r.rdb != nil {
-		if s, err := r.rdb.Get(context.Background(), cacheKey).Result(); err == nil {
-			var cached []model.Task
-			if jerr := json.Unmarshal([]byte(s), &cached); jerr == nil {
-				return cached, nil
+// With stale serving enabled, an expired page is still returned while a
+// single background refresh reloads it.
+func (r *taskRepo) List(ctx context.Context, opts ListOptions) ([]model.Task, error) {
+	ctx = database.WithOperation(ctx, "tasks", "list")
+	if len(opts.Sort) == 0 {
+		opts.Sort = r.sortFields()
+	}
+	if r.tx != nil {
+		// the transaction may see its own uncommitted writes; keep them out of the cache
+		return r.queryList(ctx, opts)
+	}
+
+	// Attempt cache read first (cache-aside). On a miss fall back to DB and
+	// then populate the cache.
+	cacheKey := r.cacheKeyForList(opts)
+	if s, ok := r.cacheGet(ctx, cacheKey, "list"); ok {
+		if cached, ok := decodeCachedList(s); ok {
+			if cached.stale() {
+				r.refreshListAsync(ctx, cacheKey, opts)
 			}
+			return cached.Items, nil
 		}
 	}
 
+	tasks, err := r.queryList(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	r.storeList(ctx, cacheKey, tasks)
+	return tasks, nil
+}
+
+func (r *taskRepo) queryList(ctx context.Context, opts ListOptions) ([]model.Task, error) {
+	limit, offset := opts.Limit, opts.Offset
 	if limit <= 0 {
 		limit = 100
 	}
@@ -135,121 +512,536 @@ func (r *taskRepo) List(limit, offset int, completed *bool, assignee *string) ([
 		offset = 0
 	}
 
-	ctx := context.Background()
-
-	baseSelect := `
-SELECT id, title, description, assignee, completed, due_date, created_at, updated_at
-FROM tasks
-`
-	var query string
-	var args []interface{}
-
-	if completed == nil && (assignee == nil || *assignee == "") {
-		query = baseSelect + " ORDER BY created_at DESC LIMIT $1 OFFSET $2"
-		args = []interface{}{limit, offset}
-	} else if completed != nil && (assignee == nil || *assignee == "") {
-		query = baseSelect + " WHERE completed = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3"
-		args = []interface{}{*completed, limit, offset}
-	} else if completed == nil && assignee != nil {
-		query = baseSelect + " WHERE assignee = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3"
-		args = []interface{}{*assignee, limit, offset}
-	} else {
-		query = baseSelect + " WHERE completed = $1 AND assignee = $2 ORDER BY created_at DESC LIMIT $3 OFFSET $4"
-		args = []interface{}{*completed, *assignee, limit, offset}
-	}
+	b := &queryBuilder{d: r.d}
+	opts.Filter.apply(b)
+	query := selectTaskFields(opts.Fields) + b.WhereClause() + orderByClause(opts.Sort, r.d) + " LIMIT " + b.Arg(limit) + " OFFSET " + b.Arg(offset)
 
 	var tasks []model.Task
-	if err := r.db.Select(&tasks, query, args...); err != nil {
+	if err := sqlx.SelectContext(ctx, r.conn(ctx), &tasks, r.d.Rebind(query), b.Args()...); err != nil {
 		// If no rows found, return empty slice and total=0
 		if err == sql.ErrNoRows {
 			return []model.Task{}, nil
 		}
 		return nil, err
 	}
+	return tasks, nil
+}
+
+// storeList writes a list page to the cache. Without stale serving the plain
+// items array is stored (the original cache format).
+func (r *taskRepo) storeList(ctx context.Context, cacheKey string, tasks []model.Task) {
+	if r.cacheClient() == nil && r.local == nil {
+		return
+	}
+	opts := r.cacheOptions()
+	ttl := opts.listTTL()
+	var payload interface{} = tasks
+	if opts.StaleTTL > 0 {
+		payload = cachedList{FreshUntil: time.Now().Add(ttl), Items: tasks}
+		ttl += opts.StaleTTL
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	r.cacheSet(ctx, cacheKey, string(b), ttl, "list")
+}
+
+// refreshListAsync reloads a stale list page in the background. At most one
+// refresh per key runs in this process at a time.
+func (r *taskRepo) refreshListAsync(ctx context.Context, cacheKey string, opts ListOptions) {
+	if _, busy := r.refreshing.LoadOrStore(cacheKey, struct{}{}); busy {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer r.refreshing.Delete(cacheKey)
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		tasks, err := r.queryList(ctx, opts)
+		if err != nil {
+			logging.FromContext(ctx).Warn("list cache refresh failed", "key", cacheKey, "err", err)
+			return
+		}
+		r.storeList(ctx, cacheKey, tasks)
+	}()
+}
 
-	// Populate cache (repositories only items for backward compatibility with prior cache format)
-	// Note: cache key remains the same. We continue to cache items array.
-	if r.rdb != nil {
-		if b, merr := json.Marshal(tasks); merr == nil {
-			_ = r.rdb.Set(ctx, cacheKey, string(b), 60*time.Second).Err()
+// GetMany reads the tasks straight from the database (bypassing the item
+// cache) with a single id = ANY / id IN query.
+func (r *taskRepo) GetMany(ctx context.Context, ids []string) ([]model.Task, error) {
+	ctx = database.WithOperation(ctx, "tasks", "get")
+	tasks := []model.Task{}
+	if r.d.Postgres() {
+		// ids are UUIDs there; anything else cannot match and would fail the cast
+		valid := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if _, err := uuid.Parse(id); err == nil {
+				valid = append(valid, id)
+			}
+		}
+		if len(valid) == 0 {
+			return tasks, nil
 		}
+		err := sqlx.SelectContext(ctx, r.conn(ctx), &tasks, selectTask+" WHERE id = ANY($1::uuid[])", pq.Array(valid))
+		return tasks, err
 	}
+	if len(ids) == 0 {
+		return tasks, nil
+	}
+	query, args, err := sqlx.In(selectTask+" WHERE id IN (?)", ids)
+	if err != nil {
+		return nil, err
+	}
+	err = sqlx.SelectContext(ctx, r.conn(ctx), &tasks, r.d.Rebind(query), args...)
+	return tasks, err
+}
 
-	return tasks, nil
+// GetManyByNumber reads the tasks straight from the database with a single
+// number = ANY / number IN query.
+func (r *taskRepo) GetManyByNumber(ctx context.Context, numbers []int64) ([]model.Task, error) {
+	ctx = database.WithOperation(ctx, "tasks", "get")
+	tasks := []model.Task{}
+	if len(numbers) == 0 {
+		return tasks, nil
+	}
+	if r.d.Postgres() {
+		err := sqlx.SelectContext(ctx, r.conn(ctx), &tasks, selectTask+" WHERE number = ANY($1)", pq.Array(numbers))
+		return tasks, err
+	}
+	query, args, err := sqlx.In(selectTask+" WHERE number IN (?)", numbers)
+	if err != nil {
+		return nil, err
+	}
+	err = sqlx.SelectContext(ctx, r.conn(ctx), &tasks, r.d.Rebind(query), args...)
+	return tasks, err
 }
 
-func (r *taskRepo) Update(task *model.Task) error {
+// Export calls fn with every task, oldest first, as the rows come off the
+// database cursor: the tasks are never all in memory and the cache is
+// bypassed. It stops at the first error fn returns.
+func (r *taskRepo) Export(ctx context.Context, fn func(model.Task) error) error {
+	ctx = database.WithOperation(ctx, "tasks", "export")
+	rows, err := r.conn(ctx).QueryxContext(ctx, selectTask+" ORDER BY created_at, id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t model.Task
+		if err := rows.StructScan(&t); err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *taskRepo) Update(ctx context.Context, task *model.Task) error {
+	ctx = database.WithOperation(ctx, "tasks", "update")
 	if task == nil {
 		return errors.New("task is nil")
 	}
 	task.UpdatedAt = time.Now()
 
-	query := `UPDATE tasks SET title = :title, description = :description, completed = :completed, due_date = :due_date, updated_at = :updated_at WHERE id = :id`
-	res, err := r.db.NamedExec(query, task)
+	// completed_at keeps the time of the original transition while the task
+	// stays completed
+	query := `UPDATE tasks SET title = :title, description = :description, completed = :completed,
+completed_at = CASE WHEN :completed THEN COALESCE(completed_at, :updated_at) END,
+due_date = :due_date, estimate_minutes = :estimate_minutes, remaining_minutes = :remaining_minutes, updated_at = :updated_at WHERE id = :id`
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.NamedExecContext(ctx, query, task)
+		if err != nil {
+			return err
+		}
+		ra, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if ra == 0 {
+			return ErrNotFound
+		}
+		return outbox.Insert(ctx, tx, outbox.EventTaskUpdated, task.ID, task)
+	})
 	if err != nil {
 		return err
 	}
-	ra, err := res.RowsAffected()
+
+	// invalidate caches after update
+	r.invalidateAfterWrite(ctx, task.ID)
+	return nil
+}
+
+// SetCompleted implements TaskRepository.
+func (r *taskRepo) SetCompleted(ctx context.Context, id string, completed bool) (*model.Task, bool, error) {
+	ctx = database.WithOperation(ctx, "tasks", "update")
+	now := time.Now().UTC()
+	completedAt := sql.NullTime{Time: now, Valid: completed}
+	event := outbox.EventTaskReopened
+	if completed {
+		event = outbox.EventTaskCompleted
+	}
+
+	var t model.Task
+	var changed bool
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, r.d.Rebind("UPDATE tasks SET completed = $1, completed_at = $2, updated_at = $3 WHERE id = $4 AND completed = $5"),
+			completed, completedAt, now, id, !completed)
+		if err != nil {
+			return err
+		}
+		ra, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if err := tx.GetContext(ctx, &t, r.d.Rebind(selectTask+" WHERE id = $1"), id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if ra == 0 {
+			return nil
+		}
+		changed = true
+		return outbox.Insert(ctx, tx, event, id, &t)
+	})
 	if err != nil {
-		return err
+		return nil, false, err
 	}
-	if ra == 0 {
-		return ErrNotFound
+	if changed {
+		r.invalidateAfterWrite(ctx, id)
 	}
+	return &t, changed, nil
+}
 
-	// invalidate list cache after update
-	r.invalidateListCache(context.Background())
-	return nil
+// Move implements TaskRepository.
+func (r *taskRepo) Move(ctx context.Context, id, afterID string) (*model.Task, error) {
+	ctx = database.WithOperation(ctx, "tasks", "move")
+	var t model.Task
+	var rebalanced []string
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, &t, r.d.Rebind(selectTask+" WHERE id = $1")+r.d.ForUpdate(), id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+		pos, ok, err := r.positionAfter(ctx, tx, id, afterID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if rebalanced, err = r.rebalance(ctx, tx); err != nil {
+				return err
+			}
+			if pos, _, err = r.positionAfter(ctx, tx, id, afterID); err != nil {
+				return err
+			}
+		}
+		now := time.Now().UTC()
+		if _, err := tx.ExecContext(ctx, r.d.Rebind("UPDATE tasks SET position = $1, updated_at = $2 WHERE id = $3"), pos, now, id); err != nil {
+			return err
+		}
+		t.Position = pos
+		t.UpdatedAt = now
+		return outbox.Insert(ctx, tx, outbox.EventTaskMoved, id, &t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.invalidateAfterWrite(ctx, append(rebalanced, id)...)
+	return &t, nil
 }
 
-func (r *taskRepo) Delete(id string) (bool, error) {
-	res, err := r.db.Exec("DELETE FROM tasks WHERE id = $1", id)
+// positionAfter computes the position for moving id right after afterID
+// (first when empty). ok is false when the gap there is too small; a task
+// sharing the anchor's position counts as no gap at all.
+func (r *taskRepo) positionAfter(ctx context.Context, tx *sqlx.Tx, id, afterID string) (pos float64, ok bool, err error) {
+	var prev *float64
+	next := sql.NullFloat64{}
+	if afterID == "" {
+		err = tx.GetContext(ctx, &next, r.d.Rebind("SELECT MIN(position) FROM tasks WHERE id <> $1"), id)
+	} else {
+		var anchor float64
+		if err := tx.GetContext(ctx, &anchor, r.d.Rebind("SELECT position FROM tasks WHERE id = $1"), afterID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, false, ErrMoveAnchorNotFound
+			}
+			return 0, false, err
+		}
+		prev = &anchor
+		err = tx.GetContext(ctx, &next, r.d.Rebind("SELECT MIN(position) FROM tasks WHERE position >= $1 AND id <> $2 AND id <> $3"), anchor, id, afterID)
+	}
 	if err != nil {
-		return false, err
+		return 0, false, err
+	}
+	pos, ok = positionBetween(prev, nullFloat(next))
+	return pos, ok, nil
+}
+
+// rebalance renumbers every task PositionGap apart, keeping the current order,
+// and returns the ids whose position changed. It is O(n) statements but only
+// runs once a gap has been halved some 30 times. On PostgreSQL the updated_at
+// trigger touches the renumbered rows as well.
+func (r *taskRepo) rebalance(ctx context.Context, tx *sqlx.Tx) ([]string, error) {
+	var rows []struct {
+		ID       string  `db:"id"`
+		Position float64 `db:"position"`
+	}
+	if err := tx.SelectContext(ctx, &rows, "SELECT id, position FROM tasks ORDER BY position, id"+r.d.ForUpdate()); err != nil {
+		return nil, err
+	}
+	var changed []string
+	for i, row := range rows {
+		pos := float64(i+1) * PositionGap
+		if row.Position == pos {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, r.d.Rebind("UPDATE tasks SET position = $1 WHERE id = $2"), pos, row.ID); err != nil {
+			return nil, err
+		}
+		changed = append(changed, row.ID)
+	}
+	logging.FromContext(ctx).Info("rebalanced task positions", "tasks", len(rows), "changed", len(changed))
+	return changed, nil
+}
+
+func nullFloat(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
 	}
-	ra, err := res.RowsAffected()
+	return &f.Float64
+}
+
+func (r *taskRepo) Delete(ctx context.Context, id string) (bool, error) {
+	ctx = database.WithOperation(ctx, "tasks", "delete")
+	var deleted bool
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, r.d.Rebind("DELETE FROM tasks WHERE id = $1"), id)
+		if err != nil {
+			return err
+		}
+		ra, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		deleted = ra > 0
+		if !deleted {
+			return nil
+		}
+		if r.d.SQLite() {
+			// SQLite doesn't enforce the ON DELETE CASCADE unless foreign keys
+			// are enabled on the connection
+			for _, table := range []string{"task_watchers", "time_entries"} {
+				if _, err := tx.ExecContext(ctx, r.d.Rebind("DELETE FROM "+table+" WHERE task_id = $1"), id); err != nil {
+					return err
+				}
+			}
+		}
+		t := newTombstone(ctx, id)
+		if err := insertTombstone(ctx, tx, r.d, t); err != nil {
+			return err
+		}
+		return outbox.Insert(ctx, tx, outbox.EventTaskDeleted, id, deletedPayload(t))
+	})
 	if err != nil {
 		return false, err
 	}
-	deleted := ra > 0
 
-	// invalidate list cache after delete
+	// invalidate caches after delete
 	if deleted {
-		r.invalidateListCache(context.Background())
+		r.invalidateAfterWrite(ctx, id)
 	}
 	return deleted, nil
 }
 
-func (r *taskRepo) Count() (int, error) {
+// deletedPayload is the task.deleted event of the task t is the tombstone of.
+func deletedPayload(t model.Tombstone) map[string]any {
+	return map[string]any{"id": t.TaskID, "deleted_at": t.DeletedAt, "actor": t.Actor}
+}
+
+// completedFilter selects the completed tasks, optionally only those
+// completed before the given time.
+func (r *taskRepo) completedFilter(before *time.Time) *queryBuilder {
+	b := &queryBuilder{d: r.d}
+	b.Where("completed = ?", true)
+	if before != nil {
+		b.Where("completed_at < ?", before.UTC())
+	}
+	return b
+}
+
+// DeleteCompleted implements TaskRepository. Postgres and SQLite report the
+// deleted ids through RETURNING; MySQL has none, so the ids are read first
+// under a row lock, as in Reassign.
+func (r *taskRepo) DeleteCompleted(ctx context.Context, before *time.Time) ([]string, error) {
+	ctx = database.WithOperation(ctx, "tasks", "delete")
+	var ids []string
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		if r.d.SQLite() {
+			// no ON DELETE CASCADE without foreign keys enabled, see Delete
+			for _, table := range []string{"task_watchers", "time_entries"} {
+				b := r.completedFilter(before)
+				if _, err := tx.ExecContext(ctx, r.d.Rebind("DELETE FROM "+table+" WHERE task_id IN (SELECT id FROM tasks"+b.WhereClause()+")"), b.Args()...); err != nil {
+					return err
+				}
+			}
+		}
+		b := r.completedFilter(before)
+		if r.d.MySQL() {
+			if err := tx.SelectContext(ctx, &ids, r.d.Rebind("SELECT id FROM tasks"+b.WhereClause()+r.d.ForUpdate()), b.Args()...); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, r.d.Rebind("DELETE FROM tasks"+b.WhereClause()), b.Args()...); err != nil {
+				return err
+			}
+		} else if err := tx.SelectContext(ctx, &ids, r.d.Rebind("DELETE FROM tasks"+b.WhereClause()+" RETURNING id"), b.Args()...); err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			t := newTombstone(ctx, id)
+			if err := insertTombstone(ctx, tx, r.d, t); err != nil {
+				return err
+			}
+			if err := outbox.Insert(ctx, tx, outbox.EventTaskDeleted, id, deletedPayload(t)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) > 0 {
+		r.invalidateAfterWrite(ctx, ids...)
+	}
+	return ids, nil
+}
+
+// CountCompleted implements TaskRepository.
+func (r *taskRepo) CountCompleted(ctx context.Context, before *time.Time) (int, error) {
+	ctx = database.WithOperation(ctx, "tasks", "count")
+	b := r.completedFilter(before)
 	var count int
-	if err := r.db.Get(&count, "SELECT count(1) FROM tasks"); err != nil {
+	if err := sqlx.GetContext(ctx, r.conn(ctx), &count, r.d.Rebind("SELECT count(1) FROM tasks"+b.WhereClause()), b.Args()...); err != nil {
 		return 0, err
 	}
 	return count, nil
 }
 
-// CountFiltered counts tasks using the same filter semantics as List.
-// It supports optional filtering by `completed` and `assignee`.
-func (r *taskRepo) CountFiltered(completed *bool, assignee *string) (int, error) {
+func (r *taskRepo) Count(ctx context.Context) (int, error) {
+	ctx = database.WithOperation(ctx, "tasks", "count")
 	var count int
-	var err error
-
-	// No filters: simple count
-	if completed == nil && (assignee == nil || *assignee == "") {
-		err = r.db.Get(&count, "SELECT count(1) FROM tasks")
-	} else if completed != nil && (assignee == nil || *assignee == "") {
-		// Filter by completed only
-		err = r.db.Get(&count, "SELECT count(1) FROM tasks WHERE completed = $1", *completed)
-	} else if completed == nil && assignee != nil {
-		// Filter by assignee only
-		err = r.db.Get(&count, "SELECT count(1) FROM tasks WHERE assignee = $1", *assignee)
-	} else {
-		// Both filters present
-		err = r.db.Get(&count, "SELECT count(1) FROM tasks WHERE completed = $1 AND assignee = $2", *completed, *assignee)
+	if err := sqlx.GetContext(ctx, r.conn(ctx), &count, "SELECT count(1) FROM tasks"); err != nil {
+		return 0, err
 	}
+	return count, nil
+}
 
-	if err != nil {
+// CountFiltered counts tasks using the same filter semantics as List.
+func (r *taskRepo) CountFiltered(ctx context.Context, f TaskFilter) (int, error) {
+	ctx = database.WithOperation(ctx, "tasks", "count")
+	b := &queryBuilder{d: r.d}
+	f.apply(b)
+
+	var count int
+	if err := sqlx.GetContext(ctx, r.conn(ctx), &count, r.d.Rebind("SELECT count(1) FROM tasks"+b.WhereClause()), b.Args()...); err != nil {
 		return 0, err
 	}
 	return count, nil
 }
+
+// Stats implements TaskRepository with one GROUP BY query; the overall
+// totals are summed from the groups.
+func (r *taskRepo) Stats(ctx context.Context, f TaskFilter) (*model.TaskStats, error) {
+	ctx = database.WithOperation(ctx, "tasks", "stats")
+	b := &queryBuilder{d: r.d}
+	f.apply(b)
+
+	query := `SELECT assignee, count(1) AS count, COALESCE(SUM(CASE WHEN completed THEN 1 ELSE 0 END), 0) AS completed,
+COALESCE(SUM(estimate_minutes), 0) AS estimate_minutes, COALESCE(SUM(remaining_minutes), 0) AS remaining_minutes,
+COALESCE(SUM(time_spent_seconds), 0) AS time_spent_seconds FROM tasks` + b.WhereClause() +
+		" GROUP BY assignee ORDER BY " + r.d.OrderBy("assignee", false, "FIRST")
+	groups := []model.AssigneeStats{}
+	if err := sqlx.SelectContext(ctx, r.conn(ctx), &groups, r.d.Rebind(query), b.Args()...); err != nil {
+		return nil, err
+	}
+	return statsOf(groups), nil
+}
+
+// statsOf builds TaskStats from the per-assignee groups.
+func statsOf(groups []model.AssigneeStats) *model.TaskStats {
+	stats := &model.TaskStats{ByAssignee: groups}
+	for _, g := range groups {
+		stats.Add(g.TaskTotals)
+	}
+	return stats
+}
+
+// Reassign updates all matching tasks in one statement and records a
+// task.reassigned outbox event (old and new assignee) per task in the same
+// transaction, which serves as the audit trail and notification trigger.
+func (r *taskRepo) Reassign(ctx context.Context, f TaskFilter, to string) ([]string, error) {
+	ctx = database.WithOperation(ctx, "tasks", "reassign")
+	if f.Empty() {
+		return nil, errors.New("reassign requires at least one filter")
+	}
+	b := &queryBuilder{d: r.d}
+	set := b.Arg(to)
+	f.apply(b)
+
+	query := `UPDATE tasks SET assignee = ` + set + `, updated_at = now()
+FROM (SELECT id, assignee FROM tasks` + b.WhereClause() + ` FOR UPDATE) AS prev
+WHERE tasks.id = prev.id
+RETURNING tasks.id, prev.assignee`
+
+	var ids []string
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		var rows []struct {
+			ID       string         `db:"id"`
+			Assignee sql.NullString `db:"assignee"`
+		}
+		if !r.d.Postgres() {
+			// SQLite's RETURNING cannot see the FROM subquery and MySQL has no
+			// RETURNING; reading the previous assignees first under a row lock
+			// (or SQLite's single writer) is equivalent.
+			sel := &queryBuilder{d: r.d}
+			f.apply(sel)
+			if err := tx.SelectContext(ctx, &rows, r.d.Rebind("SELECT id, assignee FROM tasks"+sel.WhereClause()+r.d.ForUpdate()), sel.Args()...); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, r.d.Rebind("UPDATE tasks SET assignee = "+set+", updated_at = "+r.d.Now()+b.WhereClause()), b.Args()...); err != nil {
+				return err
+			}
+		} else if err := tx.SelectContext(ctx, &rows, query, b.Args()...); err != nil {
+			return err
+		}
+
+		ids = make([]string, 0, len(rows))
+		for _, row := range rows {
+			var from *string
+			if row.Assignee.Valid {
+				from = &row.Assignee.String
+			}
+			payload := map[string]interface{}{"id": row.ID, "from": from, "to": to}
+			if err := outbox.Insert(ctx, tx, outbox.EventTaskReassigned, row.ID, payload); err != nil {
+				return err
+			}
+			ids = append(ids, row.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) > 0 {
+		r.invalidateAfterWrite(ctx, ids...)
+	}
+	return ids, nil
+}
@@ -1,16 +1,22 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	redismock "github.com/go-redis/redismock/v9"
 	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
+	"taskmanager/internal/database"
+	"taskmanager/internal/logging"
+	"taskmanager/internal/metric"
 	"taskmanager/internal/model"
 )
 
@@ -29,16 +35,20 @@ func TestList_CacheHit(t *testing.T) {
 
 	tasks := []model.Task{{ID: "t1", Title: "one"}}
 	b, _ := json.Marshal(tasks)
-	key := repo.cacheKeyForList(100, 0, nil, nil)
+	key := repo.cacheKeyForList(ListOptions{Sort: repo.sortFields(), Limit: 100})
 	mock.ExpectGet(key).SetVal(string(b))
 
-	got, err := repo.List(100, 0, nil, nil)
+	hits := testutil.ToFloat64(metric.CacheHits.WithLabelValues("list"))
+	got, err := repo.List(context.Background(), ListOptions{Limit: 100})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
 	if len(got) != 1 || got[0].ID != "t1" {
 		t.Fatalf("unexpected result: %+v", got)
 	}
+	if d := testutil.ToFloat64(metric.CacheHits.WithLabelValues("list")) - hits; d != 1 {
+		t.Fatalf("expected cache_hits_total to grow by 1, got %v", d)
+	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatalf("redis expectations: %v", err)
 	}
@@ -55,21 +65,25 @@ func TestList_CacheMiss_DBAndSet(t *testing.T) {
 	rdb, rmock := redismock.NewClientMock()
 	repo := &taskRepo{db: sx, rdb: rdb}
 
-	key := repo.cacheKeyForList(100, 0, nil, nil)
+	key := repo.cacheKeyForList(ListOptions{Sort: repo.sortFields(), Limit: 100})
 	rmock.ExpectGet(key).RedisNil()
 
 	// expect select - provide non-nil timestamps to satisfy Scan into time.Time
 	now := time.Now()
 	rows := sqlmock.NewRows([]string{"id", "title", "description", "assignee", "completed", "due_date", "created_at", "updated_at"}).AddRow("t1", "one", nil, nil, false, nil, now, now)
-	mock.ExpectQuery("SELECT id, title, description").WillReturnRows(rows)
+	mock.ExpectQuery("SELECT id, number, title, description").WillReturnRows(rows)
 
-	got, err := repo.List(100, 0, nil, nil)
+	misses := testutil.ToFloat64(metric.CacheMisses.WithLabelValues("list"))
+	got, err := repo.List(context.Background(), ListOptions{Limit: 100})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
 	if len(got) != 1 || got[0].ID != "t1" {
 		t.Fatalf("unexpected rows: %+v", got)
 	}
+	if d := testutil.ToFloat64(metric.CacheMisses.WithLabelValues("list")) - misses; d != 1 {
+		t.Fatalf("expected cache_misses_total to grow by 1, got %v", d)
+	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatalf("sql expectations: %v", err)
@@ -88,16 +102,24 @@ func TestCreate_NilAndSuccess(t *testing.T) {
 	sx := sqlx.NewDb(db, "sqlmock")
 	repo := &taskRepo{db: sx}
 
-	if err := repo.Create(nil); err == nil {
+	if err := repo.Create(context.Background(), nil); err == nil {
 		t.Fatalf("expected error when task is nil")
 	}
 
-	// success path: expect NamedExec insert
-	mock.ExpectExec("INSERT INTO tasks").WithArgs(sqlmock.AnyArg(), "t", sqlmock.AnyArg(), sqlmock.AnyArg(), false, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	// success path: expect insert and outbox event in one transaction
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT MAX\(position\) FROM tasks`).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(2048.0))
+	mock.ExpectExec("INSERT INTO tasks").WithArgs(sqlmock.AnyArg(), "t", sqlmock.AnyArg(), sqlmock.AnyArg(), false, sql.NullTime{}, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 3072.0, sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT number FROM tasks WHERE id = \$1`).WillReturnRows(sqlmock.NewRows([]string{"number"}).AddRow(42))
+	mock.ExpectExec("INSERT INTO outbox").WithArgs("task.created", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 	tsk := &model.Task{Title: "t"}
-	if err := repo.Create(tsk); err != nil {
+	if err := repo.Create(context.Background(), tsk); err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
+	if tsk.Key() != "TM-42" {
+		t.Fatalf("expected the number read back got key %q", tsk.Key())
+	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatalf("sql expectations: %v", err)
 	}
@@ -112,8 +134,8 @@ func TestGetByID_NotFound(t *testing.T) {
 	sx := sqlx.NewDb(db, "sqlmock")
 	repo := &taskRepo{db: sx}
 
-	mock.ExpectQuery("SELECT id, title, description").WillReturnError(sql.ErrNoRows)
-	_, err = repo.GetByID("missing")
+	mock.ExpectQuery("SELECT id, number, title, description").WillReturnError(sql.ErrNoRows)
+	_, err = repo.GetByID(context.Background(), "missing")
 	if !errors.Is(err, ErrNotFound) {
 		t.Fatalf("expected ErrNotFound got %v", err)
 	}
@@ -122,6 +144,74 @@ func TestGetByID_NotFound(t *testing.T) {
 	}
 }
 
+func TestCreate_RollsBackWhenOutboxInsertFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	sx := sqlx.NewDb(db, "sqlmock")
+	repo := &taskRepo{db: sx}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT MAX\(position\) FROM tasks`).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+	mock.ExpectExec("INSERT INTO tasks").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT number FROM tasks").WillReturnRows(sqlmock.NewRows([]string{"number"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO outbox").WillReturnError(errors.New("boom"))
+	mock.ExpectRollback()
+
+	if err := repo.Create(context.Background(), &model.Task{Title: "t"}); err == nil {
+		t.Fatalf("expected error when outbox insert fails")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestSetCompleted_GuardedUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	repo := &taskRepo{db: sqlx.NewDb(db, "sqlmock")}
+	cols := []string{"id", "title", "completed", "completed_at"}
+	now := time.Now()
+
+	// open -> completed: one UPDATE guarded by the current state, then the event
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE tasks SET completed = \$1, completed_at = \$2, updated_at = \$3 WHERE id = \$4 AND completed = \$5`).
+		WithArgs(true, sqlmock.AnyArg(), sqlmock.AnyArg(), "t1", false).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id, number, title").WithArgs("t1").WillReturnRows(sqlmock.NewRows(cols).AddRow("t1", "one", true, now))
+	mock.ExpectExec("INSERT INTO outbox").WithArgs("task.completed", "t1", sqlmock.AnyArg(), nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	task, changed, err := repo.SetCompleted(context.Background(), "t1", true)
+	if err != nil || !changed || !task.Completed || !task.CompletedAt.Valid {
+		t.Fatalf("unexpected result %+v changed=%v err=%v", task, changed, err)
+	}
+
+	// already completed: nothing written, no event
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE tasks SET completed").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, number, title").WithArgs("t1").WillReturnRows(sqlmock.NewRows(cols).AddRow("t1", "one", true, now))
+	mock.ExpectCommit()
+	if _, changed, err := repo.SetCompleted(context.Background(), "t1", true); err != nil || changed {
+		t.Fatalf("expected no change got changed=%v err=%v", changed, err)
+	}
+
+	// unknown id
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE tasks SET completed").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, number, title").WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	if _, _, err := repo.SetCompleted(context.Background(), "missing", false); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
 func TestUpdate_Delete_NotFound_Success(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -131,15 +221,21 @@ func TestUpdate_Delete_NotFound_Success(t *testing.T) {
 	sx := sqlx.NewDb(db, "sqlmock")
 	repo := &taskRepo{db: sx}
 
-	// Update not found -> RowsAffected 0
+	// Update not found -> RowsAffected 0, no outbox event
+	mock.ExpectBegin()
 	mock.ExpectExec("UPDATE tasks SET").WillReturnResult(sqlmock.NewResult(0, 0))
-	if err := repo.Update(&model.Task{ID: "x"}); !errors.Is(err, ErrNotFound) {
+	mock.ExpectRollback()
+	if err := repo.Update(context.Background(), &model.Task{ID: "x"}); !errors.Is(err, ErrNotFound) {
 		t.Fatalf("expected ErrNotFound got %v", err)
 	}
 
-	// Delete success
+	// Delete success: the tombstone records the actor of the request
+	mock.ExpectBegin()
 	mock.ExpectExec("DELETE FROM tasks").WillReturnResult(sqlmock.NewResult(1, 1))
-	ok, err := repo.Delete("x")
+	expectTombstone(mock, "x", "k1")
+	mock.ExpectExec("INSERT INTO outbox").WithArgs("task.deleted", "x", sqlmock.AnyArg(), nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	ok, err := repo.Delete(logging.WithActor(context.Background(), "k1"), "x")
 	if err != nil || !ok {
 		t.Fatalf("expected deleted got ok=%v err=%v", ok, err)
 	}
@@ -148,3 +244,823 @@ func TestUpdate_Delete_NotFound_Success(t *testing.T) {
 		t.Fatalf("sql expectations: %v", err)
 	}
 }
+
+func TestGetByID_ContextCancelled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	sx := sqlx.NewDb(db, "sqlmock")
+	repo := &taskRepo{db: sx}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = repo.GetByID(ctx, "x")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestReassign_WritesOutboxEventPerTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	sx := sqlx.NewDb(db, "sqlmock")
+	repo := &taskRepo{db: sx}
+
+	alice := "alice"
+	open := false
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE tasks SET assignee").WithArgs("bob", false, "alice").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "assignee"}).AddRow("t1", "alice").AddRow("t2", "alice"))
+	mock.ExpectExec("INSERT INTO outbox").WithArgs("task.reassigned", "t1", sqlmock.AnyArg(), nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO outbox").WithArgs("task.reassigned", "t2", sqlmock.AnyArg(), nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	ids, err := repo.Reassign(context.Background(), TaskFilter{Completed: &open, Assignee: AssigneeIs(&alice)}, "bob")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("expected 2 ids got %v", ids)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestDeleteCompleted_SingleStatementWithOutboxEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	sx := sqlx.NewDb(db, "sqlmock")
+	repo := &taskRepo{db: sx}
+
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM tasks WHERE completed = \$1 AND completed_at < \$2 RETURNING id`).WithArgs(true, before).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("t1").AddRow("t2"))
+	expectTombstone(mock, "t1", nil)
+	mock.ExpectExec("INSERT INTO outbox").WithArgs("task.deleted", "t1", sqlmock.AnyArg(), nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	expectTombstone(mock, "t2", nil)
+	mock.ExpectExec("INSERT INTO outbox").WithArgs("task.deleted", "t2", sqlmock.AnyArg(), nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	ids, err := repo.DeleteCompleted(context.Background(), &before)
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("expected 2 ids got %v err=%v", ids, err)
+	}
+
+	mock.ExpectQuery(`SELECT count\(1\) FROM tasks WHERE completed = \$1`).WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	if n, err := repo.CountCompleted(context.Background(), nil); err != nil || n != 5 {
+		t.Fatalf("expected 5 got %d err=%v", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	fields, err := ParseSort("due_date ASC nulls last, created_at desc")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := " ORDER BY due_date ASC NULLS LAST, created_at DESC, id ASC"
+	if got := orderByClause(fields, database.Dialect{}); got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+
+	for _, bad := range []string{"", "priority desc", "title sideways", "due_date nulls", "title; DROP TABLE tasks"} {
+		if _, err := ParseSort(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestList_UsesConfiguredSortWithIDTieBreak(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	sx := sqlx.NewDb(db, "sqlmock")
+
+	repo := &taskRepo{db: sx}
+	fields, _ := ParseSort("due_date asc nulls last")
+	repo.SetDefaultSort(fields)
+
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "assignee", "completed", "due_date", "created_at", "updated_at"})
+	mock.ExpectQuery(`WHERE completed = \$1 ORDER BY due_date ASC NULLS LAST, id ASC LIMIT \$2 OFFSET \$3`).
+		WithArgs(false, 10, 0).WillReturnRows(rows)
+
+	completed := false
+	if _, err := repo.List(context.Background(), ListOptions{Filter: TaskFilter{Completed: &completed}, Limit: 10}); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestList_DateRangePredicates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	repo := &taskRepo{db: sqlx.NewDb(db, "sqlmock")}
+
+	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := after.AddDate(0, 1, 0)
+	dates := DateRange{CreatedAfter: &after, UpdatedBefore: &before}
+
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "assignee", "completed", "due_date", "created_at", "updated_at"})
+	mock.ExpectQuery(`WHERE created_at > \$1 AND updated_at < \$2 ORDER BY created_at DESC, id ASC LIMIT \$3 OFFSET \$4`).
+		WithArgs(after, before, 10, 0).WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT count\(1\) FROM tasks WHERE created_at > \$1 AND updated_at < \$2`).
+		WithArgs(after, before).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	if _, err := repo.List(context.Background(), ListOptions{Filter: TaskFilter{Dates: dates}, Limit: 10}); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if _, err := repo.CountFiltered(context.Background(), TaskFilter{Dates: dates}); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+
+	if repo.cacheKeyForList(ListOptions{Filter: TaskFilter{Dates: dates}, Sort: repo.sortFields(), Limit: 10}) == repo.cacheKeyForList(ListOptions{Sort: repo.sortFields(), Limit: 10}) {
+		t.Fatalf("expected the date range to be part of the list cache key")
+	}
+}
+
+func TestList_TitleSubstring(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	repo := &taskRepo{db: sqlx.NewDb(db, "sqlmock")}
+
+	// LIKE wildcards in the term match literally
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "assignee", "completed", "due_date", "created_at", "updated_at"})
+	mock.ExpectQuery(`WHERE title ILIKE \$1 ORDER BY created_at DESC, id ASC LIMIT \$2 OFFSET \$3`).
+		WithArgs(`%50\%\_off\_%`, 10, 0).WillReturnRows(rows)
+
+	if _, err := repo.List(context.Background(), ListOptions{Filter: TaskFilter{Title: "50%_off_"}, Limit: 10}); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+
+	if repo.cacheKeyForList(ListOptions{Filter: TaskFilter{Title: "Deploy"}, Sort: repo.sortFields(), Limit: 10}) == repo.cacheKeyForList(ListOptions{Filter: TaskFilter{Title: "deploy x"}, Sort: repo.sortFields(), Limit: 10}) {
+		t.Fatalf("expected the title term to be part of the list cache key")
+	}
+}
+
+func TestList_SparseFields(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	repo := &taskRepo{db: sqlx.NewDb(db, "sqlmock")}
+
+	fields, err := ParseFields("key, title,due_date,title")
+	if err != nil || strings.Join(fields, ",") != "due_date,id,key,title" {
+		t.Fatalf("unexpected fields %v err=%v", fields, err)
+	}
+	if _, err := ParseFields("id,secret"); err == nil {
+		t.Fatalf("expected an unknown field to be rejected")
+	}
+
+	rows := sqlmock.NewRows([]string{"due_date", "id", "number", "title"}).AddRow(nil, "t1", 7, "one")
+	mock.ExpectQuery(`^SELECT due_date, id, number, title FROM tasks ORDER BY`).WillReturnRows(rows)
+	got, err := repo.List(context.Background(), ListOptions{Limit: 10, Fields: fields})
+	if err != nil || len(got) != 1 || got[0].Key() != "TM-7" || got[0].Title != "one" {
+		t.Fatalf("unexpected tasks %+v err=%v", got, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+
+	if repo.cacheKeyForList(ListOptions{Sort: repo.sortFields(), Limit: 10, Fields: fields}) == repo.cacheKeyForList(ListOptions{Sort: repo.sortFields(), Limit: 10}) {
+		t.Fatalf("expected the fields to be part of the list cache key")
+	}
+}
+
+func TestEstimateCount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	repo := &taskRepo{db: sqlx.NewDb(db, "sqlmock")}
+	ctx := context.Background()
+
+	mock.ExpectQuery(`SELECT reltuples FROM pg_class`).WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow(1.234e6))
+	if n, err := repo.EstimateCount(ctx, TaskFilter{}); err != nil || n != 1234000 {
+		t.Fatalf("expected the reltuples estimate got %d err=%v", n, err)
+	}
+
+	// never analyzed: count exactly instead
+	mock.ExpectQuery(`SELECT reltuples FROM pg_class`).WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow(-1.0))
+	mock.ExpectQuery(`SELECT count\(1\) FROM tasks`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	if n, err := repo.EstimateCount(ctx, TaskFilter{}); err != nil || n != 3 {
+		t.Fatalf("expected the exact count got %d err=%v", n, err)
+	}
+
+	completed := true
+	plan := `[{"Plan": {"Node Type": "Seq Scan", "Plan Rows": 4821}}]`
+	mock.ExpectQuery(`EXPLAIN \(FORMAT JSON\) SELECT 1 FROM tasks WHERE completed = \$1`).WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).AddRow([]byte(plan)))
+	if n, err := repo.EstimateCount(ctx, TaskFilter{Completed: &completed}); err != nil || n != 4821 {
+		t.Fatalf("expected the plan estimate got %d err=%v", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestGetByID_ItemCacheHit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	rdb, rmock := redismock.NewClientMock()
+	repo := &taskRepo{db: sqlx.NewDb(db, "sqlmock"), rdb: rdb}
+
+	b, _ := json.Marshal(&model.Task{ID: "t1", Title: "one"})
+	rmock.ExpectGet("tasks:id:t1").SetVal(string(b))
+
+	got, err := repo.GetByID(context.Background(), "t1")
+	if err != nil || got.Title != "one" {
+		t.Fatalf("unexpected result %+v err=%v", got, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expected no db calls: %v", err)
+	}
+	if err := rmock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("redis expectations: %v", err)
+	}
+}
+
+func TestGetByID_NegativeCaching(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	rdb, rmock := redismock.NewClientMock()
+	repo := &taskRepo{db: sqlx.NewDb(db, "sqlmock"), rdb: rdb}
+	repo.SetCacheOptions(CacheOptions{NegativeTTL: 5 * time.Second})
+
+	// first lookup misses everywhere and records the miss
+	rmock.ExpectGet("tasks:id:missing").RedisNil()
+	mock.ExpectQuery("SELECT id, number, title, description").WithArgs("missing").WillReturnError(sql.ErrNoRows)
+	rmock.ExpectSet("tasks:id:missing", notFoundMarker, 5*time.Second).SetVal("OK")
+	if _, err := repo.GetByID(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound got %v", err)
+	}
+
+	// second lookup is answered from the cache without touching the DB
+	rmock.ExpectGet("tasks:id:missing").SetVal(notFoundMarker)
+	if _, err := repo.GetByID(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+	if err := rmock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("redis expectations: %v", err)
+	}
+}
+
+func TestSetCacheTTLs_KeepsL1AndPubSub(t *testing.T) {
+	repo := &taskRepo{}
+	repo.SetCacheOptions(CacheOptions{ListTTL: time.Minute, LocalMaxEntries: 10, LocalTTL: time.Minute, PubSub: true})
+	repo.SetCacheTTLs(CacheOptions{ListTTL: 10 * time.Second, NegativeTTL: 2 * time.Second})
+	o := repo.cacheOptions()
+	if o.ListTTL != 10*time.Second || o.NegativeTTL != 2*time.Second {
+		t.Fatalf("expected the new TTLs, got %+v", o)
+	}
+	if o.LocalMaxEntries != 10 || o.LocalTTL != time.Minute || !o.PubSub || repo.local == nil {
+		t.Fatalf("expected the L1 and Pub/Sub settings kept, got %+v", o)
+	}
+}
+
+func TestList_ServesStaleAndRefreshesInBackground(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	rdb, rmock := redismock.NewClientMock()
+	rmock.MatchExpectationsInOrder(false)
+	repo := &taskRepo{db: sqlx.NewDb(db, "sqlmock"), rdb: rdb}
+	repo.SetCacheOptions(CacheOptions{ListTTL: time.Minute, StaleTTL: time.Minute})
+
+	key := repo.cacheKeyForList(ListOptions{Sort: repo.sortFields(), Limit: 100})
+	stale, _ := json.Marshal(cachedList{FreshUntil: time.Now().Add(-time.Second), Items: []model.Task{{ID: "old"}}})
+	rmock.ExpectGet(key).SetVal(string(stale))
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "assignee", "completed", "due_date", "created_at", "updated_at"}).AddRow("new", "n", nil, nil, false, nil, now, now)
+	mock.ExpectQuery("SELECT id, number, title, description").WillReturnRows(rows)
+	rmock.Regexp().ExpectSet(key, `"id":"new"`, 2*time.Minute).SetVal("OK")
+
+	got, err := repo.List(context.Background(), ListOptions{Limit: 100})
+	if err != nil || len(got) != 1 || got[0].ID != "old" {
+		t.Fatalf("expected stale page, got %+v err=%v", got, err)
+	}
+
+	// wait for the background refresh to finish
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, busy := repo.refreshing.Load(key); !busy {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("refresh did not finish")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+	if err := rmock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("redis expectations: %v", err)
+	}
+}
+
+func TestFlushCache_RemovesLocalAndRedisEntries(t *testing.T) {
+	rdb, rmock := redismock.NewClientMock()
+	repo := &taskRepo{}
+	repo.SetCacheOptions(CacheOptions{LocalMaxEntries: 10, LocalTTL: time.Minute})
+	repo.SetCacheClient(rdb)
+	repo.local.Set("tasks:id:a", "{}", time.Minute)
+
+	rmock.ExpectScan(0, "tasks:*", 100).SetVal([]string{"tasks:id:a", "tasks:list:x"}, 0)
+	rmock.ExpectDel("tasks:id:a").SetVal(1)
+	rmock.ExpectDel("tasks:list:x").SetVal(1)
+
+	if n, err := repo.FlushCache(context.Background()); err != nil || n != 2 {
+		t.Fatalf("expected 2 flushed keys got %d err=%v", n, err)
+	}
+	if repo.local.Len() != 0 {
+		t.Fatalf("expected the local cache to be empty got %d entries", repo.local.Len())
+	}
+	if err := rmock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("redis expectations: %v", err)
+	}
+}
+
+func TestList_LocalCacheWithoutRedis(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	repo := &taskRepo{db: sqlx.NewDb(db, "sqlmock")}
+	repo.SetCacheOptions(CacheOptions{LocalMaxEntries: 10, LocalTTL: time.Minute})
+
+	now := time.Now()
+	cols := []string{"id", "title", "description", "assignee", "completed", "due_date", "created_at", "updated_at"}
+	mock.ExpectQuery("SELECT id, number, title, description").WillReturnRows(sqlmock.NewRows(cols).AddRow("t1", "one", nil, nil, false, nil, now, now))
+
+	for i := 0; i < 2; i++ {
+		got, err := repo.List(context.Background(), ListOptions{Limit: 10})
+		if err != nil || len(got) != 1 {
+			t.Fatalf("call %d: unexpected result %+v err=%v", i, got, err)
+		}
+	}
+
+	// a write invalidates the local copy
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM tasks").WithArgs("t1").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectTombstone(mock, "t1", nil)
+	mock.ExpectExec("INSERT INTO outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	if _, err := repo.Delete(context.Background(), "t1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	mock.ExpectQuery("SELECT id, number, title, description").WillReturnRows(sqlmock.NewRows(cols))
+	if got, _ := repo.List(context.Background(), ListOptions{Limit: 10}); len(got) != 0 {
+		t.Fatalf("expected fresh empty page after delete, got %+v", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestQueryBuilder_ComposesFilters(t *testing.T) {
+	done, who := true, "alice"
+	b := &queryBuilder{}
+	set := b.Arg("bob")
+	TaskFilter{Completed: &done, Assignee: AssigneeIs(&who)}.apply(b)
+	if got := set + b.WhereClause(); got != "$1 WHERE completed = $2 AND assignee = $3" {
+		t.Fatalf("unexpected sql %q", got)
+	}
+	if args := b.Args(); len(args) != 3 || args[1] != true || args[2] != "alice" {
+		t.Fatalf("unexpected args %v", args)
+	}
+
+	empty := ""
+	if !(TaskFilter{Assignee: AssigneeIs(&empty)}).Empty() {
+		t.Fatalf("expected empty assignee to be ignored")
+	}
+	since := time.Now()
+	for _, f := range []TaskFilter{{Title: "docs"}, {Dates: DateRange{DueBefore: &since}}} {
+		if f.Empty() {
+			t.Fatalf("expected %+v not to be empty", f)
+		}
+	}
+	b = &queryBuilder{}
+	TaskFilter{}.apply(b)
+	if b.WhereClause() != "" {
+		t.Fatalf("expected no WHERE clause, got %q", b.WhereClause())
+	}
+
+	b = &queryBuilder{}
+	TaskFilter{Assignee: AssigneeFilter{Names: []string{"alice", "bob"}, Unassigned: true}}.apply(b)
+	if got := b.WhereClause(); got != " WHERE (assignee IN ($1, $2) OR assignee IS NULL)" {
+		t.Fatalf("unexpected sql %q", got)
+	}
+	b = &queryBuilder{}
+	TaskFilter{Assignee: AssigneeFilter{Unassigned: true}}.apply(b)
+	if got := b.WhereClause(); got != " WHERE assignee IS NULL" || len(b.Args()) != 0 {
+		t.Fatalf("unexpected sql %q args %v", got, b.Args())
+	}
+}
+
+func TestCacheKeyForList_EncodesAssigneeSet(t *testing.T) {
+	repo := &taskRepo{}
+	key := func(a AssigneeFilter) string {
+		return repo.cacheKeyForList(ListOptions{Filter: TaskFilter{Assignee: a}, Sort: repo.sortFields(), Limit: 10})
+	}
+
+	alice := "alice"
+	if got := key(AssigneeIs(&alice)); !strings.Contains(got, ":assignee=alice:") {
+		t.Fatalf("expected the single-assignee key format to be kept, got %q", got)
+	}
+	ab := key(AssigneeFilter{Names: []string{"alice", "bob"}})
+	if ab != key(AssigneeFilter{Names: []string{"bob", "alice", "bob"}}) {
+		t.Fatalf("expected the same set to map to the same key")
+	}
+	seen := map[string]bool{}
+	for _, k := range []string{
+		key(AssigneeFilter{}), key(AssigneeIs(&alice)), ab,
+		key(AssigneeFilter{Names: []string{"alice"}, Unassigned: true}),
+		key(AssigneeFilter{Unassigned: true}),
+		key(AssigneeFilter{Names: []string{"alice,bob"}, Unassigned: true}),
+	} {
+		if seen[k] {
+			t.Fatalf("duplicate cache key %q", k)
+		}
+		seen[k] = true
+	}
+}
+
+func TestWithTx_CommitsOnceAndInvalidatesAfterCommit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	repo := NewTaskRepository(sqlx.NewDb(db, "sqlmock")).(*taskRepo)
+	repo.SetCacheOptions(CacheOptions{ListTTL: time.Minute, ItemTTL: time.Minute, LocalMaxEntries: 10, LocalTTL: time.Minute})
+	repo.local.Set(cacheKeyForItem("x"), `{"id":"x"}`, time.Minute)
+
+	cols := []string{"id", "title", "description", "assignee", "completed", "due_date", "remaining_minutes", "created_at", "updated_at"}
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM tasks WHERE id = \$1 FOR UPDATE`).WithArgs("x").
+		WillReturnRows(sqlmock.NewRows(cols).AddRow("x", "old", nil, nil, false, nil, nil, now, now))
+	mock.ExpectExec("UPDATE tasks SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO outbox").WithArgs("task.updated", "x", sqlmock.AnyArg(), nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = repo.WithTx(context.Background(), func(tx TaskRepository) error {
+		task, err := tx.GetByID(context.Background(), "x")
+		if err != nil {
+			return err
+		}
+		task.Title = "new"
+		if err := tx.Update(context.Background(), task); err != nil {
+			return err
+		}
+		if _, ok := repo.local.Get(cacheKeyForItem("x")); !ok {
+			t.Fatalf("cache invalidated before commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if _, ok := repo.local.Get(cacheKeyForItem("x")); ok {
+		t.Fatalf("expected item cache to be invalidated after commit")
+	}
+
+	// an error from fn rolls everything back
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM tasks").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectTombstone(mock, "x", nil)
+	mock.ExpectExec("INSERT INTO outbox").WithArgs("task.deleted", "x", sqlmock.AnyArg(), nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+	boom := errors.New("boom")
+	err = repo.WithTx(context.Background(), func(tx TaskRepository) error {
+		if _, err := tx.Delete(context.Background(), "x"); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected fn error got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestReadReplica_RoutesReadsAndHonoursReadYourWrites(t *testing.T) {
+	primaryDB, primary, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer primaryDB.Close()
+	replicaDB, replica, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer replicaDB.Close()
+
+	repo := NewTaskRepository(sqlx.NewDb(primaryDB, "sqlmock")).(*taskRepo)
+	repo.SetReadReplica(sqlx.NewDb(replicaDB, "sqlmock"), time.Minute)
+	ctx := context.Background()
+
+	replica.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	if n, err := repo.Count(ctx); err != nil || n != 2 {
+		t.Fatalf("expected count from replica got %d err=%v", n, err)
+	}
+
+	primary.ExpectBegin()
+	primary.ExpectExec("DELETE FROM tasks").WithArgs("x").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectTombstone(primary, "x", nil)
+	primary.ExpectExec("INSERT INTO outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+	primary.ExpectCommit()
+	if ok, err := repo.Delete(ctx, "x"); err != nil || !ok {
+		t.Fatalf("delete ok=%v err=%v", ok, err)
+	}
+
+	// within the read-your-writes window reads go to the primary
+	primary.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	if n, err := repo.Count(ctx); err != nil || n != 1 {
+		t.Fatalf("expected count from primary got %d err=%v", n, err)
+	}
+
+	repo.SetReadReplica(repo.replica, 0)
+	replica.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	if _, err := repo.Count(ctx); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+
+	// unless the caller asks for the primary
+	primary.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	if _, err := repo.Count(WithPrimary(ctx)); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+
+	for name, mock := range map[string]sqlmock.Sqlmock{"primary": primary, "replica": replica} {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("%s expectations: %v", name, err)
+		}
+	}
+}
+
+func TestGetMany_SingleQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	repo := &taskRepo{db: sqlx.NewDb(db, "sqlmock")}
+
+	id := "7b0e6a4e-2f7c-4a52-9a0c-2d1f5b9c6e11"
+	now := time.Now()
+	cols := []string{"id", "title", "description", "assignee", "completed", "due_date", "remaining_minutes", "created_at", "updated_at"}
+	// the malformed id is dropped before the uuid[] cast
+	mock.ExpectQuery(`FROM tasks WHERE id = ANY\(\$1::uuid\[\]\)`).WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(id, "one", nil, nil, false, nil, nil, now, now))
+
+	tasks, err := repo.GetMany(context.Background(), []string{id, "not-a-uuid"})
+	if err != nil || len(tasks) != 1 || tasks[0].ID != id {
+		t.Fatalf("unexpected tasks %v err=%v", tasks, err)
+	}
+	if tasks, err := repo.GetMany(context.Background(), []string{"not-a-uuid"}); err != nil || len(tasks) != 0 {
+		t.Fatalf("expected no query and no tasks got %v err=%v", tasks, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+
+	// elsewhere any id is passed on with the dialect's placeholders
+	lite, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer lite.Close()
+	litex := sqlx.NewDb(lite, database.SQLite)
+	repo = &taskRepo{db: litex, d: database.For(litex)}
+	mock.ExpectQuery(`FROM tasks WHERE id IN \(\?, \?\)`).WithArgs(id, "not-a-uuid").
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(id, "one", nil, nil, false, nil, nil, now, now))
+	if tasks, err := repo.GetMany(context.Background(), []string{id, "not-a-uuid"}); err != nil || len(tasks) != 1 {
+		t.Fatalf("unexpected tasks %v err=%v", tasks, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestGetManyByNumber_SingleQuery(t *testing.T) {
+	cols := []string{"id", "number", "title", "created_at", "updated_at"}
+	now := time.Now()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	repo := &taskRepo{db: sqlx.NewDb(db, "sqlmock")}
+	mock.ExpectQuery(`FROM tasks WHERE number = ANY\(\$1\)`).WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow("a", 7, "seven", now, now))
+	if tasks, err := repo.GetManyByNumber(context.Background(), []int64{7, 8}); err != nil || len(tasks) != 1 || tasks[0].Number != 7 {
+		t.Fatalf("unexpected tasks %v err=%v", tasks, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+
+	lite, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer lite.Close()
+	litex := sqlx.NewDb(lite, database.SQLite)
+	repo = &taskRepo{db: litex, d: database.For(litex)}
+	mock.ExpectQuery(`FROM tasks WHERE number IN \(\?, \?\)`).WithArgs(7, 8).
+		WillReturnRows(sqlmock.NewRows(cols))
+	if tasks, err := repo.GetManyByNumber(context.Background(), []int64{7, 8}); err != nil || len(tasks) != 0 {
+		t.Fatalf("unexpected tasks %v err=%v", tasks, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestInvalidate_PublishesToOtherInstances(t *testing.T) {
+	rdb, rmock := redismock.NewClientMock()
+	repo := &taskRepo{origin: "a"}
+	repo.SetCacheOptions(CacheOptions{LocalMaxEntries: 10, LocalTTL: time.Minute, PubSub: true})
+	repo.SetCacheClient(rdb)
+
+	rmock.ExpectScan(0, "tasks:list:*", 100).SetVal(nil, 0)
+	rmock.ExpectDel("tasks:id:x").SetVal(1)
+	rmock.ExpectPublish(invalidationChannel, []byte(`{"origin":"a","list":true,"ids":["x"]}`)).SetVal(1)
+	repo.invalidateAfterWrite(context.Background(), "x")
+	if err := rmock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("redis expectations: %v", err)
+	}
+
+	// another instance receives it
+	other := &taskRepo{origin: "b"}
+	other.SetCacheOptions(CacheOptions{LocalMaxEntries: 10, LocalTTL: time.Minute})
+	other.local.Set("tasks:list:page", "[]", time.Minute)
+	other.local.Set("tasks:id:x", "{}", time.Minute)
+	other.local.Set("tasks:id:y", "{}", time.Minute)
+	other.applyInvalidation(`{"origin":"b","list":true,"ids":["x"]}`)
+	if other.local.Len() != 3 {
+		t.Fatalf("expected an instance to ignore its own message, %d entries left", other.local.Len())
+	}
+	other.applyInvalidation(`{"origin":"a","list":true,"ids":["x"]}`)
+	if _, ok := other.local.Get("tasks:id:y"); !ok || other.local.Len() != 1 {
+		t.Fatalf("expected only tasks:id:y to survive, %d entries left", other.local.Len())
+	}
+	other.applyInvalidation(`{"origin":"a","all":true}`)
+	if other.local.Len() != 0 {
+		t.Fatalf("expected a flush to empty the local cache, %d entries left", other.local.Len())
+	}
+}
+
+func TestWatchersListMany_SingleQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	repo := &watcherRepo{db: sqlx.NewDb(db, "sqlmock")}
+
+	now := time.Now()
+	mock.ExpectQuery(`FROM task_watchers WHERE task_id IN \(\?, \?, \?\) ORDER BY watcher`).WithArgs("t1", "t2", "t3").
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "watcher", "created_at"}).
+			AddRow("t2", "alice", now).AddRow("t1", "bob", now).AddRow("t2", "carol", now))
+
+	byTask, err := repo.ListMany(context.Background(), []string{"t1", "t2", "t3"})
+	if err != nil {
+		t.Fatalf("list many: %v", err)
+	}
+	if len(byTask) != 2 || len(byTask["t1"]) != 1 || len(byTask["t2"]) != 2 || byTask["t2"][1].Watcher != "carol" {
+		t.Fatalf("unexpected watchers %v", byTask)
+	}
+	if byTask, err := repo.ListMany(context.Background(), nil); err != nil || len(byTask) != 0 {
+		t.Fatalf("expected no query for no tasks got %v err=%v", byTask, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestTombstones_GetManyAndPurge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	repo := NewTombstoneRepository(sqlx.NewDb(db, "sqlmock"))
+
+	now := time.Now().UTC()
+	mock.ExpectQuery(`FROM task_tombstones WHERE task_id IN \(\?, \?\)`).WithArgs("t1", "t2").
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "deleted_at", "actor"}).AddRow("t2", now, "k1"))
+	mock.ExpectExec(`DELETE FROM task_tombstones WHERE deleted_at < \$1`).WithArgs(now).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	byTask, err := repo.GetMany(context.Background(), []string{"t1", "t2"})
+	if err != nil || len(byTask) != 1 || byTask["t2"].Actor == nil || *byTask["t2"].Actor != "k1" {
+		t.Fatalf("unexpected tombstones %v err=%v", byTask, err)
+	}
+	if n, err := repo.Purge(context.Background(), now); err != nil || n != 3 {
+		t.Fatalf("expected 3 purged got %d err=%v", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+// expectTombstone expects the tombstone of a deleted task, replacing any
+// older one; actor is the expected actor argument (nil for none).
+func expectTombstone(mock sqlmock.Sqlmock, id string, actor any) {
+	mock.ExpectExec(`DELETE FROM task_tombstones WHERE task_id = \$1`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO task_tombstones \(task_id, deleted_at, actor\)`).WithArgs(id, sqlmock.AnyArg(), actor).WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestExport_StreamsRowsAndStopsOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	defer db.Close()
+	repo := &taskRepo{db: sqlx.NewDb(db, "sqlmock")}
+
+	now := time.Now()
+	columns := []string{"id", "title", "description", "assignee", "completed", "due_date", "created_at", "updated_at"}
+	mock.ExpectQuery(`SELECT id, number, title, description, .* FROM tasks ORDER BY created_at, id`).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("t1", "one", nil, nil, false, nil, now, now).AddRow("t2", "two", nil, nil, false, nil, now, now))
+	var ids []string
+	if err := repo.Export(context.Background(), func(t model.Task) error {
+		ids = append(ids, t.ID)
+		return nil
+	}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "t1" || ids[1] != "t2" {
+		t.Fatalf("expected both rows in order got %v", ids)
+	}
+
+	// an error from fn ends the export with it
+	stop := errors.New("client gone")
+	mock.ExpectQuery(`FROM tasks ORDER BY created_at, id`).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("t1", "one", nil, nil, false, nil, now, now).AddRow("t2", "two", nil, nil, false, nil, now, now))
+	calls := 0
+	if err := repo.Export(context.Background(), func(model.Task) error {
+		calls++
+		return stop
+	}); !errors.Is(err, stop) || calls != 1 {
+		t.Fatalf("expected the fn error after one row got %v after %d", err, calls)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+	"taskmanager/internal/model"
+	"taskmanager/internal/outbox"
+)
+
+var (
+	// ErrTimerRunning is returned when starting a timer on a task that
+	// already has one running.
+	ErrTimerRunning = errors.New("timer already running")
+	// ErrTimerNotRunning is returned when stopping a timer that isn't running.
+	ErrTimerNotRunning = errors.New("timer not running")
+)
+
+// TimeEntryRepository tracks the time spent on tasks. Each task has at most
+// one running timer. Closing an entry (stopping the timer or adding a manual
+// entry) adds its seconds to the task's time_spent_seconds and records a
+// task.time_logged outbox event. The task-scoped methods fail with
+// ErrNotFound when the task does not exist.
+type TimeEntryRepository interface {
+	StartTimer(ctx context.Context, taskID string) (*model.TimeEntry, error)
+	StopTimer(ctx context.Context, taskID string) (*model.TimeEntry, error)
+	// AddEntry records a finished span of work.
+	AddEntry(ctx context.Context, taskID string, startedAt, endedAt time.Time) (*model.TimeEntry, error)
+	// List returns the task's entries, newest first.
+	List(ctx context.Context, taskID string) ([]model.TimeEntry, error)
+	// ListMany returns the entries of each of the tasks, newest first, in
+	// one query. Tasks without entries, or that don't exist, are absent.
+	ListMany(ctx context.Context, taskIDs []string) (map[string][]model.TimeEntry, error)
+	// Report sums the finished entries started in [from, to) per assignee,
+	// ordered by assignee with the unassigned row first. Nil bounds are open.
+	Report(ctx context.Context, from, to *time.Time) ([]model.TimeReportRow, error)
+}
+
+type timeEntryRepo struct {
+	db    *sqlx.DB
+	d     database.Dialect
+	tasks TaskRepository
+}
+
+// NewTimeEntryRepository creates a TimeEntryRepository backed by sqlx.DB.
+// tasks is the TaskRepository over the same database, told about the task
+// writes (see written).
+func NewTimeEntryRepository(db *sqlx.DB, tasks TaskRepository) TimeEntryRepository {
+	return &timeEntryRepo{db: db, d: database.For(db), tasks: tasks}
+}
+
+const selectTimeEntry = "SELECT id, task_id, assignee, started_at, ended_at, seconds, created_at FROM time_entries"
+
+// inTx runs fn in a transaction that is committed when fn succeeds. The task
+// row is locked first, which also serializes timer starts; fn gets the task's
+// current assignee.
+func (r *timeEntryRepo) inTx(ctx context.Context, taskID string, fn func(tx *sqlx.Tx, assignee *string) error) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var assignee sql.NullString
+	if err := tx.GetContext(ctx, &assignee, r.d.Rebind("SELECT assignee FROM tasks WHERE id = $1")+r.d.ForUpdate(), taskID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	var a *string
+	if assignee.Valid {
+		a = &assignee.String
+	}
+	if err := fn(tx, a); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *timeEntryRepo) insert(ctx context.Context, tx *sqlx.Tx, e *model.TimeEntry) error {
+	_, err := tx.ExecContext(ctx, r.d.Rebind("INSERT INTO time_entries (id, task_id, assignee, started_at, ended_at, seconds, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)"),
+		e.ID, e.TaskID, e.Assignee, e.StartedAt, e.EndedAt, e.Seconds, e.CreatedAt)
+	return err
+}
+
+// logTime adds the seconds of the closed entry e to its task.
+func (r *timeEntryRepo) logTime(ctx context.Context, tx *sqlx.Tx, e *model.TimeEntry) error {
+	if _, err := tx.ExecContext(ctx, r.d.Rebind("UPDATE tasks SET time_spent_seconds = time_spent_seconds + $1, updated_at = $2 WHERE id = $3"),
+		e.Seconds, time.Now().UTC(), e.TaskID); err != nil {
+		return err
+	}
+	return outbox.Insert(ctx, tx, outbox.EventTaskTimeLogged, e.TaskID, e)
+}
+
+// written tells the task repository that time was logged on the task: its
+// cached copies are dropped and reads stay on the primary for the
+// read-your-writes window.
+func (r *timeEntryRepo) written(ctx context.Context, taskID string) {
+	if tasks, ok := r.tasks.(*taskRepo); ok {
+		tasks.markWritten()
+		tasks.invalidateAfterWrite(ctx, taskID)
+	}
+}
+
+func (r *timeEntryRepo) StartTimer(ctx context.Context, taskID string) (*model.TimeEntry, error) {
+	ctx = database.WithOperation(ctx, "time_entries", "create")
+	now := time.Now().UTC()
+	var e model.TimeEntry
+	err := r.inTx(ctx, taskID, func(tx *sqlx.Tx, assignee *string) error {
+		var running int
+		if err := tx.GetContext(ctx, &running, r.d.Rebind("SELECT count(1) FROM time_entries WHERE task_id = $1 AND ended_at IS NULL"), taskID); err != nil {
+			return err
+		}
+		if running > 0 {
+			return ErrTimerRunning
+		}
+		e = model.TimeEntry{ID: uuid.New().String(), TaskID: taskID, Assignee: assignee, StartedAt: now, CreatedAt: now}
+		return r.insert(ctx, tx, &e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *timeEntryRepo) StopTimer(ctx context.Context, taskID string) (*model.TimeEntry, error) {
+	ctx = database.WithOperation(ctx, "time_entries", "update")
+	now := time.Now().UTC()
+	var e model.TimeEntry
+	err := r.inTx(ctx, taskID, func(tx *sqlx.Tx, _ *string) error {
+		if err := tx.GetContext(ctx, &e, r.d.Rebind(selectTimeEntry+" WHERE task_id = $1 AND ended_at IS NULL"), taskID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrTimerNotRunning
+			}
+			return err
+		}
+		e.EndedAt = &now
+		e.Seconds = spanSeconds(e.StartedAt, now)
+		if _, err := tx.ExecContext(ctx, r.d.Rebind("UPDATE time_entries SET ended_at = $1, seconds = $2 WHERE id = $3"), e.EndedAt, e.Seconds, e.ID); err != nil {
+			return err
+		}
+		return r.logTime(ctx, tx, &e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.written(ctx, taskID)
+	return &e, nil
+}
+
+func (r *timeEntryRepo) AddEntry(ctx context.Context, taskID string, startedAt, endedAt time.Time) (*model.TimeEntry, error) {
+	ctx = database.WithOperation(ctx, "time_entries", "create")
+	startedAt, endedAt = startedAt.UTC(), endedAt.UTC()
+	var e model.TimeEntry
+	err := r.inTx(ctx, taskID, func(tx *sqlx.Tx, assignee *string) error {
+		e = model.TimeEntry{
+			ID:        uuid.New().String(),
+			TaskID:    taskID,
+			Assignee:  assignee,
+			StartedAt: startedAt,
+			EndedAt:   &endedAt,
+			Seconds:   spanSeconds(startedAt, endedAt),
+			CreatedAt: time.Now().UTC(),
+		}
+		if err := r.insert(ctx, tx, &e); err != nil {
+			return err
+		}
+		return r.logTime(ctx, tx, &e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.written(ctx, taskID)
+	return &e, nil
+}
+
+func (r *timeEntryRepo) List(ctx context.Context, taskID string) ([]model.TimeEntry, error) {
+	ctx = database.WithOperation(ctx, "time_entries", "list")
+	var n int
+	if err := r.db.GetContext(ctx, &n, r.d.Rebind("SELECT count(1) FROM tasks WHERE id = $1"), taskID); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrNotFound
+	}
+	entries := []model.TimeEntry{}
+	err := r.db.SelectContext(ctx, &entries, r.d.Rebind(selectTimeEntry+" WHERE task_id = $1 ORDER BY started_at DESC, id"), taskID)
+	return entries, err
+}
+
+func (r *timeEntryRepo) ListMany(ctx context.Context, taskIDs []string) (map[string][]model.TimeEntry, error) {
+	ctx = database.WithOperation(ctx, "time_entries", "list")
+	out := map[string][]model.TimeEntry{}
+	if len(taskIDs) == 0 {
+		return out, nil
+	}
+	query, args, err := sqlx.In(selectTimeEntry+" WHERE task_id IN (?) ORDER BY started_at DESC, id", taskIDs)
+	if err != nil {
+		return nil, err
+	}
+	var entries []model.TimeEntry
+	if err := r.db.SelectContext(ctx, &entries, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		out[e.TaskID] = append(out[e.TaskID], e)
+	}
+	return out, nil
+}
+
+func (r *timeEntryRepo) Report(ctx context.Context, from, to *time.Time) ([]model.TimeReportRow, error) {
+	ctx = database.WithOperation(ctx, "time_entries", "report")
+	query := "SELECT assignee, COALESCE(SUM(seconds), 0) AS seconds, count(1) AS entries FROM time_entries WHERE ended_at IS NOT NULL"
+	var args []interface{}
+	if from != nil {
+		args = append(args, from.UTC())
+		query += " AND started_at >= $1"
+	}
+	if to != nil {
+		args = append(args, to.UTC())
+		query += " AND started_at < $" + strconv.Itoa(len(args))
+	}
+	query += " GROUP BY assignee ORDER BY " + r.d.OrderBy("assignee", false, "FIRST")
+	rows := []model.TimeReportRow{}
+	err := r.db.SelectContext(ctx, &rows, r.d.Rebind(query), args...)
+	return rows, err
+}
+
+// spanSeconds is the length of [start, end) in whole seconds; never negative.
+func spanSeconds(start, end time.Time) int64 {
+	return max(int64(end.Sub(start)/time.Second), 0)
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+	"taskmanager/internal/logging"
+	"taskmanager/internal/model"
+)
+
+// TombstoneRepository reads the tombstones TaskRepository leaves behind for
+// deleted tasks (see model.Tombstone). Delete and DeleteCompleted write them
+// in the deleting transaction, with the actor in the request context.
+type TombstoneRepository interface {
+	// GetMany returns the tombstones of the given tasks in one query, keyed
+	// by task id. Tasks without one are absent.
+	GetMany(ctx context.Context, taskIDs []string) (map[string]model.Tombstone, error)
+	// Purge removes the tombstones of tasks deleted before the given time and
+	// returns how many were removed.
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+type tombstoneRepo struct {
+	db *sqlx.DB
+	d  database.Dialect
+}
+
+// NewTombstoneRepository creates a TombstoneRepository backed by sqlx.DB.
+func NewTombstoneRepository(db *sqlx.DB) TombstoneRepository {
+	return &tombstoneRepo{db: db, d: database.For(db)}
+}
+
+// newTombstone is the tombstone of a task deleted now by the actor in ctx.
+func newTombstone(ctx context.Context, id string) model.Tombstone {
+	t := model.Tombstone{TaskID: id, DeletedAt: time.Now().UTC()}
+	if actor := logging.Actor(ctx); actor != "" {
+		t.Actor = &actor
+	}
+	return t
+}
+
+// insertTombstone writes t in tx, replacing an older tombstone of a task
+// that was created again with the same id.
+func insertTombstone(ctx context.Context, tx *sqlx.Tx, d database.Dialect, t model.Tombstone) error {
+	if _, err := tx.ExecContext(ctx, d.Rebind("DELETE FROM task_tombstones WHERE task_id = $1"), t.TaskID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, d.Rebind("INSERT INTO task_tombstones (task_id, deleted_at, actor) VALUES ($1, $2, $3)"), t.TaskID, t.DeletedAt, t.Actor)
+	return err
+}
+
+func (r *tombstoneRepo) GetMany(ctx context.Context, taskIDs []string) (map[string]model.Tombstone, error) {
+	ctx = database.WithOperation(ctx, "tombstones", "get")
+	out := map[string]model.Tombstone{}
+	if len(taskIDs) == 0 {
+		return out, nil
+	}
+	query, args, err := sqlx.In("SELECT task_id, deleted_at, actor FROM task_tombstones WHERE task_id IN (?)", taskIDs)
+	if err != nil {
+		return nil, err
+	}
+	var tombstones []model.Tombstone
+	if err := r.db.SelectContext(ctx, &tombstones, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, t := range tombstones {
+		out[t.TaskID] = t
+	}
+	return out, nil
+}
+
+func (r *tombstoneRepo) Purge(ctx context.Context, before time.Time) (int, error) {
+	ctx = database.WithOperation(ctx, "tombstones", "delete")
+	res, err := r.db.ExecContext(ctx, r.d.Rebind("DELETE FROM task_tombstones WHERE deleted_at < $1"), before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+	"taskmanager/internal/model"
+)
+
+// ErrViewNotFound is returned when a view id does not exist.
+var ErrViewNotFound = errors.New("view not found")
+
+// ViewRepository stores saved task list queries. The filter is stored as
+// given; the handler validates it before Create.
+type ViewRepository interface {
+	// Create assigns the id and timestamps.
+	Create(ctx context.Context, v *model.View) error
+	Get(ctx context.Context, id string) (*model.View, error)
+	// List returns all views ordered by name.
+	List(ctx context.Context) ([]model.View, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type viewRepo struct {
+	db *sqlx.DB
+	d  database.Dialect
+}
+
+// NewViewRepository creates a ViewRepository backed by sqlx.DB.
+func NewViewRepository(db *sqlx.DB) ViewRepository {
+	return &viewRepo{db: db, d: database.For(db)}
+}
+
+const selectView = "SELECT id, name, filter, created_at, updated_at FROM views"
+
+func (r *viewRepo) Create(ctx context.Context, v *model.View) error {
+	ctx = database.WithOperation(ctx, "views", "create")
+	v.ID = uuid.New().String()
+	v.CreatedAt = time.Now().UTC()
+	v.UpdatedAt = v.CreatedAt
+	_, err := r.db.ExecContext(ctx, r.d.Rebind("INSERT INTO views (id, name, filter, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)"),
+		v.ID, v.Name, v.Filter, v.CreatedAt, v.UpdatedAt)
+	return err
+}
+
+func (r *viewRepo) Get(ctx context.Context, id string) (*model.View, error) {
+	ctx = database.WithOperation(ctx, "views", "get")
+	var v model.View
+	if err := r.db.GetContext(ctx, &v, r.d.Rebind(selectView+" WHERE id = $1"), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrViewNotFound
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *viewRepo) List(ctx context.Context) ([]model.View, error) {
+	ctx = database.WithOperation(ctx, "views", "list")
+	views := []model.View{}
+	err := r.db.SelectContext(ctx, &views, selectView+" ORDER BY name, id")
+	return views, err
+}
+
+func (r *viewRepo) Delete(ctx context.Context, id string) error {
+	ctx = database.WithOperation(ctx, "views", "delete")
+	res, err := r.db.ExecContext(ctx, r.d.Rebind("DELETE FROM views WHERE id = $1"), id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrViewNotFound
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+	"taskmanager/internal/model"
+	"taskmanager/internal/outbox"
+)
+
+// WatcherRepository stores who watches which task. Adding and removing a
+// watcher records a task.watched / task.unwatched outbox event; notification
+// consumers use the list to fan the other task events out. Every method fails
+// with ErrNotFound when the task does not exist.
+type WatcherRepository interface {
+	// Add makes watcher watch the task. added is false, and nothing is
+	// written, when it already does.
+	Add(ctx context.Context, taskID, watcher string) (w *model.Watcher, added bool, err error)
+	// Remove stops watcher watching the task; removed is false when it
+	// didn't.
+	Remove(ctx context.Context, taskID, watcher string) (removed bool, err error)
+	// List returns the task's watchers ordered by name.
+	List(ctx context.Context, taskID string) ([]model.Watcher, error)
+	// ListMany returns the watchers of each of the tasks, ordered by name,
+	// in one query. Tasks without watchers, or that don't exist, are absent.
+	ListMany(ctx context.Context, taskIDs []string) (map[string][]model.Watcher, error)
+}
+
+type watcherRepo struct {
+	db *sqlx.DB
+	d  database.Dialect
+}
+
+// NewWatcherRepository creates a WatcherRepository backed by sqlx.DB.
+func NewWatcherRepository(db *sqlx.DB) WatcherRepository {
+	return &watcherRepo{db: db, d: database.For(db)}
+}
+
+// inTx runs fn in a transaction that is committed when fn succeeds. The task
+// row is locked first so it cannot be deleted underneath the change.
+func (r *watcherRepo) inTx(ctx context.Context, taskID string, fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var id string
+	if err := tx.GetContext(ctx, &id, r.d.Rebind("SELECT id FROM tasks WHERE id = $1")+r.d.ForUpdate(), taskID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *watcherRepo) Add(ctx context.Context, taskID, watcher string) (*model.Watcher, bool, error) {
+	ctx = database.WithOperation(ctx, "watchers", "create")
+	insert := "INSERT INTO task_watchers (task_id, watcher, created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING"
+	if r.d.MySQL() {
+		insert = "INSERT IGNORE INTO task_watchers (task_id, watcher, created_at) VALUES ($1, $2, $3)"
+	}
+
+	var w model.Watcher
+	var added bool
+	err := r.inTx(ctx, taskID, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, r.d.Rebind(insert), taskID, watcher, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		ra, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if err := tx.GetContext(ctx, &w, r.d.Rebind("SELECT task_id, watcher, created_at FROM task_watchers WHERE task_id = $1 AND watcher = $2"), taskID, watcher); err != nil {
+			return err
+		}
+		if added = ra > 0; !added {
+			return nil
+		}
+		return outbox.Insert(ctx, tx, outbox.EventTaskWatched, taskID, &w)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &w, added, nil
+}
+
+func (r *watcherRepo) Remove(ctx context.Context, taskID, watcher string) (bool, error) {
+	ctx = database.WithOperation(ctx, "watchers", "delete")
+	var removed bool
+	err := r.inTx(ctx, taskID, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, r.d.Rebind("DELETE FROM task_watchers WHERE task_id = $1 AND watcher = $2"), taskID, watcher)
+		if err != nil {
+			return err
+		}
+		ra, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if removed = ra > 0; !removed {
+			return nil
+		}
+		return outbox.Insert(ctx, tx, outbox.EventTaskUnwatched, taskID, map[string]string{"task_id": taskID, "watcher": watcher})
+	})
+	return removed, err
+}
+
+func (r *watcherRepo) List(ctx context.Context, taskID string) ([]model.Watcher, error) {
+	ctx = database.WithOperation(ctx, "watchers", "list")
+	var n int
+	if err := r.db.GetContext(ctx, &n, r.d.Rebind("SELECT count(1) FROM tasks WHERE id = $1"), taskID); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrNotFound
+	}
+	watchers := []model.Watcher{}
+	err := r.db.SelectContext(ctx, &watchers, r.d.Rebind("SELECT task_id, watcher, created_at FROM task_watchers WHERE task_id = $1 ORDER BY watcher"), taskID)
+	return watchers, err
+}
+
+func (r *watcherRepo) ListMany(ctx context.Context, taskIDs []string) (map[string][]model.Watcher, error) {
+	ctx = database.WithOperation(ctx, "watchers", "list")
+	out := map[string][]model.Watcher{}
+	if len(taskIDs) == 0 {
+		return out, nil
+	}
+	query, args, err := sqlx.In("SELECT task_id, watcher, created_at FROM task_watchers WHERE task_id IN (?) ORDER BY watcher", taskIDs)
+	if err != nil {
+		return nil, err
+	}
+	var watchers []model.Watcher
+	if err := r.db.SelectContext(ctx, &watchers, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, w := range watchers {
+		out[w.TaskID] = append(out[w.TaskID], w)
+	}
+	return out, nil
+}
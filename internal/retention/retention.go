@@ -0,0 +1,169 @@
+// Package retention applies the data retention policy: completed tasks and
+// the tombstones of deleted tasks older than the configured ages are purged
+// on a schedule or on demand.
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"taskmanager/internal/logging"
+	"taskmanager/internal/metric"
+)
+
+// Actor is the actor recorded on the tombstones of the tasks a run deletes.
+const Actor = "retention"
+
+// JobKind is the job queue kind of a scheduled run (see SetEnqueue).
+const JobKind = "retention.run"
+
+// ErrRunning is returned by RunOnce while another run is still in progress.
+var ErrRunning = errors.New("retention run already in progress")
+
+// Purger deletes completed tasks; service.TaskService implements it.
+type Purger interface {
+	DeleteCompleted(ctx context.Context, before *time.Time) (int, error)
+}
+
+// TombstonePurger removes old tombstones; repositories.TombstoneRepository
+// implements it.
+type TombstonePurger interface {
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// Policy says what to remove. A zero age disables that rule.
+type Policy struct {
+	// PurgeCompletedAfter deletes tasks completed longer ago than this.
+	PurgeCompletedAfter time.Duration
+	// PurgeTombstonesAfter deletes the tombstones of tasks deleted longer
+	// ago than this; it needs SetTombstones.
+	PurgeTombstonesAfter time.Duration
+}
+
+// Enabled reports whether any rule is set.
+func (p Policy) Enabled() bool {
+	return p.PurgeCompletedAfter > 0 || p.PurgeTombstonesAfter > 0
+}
+
+// Result reports what one run removed.
+type Result struct {
+	PurgedCompleted  int `json:"purged_completed"`
+	PurgedTombstones int `json:"purged_tombstones"`
+}
+
+// Worker runs the policy. Runs never overlap.
+type Worker struct {
+	tasks      Purger
+	tombstones TombstonePurger
+	policy     Policy
+	now        func() time.Time
+	mu         sync.Mutex
+	// enqueue, when set, replaces running the policy on each tick
+	enqueue func(ctx context.Context) error
+	// leader, when set, skips ticks while this instance is not the leader
+	leader func() bool
+}
+
+// New creates a Worker applying policy to tasks.
+func New(tasks Purger, policy Policy) *Worker {
+	return &Worker{tasks: tasks, policy: policy, now: time.Now}
+}
+
+// Policy returns the policy the worker applies.
+func (w *Worker) Policy() Policy {
+	return w.policy
+}
+
+// SetTombstones enables the PurgeTombstonesAfter rule on t. Call it before
+// the first run.
+func (w *Worker) SetTombstones(t TombstonePurger) {
+	w.tombstones = t
+}
+
+// SetEnqueue makes Run enqueue a JobKind job on each tick instead of running
+// the policy itself, so scheduled runs go through the job queue and get its
+// retries; the queue's worker calls HandleJob.
+func (w *Worker) SetEnqueue(fn func(ctx context.Context) error) {
+	w.enqueue = fn
+}
+
+// SetLeader makes Run skip its ticks unless isLeader reports true, so with
+// several replicas the schedule fires on one of them only. Manual runs are
+// not affected.
+func (w *Worker) SetLeader(isLeader func() bool) {
+	w.leader = isLeader
+}
+
+// HandleJob is the jobs.HandlerFunc for JobKind. A run already in progress
+// counts as done.
+func (w *Worker) HandleJob(ctx context.Context, _ json.RawMessage) error {
+	res, err := w.RunOnce(ctx, "schedule")
+	if errors.Is(err, ErrRunning) {
+		return nil
+	}
+	if err == nil {
+		slog.Info("retention run finished", "purged_completed", res.PurgedCompleted, "purged_tombstones", res.PurgedTombstones)
+	}
+	return err
+}
+
+// RunOnce applies the policy now; trigger labels the run in the metrics
+// ("schedule" or "manual"). Tasks it deletes get a tombstone by Actor. It
+// fails with ErrRunning instead of waiting when a run is already in
+// progress.
+func (w *Worker) RunOnce(ctx context.Context, trigger string) (Result, error) {
+	if !w.mu.TryLock() {
+		return Result{}, ErrRunning
+	}
+	defer w.mu.Unlock()
+
+	var res Result
+	var err error
+	if d := w.policy.PurgeCompletedAfter; d > 0 {
+		before := w.now().Add(-d)
+		res.PurgedCompleted, err = w.tasks.DeleteCompleted(logging.WithActor(ctx, Actor), &before)
+		metric.RetentionRows.WithLabelValues("purge_completed").Add(float64(res.PurgedCompleted))
+	}
+	if d := w.policy.PurgeTombstonesAfter; d > 0 && w.tombstones != nil && err == nil {
+		res.PurgedTombstones, err = w.tombstones.Purge(ctx, w.now().Add(-d))
+		metric.RetentionRows.WithLabelValues("purge_tombstones").Add(float64(res.PurgedTombstones))
+	}
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	metric.RetentionRuns.WithLabelValues(trigger, result).Inc()
+	return res, err
+}
+
+// Run applies the policy every interval until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if w.leader != nil && !w.leader() {
+			continue
+		}
+		if w.enqueue != nil {
+			if err := w.enqueue(ctx); err != nil {
+				slog.Error("retention job enqueue failed", "err", err)
+			}
+			continue
+		}
+		res, err := w.RunOnce(ctx, "schedule")
+		if err != nil {
+			slog.Error("retention run failed", "err", err)
+			continue
+		}
+		slog.Info("retention run finished", "purged_completed", res.PurgedCompleted, "purged_tombstones", res.PurgedTombstones)
+	}
+}
@@ -0,0 +1,140 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"taskmanager/internal/logging"
+	"taskmanager/internal/metric"
+)
+
+type purgerFunc func(ctx context.Context, before *time.Time) (int, error)
+
+func (f purgerFunc) DeleteCompleted(ctx context.Context, before *time.Time) (int, error) {
+	return f(ctx, before)
+}
+
+func TestWorker_RunOncePurgesCompletedBeforeCutoff(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	var cutoff *time.Time
+	w := New(purgerFunc(func(_ context.Context, before *time.Time) (int, error) {
+		cutoff = before
+		return 3, nil
+	}), Policy{PurgeCompletedAfter: 90 * 24 * time.Hour})
+	w.now = func() time.Time { return now }
+
+	purged := testutil.ToFloat64(metric.RetentionRows.WithLabelValues("purge_completed"))
+	res, err := w.RunOnce(context.Background(), "manual")
+	if err != nil || res.PurgedCompleted != 3 {
+		t.Fatalf("unexpected result %+v err=%v", res, err)
+	}
+	if cutoff == nil || !cutoff.Equal(now.Add(-90*24*time.Hour)) {
+		t.Fatalf("unexpected cutoff %v", cutoff)
+	}
+	if d := testutil.ToFloat64(metric.RetentionRows.WithLabelValues("purge_completed")) - purged; d != 3 {
+		t.Fatalf("expected 3 purged rows counted got %v", d)
+	}
+}
+
+type tombstonesFunc func(ctx context.Context, before time.Time) (int, error)
+
+func (f tombstonesFunc) Purge(ctx context.Context, before time.Time) (int, error) {
+	return f(ctx, before)
+}
+
+func TestWorker_RunOncePurgesTombstones(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	var actor string
+	w := New(purgerFunc(func(ctx context.Context, _ *time.Time) (int, error) {
+		actor = logging.Actor(ctx)
+		return 1, nil
+	}), Policy{PurgeCompletedAfter: time.Hour, PurgeTombstonesAfter: 30 * 24 * time.Hour})
+	w.now = func() time.Time { return now }
+	var cutoff time.Time
+	w.SetTombstones(tombstonesFunc(func(_ context.Context, before time.Time) (int, error) {
+		cutoff = before
+		return 5, nil
+	}))
+
+	res, err := w.RunOnce(context.Background(), "manual")
+	if err != nil || res.PurgedCompleted != 1 || res.PurgedTombstones != 5 {
+		t.Fatalf("unexpected result %+v err=%v", res, err)
+	}
+	if !cutoff.Equal(now.Add(-30 * 24 * time.Hour)) {
+		t.Fatalf("unexpected cutoff %v", cutoff)
+	}
+	// the purged tasks' tombstones name the policy
+	if actor != Actor {
+		t.Fatalf("expected actor %q got %q", Actor, actor)
+	}
+}
+
+func TestWorker_DisabledRuleAndErrors(t *testing.T) {
+	w := New(purgerFunc(func(context.Context, *time.Time) (int, error) {
+		t.Fatalf("a disabled rule must not purge")
+		return 0, nil
+	}), Policy{})
+	if res, err := w.RunOnce(context.Background(), "manual"); err != nil || res.PurgedCompleted != 0 {
+		t.Fatalf("unexpected result %+v err=%v", res, err)
+	}
+
+	boom := errors.New("boom")
+	w = New(purgerFunc(func(context.Context, *time.Time) (int, error) { return 0, boom }), Policy{PurgeCompletedAfter: time.Hour})
+	failed := testutil.ToFloat64(metric.RetentionRuns.WithLabelValues("schedule", "error"))
+	if _, err := w.RunOnce(context.Background(), "schedule"); !errors.Is(err, boom) {
+		t.Fatalf("expected boom got %v", err)
+	}
+	if d := testutil.ToFloat64(metric.RetentionRuns.WithLabelValues("schedule", "error")) - failed; d != 1 {
+		t.Fatalf("expected one failed run counted got %v", d)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.RunOnce(context.Background(), "manual"); !errors.Is(err, ErrRunning) {
+		t.Fatalf("expected ErrRunning during a run got %v", err)
+	}
+}
+
+func TestWorker_HandleJob(t *testing.T) {
+	boom := errors.New("boom")
+	w := New(purgerFunc(func(context.Context, *time.Time) (int, error) { return 0, boom }), Policy{PurgeCompletedAfter: time.Hour})
+	if err := w.HandleJob(context.Background(), nil); !errors.Is(err, boom) {
+		t.Fatalf("expected the failure to reach the job queue got %v", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.HandleJob(context.Background(), nil); err != nil {
+		t.Fatalf("a run in progress must complete the job, got %v", err)
+	}
+}
+
+func TestWorker_RunSkipsTicksWhenNotLeader(t *testing.T) {
+	w := New(purgerFunc(func(context.Context, *time.Time) (int, error) { return 0, nil }), Policy{PurgeCompletedAfter: time.Hour})
+	var asked, enqueued atomic.Int32
+	w.SetLeader(func() bool { return asked.Add(1) > 2 })
+	w.SetEnqueue(func(context.Context) error {
+		enqueued.Add(1)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx, time.Millisecond)
+		close(done)
+	}()
+	for enqueued.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	if asked.Load() < 3 {
+		t.Fatalf("expected the first ticks to be skipped, asked %d times", asked.Load())
+	}
+}
@@ -0,0 +1,63 @@
+// Package retry repeats an operation with exponential backoff, for waiting
+// on dependencies (the database, Redis) that may come up after the service.
+package retry
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Policy bounds the retries. Zero Initial and Max use 500ms and 10s.
+type Policy struct {
+	// Timeout is how long to keep retrying; 0 retries until ctx is done.
+	Timeout time.Duration
+	// Initial is the wait after the first failure; it doubles up to Max.
+	Initial time.Duration
+	Max     time.Duration
+}
+
+func (p Policy) initial() time.Duration {
+	if p.Initial > 0 {
+		return p.Initial
+	}
+	return 500 * time.Millisecond
+}
+
+func (p Policy) max() time.Duration {
+	if p.Max > 0 {
+		return p.Max
+	}
+	return 10 * time.Second
+}
+
+// Do calls fn until it succeeds, the policy's timeout runs out or ctx is
+// cancelled, logging every failure as "<what> not reachable". It returns the
+// last error of fn (or ctx's error if fn never ran).
+func Do(ctx context.Context, p Policy, what string, fn func(ctx context.Context) error) error {
+	var deadline time.Time
+	if p.Timeout > 0 {
+		deadline = time.Now().Add(p.Timeout)
+	}
+	wait := p.initial()
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			if attempt > 1 {
+				slog.Info(what+" reachable", "attempts", attempt)
+			}
+			return nil
+		}
+		if !deadline.IsZero() && time.Now().Add(wait).After(deadline) {
+			slog.Error(what+" not reachable, giving up", "attempts", attempt, "err", err)
+			return err
+		}
+		slog.Warn(what+" not reachable, retrying", "attempt", attempt, "retry_in", wait.String(), "err", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, p.max())
+	}
+}
@@ -0,0 +1,50 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDo_RetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), Policy{Timeout: time.Second, Initial: time.Millisecond}, "test", func(context.Context) error {
+		if calls++; calls < 3 {
+			return errors.New("down")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("expected success on the third call got calls=%d err=%v", calls, err)
+	}
+}
+
+func TestDo_GivesUpAfterTimeout(t *testing.T) {
+	down := errors.New("down")
+	calls := 0
+	start := time.Now()
+	err := Do(context.Background(), Policy{Timeout: 20 * time.Millisecond, Initial: time.Millisecond, Max: 4 * time.Millisecond}, "test", func(context.Context) error {
+		calls++
+		return down
+	})
+	if !errors.Is(err, down) || calls < 2 {
+		t.Fatalf("expected the last error after several calls got calls=%d err=%v", calls, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected to give up within the timeout, took %v", elapsed)
+	}
+
+	// without a timeout only cancellation stops it
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	err = Do(ctx, Policy{Initial: time.Millisecond}, "test", func(context.Context) error {
+		if calls++; calls == 3 {
+			cancel()
+		}
+		return down
+	})
+	if !errors.Is(err, down) || calls != 3 {
+		t.Fatalf("expected to stop on cancellation got calls=%d err=%v", calls, err)
+	}
+}
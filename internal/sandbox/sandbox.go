@@ -0,0 +1,122 @@
+// Package sandbox implements the SANDBOX run mode: an in-memory instance
+// seeded with demo data that is wiped and re-seeded periodically, for product
+// demos and client SDK development.
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"taskmanager/internal/metric"
+	"taskmanager/internal/model"
+	"taskmanager/internal/repositories"
+)
+
+// Resetter is implemented by the in-memory repositories.
+type Resetter interface {
+	Reset()
+}
+
+// Sandbox owns the demo data of a sandbox instance.
+type Sandbox struct {
+	tasks     repositories.TaskRepository
+	incidents repositories.IncidentRepository
+}
+
+// New creates a Sandbox for the given repositories. Both must implement
+// Resetter.
+func New(tasks repositories.TaskRepository, incidents repositories.IncidentRepository) (*Sandbox, error) {
+	for _, r := range []interface{}{tasks, incidents} {
+		if _, ok := r.(Resetter); !ok {
+			return nil, fmt.Errorf("sandbox: %T cannot be reset", r)
+		}
+	}
+	return &Sandbox{tasks: tasks, incidents: incidents}, nil
+}
+
+// Reset drops all data and seeds the demo data again.
+func (s *Sandbox) Reset(ctx context.Context) error {
+	s.tasks.(Resetter).Reset()
+	s.incidents.(Resetter).Reset()
+	n, err := Seed(ctx, s.tasks, s.incidents, time.Now().UTC())
+	metric.TasksCount.Set(float64(n))
+	return err
+}
+
+// Run resets the data every interval until ctx is cancelled.
+func (s *Sandbox) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.Reset(ctx); err != nil {
+			slog.Error("sandbox reset failed", "err", err)
+			continue
+		}
+		slog.Info("sandbox data reset")
+	}
+}
+
+// demoTask describes one seeded task relative to the seeding time.
+type demoTask struct {
+	title, description, assignee string
+	completed                    bool
+	due                          time.Duration // 0: no due date
+	remaining                    int64         // minutes; 0: no estimate
+}
+
+var demoTasks = []demoTask{
+	{title: "Draft Q3 roadmap", description: "Collect input from product and engineering leads", assignee: "alice", due: 7 * 24 * time.Hour, remaining: 240},
+	{title: "Review onboarding flow", description: "Walk through sign-up on mobile and desktop", assignee: "alice", due: 2 * 24 * time.Hour, remaining: 90},
+	{title: "Fix flaky checkout test", assignee: "bob", due: 24 * time.Hour, remaining: 60},
+	{title: "Upgrade PostgreSQL to 16", description: "Staging first, then production during the maintenance window", assignee: "bob", due: 14 * 24 * time.Hour, remaining: 480},
+	{title: "Write release notes for 2.4", assignee: "carol", completed: true},
+	{title: "Prepare customer webinar", description: "Slides and demo script", assignee: "carol", due: 5 * 24 * time.Hour, remaining: 180},
+	{title: "Triage support backlog", assignee: "dave", due: -24 * time.Hour, remaining: 120},
+	{title: "Rotate API credentials", assignee: "dave", completed: true},
+	{title: "Evaluate error tracking vendors", description: "Shortlist three and compare pricing"},
+	{title: "Clean up feature flags", due: 30 * 24 * time.Hour},
+	{title: "Book team offsite venue", completed: true},
+	{title: "Update privacy policy links", description: "Footer and account settings pages", assignee: "alice", completed: true},
+}
+
+// Seed inserts the demo tasks and a resolved demo incident, returning the
+// number of tasks created.
+func Seed(ctx context.Context, tasks repositories.TaskRepository, incidents repositories.IncidentRepository, now time.Time) (int, error) {
+	n := 0
+	for _, d := range demoTasks {
+		t := &model.Task{Title: d.title, Completed: d.completed}
+		if d.description != "" {
+			t.SetDescription(d.description)
+		}
+		if d.assignee != "" {
+			t.SetAssignee(d.assignee)
+		}
+		if d.due != 0 {
+			t.SetDueDate(now.Add(d.due).Truncate(time.Hour))
+		}
+		if d.remaining > 0 {
+			t.SetRemainingMinutes(d.remaining)
+		}
+		if err := tasks.Create(ctx, t); err != nil {
+			return n, err
+		}
+		n++
+	}
+	resolved := now.Add(-25 * time.Hour)
+	if err := incidents.Create(ctx, &model.Incident{
+		Title:       "Elevated API latency",
+		Description: "Database failover; resolved automatically",
+		StartedAt:   now.Add(-26 * time.Hour),
+		ResolvedAt:  &resolved,
+	}); err != nil {
+		return n, err
+	}
+	return n, nil
+}
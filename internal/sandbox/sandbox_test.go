@@ -0,0 +1,47 @@
+package sandbox
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/model"
+	"taskmanager/internal/repositories"
+)
+
+func TestSandbox_ResetReseeds(t *testing.T) {
+	ctx := context.Background()
+	tasks := repositories.NewMemoryTaskRepository()
+	incidents := repositories.NewMemoryIncidentRepository()
+	sb, err := New(tasks, incidents)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	if err := sb.Reset(ctx); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if err := tasks.Create(ctx, &model.Task{Title: "scratch"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := sb.Reset(ctx); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	if n, _ := tasks.Count(ctx); n != len(demoTasks) {
+		t.Fatalf("expected %d demo tasks after reset got %d", len(demoTasks), n)
+	}
+	if list, _ := incidents.ListSince(ctx, time.Now().Add(-48*time.Hour)); len(list) != 1 || list[0].ResolvedAt == nil {
+		t.Fatalf("expected one resolved demo incident got %+v", list)
+	}
+}
+
+func TestNew_RequiresResettableRepositories(t *testing.T) {
+	db := sqlx.NewDb(&sql.DB{}, "postgres")
+	if _, err := New(repositories.NewTaskRepository(db), repositories.NewMemoryIncidentRepository()); err == nil {
+		t.Fatalf("expected a database-backed repository to be rejected")
+	}
+}
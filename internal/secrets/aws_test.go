@@ -0,0 +1,60 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSignV4 checks the signature against the example of the AWS Signature
+// Version 4 documentation (IAM ListUsers).
+func TestSignV4(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, nil, creds, "us-east-1", "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("unexpected signature\n got %s\nwant %s", got, want)
+	}
+	if !strings.HasPrefix(req.Header.Get("X-Amz-Date"), "20150830T123600Z") {
+		t.Fatalf("unexpected date %q", req.Header.Get("X-Amz-Date"))
+	}
+}
+
+func TestAWS_Secret(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" || r.Header.Get("X-Amz-Security-Token") != "session" ||
+			!strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Errorf("unexpected request headers %v", r.Header)
+		}
+		var in struct{ SecretId string }
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		switch in.SecretId {
+		case "taskmanager/prod":
+			_, _ = w.Write([]byte(`{"Name":"taskmanager/prod","SecretString":"{\"database_url\":\"postgres://rotated\"}"}`))
+		case "binary":
+			_, _ = w.Write([]byte(`{"SecretBinary":"aHVudGVyMg=="}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer srv.Close()
+
+	p := NewAWS("eu-west-1", srv.URL, AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session"})
+	if v, err := Resolve(context.Background(), p, "taskmanager/prod#database_url"); err != nil || v != "postgres://rotated" {
+		t.Fatalf("unexpected value %q err=%v", v, err)
+	}
+	if v, err := Resolve(context.Background(), p, "binary"); err != nil || v != "hunter2" {
+		t.Fatalf("unexpected binary value %q err=%v", v, err)
+	}
+	if _, err := Resolve(context.Background(), p, "missing#x"); err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Fatalf("expected the AWS error, got %v", err)
+	}
+}
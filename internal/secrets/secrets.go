@@ -0,0 +1,120 @@
+// Package secrets fetches credentials (database URLs, the Redis password,
+// API keys) from a secrets manager at startup and keeps them fresh, so they
+// can be rotated without redeploying. HashiCorp Vault (KV version 2) and AWS
+// Secrets Manager are supported through their HTTP APIs.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider returns the value of a secret: for Vault the JSON object of its
+// fields, for AWS Secrets Manager its SecretString.
+type Provider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// Resolve fetches the value a reference points at. A reference is a secret
+// name, optionally followed by "#" and a field of the secret's JSON object:
+// "taskmanager/prod#database_url".
+func Resolve(ctx context.Context, p Provider, ref string) (string, error) {
+	name, field, _ := strings.Cut(ref, "#")
+	value, err := p.Secret(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %w", name, err)
+	}
+	if field == "" {
+		return value, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret %s: not a JSON object, cannot read field %q", name, field)
+	}
+	switch v := fields[field].(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", fmt.Errorf("secret %s has no field %q", name, field)
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// Set resolves a fixed list of references, by name, and keeps their latest
+// values for the rest of the service to read.
+type Set struct {
+	provider Provider
+	refs     map[string]string
+
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// NewSet creates a set resolving refs (name to reference) with p. Nothing is
+// fetched before the first Refresh.
+func NewSet(p Provider, refs map[string]string) *Set {
+	return &Set{provider: p, refs: refs, values: map[string]string{}}
+}
+
+// Refresh fetches every reference. The values are replaced together, and
+// only when all of them could be fetched.
+func (s *Set) Refresh(ctx context.Context) error {
+	values := make(map[string]string, len(s.refs))
+	for name, ref := range s.refs {
+		v, err := Resolve(ctx, s.provider, ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		values[name] = v
+	}
+	s.mu.Lock()
+	s.values = values
+	s.mu.Unlock()
+	return nil
+}
+
+// Get returns the value of name and whether the set has it.
+func (s *Set) Get(name string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.values[name]
+	return v, ok
+}
+
+// Source returns a function reading the current value of name, or fallback
+// when the set (which may be nil) does not resolve it. Consumers that call it
+// for every new connection pick up rotated credentials.
+func (s *Set) Source(name, fallback string) func() string {
+	return func() string {
+		if v, ok := s.Get(name); ok {
+			return v
+		}
+		return fallback
+	}
+}
+
+// Run refreshes the set every interval until ctx is cancelled. A failed
+// refresh is logged and the previous values are kept.
+func (s *Set) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("secrets refresh failed, keeping the previous values", "err", err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVault_SetRefresh(t *testing.T) {
+	password := "first"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/kv/data/taskmanager/prod":
+			_, _ = w.Write([]byte(`{"data":{"data":{"database_url":"postgres://db","redis_password":"` + password + `","port":5432},"metadata":{"version":3}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer srv.Close()
+
+	set := NewSet(NewVault(srv.URL+"/", "root", "/kv/"), map[string]string{
+		"database.url":   "taskmanager/prod#database_url",
+		"redis.password": "taskmanager/prod#redis_password",
+	})
+	redisPassword := set.Source("redis.password", "configured")
+	if v := redisPassword(); v != "configured" {
+		t.Fatalf("expected the fallback before the first refresh, got %q", v)
+	}
+	if err := set.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if v, _ := set.Get("database.url"); v != "postgres://db" || redisPassword() != "first" {
+		t.Fatalf("unexpected values %q %q", v, redisPassword())
+	}
+
+	password = "second"
+	_ = set.Refresh(context.Background())
+	if redisPassword() != "second" {
+		t.Fatalf("expected the rotated password, got %q", redisPassword())
+	}
+
+	// a failing reference keeps every previous value
+	set.refs["auth.api_keys"] = "taskmanager/missing#keys"
+	password = "third"
+	if err := set.Refresh(context.Background()); err == nil || !strings.Contains(err.Error(), "auth.api_keys") {
+		t.Fatalf("expected the missing secret reported, got %v", err)
+	}
+	if redisPassword() != "second" {
+		t.Fatalf("expected the previous password kept, got %q", redisPassword())
+	}
+
+	v := NewVault(srv.URL, "wrong", "kv")
+	if _, err := Resolve(context.Background(), v, "taskmanager/prod#database_url"); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected the vault error, got %v", err)
+	}
+	if _, err := Resolve(context.Background(), NewVault(srv.URL, "root", "kv"), "taskmanager/prod#nope"); err == nil {
+		t.Fatalf("expected a missing field error")
+	}
+	if s, err := Resolve(context.Background(), NewVault(srv.URL, "root", "kv"), "taskmanager/prod#port"); err != nil || s != "5432" {
+		t.Fatalf("expected numbers as text, got %q %v", s, err)
+	}
+}
@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Vault reads secrets from a KV version 2 engine of HashiCorp Vault.
+type Vault struct {
+	addr  string
+	token string
+	mount string
+	http  *http.Client
+}
+
+// NewVault creates a provider reading from the KV engine mounted at mount
+// ("secret" by default) of the Vault server at addr, authenticated with
+// token.
+func NewVault(addr, token, mount string) *Vault {
+	if mount == "" {
+		mount = "secret"
+	}
+	return &Vault{
+		addr:  strings.TrimSuffix(addr, "/"),
+		token: token,
+		mount: strings.Trim(mount, "/"),
+		http:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Secret returns the latest version of the fields of secret name as a JSON
+// object.
+func (v *Vault) Secret(ctx context.Context, name string) (string, error) {
+	u := v.addr + "/v1/" + v.mount + "/data/" + (&url.URL{Path: strings.Trim(name, "/")}).EscapedPath()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	resp, err := v.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(body, &e)
+		return "", fmt.Errorf("vault answered %s: %s", resp.Status, strings.Join(e.Errors, "; "))
+	}
+	var out struct {
+		Data struct {
+			Data json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", fmt.Errorf("decode vault response: %w", err)
+	}
+	if len(out.Data.Data) == 0 || string(out.Data.Data) == "null" {
+		return "", fmt.Errorf("vault returned no data (deleted version?)")
+	}
+	return string(out.Data.Data), nil
+}
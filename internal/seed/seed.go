@@ -0,0 +1,162 @@
+// Package seed generates realistic demo tasks for demos, load tests and
+// local development. The same Options (including Seed) and time generate
+// the same tasks.
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Options shape the generated tasks. Ratios are fractions between 0 and 1.
+type Options struct {
+	Count int
+	// Assignees receive tasks with decreasing weight (the first one the
+	// most), like real teams; UnassignedRatio of the tasks have none.
+	Assignees       []string
+	UnassignedRatio float64
+	CompletedRatio  float64
+	// DueRatio of the tasks have a due date within DueWithin from now;
+	// OverdueRatio of the open ones among them are past due instead.
+	DueRatio     float64
+	OverdueRatio float64
+	DueWithin    time.Duration
+	// EstimateRatio of the tasks have an estimate; open ones have part of it
+	// remaining.
+	EstimateRatio float64
+	Seed          uint64
+}
+
+// DefaultOptions returns options for 100 tasks of a five-person team.
+func DefaultOptions() Options {
+	return Options{
+		Count:           100,
+		Assignees:       []string{"alice", "bob", "carol", "dave", "erin"},
+		UnassignedRatio: 0.15,
+		CompletedRatio:  0.3,
+		DueRatio:        0.6,
+		OverdueRatio:    0.15,
+		DueWithin:       30 * 24 * time.Hour,
+		EstimateRatio:   0.7,
+		Seed:            1,
+	}
+}
+
+// Validate reports every invalid option.
+func (o Options) Validate() error {
+	var errs []error
+	if o.Count <= 0 {
+		errs = append(errs, errors.New("count must be positive"))
+	}
+	for _, r := range []struct {
+		name string
+		v    float64
+	}{
+		{"unassigned ratio", o.UnassignedRatio}, {"completed ratio", o.CompletedRatio},
+		{"due ratio", o.DueRatio}, {"overdue ratio", o.OverdueRatio}, {"estimate ratio", o.EstimateRatio},
+	} {
+		if r.v < 0 || r.v > 1 {
+			errs = append(errs, fmt.Errorf("%s must be between 0 and 1, got %g", r.name, r.v))
+		}
+	}
+	if o.DueRatio > 0 && o.DueWithin <= 0 {
+		errs = append(errs, errors.New("due within must be positive"))
+	}
+	if len(o.Assignees) == 0 && o.UnassignedRatio < 1 {
+		errs = append(errs, errors.New("assignees are required unless every task is unassigned"))
+	}
+	return errors.Join(errs...)
+}
+
+// Task is one generated task; empty strings and zero numbers are unset.
+type Task struct {
+	Title            string
+	Description      string
+	Assignee         string
+	DueDate          *time.Time
+	EstimateMinutes  int64
+	RemainingMinutes int64
+	Completed        bool
+}
+
+// Tasks generates o.Count tasks, with due dates relative to now.
+func Tasks(o Options, now time.Time) ([]Task, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewPCG(o.Seed, o.Seed^0x9e3779b97f4a7c15))
+	// weight 1/(i+1): the first assignee gets about twice the tasks of the
+	// second
+	var weights []float64
+	var total float64
+	for i := range o.Assignees {
+		weights = append(weights, 1/float64(i+1))
+		total += weights[i]
+	}
+	now = now.UTC().Truncate(time.Hour)
+
+	tasks := make([]Task, o.Count)
+	for i := range tasks {
+		t := &tasks[i]
+		action, subject := actions[rng.IntN(len(actions))], subjects[rng.IntN(len(subjects))]
+		t.Title = action + " " + subject
+		if rng.Float64() < 0.5 {
+			t.Description = fmt.Sprintf(details[rng.IntN(len(details))], subject)
+		}
+		if rng.Float64() >= o.UnassignedRatio {
+			p := rng.Float64() * total
+			for j, w := range weights {
+				if p -= w; p < 0 || j == len(weights)-1 {
+					t.Assignee = o.Assignees[j]
+					break
+				}
+			}
+		}
+		t.Completed = rng.Float64() < o.CompletedRatio
+		if rng.Float64() < o.DueRatio {
+			var due time.Time
+			if !t.Completed && rng.Float64() < o.OverdueRatio {
+				due = now.Add(-time.Duration(1+rng.IntN(14*24)) * time.Hour)
+			} else {
+				due = now.Add(time.Duration(1+rng.Int64N(int64(o.DueWithin/time.Hour)+1)) * time.Hour)
+			}
+			t.DueDate = &due
+		}
+		if rng.Float64() < o.EstimateRatio {
+			t.EstimateMinutes = estimates[rng.IntN(len(estimates))]
+			if !t.Completed {
+				// a quarter of the way in on average, rounded to 15 minutes
+				left := float64(t.EstimateMinutes) * (0.5 + rng.Float64()/2)
+				t.RemainingMinutes = max(15, int64(left)/15*15)
+			}
+		}
+	}
+	return tasks, nil
+}
+
+var actions = []string{
+	"Review", "Fix", "Update", "Document", "Refactor", "Test", "Draft",
+	"Migrate", "Investigate", "Clean up", "Design", "Automate", "Audit",
+	"Prepare", "Benchmark",
+}
+
+var subjects = []string{
+	"onboarding flow", "checkout tests", "billing export", "release notes",
+	"search indexing", "password reset emails", "mobile navigation",
+	"API rate limits", "database backups", "access logs", "invoice templates",
+	"dashboard charts", "notification settings", "CI pipeline", "feature flags",
+	"error pages", "customer webinar", "Q3 roadmap", "support macros",
+	"staging environment", "SSO configuration", "data retention policy",
+}
+
+var details = []string{
+	"Walk through the %s with the team and note the rough edges",
+	"Roll out the %s on staging first, then production",
+	"Follow up on the support tickets about the %s",
+	"Keep the scope small: the %s only, no redesign",
+	"Pair with someone new to the %s",
+}
+
+var estimates = []int64{15, 30, 60, 90, 120, 180, 240, 480}
@@ -0,0 +1,100 @@
+package seed
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTasks_Distributions(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	o := DefaultOptions()
+	o.Count = 2000
+	tasks, err := Tasks(o, now)
+	if err != nil {
+		t.Fatalf("tasks: %v", err)
+	}
+	if len(tasks) != o.Count {
+		t.Fatalf("expected %d tasks got %d", o.Count, len(tasks))
+	}
+
+	perAssignee := map[string]int{}
+	var completed, due, overdue, estimated int
+	for _, task := range tasks {
+		perAssignee[task.Assignee]++
+		if task.Title == "" || len(task.Title) > 200 {
+			t.Fatalf("invalid title %q", task.Title)
+		}
+		if task.Completed {
+			completed++
+			if task.RemainingMinutes != 0 {
+				t.Fatalf("completed task with remaining minutes: %+v", task)
+			}
+		}
+		if task.DueDate != nil {
+			due++
+			if task.DueDate.Before(now) {
+				overdue++
+				if task.Completed {
+					t.Fatalf("completed tasks are not generated overdue: %+v", task)
+				}
+			}
+			if task.DueDate.After(now.Add(o.DueWithin + time.Hour)) {
+				t.Fatalf("due date beyond DueWithin: %v", task.DueDate)
+			}
+		}
+		if task.EstimateMinutes > 0 {
+			estimated++
+			if task.RemainingMinutes > task.EstimateMinutes {
+				t.Fatalf("more remaining than estimated: %+v", task)
+			}
+		}
+	}
+	near := func(name string, got int, ratio float64) {
+		t.Helper()
+		if want := ratio * float64(o.Count); float64(got) < want*0.85 || float64(got) > want*1.15 {
+			t.Errorf("%s: %d tasks, expected about %.0f", name, got, want)
+		}
+	}
+	near("unassigned", perAssignee[""], o.UnassignedRatio)
+	near("completed", completed, o.CompletedRatio)
+	near("due", due, o.DueRatio)
+	near("estimated", estimated, o.EstimateRatio)
+	if overdue == 0 {
+		t.Errorf("expected some overdue tasks")
+	}
+	if perAssignee["alice"] <= perAssignee["erin"] {
+		t.Errorf("expected the first assignee to get more tasks than the last: %v", perAssignee)
+	}
+}
+
+func TestTasks_Deterministic(t *testing.T) {
+	now := time.Now()
+	o := DefaultOptions()
+	a, _ := Tasks(o, now)
+	b, _ := Tasks(o, now)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("expected the same seed to generate the same tasks")
+	}
+	o.Seed = 2
+	if c, _ := Tasks(o, now); reflect.DeepEqual(a, c) {
+		t.Fatalf("expected another seed to generate other tasks")
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	o := Options{Count: 0, CompletedRatio: 1.5, DueRatio: 0.5}
+	err := o.Validate()
+	if err == nil {
+		t.Fatalf("expected invalid options to be rejected")
+	}
+	for _, want := range []string{"count", "completed ratio", "due within", "assignees"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+	if err := (Options{Count: 3, UnassignedRatio: 1}).Validate(); err != nil {
+		t.Fatalf("expected unassigned tasks without assignees to be valid: %v", err)
+	}
+}
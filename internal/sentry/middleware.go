@@ -0,0 +1,134 @@
+package sentry
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/logging"
+	"taskmanager/internal/middleware"
+	"taskmanager/internal/problem"
+)
+
+// reportedHeaders are the request headers sent along with an event; the
+// others (Authorization, X-API-Key, cookies) are left out.
+var reportedHeaders = []string{"User-Agent", "Content-Type", "Accept", "Origin"}
+
+// Middleware reports panics and 5xx responses to c under the response's
+// incident id (see problem.IncidentID), tagged with the request id and
+// route, and with the caller's API key fingerprint (or client certificate
+// identity) and IP as the user. It
+// must run inside the recovery middleware: a panic is reported and then
+// re-raised for it to answer. http.ErrAbortHandler, a handler cutting a
+// response short on purpose (see ExportHandler), is re-raised unreported.
+func (c *Client) Middleware() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		defer func() {
+			if v := recover(); v != nil {
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				e := c.event(g, "fatal")
+				e.Tags["status"] = "500"
+				e.Exception = []Exception{{Type: "panic", Value: fmt.Sprint(v), Stacktrace: stacktrace(3)}}
+				c.Capture(e)
+				panic(v)
+			}
+		}()
+		g.Next()
+
+		if g.Writer.Status() < http.StatusInternalServerError {
+			return
+		}
+		e := c.event(g, "error")
+		for _, err := range g.Errors {
+			e.Exception = append(e.Exception, Exception{Type: fmt.Sprintf("%T", err.Err), Value: err.Error()})
+		}
+		if len(e.Exception) == 0 {
+			e.Message = "HTTP " + strconv.Itoa(g.Writer.Status()) + " " + e.Transaction
+		}
+		c.Capture(e)
+	}
+}
+
+// event starts an event for the request in g.
+func (c *Client) event(g *gin.Context, level string) *Event {
+	route := g.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	r := g.Request
+	e := &Event{
+		EventID:     problem.IncidentID(g),
+		Level:       level,
+		Transaction: r.Method + " " + route,
+		Tags: map[string]string{
+			"request_id": logging.RequestID(r.Context()),
+			"route":      route,
+			"status":     strconv.Itoa(g.Writer.Status()),
+		},
+		Request: &Request{
+			URL:         requestURL(r),
+			Method:      r.Method,
+			QueryString: r.URL.RawQuery,
+			Headers:     map[string]string{},
+		},
+		User: &User{ID: cmp.Or(g.GetString(middleware.KeyIDKey), middleware.ClientIdentity(g)), IPAddress: g.ClientIP()},
+	}
+	for _, h := range reportedHeaders {
+		if v := r.Header.Get(h); v != "" {
+			e.Request.Headers[h] = v
+		}
+	}
+	return e
+}
+
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.Path
+}
+
+// stacktrace returns the calling goroutine's stack, skipping skip frames,
+// outermost call first as Sentry expects.
+func stacktrace(skip int) *Stacktrace {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var out []Frame
+	for {
+		f, more := frames.Next()
+		module, function := splitFunction(f.Function)
+		out = append(out, Frame{
+			Function: function,
+			Module:   module,
+			AbsPath:  f.File,
+			Lineno:   f.Line,
+			InApp:    strings.HasPrefix(module, "taskmanager/"),
+		})
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return &Stacktrace{Frames: out}
+}
+
+// splitFunction splits "taskmanager/internal/handler.(*TaskHandler).Get"
+// into its package path and function name.
+func splitFunction(name string) (module, function string) {
+	slash := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[slash+1:], "."); dot >= 0 {
+		return name[:slash+1+dot], name[slash+2+dot:]
+	}
+	return "", name
+}
@@ -0,0 +1,207 @@
+// Package sentry reports server errors to Sentry (or any service speaking
+// its envelope protocol, such as GlitchTip). Only what the service needs is
+// implemented: events are built by Middleware from panics and 5xx responses
+// and sent in the background, so a slow or unreachable Sentry never delays a
+// request.
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// queueSize bounds the events waiting to be sent; more are dropped.
+const queueSize = 100
+
+// Event is a Sentry event, with the members this service fills in.
+type Event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Transaction string            `json:"transaction,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Exception   []Exception       `json:"exception,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Request     *Request          `json:"request,omitempty"`
+	User        *User             `json:"user,omitempty"`
+}
+
+// Exception is one error of an event.
+type Exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *Stacktrace `json:"stacktrace,omitempty"`
+}
+
+// Stacktrace lists the frames of a panic, outermost call first.
+type Stacktrace struct {
+	Frames []Frame `json:"frames"`
+}
+
+// Frame is one stack frame.
+type Frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// Request describes the HTTP request that failed.
+type Request struct {
+	URL         string            `json:"url"`
+	Method      string            `json:"method"`
+	QueryString string            `json:"query_string,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// User identifies the caller.
+type User struct {
+	ID        string `json:"id,omitempty"`
+	IPAddress string `json:"ip_address,omitempty"`
+}
+
+// Client sends events to the project of a DSN.
+type Client struct {
+	dsn         string
+	endpoint    string
+	auth        string
+	release     string
+	environment string
+	serverName  string
+	http        *http.Client
+
+	queue chan *Event
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// New creates a client for dsn (https://<key>@<host>/<project id>) and
+// starts its sender; Close flushes and stops it.
+func New(dsn, environment, release string) (*Client, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry DSN: %w", err)
+	}
+	key := u.User.Username()
+	path, project, _ := cutLast(strings.TrimSuffix(u.Path, "/"), "/")
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || key == "" || project == "" {
+		return nil, errors.New("invalid sentry DSN: want <scheme>://<key>@<host>/<project id>")
+	}
+	host, _ := os.Hostname()
+	c := &Client{
+		dsn:         dsn,
+		endpoint:    u.Scheme + "://" + u.Host + path + "/api/" + project + "/envelope/",
+		auth:        "Sentry sentry_version=7, sentry_client=taskmanager/" + release + ", sentry_key=" + key,
+		release:     release,
+		environment: environment,
+		serverName:  host,
+		http:        &http.Client{Timeout: 5 * time.Second},
+		queue:       make(chan *Event, queueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go c.run()
+	return c, nil
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return "", s, false
+}
+
+// Capture queues e for sending, filling in the client-wide members. It never
+// blocks: when the queue is full the event is dropped and logged.
+func (c *Client) Capture(e *Event) {
+	e.Platform = "go"
+	e.Release = c.release
+	e.Environment = c.environment
+	e.ServerName = c.serverName
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+	select {
+	case c.queue <- e:
+	default:
+		slog.Warn("sentry queue full, event dropped", "event_id", e.EventID)
+	}
+}
+
+// Close sends the queued events, waiting at most until ctx is done.
+func (c *Client) Close(ctx context.Context) {
+	close(c.stop)
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+	}
+}
+
+func (c *Client) run() {
+	defer close(c.done)
+	for {
+		select {
+		case e := <-c.queue:
+			c.send(e)
+		case <-c.stop:
+			for {
+				select {
+				case e := <-c.queue:
+					c.send(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *Client) send(e *Event) {
+	if err := c.post(e); err != nil {
+		slog.Warn("sentry event not sent", "event_id", e.EventID, "err", err)
+	}
+}
+
+// post sends e as an envelope with a single event item.
+func (c *Client) post(e *Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	header, _ := json.Marshal(map[string]string{"event_id": e.EventID, "dsn": c.dsn, "sent_at": time.Now().UTC().Format(time.RFC3339)})
+	body.Write(header)
+	fmt.Fprintf(&body, "\n{\"type\":\"event\",\"length\":%d}\n", len(payload))
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", c.auth)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package sentry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanager/internal/logging"
+	"taskmanager/internal/problem"
+)
+
+// collector is a fake Sentry that decodes the events of the envelopes it
+// receives.
+func collector(t *testing.T) (*httptest.Server, chan Event) {
+	t.Helper()
+	events := make(chan Event, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/42/envelope/" || !strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=public") {
+			t.Errorf("unexpected request %s auth=%q", r.URL.Path, r.Header.Get("X-Sentry-Auth"))
+		}
+		sc := bufio.NewScanner(r.Body)
+		sc.Buffer(nil, 1<<20)
+		var lines []string
+		for sc.Scan() {
+			lines = append(lines, sc.Text())
+		}
+		if len(lines) != 3 || !strings.Contains(lines[1], `"type":"event"`) {
+			t.Errorf("unexpected envelope %q", lines)
+			return
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(lines[2]), &e); err != nil {
+			t.Errorf("decode event: %v", err)
+		}
+		events <- e
+	}))
+	t.Cleanup(srv.Close)
+	return srv, events
+}
+
+func receive(t *testing.T, events chan Event) Event {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no event received")
+		return Event{}
+	}
+}
+
+func TestNew_InvalidDSN(t *testing.T) {
+	for _, dsn := range []string{"", "https://sentry.io/42", "https://key@sentry.io/", "ftp://key@sentry.io/42"} {
+		if _, err := New(dsn, "", "dev"); err == nil {
+			t.Fatalf("expected %q to be rejected", dsn)
+		}
+	}
+}
+
+func TestMiddleware_ReportsServerErrorsAndPanics(t *testing.T) {
+	srv, events := collector(t)
+	client, err := New(strings.Replace(srv.URL, "://", "://public@", 1)+"/42", "test", "1.2.3")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer client.Close(context.Background())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(logging.Middleware(logging.FromContext(nil), nil))
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+		problem.Abort(c, http.StatusInternalServerError, "internal server error")
+	}))
+	r.Use(client.Middleware())
+	r.GET("/tasks/:id", func(c *gin.Context) {
+		_ = c.Error(errors.New("connection reset"))
+		problem.Abort(c, http.StatusInternalServerError, "failed to fetch task")
+	})
+	r.GET("/boom", func(c *gin.Context) { panic("nil map") })
+	r.GET("/missing", func(c *gin.Context) { problem.Abort(c, http.StatusNotFound, "not here") })
+
+	serve := func(path string) (*httptest.ResponseRecorder, problem.Problem) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set(logging.RequestIDHeader, "req-"+path[1:])
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var p problem.Problem
+		_ = json.Unmarshal(w.Body.Bytes(), &p)
+		return w, p
+	}
+
+	w, p := serve("/tasks")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+	w, p = serve("/tasks/7")
+	incident, _ := p.Extensions["incident_id"].(string)
+	if w.Code != http.StatusInternalServerError || len(incident) != 32 {
+		t.Fatalf("expected a 500 with an incident id, got %d %s", w.Code, w.Body.String())
+	}
+	e := receive(t, events)
+	if e.EventID != incident || e.Level != "error" || e.Release != "1.2.3" || e.Environment != "test" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	if e.Tags["request_id"] != "req-tasks/7" || e.Tags["route"] != "/tasks/:id" || e.Transaction != "GET /tasks/:id" {
+		t.Fatalf("unexpected tags %v transaction %q", e.Tags, e.Transaction)
+	}
+	if len(e.Exception) != 1 || e.Exception[0].Value != "connection reset" {
+		t.Fatalf("unexpected exception %+v", e.Exception)
+	}
+	if e.User == nil || e.Request == nil || e.Request.Headers["Authorization"] != "" {
+		t.Fatalf("unexpected user %+v request %+v", e.User, e.Request)
+	}
+
+	w, p = serve("/boom")
+	incident, _ = p.Extensions["incident_id"].(string)
+	if w.Code != http.StatusInternalServerError || incident == "" {
+		t.Fatalf("expected the panic answered with an incident id, got %d %s", w.Code, w.Body.String())
+	}
+	e = receive(t, events)
+	if e.EventID != incident || e.Level != "fatal" || e.Tags["status"] != "500" || len(e.Exception) != 1 || e.Exception[0].Value != "nil map" {
+		t.Fatalf("unexpected panic event %+v", e)
+	}
+	frames := e.Exception[0].Stacktrace.Frames
+	if last := frames[len(frames)-1]; !last.InApp || !strings.Contains(last.Function, "TestMiddleware_ReportsServerErrorsAndPanics") {
+		t.Fatalf("expected the panicking handler as the innermost frame, got %+v", last)
+	}
+
+	serve("/missing")
+	select {
+	case e := <-events:
+		t.Fatalf("a 404 must not be reported, got %+v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMiddleware_PassesAbortHandlerOn(t *testing.T) {
+	srv, events := collector(t)
+	client, err := New(strings.Replace(srv.URL, "://", "://public@", 1)+"/42", "test", "1.2.3")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer client.Close(context.Background())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(client.Middleware())
+	// an export cut short mid-stream
+	r.GET("/tasks/export", func(c *gin.Context) {
+		c.Writer.WriteString("{}\n")
+		panic(http.ErrAbortHandler)
+	})
+
+	func() {
+		defer func() {
+			if v := recover(); v != http.ErrAbortHandler {
+				t.Fatalf("expected http.ErrAbortHandler re-raised, got %v", v)
+			}
+		}()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tasks/export", nil))
+	}()
+	select {
+	case e := <-events:
+		t.Fatalf("an aborted response must not be reported, got %+v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
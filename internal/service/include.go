@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"taskmanager/internal/repositories"
+)
+
+// The related collections a task read can embed (the include query param).
+const (
+	IncludeWatchers    = "watchers"
+	IncludeTimeEntries = "time_entries"
+)
+
+// ParseIncludes parses a comma-separated list of related collections,
+// rejecting unknown names. The result is sorted and without duplicates; an
+// empty spec returns nil.
+func ParseIncludes(spec string) ([]string, error) {
+	var include []string
+	for _, part := range strings.Split(spec, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" {
+			continue
+		}
+		if name != IncludeWatchers && name != IncludeTimeEntries {
+			return nil, fmt.Errorf("unknown collection %q (expected %s or %s)", strings.TrimSpace(part), IncludeWatchers, IncludeTimeEntries)
+		}
+		include = append(include, name)
+	}
+	slices.Sort(include)
+	return slices.Compact(include), nil
+}
+
+// RelatedLoader loads the related collections of a set of tasks with one
+// query per collection, however many tasks there are.
+type RelatedLoader struct {
+	watchers    repositories.WatcherRepository
+	timeEntries repositories.TimeEntryRepository
+}
+
+// NewRelatedLoader creates a RelatedLoader over the given repositories.
+func NewRelatedLoader(watchers repositories.WatcherRepository, timeEntries repositories.TimeEntryRepository) *RelatedLoader {
+	return &RelatedLoader{watchers: watchers, timeEntries: timeEntries}
+}
+
+// Load returns, per task id, the included collections by name. Every task
+// gets every included collection, empty when it has none.
+func (l *RelatedLoader) Load(ctx context.Context, taskIDs []string, include []string) (map[string]map[string]any, error) {
+	out := make(map[string]map[string]any, len(taskIDs))
+	for _, id := range taskIDs {
+		out[id] = make(map[string]any, len(include))
+	}
+	if len(taskIDs) == 0 {
+		return out, nil
+	}
+	for _, name := range include {
+		switch name {
+		case IncludeWatchers:
+			byTask, err := l.watchers.ListMany(ctx, taskIDs)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range taskIDs {
+				out[id][name] = nonNil(byTask[id])
+			}
+		case IncludeTimeEntries:
+			byTask, err := l.timeEntries.ListMany(ctx, taskIDs)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range taskIDs {
+				out[id][name] = nonNil(byTask[id])
+			}
+		default:
+			return nil, fmt.Errorf("unknown collection %q", name)
+		}
+	}
+	return out, nil
+}
+
+// nonNil makes an empty collection render as [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 
+	"taskmanager/internal/logging"
 	"taskmanager/internal/metric"
 	"taskmanager/internal/model"
 	"taskmanager/internal/repositories"
@@ -14,30 +17,103 @@ import (
 
 var ErrInvalidInput = errors.New("invalid input")
 
+// MaxBatchGet is the largest number of ids BatchGet accepts.
+const MaxBatchGet = 100
+
+// ListOptions selects a page of tasks for List: the repository's options
+// (filter, sort, page and fields) plus how the total is computed.
+type ListOptions struct {
+	repositories.ListOptions
+	// Count selects an exact, estimated or no total (then 0).
+	Count repositories.CountMode
+	// Include names the related collections (see ParseIncludes) to embed
+	// with each task. List reads the tasks only; the caller loads them.
+	Include []string
+}
+
 // TaskService defines business-logic operations for tasks.
 type TaskService interface {
 	Create(ctx context.Context, task *model.Task) (*model.Task, error)
 
 	GetByID(ctx context.Context, id string) (*model.Task, error)
+	// BatchGet fetches up to MaxBatchGet tasks by id in one query, plus one
+	// lookup per key (TM-1042). Tasks come back in request order (duplicates
+	// removed) and unknown ids and keys are listed in notFound.
+	BatchGet(ctx context.Context, ids []string) (tasks []model.Task, notFound []string, err error)
 
-	List(ctx context.Context, limit, offset int, completed *bool, assignee *string) ([]model.Task, int, error)
+	// List returns the page of tasks selected by opts and the total matching
+	// its filter (see ListOptions).
+	List(ctx context.Context, opts ListOptions) ([]model.Task, int, error)
 
 	Update(ctx context.Context, task *model.Task) (*model.Task, error)
+	// PreviewUpdate runs the same validation as Update and returns the task
+	// before and after the change without persisting it (dry run).
+	PreviewUpdate(ctx context.Context, task *model.Task) (before, after *model.Task, err error)
+
+	// Complete and Reopen flip a task's completion with a single guarded
+	// UPDATE, without the read-modify-write of Update. A task already in the
+	// requested state is returned unchanged. Reopen applies the WIP limit to
+	// the task's assignee.
+	Complete(ctx context.Context, id string) (*model.Task, error)
+	Reopen(ctx context.Context, id string) (*model.Task, error)
+
+	// Move places a task right after afterID, a task id or key, in the
+	// manual order (first when afterID is empty).
+	Move(ctx context.Context, id, afterID string) (*model.Task, error)
+
+	// Duplicate creates an open copy of a task with fresh timestamps; it goes
+	// through Create, so the copy counts against the WIP limit.
+	Duplicate(ctx context.Context, id string) (*model.Task, error)
 
 	Delete(ctx context.Context, id string) error
+	// PreviewDelete returns the task that Delete would remove (dry run).
+	PreviewDelete(ctx context.Context, id string) (*model.Task, error)
+	// DeleteCompleted deletes every completed task, or only those completed
+	// before the given time, and returns how many were deleted.
+	DeleteCompleted(ctx context.Context, before *time.Time) (int, error)
+	// PreviewDeleteCompleted returns how many tasks DeleteCompleted would
+	// delete (dry run).
+	PreviewDeleteCompleted(ctx context.Context, before *time.Time) (int, error)
 	Count(ctx context.Context) (int, error)
+	// Stats aggregates the tasks matching the list filters: counts and the
+	// estimate, remaining and tracked time sums, overall and per assignee.
+	Stats(ctx context.Context, f repositories.TaskFilter) (*model.TaskStats, error)
+
+	// Reassign moves every task matching f to a new assignee. f must not be
+	// empty. Create and Reassign fail with a *WIPLimitError when the new
+	// assignee would exceed the WIP limit.
+	Reassign(ctx context.Context, f repositories.TaskFilter, to string) ([]string, error)
 
 	SetCacheClient(rdb *redis.Client)
 }
 
 type taskService struct {
-	repo repositories.TaskRepository
+	repo     repositories.TaskRepository
+	wipLimit atomic.Int64
 }
 
 func NewTaskService(repo repositories.TaskRepository) TaskService {
 	return &taskService{repo: repo}
 }
 
+// Transactor is implemented by task services that can run several calls in
+// one database transaction.
+type Transactor interface {
+	// InTx runs fn with a TaskService whose calls share one transaction,
+	// committed when fn returns nil and rolled back otherwise. Cache
+	// invalidation happens after the commit.
+	InTx(ctx context.Context, fn func(TaskService) error) error
+}
+
+// InTx implements Transactor.
+func (s *taskService) InTx(ctx context.Context, fn func(TaskService) error) error {
+	return s.repo.WithTx(ctx, func(repo repositories.TaskRepository) error {
+		tx := &taskService{repo: repo}
+		tx.wipLimit.Store(s.wipLimit.Load())
+		return fn(tx)
+	})
+}
+
 func (s *taskService) SetCacheClient(rdb *redis.Client) {
 	s.repo.SetCacheClient(rdb)
 }
@@ -47,76 +123,307 @@ func (s *taskService) Create(ctx context.Context, task *model.Task) (*model.Task
 	if task.Title == "" {
 		return nil, ErrInvalidInput
 	}
+	if !task.Completed && task.Assignee.Valid {
+		if err := s.checkCreateWIP(ctx, task.Assignee.String); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := s.repo.Create(task); err != nil {
+	if err := s.repo.Create(ctx, task); err != nil {
 		return nil, err
 	}
-
-	// Update metrics
-	metric.IncTaskCount()
-
 	return task, nil
 }
 
 func (s *taskService) GetByID(ctx context.Context, id string) (*model.Task, error) {
-	t, err := s.repo.GetByID(id)
+	t, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return t, nil
 }
 
-func (s *taskService) List(ctx context.Context, limit, offset int, completed *bool, assignee *string) ([]model.Task, int, error) {
-	tasks, err := s.repo.List(limit, offset, completed, assignee)
+func (s *taskService) BatchGet(ctx context.Context, ids []string) ([]model.Task, []string, error) {
+	wanted := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		wanted = append(wanted, id)
+	}
+	if len(wanted) == 0 || len(wanted) > MaxBatchGet {
+		return nil, nil, ErrInvalidInput
+	}
+
+	// keys (TM-1042) and ids are each looked up in a single query
+	byRef := make(map[string]model.Task, len(wanted))
+	byID := make([]string, 0, len(wanted))
+	var numbers []int64
+	keys := make(map[int64][]string)
+	for _, ref := range wanted {
+		number, ok := model.ParseKey(ref)
+		if !ok {
+			byID = append(byID, ref)
+			continue
+		}
+		if keys[number] == nil {
+			numbers = append(numbers, number)
+		}
+		// the same key may be spelled tm-7 and TM-7
+		keys[number] = append(keys[number], ref)
+	}
+	if len(numbers) > 0 {
+		found, err := s.repo.GetManyByNumber(ctx, numbers)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, t := range found {
+			for _, ref := range keys[t.Number] {
+				byRef[ref] = t
+			}
+		}
+	}
+	if len(byID) > 0 {
+		found, err := s.repo.GetMany(ctx, byID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, t := range found {
+			byRef[t.ID] = t
+		}
+	}
+	tasks := make([]model.Task, 0, len(byRef))
+	notFound := []string{}
+	returned := make(map[string]bool, len(byRef))
+	for _, ref := range wanted {
+		t, ok := byRef[ref]
+		if !ok {
+			notFound = append(notFound, ref)
+			continue
+		}
+		// a task asked for by both its id and its key comes back once
+		if !returned[t.ID] {
+			returned[t.ID] = true
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, notFound, nil
+}
+
+func (s *taskService) List(ctx context.Context, opts ListOptions) ([]model.Task, int, error) {
+	tasks, err := s.repo.List(ctx, opts.ListOptions)
 	if err != nil {
 		return nil, 0, err
 	}
-	total, err := s.repo.CountFiltered(completed, assignee)
+	var total int
+	switch opts.Count {
+	case repositories.CountNone:
+	case repositories.CountEstimate:
+		total, err = s.repo.EstimateCount(ctx, opts.Filter)
+	default:
+		total, err = s.repo.CountFiltered(ctx, opts.Filter)
+	}
 	if err != nil {
 		return nil, 0, err
 	}
 	return tasks, total, nil
 }
 
+// Update reads, merges and writes the task in one transaction so concurrent
+// partial updates cannot overwrite each other.
 func (s *taskService) Update(ctx context.Context, task *model.Task) (*model.Task, error) {
-	t, err := s.repo.GetByID(task.ID)
+	err := s.repo.WithTx(ctx, func(repo repositories.TaskRepository) error {
+		t, err := repo.GetByID(ctx, task.ID)
+		if err != nil {
+			return err
+		}
+		if err := applyUpdate(t, task); err != nil {
+			return err
+		}
+		return repo.Update(ctx, t)
+	})
 	if err != nil {
 		return nil, err
 	}
+	updated, err := s.repo.GetByID(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// PreviewUpdate validates an update and returns the task before and after it
+// would be applied, without persisting anything.
+func (s *taskService) PreviewUpdate(ctx context.Context, task *model.Task) (*model.Task, *model.Task, error) {
+	current, err := s.repo.GetByID(ctx, task.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	next := *current
+	if err := applyUpdate(&next, task); err != nil {
+		return nil, nil, err
+	}
+	return current, &next, nil
+}
 
-	if task.Title != "" {
-		tt := strings.TrimSpace(task.Title)
+// applyUpdate merges the fields of a partial update into t. A valid but
+// empty description clears it.
+func applyUpdate(t *model.Task, upd *model.Task) error {
+	if upd.Title != "" {
+		tt := strings.TrimSpace(upd.Title)
 		if tt == "" {
-			return nil, ErrInvalidInput
+			return ErrInvalidInput
 		}
 		t.Title = tt
 	}
+	if upd.Description.Valid {
+		if upd.Description.String == "" {
+			t.ClearDescription()
+		} else {
+			t.Description = upd.Description
+		}
+	}
+	if upd.DueDate.Valid {
+		t.DueDate = upd.DueDate
+	}
+	if upd.EstimateMinutes.Valid {
+		if upd.EstimateMinutes.Int64 < 0 {
+			return ErrInvalidInput
+		}
+		t.EstimateMinutes = upd.EstimateMinutes
+	}
+	if upd.RemainingMinutes.Valid {
+		if upd.RemainingMinutes.Int64 < 0 {
+			return ErrInvalidInput
+		}
+		t.RemainingMinutes = upd.RemainingMinutes
+	}
+	return nil
+}
+
+func (s *taskService) Complete(ctx context.Context, id string) (*model.Task, error) {
+	return s.setCompleted(ctx, id, true)
+}
+
+func (s *taskService) Reopen(ctx context.Context, id string) (*model.Task, error) {
+	if s.wip() > 0 {
+		t, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if t.Completed && t.Assignee.Valid {
+			if err := s.checkCreateWIP(ctx, t.Assignee.String); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s.setCompleted(ctx, id, false)
+}
 
-	if err := s.repo.Update(t); err != nil {
+func (s *taskService) setCompleted(ctx context.Context, id string, completed bool) (*model.Task, error) {
+	t, changed, err := s.repo.SetCompleted(ctx, id, completed)
+	if err != nil {
 		return nil, err
 	}
-	updated, err := s.repo.GetByID(task.ID)
+	if changed {
+		action := "reopen"
+		if completed {
+			action = "complete"
+		}
+		metric.TaskStateChanges.WithLabelValues(action).Inc()
+		if completed && t.CompletedAt.Valid {
+			metric.TaskCycleTime.Observe(t.CompletedAt.Time.Sub(t.CreatedAt).Seconds())
+		}
+	}
+	return t, nil
+}
+
+func (s *taskService) Move(ctx context.Context, id, afterID string) (*model.Task, error) {
+	if number, ok := model.ParseKey(afterID); ok {
+		anchor, err := s.repo.GetByNumber(ctx, number)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, repositories.ErrMoveAnchorNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		afterID = anchor.ID
+	}
+	if afterID == id {
+		return nil, ErrInvalidInput
+	}
+	return s.repo.Move(ctx, id, afterID)
+}
+
+// Duplicate copies the user-editable fields of a task. Completion, ids and
+// timestamps start over.
+func (s *taskService) Duplicate(ctx context.Context, id string) (*model.Task, error) {
+	src, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	return updated, nil
+	return s.Create(ctx, &model.Task{
+		Title:            src.Title,
+		Description:      src.Description,
+		Assignee:         src.Assignee,
+		DueDate:          src.DueDate,
+		EstimateMinutes:  src.EstimateMinutes,
+		RemainingMinutes: src.RemainingMinutes,
+	})
 }
 
 func (s *taskService) Delete(ctx context.Context, id string) error {
-	ok, err := s.repo.Delete(id)
+	ok, err := s.repo.Delete(ctx, id)
 	if err != nil {
 		return err
 	}
 	if !ok {
 		return repositories.ErrNotFound
 	}
+	return nil
+}
 
-	// Update metrics
-	metric.DecTaskCount()
+// PreviewDelete returns the task a Delete would remove, without removing it.
+func (s *taskService) PreviewDelete(ctx context.Context, id string) (*model.Task, error) {
+	return s.repo.GetByID(ctx, id)
+}
 
-	return nil
+func (s *taskService) DeleteCompleted(ctx context.Context, before *time.Time) (int, error) {
+	ids, err := s.repo.DeleteCompleted(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	logging.FromContext(ctx).Info("completed tasks deleted", "count", len(ids))
+	return len(ids), nil
+}
+
+func (s *taskService) PreviewDeleteCompleted(ctx context.Context, before *time.Time) (int, error) {
+	return s.repo.CountCompleted(ctx, before)
 }
 
 func (s *taskService) Count(ctx context.Context) (int, error) {
-	return s.repo.Count()
+	return s.repo.Count(ctx)
+}
+
+func (s *taskService) Stats(ctx context.Context, f repositories.TaskFilter) (*model.TaskStats, error) {
+	return s.repo.Stats(ctx, f)
+}
+
+func (s *taskService) Reassign(ctx context.Context, f repositories.TaskFilter, to string) ([]string, error) {
+	to = strings.TrimSpace(to)
+	if to == "" || f.Empty() {
+		return nil, ErrInvalidInput
+	}
+	if err := s.checkReassignWIP(ctx, f, to); err != nil {
+		return nil, err
+	}
+	ids, err := s.repo.Reassign(ctx, f, to)
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("tasks reassigned", "count", len(ids), "to", to)
+	return ids, nil
 }
@@ -1,10 +1,15 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"strconv"
 	"testing"
+	"time"
 
+	dto "github.com/prometheus/client_model/go"
 	"github.com/redis/go-redis/v9"
+	"taskmanager/internal/metric"
 	"taskmanager/internal/model"
 	"taskmanager/internal/repositories"
 )
@@ -12,23 +17,64 @@ import (
 type fakeRepo struct {
 	createFn        func(task *model.Task) error
 	getFn           func(id string) (*model.Task, error)
-	listFn          func(limit, offset int, completed *bool, assignee *string) ([]model.Task, error)
+	getManyFn       func(ids []string) ([]model.Task, error)
+	listFn          func(limit, offset int, completed *bool, assignee repositories.AssigneeFilter) ([]model.Task, error)
 	countFn         func() (int, error)
-	countFilteredFn func(completed *bool, assignee *string) (int, error)
+	countFilteredFn func(completed *bool, assignee repositories.AssigneeFilter) (int, error)
 	updateFn        func(task *model.Task) error
 	deleteFn        func(id string) (bool, error)
+	setCompletedFn  func(id string, completed bool) (*model.Task, bool, error)
+	moveFn          func(id, afterID string) (*model.Task, error)
+	reassignFn      func(f repositories.TaskFilter, to string) ([]string, error)
+	deleteDoneFn    func(before *time.Time) ([]string, error)
 }
 
-func (f *fakeRepo) Create(task *model.Task) error          { return f.createFn(task) }
-func (f *fakeRepo) GetByID(id string) (*model.Task, error) { return f.getFn(id) }
-func (f *fakeRepo) List(limit, offset int, completed *bool, assignee *string) ([]model.Task, error) {
-	return f.listFn(limit, offset, completed, assignee)
+func (f *fakeRepo) Create(_ context.Context, task *model.Task) error { return f.createFn(task) }
+func (f *fakeRepo) GetByID(_ context.Context, id string) (*model.Task, error) {
+	return f.getFn(id)
 }
-func (f *fakeRepo) Update(task *model.Task) error  { return f.updateFn(task) }
-func (f *fakeRepo) Delete(id string) (bool, error) { return f.deleteFn(id) }
-func (f *fakeRepo) Count() (int, error)            { return f.countFn() }
-func (f *fakeRepo) CountFiltered(completed *bool, assignee *string) (int, error) {
-	return f.countFilteredFn(completed, assignee)
+func (f *fakeRepo) GetByNumber(_ context.Context, _ int64) (*model.Task, error) {
+	return nil, repositories.ErrNotFound
+}
+func (f *fakeRepo) GetManyByNumber(_ context.Context, _ []int64) ([]model.Task, error) {
+	return []model.Task{}, nil
+}
+func (f *fakeRepo) GetMany(_ context.Context, ids []string) ([]model.Task, error) {
+	return f.getManyFn(ids)
+}
+func (f *fakeRepo) List(_ context.Context, opts repositories.ListOptions) ([]model.Task, error) {
+	return f.listFn(opts.Limit, opts.Offset, opts.Filter.Completed, opts.Filter.Assignee)
+}
+func (f *fakeRepo) Update(_ context.Context, task *model.Task) error { return f.updateFn(task) }
+func (f *fakeRepo) SetCompleted(_ context.Context, id string, completed bool) (*model.Task, bool, error) {
+	return f.setCompletedFn(id, completed)
+}
+func (f *fakeRepo) Move(_ context.Context, id, afterID string) (*model.Task, error) {
+	return f.moveFn(id, afterID)
+}
+func (f *fakeRepo) Delete(_ context.Context, id string) (bool, error) { return f.deleteFn(id) }
+func (f *fakeRepo) DeleteCompleted(_ context.Context, before *time.Time) ([]string, error) {
+	return f.deleteDoneFn(before)
+}
+func (f *fakeRepo) CountCompleted(_ context.Context, before *time.Time) (int, error) {
+	ids, err := f.deleteDoneFn(before)
+	return len(ids), err
+}
+func (f *fakeRepo) Count(_ context.Context) (int, error) { return f.countFn() }
+func (f *fakeRepo) CountFiltered(_ context.Context, filter repositories.TaskFilter) (int, error) {
+	return f.countFilteredFn(filter.Completed, filter.Assignee)
+}
+func (f *fakeRepo) EstimateCount(_ context.Context, filter repositories.TaskFilter) (int, error) {
+	return f.countFilteredFn(filter.Completed, filter.Assignee)
+}
+func (f *fakeRepo) Stats(context.Context, repositories.TaskFilter) (*model.TaskStats, error) {
+	return &model.TaskStats{}, nil
+}
+func (f *fakeRepo) Reassign(_ context.Context, filter repositories.TaskFilter, to string) ([]string, error) {
+	return f.reassignFn(filter, to)
+}
+func (f *fakeRepo) WithTx(_ context.Context, fn func(repo repositories.TaskRepository) error) error {
+	return fn(f)
 }
 func (f *fakeRepo) SetCacheClient(_ *redis.Client) {}
 
@@ -87,6 +133,66 @@ func TestTaskService_UpdateAndDelete(t *testing.T) {
 		}
 	})
 
+	t.Run("PreviewUpdate_DoesNotPersist", func(t *testing.T) {
+		repo.updateFn = func(task *model.Task) error {
+			t.Fatalf("preview must not call repo.Update")
+			return nil
+		}
+		defer func() { repo.updateFn = func(task *model.Task) error { return nil } }()
+
+		before, after, err := svc.PreviewUpdate(nil, &model.Task{ID: "exists", Title: " new "})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if before.Title != "old" || after.Title != "new" {
+			t.Fatalf("unexpected preview before=%q after=%q", before.Title, after.Title)
+		}
+	})
+
+	t.Run("PreviewUpdate_RemainingMinutes", func(t *testing.T) {
+		upd := &model.Task{ID: "exists"}
+		upd.SetRemainingMinutes(90)
+		_, after, err := svc.PreviewUpdate(nil, upd)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if after.Title != "old" || !after.RemainingMinutes.Valid || after.RemainingMinutes.Int64 != 90 {
+			t.Fatalf("unexpected preview %+v", after)
+		}
+
+		upd.SetRemainingMinutes(-1)
+		if _, _, err := svc.PreviewUpdate(nil, upd); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("expected invalid input got %v", err)
+		}
+
+		upd = &model.Task{ID: "exists"}
+		upd.SetEstimateMinutes(120)
+		if _, after, err := svc.PreviewUpdate(nil, upd); err != nil || after.EstimateMinutes.Int64 != 120 || after.RemainingMinutes.Valid {
+			t.Fatalf("unexpected preview %+v err=%v", after, err)
+		}
+		upd.SetEstimateMinutes(-5)
+		if _, _, err := svc.PreviewUpdate(nil, upd); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("expected invalid input got %v", err)
+		}
+	})
+
+	t.Run("PreviewUpdate_DescriptionAndDueDate", func(t *testing.T) {
+		due := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+		upd := &model.Task{ID: "exists"}
+		upd.SetDescription("details")
+		upd.SetDueDate(due)
+		_, after, err := svc.PreviewUpdate(nil, upd)
+		if err != nil || after.Description.String != "details" || !after.DueDate.Time.Equal(due) {
+			t.Fatalf("unexpected preview %+v err=%v", after, err)
+		}
+
+		upd = &model.Task{ID: "exists"}
+		upd.SetDescription("")
+		if _, after, err := svc.PreviewUpdate(nil, upd); err != nil || after.Description.Valid {
+			t.Fatalf("expected the description cleared got %+v err=%v", after, err)
+		}
+	})
+
 	t.Run("Update_NotFound", func(t *testing.T) {
 		_, err := svc.Update(nil, &model.Task{ID: "missing", Title: "new"})
 		if !errors.Is(err, repositories.ErrNotFound) {
@@ -109,17 +215,335 @@ func TestTaskService_UpdateAndDelete(t *testing.T) {
 
 func TestTaskService_List(t *testing.T) {
 	repo := &fakeRepo{
-		listFn: func(limit, offset int, completed *bool, assignee *string) ([]model.Task, error) {
+		listFn: func(limit, offset int, completed *bool, assignee repositories.AssigneeFilter) ([]model.Task, error) {
 			return []model.Task{{ID: "a"}}, nil
 		},
-		countFilteredFn: func(completed *bool, assignee *string) (int, error) { return 1, nil },
+		countFilteredFn: func(completed *bool, assignee repositories.AssigneeFilter) (int, error) { return 1, nil },
 	}
 	svc := NewTaskService(repo)
-	items, total, err := svc.List(nil, 10, 0, nil, nil)
+	items, total, err := svc.List(nil, ListOptions{ListOptions: repositories.ListOptions{Limit: 10}, Count: repositories.CountExact})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
 	if total != 1 || len(items) != 1 {
 		t.Fatalf("expected one item and total=1")
 	}
+
+	repo.countFilteredFn = func(completed *bool, assignee repositories.AssigneeFilter) (int, error) {
+		t.Fatalf("count=none must not count")
+		return 0, nil
+	}
+	if items, total, err := svc.List(nil, ListOptions{ListOptions: repositories.ListOptions{Limit: 10}, Count: repositories.CountNone}); err != nil || total != 0 || len(items) != 1 {
+		t.Fatalf("unexpected uncounted page items=%v total=%d err=%v", items, total, err)
+	}
+}
+
+func TestTaskService_DeleteCompleted(t *testing.T) {
+	repo := &fakeRepo{
+		deleteDoneFn: func(before *time.Time) ([]string, error) { return []string{"a", "b"}, nil },
+	}
+	svc := NewTaskService(repo)
+
+	if n, err := svc.PreviewDeleteCompleted(context.Background(), nil); err != nil || n != 2 {
+		t.Fatalf("unexpected preview n=%d err=%v", n, err)
+	}
+	if n, err := svc.DeleteCompleted(context.Background(), nil); err != nil || n != 2 {
+		t.Fatalf("unexpected delete n=%d err=%v", n, err)
+	}
+}
+
+func TestTaskService_Reassign(t *testing.T) {
+	repo := &fakeRepo{
+		reassignFn: func(f repositories.TaskFilter, to string) ([]string, error) {
+			if to != "bob" {
+				t.Fatalf("expected trimmed target got %q", to)
+			}
+			return []string{"a"}, nil
+		},
+	}
+	svc := NewTaskService(repo)
+
+	alice := "alice"
+	byAlice := repositories.TaskFilter{Assignee: repositories.AssigneeIs(&alice)}
+	if ids, err := svc.Reassign(nil, byAlice, " bob "); err != nil || len(ids) != 1 {
+		t.Fatalf("unexpected result ids=%v err=%v", ids, err)
+	}
+	// a title alone is a filter too
+	if ids, err := svc.Reassign(nil, repositories.TaskFilter{Title: "docs"}, "bob"); err != nil || len(ids) != 1 {
+		t.Fatalf("unexpected result ids=%v err=%v", ids, err)
+	}
+	if _, err := svc.Reassign(nil, repositories.TaskFilter{}, "bob"); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput without filters got %v", err)
+	}
+	if _, err := svc.Reassign(nil, byAlice, "  "); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput without target got %v", err)
+	}
+}
+
+func TestTaskService_WIPLimit(t *testing.T) {
+	open := map[string]int{"alice": 2, "bob": 1}
+	repo := &fakeRepo{
+		createFn: func(task *model.Task) error { return nil },
+		countFilteredFn: func(completed *bool, assignee repositories.AssigneeFilter) (int, error) {
+			if completed == nil || *completed {
+				t.Fatalf("expected only open tasks to be counted")
+			}
+			if len(assignee.Names) == 0 {
+				return open["alice"] + open["bob"], nil
+			}
+			return open[assignee.Names[0]], nil
+		},
+		reassignFn: func(f repositories.TaskFilter, to string) ([]string, error) {
+			return []string{"a"}, nil
+		},
+	}
+	svc := NewTaskService(repo)
+	svc.(*taskService).SetWIPLimit(2)
+
+	full := &model.Task{Title: "t"}
+	full.SetAssignee("alice")
+	_, err := svc.Create(context.Background(), full)
+	var wip *WIPLimitError
+	if !errors.As(err, &wip) || wip.Open != 2 || wip.Limit != 2 || !errors.Is(err, ErrWIPLimit) {
+		t.Fatalf("expected a WIP limit error got %v", err)
+	}
+	if _, err := svc.Create(WithWIPOverride(context.Background()), full); err != nil {
+		t.Fatalf("expected the override to pass got %v", err)
+	}
+	done := &model.Task{Title: "t", Completed: true}
+	done.SetAssignee("alice")
+	if _, err := svc.Create(context.Background(), done); err != nil {
+		t.Fatalf("expected completed tasks not to count got %v", err)
+	}
+	free := &model.Task{Title: "t"}
+	free.SetAssignee("bob")
+	if _, err := svc.Create(context.Background(), free); err != nil {
+		t.Fatalf("expected bob to have room got %v", err)
+	}
+
+	alice, yes, no := "alice", true, false
+	byAlice := repositories.AssigneeIs(&alice)
+	if _, err := svc.Reassign(context.Background(), repositories.TaskFilter{Assignee: byAlice}, "bob"); !errors.Is(err, ErrWIPLimit) {
+		t.Fatalf("expected moving alice's 2 open tasks to bob to fail got %v", err)
+	}
+	if _, err := svc.Reassign(context.Background(), repositories.TaskFilter{Completed: &yes, Assignee: byAlice}, "bob"); err != nil {
+		t.Fatalf("expected completed tasks to move freely got %v", err)
+	}
+	// every open task to alice: bob's one task is added to her two
+	if _, err := svc.Reassign(context.Background(), repositories.TaskFilter{Completed: &no}, "alice"); !errors.Is(err, ErrWIPLimit) {
+		t.Fatalf("expected the limit to apply got %v", err)
+	}
+	open["alice"] = 0
+	if _, err := svc.Reassign(context.Background(), repositories.TaskFilter{Completed: &no}, "alice"); err != nil {
+		t.Fatalf("expected room for bob's task got %v", err)
+	}
+}
+
+func TestTaskService_CompleteAndReopen(t *testing.T) {
+	stored := model.Task{ID: "a", Title: "t", Completed: true}
+	stored.SetAssignee("alice")
+	writes := 0
+	repo := &fakeRepo{
+		getFn: func(id string) (*model.Task, error) {
+			task := stored
+			return &task, nil
+		},
+		countFilteredFn: func(completed *bool, assignee repositories.AssigneeFilter) (int, error) { return 2, nil },
+		setCompletedFn: func(id string, completed bool) (*model.Task, bool, error) {
+			writes++
+			task := stored
+			task.Completed = completed
+			return &task, true, nil
+		},
+	}
+	svc := NewTaskService(repo)
+
+	if got, err := svc.Complete(context.Background(), "a"); err != nil || !got.Completed {
+		t.Fatalf("unexpected complete %+v err=%v", got, err)
+	}
+	if got, err := svc.Reopen(context.Background(), "a"); err != nil || got.Completed {
+		t.Fatalf("unexpected reopen %+v err=%v", got, err)
+	}
+
+	// alice already has 2 open tasks; reopening hers would make 3
+	svc.(*taskService).SetWIPLimit(2)
+	if _, err := svc.Reopen(context.Background(), "a"); !errors.Is(err, ErrWIPLimit) {
+		t.Fatalf("expected a WIP limit error got %v", err)
+	}
+	if _, err := svc.Reopen(WithWIPOverride(context.Background()), "a"); err != nil {
+		t.Fatalf("expected the override to pass got %v", err)
+	}
+	if writes != 3 {
+		t.Fatalf("expected 3 writes got %d", writes)
+	}
+}
+
+func TestTaskService_CompleteObservesCycleTime(t *testing.T) {
+	created := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	changed := true
+	repo := &fakeRepo{
+		setCompletedFn: func(id string, completed bool) (*model.Task, bool, error) {
+			task := model.Task{ID: id, Completed: completed, CreatedAt: created}
+			task.CompletedAt.Time, task.CompletedAt.Valid = created.Add(3*time.Hour), completed
+			return &task, changed, nil
+		},
+	}
+	svc := NewTaskService(repo)
+
+	before := cycleTimeSamples(t)
+	if _, err := svc.Complete(context.Background(), "a"); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if m := cycleTimeSamples(t); m.GetSampleCount()-before.GetSampleCount() != 1 || m.GetSampleSum()-before.GetSampleSum() != 3*3600 {
+		t.Fatalf("expected one 3h observation got count=%d sum=%v", m.GetSampleCount()-before.GetSampleCount(), m.GetSampleSum()-before.GetSampleSum())
+	}
+
+	// completing an already completed task and reopening don't observe
+	changed = false
+	if _, err := svc.Complete(context.Background(), "a"); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	changed = true
+	if _, err := svc.Reopen(context.Background(), "a"); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if n := cycleTimeSamples(t).GetSampleCount() - before.GetSampleCount(); n != 1 {
+		t.Fatalf("expected no further observations got %d", n-1)
+	}
+}
+
+func cycleTimeSamples(t *testing.T) *dto.Histogram {
+	t.Helper()
+	var m dto.Metric
+	if err := metric.TaskCycleTime.Write(&m); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	return m.GetHistogram()
+}
+
+func TestTaskService_Duplicate(t *testing.T) {
+	src := model.Task{ID: "a", Title: "weekly report", Completed: true, CreatedAt: time.Now().Add(-time.Hour)}
+	src.SetAssignee("alice")
+	src.SetEstimateMinutes(60)
+	src.SetRemainingMinutes(45)
+	src.CompletedAt.Time, src.CompletedAt.Valid = time.Now(), true
+	var created *model.Task
+	repo := &fakeRepo{
+		getFn: func(id string) (*model.Task, error) {
+			if id != "a" {
+				return nil, repositories.ErrNotFound
+			}
+			task := src
+			return &task, nil
+		},
+		countFilteredFn: func(completed *bool, assignee repositories.AssigneeFilter) (int, error) { return 1, nil },
+		createFn: func(task *model.Task) error {
+			created = task
+			return nil
+		},
+	}
+	svc := NewTaskService(repo)
+
+	got, err := svc.Duplicate(context.Background(), "a")
+	if err != nil || got != created {
+		t.Fatalf("expected the created copy got %+v err=%v", got, err)
+	}
+	if got.Title != src.Title || got.Assignee != src.Assignee || got.EstimateMinutes != src.EstimateMinutes || got.RemainingMinutes != src.RemainingMinutes {
+		t.Fatalf("expected the editable fields to be copied got %+v", got)
+	}
+	if got.ID == src.ID || got.Completed || got.CompletedAt.Valid || got.CreatedAt.Equal(src.CreatedAt) {
+		t.Fatalf("expected a fresh open task got %+v", got)
+	}
+
+	if _, err := svc.Duplicate(context.Background(), "missing"); !errors.Is(err, repositories.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound got %v", err)
+	}
+	// the copy is open, so it counts against alice's WIP limit
+	svc.(*taskService).SetWIPLimit(1)
+	if _, err := svc.Duplicate(context.Background(), "a"); !errors.Is(err, ErrWIPLimit) {
+		t.Fatalf("expected a WIP limit error got %v", err)
+	}
+}
+
+func TestTaskService_MoveAfterItself(t *testing.T) {
+	repo := &fakeRepo{
+		moveFn: func(id, afterID string) (*model.Task, error) { return &model.Task{ID: id}, nil },
+	}
+	svc := NewTaskService(repo)
+	if _, err := svc.Move(context.Background(), "a", "a"); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput got %v", err)
+	}
+	if got, err := svc.Move(context.Background(), "a", "b"); err != nil || got.ID != "a" {
+		t.Fatalf("unexpected move %+v err=%v", got, err)
+	}
+}
+
+func TestTaskService_TaskKeys(t *testing.T) {
+	ctx := context.Background()
+	svc := NewTaskService(repositories.NewMemoryTaskRepository())
+	var tasks []*model.Task
+	for _, title := range []string{"first", "second"} {
+		task, err := svc.Create(ctx, &model.Task{Title: title})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		tasks = append(tasks, task)
+	}
+	first, second := tasks[0], tasks[1]
+
+	// a key and the id of the same task count once
+	got, notFound, err := svc.BatchGet(ctx, []string{second.Key(), "TM-99", first.ID, first.Key()})
+	if err != nil || len(got) != 2 || got[0].ID != second.ID || got[1].ID != first.ID {
+		t.Fatalf("expected both tasks in request order got %v err=%v", got, err)
+	}
+	if len(notFound) != 1 || notFound[0] != "TM-99" {
+		t.Fatalf("expected the unknown key reported missing got %v", notFound)
+	}
+	if got, _, err := svc.BatchGet(ctx, []string{"tm-1", first.Key()}); err != nil || len(got) != 1 || got[0].ID != first.ID {
+		t.Fatalf("expected the task once for both spellings of its key got %v err=%v", got, err)
+	}
+
+	if moved, err := svc.Move(ctx, first.ID, second.Key()); err != nil || moved.Position <= second.Position {
+		t.Fatalf("expected the task moved after the second got %+v err=%v", moved, err)
+	}
+	if _, err := svc.Move(ctx, first.ID, first.Key()); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput for a move after itself by key got %v", err)
+	}
+	if _, err := svc.Move(ctx, first.ID, "TM-99"); !errors.Is(err, repositories.ErrMoveAnchorNotFound) {
+		t.Fatalf("expected ErrMoveAnchorNotFound for an unknown key got %v", err)
+	}
+}
+
+func TestTaskService_BatchGet(t *testing.T) {
+	repo := &fakeRepo{
+		getManyFn: func(ids []string) ([]model.Task, error) {
+			if len(ids) != 3 {
+				t.Fatalf("expected trimmed, de-duplicated ids got %v", ids)
+			}
+			return []model.Task{{ID: "c"}, {ID: "a"}}, nil
+		},
+	}
+	svc := NewTaskService(repo)
+
+	tasks, notFound, err := svc.BatchGet(nil, []string{"a", " b", "c", "a", ""})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != "a" || tasks[1].ID != "c" {
+		t.Fatalf("expected tasks in request order got %v", tasks)
+	}
+	if len(notFound) != 1 || notFound[0] != "b" {
+		t.Fatalf("expected b to be reported missing got %v", notFound)
+	}
+
+	if _, _, err := svc.BatchGet(nil, []string{" "}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput without ids got %v", err)
+	}
+	tooMany := make([]string, MaxBatchGet+1)
+	for i := range tooMany {
+		tooMany[i] = strconv.Itoa(i)
+	}
+	if _, _, err := svc.BatchGet(nil, tooMany); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput above the limit got %v", err)
+	}
 }
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"taskmanager/internal/logging"
+	"taskmanager/internal/metric"
+	"taskmanager/internal/repositories"
+)
+
+// ErrWIPLimit is matched (errors.Is) by every *WIPLimitError.
+var ErrWIPLimit = errors.New("wip limit reached")
+
+// WIPLimitError reports an assignment that would give Assignee more open
+// tasks than the configured limit.
+type WIPLimitError struct {
+	Assignee string
+	Limit    int
+	// Open is the number of open tasks the assignee already has.
+	Open int
+}
+
+func (e *WIPLimitError) Error() string {
+	return fmt.Sprintf("%s already has %d open tasks (WIP limit %d)", e.Assignee, e.Open, e.Limit)
+}
+
+func (e *WIPLimitError) Is(target error) bool {
+	return target == ErrWIPLimit
+}
+
+type wipOverrideKey struct{}
+
+// WithWIPOverride returns a context whose assignments may exceed the WIP
+// limit. Handlers only set it for admin callers.
+func WithWIPOverride(ctx context.Context) context.Context {
+	return context.WithValue(ctx, wipOverrideKey{}, true)
+}
+
+// SetWIPLimit caps the open (not completed) tasks per assignee for Create and
+// Reassign; 0 disables the check. The check is advisory: concurrent
+// assignments to the same person may both pass it. It is safe to call while
+// requests are being served, e.g. on a configuration reload.
+func (s *taskService) SetWIPLimit(n int) {
+	s.wipLimit.Store(int64(n))
+}
+
+func (s *taskService) wip() int {
+	return int(s.wipLimit.Load())
+}
+
+// openTasks counts the open tasks of assignee (every open task when nil).
+func (s *taskService) openTasks(ctx context.Context, assignee *string) (int, error) {
+	open := false
+	return s.repo.CountFiltered(ctx, repositories.TaskFilter{Completed: &open, Assignee: repositories.AssigneeIs(assignee)})
+}
+
+// checkWIP fails when giving assignee `adding` more open tasks on top of
+// `current` exceeds the limit, unless ctx carries an override.
+func (s *taskService) checkWIP(ctx context.Context, assignee string, current, adding int) error {
+	limit := s.wip()
+	if adding <= 0 || current+adding <= limit {
+		return nil
+	}
+	if v, _ := ctx.Value(wipOverrideKey{}).(bool); v {
+		metric.WIPLimitViolations.WithLabelValues("overridden").Inc()
+		logging.FromContext(ctx).Warn("WIP limit overridden", "assignee", assignee, "open", current, "adding", adding, "limit", limit)
+		return nil
+	}
+	metric.WIPLimitViolations.WithLabelValues("rejected").Inc()
+	return &WIPLimitError{Assignee: assignee, Limit: limit, Open: current}
+}
+
+// checkCreateWIP applies the limit to one more open task for assignee: a new
+// task or a reopened one.
+func (s *taskService) checkCreateWIP(ctx context.Context, assignee string) error {
+	if s.wip() <= 0 || assignee == "" {
+		return nil
+	}
+	current, err := s.openTasks(ctx, &assignee)
+	if err != nil {
+		return err
+	}
+	return s.checkWIP(ctx, assignee, current, 1)
+}
+
+// checkReassignWIP applies the limit to the open tasks matching f that a
+// reassignment to `to` would move over from other assignees.
+func (s *taskService) checkReassignWIP(ctx context.Context, f repositories.TaskFilter, to string) error {
+	if s.wip() <= 0 || (f.Completed != nil && *f.Completed) {
+		return nil
+	}
+	open := false
+	f.Completed = &open
+	moved, err := s.repo.CountFiltered(ctx, f)
+	if err != nil {
+		return err
+	}
+	if f.Assignee.Matches(&to) {
+		// to's own open tasks that match don't move
+		f.Assignee = repositories.AssigneeIs(&to)
+		own, err := s.repo.CountFiltered(ctx, f)
+		if err != nil {
+			return err
+		}
+		moved -= own
+	}
+	if moved <= 0 {
+		return nil
+	}
+	current, err := s.openTasks(ctx, &to)
+	if err != nil {
+		return err
+	}
+	return s.checkWIP(ctx, to, current, moved)
+}
@@ -0,0 +1,7 @@
+-- 001_create_tasks.down.sql
+-- Reverts 001_create_tasks.up.sql.
+
+DROP TRIGGER IF EXISTS trg_tasks_set_updated_at ON tasks;
+DROP FUNCTION IF EXISTS trg_set_updated_at();
+DROP INDEX IF EXISTS idx_tasks_completed;
+DROP TABLE IF EXISTS tasks;
@@ -1,4 +1,4 @@
--- 001_create_tasks.sql
+-- 001_create_tasks.up.sql
 -- Initial migration: create tasks table and supporting objects.
 -- This migration is idempotent (uses IF NOT EXISTS) so it can be safely applied
 -- against a fresh database. It creates:
@@ -61,9 +61,3 @@ $$;
 -- SELECT
 --   id, title, description, completed, due_date, created_at, updated_at
 -- FROM tasks;
-
--- Down / cleanup (commented out — uncomment if you need to roll back manually)
--- DROP TRIGGER IF EXISTS trg_tasks_set_updated_at ON tasks;
--- DROP FUNCTION IF EXISTS trg_set_updated_at();
--- DROP INDEX IF EXISTS idx_tasks_completed;
--- DROP TABLE IF EXISTS tasks;
@@ -0,0 +1,5 @@
+-- 002_create_outbox.down.sql
+-- Reverts 002_create_outbox.up.sql.
+
+DROP INDEX IF EXISTS idx_outbox_unpublished;
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,16 @@
+-- 002_create_outbox.up.sql
+-- Transactional outbox: domain events (task.created, task.updated, task.deleted)
+-- are inserted in the same transaction as the task change and later delivered
+-- to the message broker by the outbox relay, which sets published_at.
+
+CREATE TABLE IF NOT EXISTS outbox (
+  id BIGSERIAL PRIMARY KEY,
+  event_type TEXT NOT NULL,
+  aggregate_id UUID NOT NULL,
+  payload JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  published_at TIMESTAMPTZ
+);
+
+-- Partial index so the relay's "unpublished, oldest first" scan stays cheap
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox (id) WHERE published_at IS NULL;
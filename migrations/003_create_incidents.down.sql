@@ -0,0 +1,5 @@
+-- 003_create_incidents.down.sql
+-- Reverts 003_create_incidents.up.sql.
+
+DROP INDEX IF EXISTS idx_incidents_started_at;
+DROP TABLE IF EXISTS incidents;
@@ -0,0 +1,14 @@
+-- 003_create_incidents.up.sql
+-- Incident annotations shown by GET /statusz. Rows are created and resolved
+-- through the API by operators; they are never deleted by the service.
+
+CREATE TABLE IF NOT EXISTS incidents (
+  id BIGSERIAL PRIMARY KEY,
+  title TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  severity TEXT NOT NULL DEFAULT 'minor',
+  started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_started_at ON incidents (started_at);
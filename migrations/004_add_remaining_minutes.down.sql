@@ -0,0 +1,4 @@
+-- 004_add_remaining_minutes.down.sql
+-- Reverts 004_add_remaining_minutes.up.sql.
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS remaining_minutes;
@@ -0,0 +1,5 @@
+-- 004_add_remaining_minutes.up.sql
+-- Remaining effort reported by the assignee, in minutes. NULL means no
+-- estimate has been given yet; burndown reports fall back to the task status.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS remaining_minutes INTEGER CHECK (remaining_minutes >= 0);
@@ -0,0 +1,5 @@
+-- 005_add_task_date_indexes.down.sql
+-- Reverts 005_add_task_date_indexes.up.sql.
+
+DROP INDEX IF EXISTS idx_tasks_updated_at;
+DROP INDEX IF EXISTS idx_tasks_created_at;
@@ -0,0 +1,7 @@
+-- 005_add_task_date_indexes.up.sql
+-- Indexes for the created_after/created_before/updated_after/updated_before
+-- range filters on GET /api/v1/tasks (also used by the created_at DESC
+-- default sort).
+
+CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON tasks (created_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_updated_at ON tasks (updated_at);
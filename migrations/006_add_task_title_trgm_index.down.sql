@@ -0,0 +1,5 @@
+-- 006_add_task_title_trgm_index.down.sql
+-- Reverts 006_add_task_title_trgm_index.up.sql. The pg_trgm extension is left
+-- installed since other objects may depend on it.
+
+DROP INDEX IF EXISTS idx_tasks_title_trgm;
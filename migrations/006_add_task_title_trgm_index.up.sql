@@ -0,0 +1,8 @@
+-- 006_add_task_title_trgm_index.up.sql
+-- Trigram index for the q (title substring, ILIKE '%term%') filter on
+-- GET /api/v1/tasks; a B-tree index cannot serve a leading wildcard.
+-- Creating the extension needs a role allowed to do so (superuser, or the
+-- database owner on PostgreSQL 13+ since pg_trgm is a trusted extension).
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_tasks_title_trgm ON tasks USING gin (title gin_trgm_ops);
@@ -0,0 +1,4 @@
+-- 007_add_task_completed_at.down.sql
+-- Reverts 007_add_task_completed_at.up.sql.
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS completed_at;
@@ -0,0 +1,6 @@
+-- 007_add_task_completed_at.up.sql
+-- When the task was last marked completed; NULL while it is open. Tasks that
+-- are already completed get updated_at as the best available guess.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+UPDATE tasks SET completed_at = updated_at WHERE completed AND completed_at IS NULL;
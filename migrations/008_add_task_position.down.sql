@@ -0,0 +1,5 @@
+-- 008_add_task_position.down.sql
+-- Reverts 008_add_task_position.up.sql.
+
+DROP INDEX IF EXISTS idx_tasks_position;
+ALTER TABLE tasks DROP COLUMN IF EXISTS position;
@@ -0,0 +1,15 @@
+-- 008_add_task_position.up.sql
+-- Manual ordering (POST /api/v1/tasks/{id}/move). position is a sparse
+-- float rank: new tasks go 1024 past the current maximum and a move takes the
+-- midpoint of its new neighbours. Existing tasks are ranked by creation time;
+-- the updated_at trigger is paused so the backfill doesn't look like an edit.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS position DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE tasks DISABLE TRIGGER trg_tasks_set_updated_at;
+UPDATE tasks SET position = ranked.n * 1024
+FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS n FROM tasks) AS ranked
+WHERE tasks.id = ranked.id;
+ALTER TABLE tasks ENABLE TRIGGER trg_tasks_set_updated_at;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_position ON tasks (position);
@@ -0,0 +1,11 @@
+-- 009_create_task_watchers.up.sql
+-- Watchers of a task (/api/v1/tasks/{id}/watchers): free-form names, like
+-- assignees, that downstream notification consumers fan task events out to.
+-- Rows go away with their task.
+
+CREATE TABLE IF NOT EXISTS task_watchers (
+  task_id UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+  watcher TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (task_id, watcher)
+);
@@ -0,0 +1,11 @@
+-- 010_create_views.up.sql
+-- Saved task list queries (/api/v1/views). filter holds the GET /tasks query
+-- params of the view as a JSON document.
+
+CREATE TABLE IF NOT EXISTS views (
+  id UUID PRIMARY KEY,
+  name TEXT NOT NULL,
+  filter JSONB NOT NULL DEFAULT '{}',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,5 @@
+-- 011_create_time_entries.down.sql
+-- Reverts 011_create_time_entries.up.sql.
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS time_spent_seconds;
+DROP TABLE IF EXISTS time_entries;
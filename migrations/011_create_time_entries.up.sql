@@ -0,0 +1,20 @@
+-- 011_create_time_entries.up.sql
+-- Time tracked on tasks (/api/v1/tasks/{id}/timer/*, /time-entries). A running
+-- timer is an entry without ended_at; seconds is set when it stops. assignee
+-- is the task's assignee when the entry was recorded, so reports don't move
+-- with later reassignments. tasks.time_spent_seconds is the sum of seconds
+-- over the task's entries, kept by the repository.
+
+CREATE TABLE IF NOT EXISTS time_entries (
+  id UUID PRIMARY KEY,
+  task_id UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+  assignee TEXT,
+  started_at TIMESTAMPTZ NOT NULL,
+  ended_at TIMESTAMPTZ,
+  seconds BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_time_entries_task_id ON time_entries (task_id);
+CREATE INDEX IF NOT EXISTS idx_time_entries_started_at ON time_entries (started_at);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS time_spent_seconds BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- 012_add_task_estimate_minutes.down.sql
+-- Reverts 012_add_task_estimate_minutes.up.sql.
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS estimate_minutes;
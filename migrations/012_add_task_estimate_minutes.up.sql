@@ -0,0 +1,5 @@
+-- 012_add_task_estimate_minutes.up.sql
+-- Original effort estimate in minutes, next to remaining_minutes. NULL means
+-- the task has not been estimated.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS estimate_minutes INTEGER CHECK (estimate_minutes >= 0);
@@ -0,0 +1,4 @@
+-- 013_add_outbox_aggregate_index.down.sql
+-- Reverts 013_add_outbox_aggregate_index.up.sql.
+
+DROP INDEX IF EXISTS idx_outbox_aggregate;
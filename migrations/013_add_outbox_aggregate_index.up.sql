@@ -0,0 +1,5 @@
+-- 013_add_outbox_aggregate_index.up.sql
+-- Index for the per-task activity feed (GET /api/v1/tasks/:id/activity),
+-- which reads one task's outbox events newest first.
+
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate ON outbox (aggregate_id, id);
@@ -0,0 +1,5 @@
+-- 014_add_task_number.down.sql
+-- Reverts 014_add_task_number.up.sql (the sequence is dropped with the column).
+
+DROP INDEX IF EXISTS idx_tasks_number;
+ALTER TABLE tasks DROP COLUMN IF EXISTS number;
@@ -0,0 +1,7 @@
+-- 014_add_task_number.up.sql
+-- Sequential task number behind the human-readable key (TM-<number>).
+-- Existing tasks are numbered when the column is added; new ones take the
+-- next value of the sequence, so numbers of deleted tasks are not reused.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS number BIGSERIAL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_number ON tasks (number);
@@ -0,0 +1,5 @@
+-- 015_create_jobs.down.sql
+-- Reverts 015_create_jobs.up.sql.
+
+DROP TABLE IF EXISTS dead_jobs;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,26 @@
+-- 015_create_jobs.up.sql
+-- Background job queue (internal/jobs). Workers claim due rows with FOR UPDATE
+-- SKIP LOCKED and push run_at forward as a lease while the job runs; finished
+-- jobs are deleted. Jobs that exhaust their retry policy are moved to
+-- dead_jobs with the last error for inspection.
+
+CREATE TABLE IF NOT EXISTS jobs (
+  id BIGSERIAL PRIMARY KEY,
+  kind TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  run_at TIMESTAMPTZ NOT NULL,
+  last_error TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_run_at ON jobs (run_at, id);
+
+CREATE TABLE IF NOT EXISTS dead_jobs (
+  id BIGINT PRIMARY KEY,
+  kind TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  attempts INT NOT NULL,
+  last_error TEXT,
+  created_at TIMESTAMPTZ NOT NULL,
+  failed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,6 @@
+-- 016_add_outbox_request_id.up.sql
+-- X-Request-ID of the API request that caused the event, so an entry in the
+-- audit trail can be matched to the server logs. NULL for events written
+-- outside a request.
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS request_id TEXT;
@@ -0,0 +1,4 @@
+-- 017_create_task_tombstones.down.sql
+-- Reverts 017_create_task_tombstones.up.sql.
+
+DROP TABLE IF EXISTS task_tombstones;
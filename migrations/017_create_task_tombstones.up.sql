@@ -0,0 +1,13 @@
+-- 017_create_task_tombstones.up.sql
+-- A row per deleted task, written in the same transaction as the delete, so
+-- sync clients and webhook replay can tell a deleted task from one they
+-- never saw. actor is the API key fingerprint or client certificate identity
+-- of the request, "retention" for the retention policy and NULL otherwise.
+-- Rows older than retention.purge_tombstones_after are purged.
+
+CREATE TABLE IF NOT EXISTS task_tombstones (
+  task_id UUID PRIMARY KEY,
+  deleted_at TIMESTAMPTZ NOT NULL,
+  actor TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_task_tombstones_deleted_at ON task_tombstones (deleted_at);
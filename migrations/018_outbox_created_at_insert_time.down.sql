@@ -0,0 +1,4 @@
+-- 018_outbox_created_at_insert_time.down.sql
+-- Reverts 018_outbox_created_at_insert_time.up.sql.
+
+ALTER TABLE outbox ALTER COLUMN created_at SET DEFAULT now();
@@ -0,0 +1,7 @@
+-- 018_outbox_created_at_insert_time.up.sql
+-- created_at becomes the time the event was inserted rather than the start
+-- of its transaction. The change feed holds back events younger than
+-- outbox.feed_lag, which only bounds how long an event with a smaller id can
+-- still be uncommitted when that age is measured from the insert.
+
+ALTER TABLE outbox ALTER COLUMN created_at SET DEFAULT clock_timestamp();
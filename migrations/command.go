@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CommandUsage lists the arguments Run accepts.
+const CommandUsage = "up | down [N] | goto VERSION | force VERSION | version"
+
+// ErrUsage is returned by Run for arguments it does not understand, before
+// the database is touched.
+var ErrUsage = errors.New("usage: migrate " + CommandUsage)
+
+// Run runs one migrate command on db, as given on a command line: up, down
+// [N] (1 by default), goto VERSION, force VERSION or version, which writes
+// the schema version to w.
+func Run(ctx context.Context, db *sqlx.DB, args []string, w io.Writer) error {
+	if len(args) == 0 || len(args) > 2 {
+		return ErrUsage
+	}
+	n := -1
+	if len(args) > 1 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v < 0 {
+			return ErrUsage
+		}
+		n = v
+	}
+	switch {
+	case args[0] == "up" && n < 0, args[0] == "down", args[0] == "version" && n < 0:
+	case (args[0] == "goto" || args[0] == "force") && n >= 0:
+	default:
+		return ErrUsage
+	}
+
+	m, err := New(db)
+	if err != nil {
+		return fmt.Errorf("load migrations: %w", err)
+	}
+	switch args[0] {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		if n < 0 {
+			n = 1
+		}
+		return m.Down(ctx, n)
+	case "goto":
+		return m.Goto(ctx, n)
+	case "force":
+		return m.Force(ctx, n)
+	default:
+		v, dirty, err := m.Version(ctx)
+		if err != nil {
+			return fmt.Errorf("read schema version: %w", err)
+		}
+		_, err = fmt.Fprintf(w, "version=%d dirty=%t latest=%d\n", v, dirty, m.Latest())
+		return err
+	}
+}
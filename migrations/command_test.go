@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"taskmanager/internal/database"
+)
+
+func TestRun(t *testing.T) {
+	for _, args := range [][]string{nil, {"sideways"}, {"up", "3"}, {"down", "-1"}, {"goto"}, {"force", "x"}, {"version", "1"}, {"down", "1", "2"}} {
+		// a nil db: bad arguments must be rejected before it is used
+		if err := Run(context.Background(), nil, args, nil); !errors.Is(err, ErrUsage) {
+			t.Fatalf("%q: expected ErrUsage got %v", args, err)
+		}
+	}
+
+	db, err := database.Open(database.SQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	var out bytes.Buffer
+	for _, args := range [][]string{{"up"}, {"down", "2"}, {"version"}} {
+		if err := Run(ctx, db, args, &out); err != nil {
+			t.Fatalf("%q: %v", args, err)
+		}
+	}
+	m, _ := New(db)
+	if want := fmt.Sprintf("version=%d dirty=false latest=%d\n", m.Latest()-2, m.Latest()); out.String() != want {
+		t.Fatalf("expected %q got %q", want, out.String())
+	}
+}
@@ -0,0 +1,318 @@
+// Package migrations holds the numbered schema migrations and a small runner
+// for them. Files follow the golang-migrate layout (NNN_name.up.sql /
+// NNN_name.down.sql) and the applied revision is tracked in the same
+// schema_migrations table, so either tool can take over a database.
+// PostgreSQL migrations live in this directory; sqlite/ and mysql/ hold the
+// SQLite and MySQL/MariaDB versions, which must keep the same numbering.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+)
+
+//go:embed *.sql sqlite/*.sql mysql/*.sql
+var files embed.FS
+
+// lockID is the Postgres advisory lock key held while migrating, so that
+// several replicas starting at once apply each migration only once. MySQL
+// uses the named lock lockName for the same purpose.
+const (
+	lockID   int64 = 7_262_304_517
+	lockName       = "taskmanager.migrate"
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// ErrDirty is returned when a previous migration failed half-way. Fix the
+// schema by hand, then record the right version with Force.
+var ErrDirty = errors.New("schema is dirty")
+
+// Migration is one numbered schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load returns the embedded migrations for driver ordered by version. Every
+// version must have both an up and a down file.
+func Load(driver string) ([]Migration, error) {
+	dir := "."
+	if driver == database.SQLite || driver == database.MySQL {
+		dir = driver
+	}
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file %q", e.Name())
+		}
+		v, _ := strconv.Atoi(m[1])
+		body, err := fs.ReadFile(files, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		mig, ok := byVersion[v]
+		if !ok {
+			mig = &Migration{Version: v, Name: m[2]}
+			byVersion[v] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", v, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %d (%s) needs both up and down files", mig.Version, mig.Name)
+		}
+		out = append(out, *mig)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	db         *sqlx.DB
+	dialect    database.Dialect
+	migrations []Migration
+}
+
+// New creates a Migrator for the embedded migrations of db's driver.
+func New(db *sqlx.DB) (*Migrator, error) {
+	migs, err := Load(db.DriverName())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: database.For(db), migrations: migs}, nil
+}
+
+// Latest returns the highest known migration version.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.Goto(ctx, m.Latest())
+}
+
+// Down rolls back the last steps applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive, got %d", steps)
+	}
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		current, err := m.cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		target := 0
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if m.migrations[i].Version < current {
+				steps--
+				if steps == 0 {
+					target = m.migrations[i].Version
+					break
+				}
+			}
+		}
+		return m.migrate(ctx, conn, current, target)
+	})
+}
+
+// Goto migrates up or down to version (0 rolls back everything).
+func (m *Migrator) Goto(ctx context.Context, version int) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		current, err := m.cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		return m.migrate(ctx, conn, current, version)
+	})
+}
+
+// Force records version as applied and clears the dirty flag without running
+// any SQL. Use it after repairing a failed migration by hand.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		return m.setVersion(ctx, conn, version, false)
+	})
+}
+
+// Version returns the recorded version and whether it is dirty.
+func (m *Migrator) Version(ctx context.Context) (version int, dirty bool, err error) {
+	err = m.withLock(ctx, func(conn *sqlx.Conn) error {
+		version, dirty, err = readVersion(ctx, conn)
+		return err
+	})
+	return version, dirty, err
+}
+
+// Version returns the schema revision recorded in the database.
+func Version(ctx context.Context, db *sqlx.DB) (int, error) {
+	var v int
+	if err := db.GetContext(ctx, &v, "SELECT version FROM schema_migrations LIMIT 1"); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+func (m *Migrator) index(version int) int {
+	for i, mig := range m.migrations {
+		if mig.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// withLock runs fn on a single connection holding the migration advisory
+// lock (advisory locks belong to the session, not the pool). SQLite has no
+// advisory locks; its single-writer model serializes migrations instead.
+// MySQL DDL commits implicitly, so there a failed step can leave the schema
+// partly changed; the dirty flag still records it.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	switch {
+	case m.dialect.Postgres():
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockID)
+	case m.dialect.MySQL():
+		if _, err := conn.ExecContext(ctx, "SELECT GET_LOCK(?, -1)", lockName); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT RELEASE_LOCK(?)", lockName)
+	}
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version BIGINT PRIMARY KEY,
+  dirty BOOLEAN NOT NULL
+)`); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) cleanVersion(ctx context.Context, conn *sqlx.Conn) (int, error) {
+	v, dirty, err := readVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d", ErrDirty, v)
+	}
+	return v, nil
+}
+
+// migrate walks from current to target one migration at a time. Each step
+// marks the version dirty first and runs in its own transaction, so a failure
+// leaves the schema at the last good version with the dirty flag set.
+func (m *Migrator) migrate(ctx context.Context, conn *sqlx.Conn, current, target int) error {
+	for _, mig := range m.migrations {
+		if mig.Version > current && mig.Version <= target {
+			if err := m.step(ctx, conn, mig.Version, mig.Up, mig.Version); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+			}
+		}
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if mig.Version <= current && mig.Version > target {
+			prev := 0
+			if i > 0 {
+				prev = m.migrations[i-1].Version
+			}
+			if err := m.step(ctx, conn, mig.Version, mig.Down, prev); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) step(ctx context.Context, conn *sqlx.Conn, version int, body string, result int) error {
+	if err := m.setVersion(ctx, conn, version, true); err != nil {
+		return err
+	}
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return err
+	}
+	if err := m.setVersion(ctx, tx, result, false); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func readVersion(ctx context.Context, q sqlx.QueryerContext) (int, bool, error) {
+	var row struct {
+		Version int  `db:"version"`
+		Dirty   bool `db:"dirty"`
+	}
+	err := sqlx.GetContext(ctx, q, &row, "SELECT version, dirty FROM schema_migrations LIMIT 1")
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return row.Version, row.Dirty, err
+}
+
+// setVersion replaces the single schema_migrations row; version 0 means
+// nothing is applied and leaves the table empty.
+func (m *Migrator) setVersion(ctx context.Context, exec sqlx.ExecerContext, version int, dirty bool) error {
+	if _, err := exec.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+	_, err := exec.ExecContext(ctx, m.dialect.Rebind("INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)"), version, dirty)
+	return err
+}
@@ -0,0 +1,167 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+)
+
+func TestLoad(t *testing.T) {
+	migs, err := Load(database.Postgres)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(migs) < 4 {
+		t.Fatalf("expected at least 4 migrations got %d", len(migs))
+	}
+	for i, m := range migs {
+		if m.Version != i+1 {
+			t.Fatalf("expected contiguous versions, got %d at position %d", m.Version, i)
+		}
+		if m.Up == "" || m.Down == "" {
+			t.Fatalf("migration %d is missing a direction", m.Version)
+		}
+	}
+	if migs[0].Name != "create_tasks" {
+		t.Fatalf("unexpected first migration %q", migs[0].Name)
+	}
+
+	// the SQLite and MySQL sets must mirror the PostgreSQL one
+	for _, driver := range []string{database.SQLite, database.MySQL} {
+		other, err := Load(driver)
+		if err != nil {
+			t.Fatalf("load %s: %v", driver, err)
+		}
+		if len(other) != len(migs) {
+			t.Fatalf("expected %d %s migrations got %d", len(migs), driver, len(other))
+		}
+		for i := range other {
+			if other[i].Version != migs[i].Version || other[i].Name != migs[i].Name {
+				t.Fatalf("%s migration %d_%s does not match %d_%s", driver, other[i].Version, other[i].Name, migs[i].Version, migs[i].Name)
+			}
+		}
+	}
+}
+
+func TestMigrator_SQLiteUpAndDown(t *testing.T) {
+	db, err := database.Open(database.SQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	m, err := New(db)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if v, dirty, err := m.Version(ctx); err != nil || dirty || v != m.Latest() {
+		t.Fatalf("expected version %d got %d dirty=%v err=%v", m.Latest(), v, dirty, err)
+	}
+	if _, err := db.Exec("INSERT INTO tasks (id, title, remaining_minutes) VALUES ('a', 't', 5)"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	if err := m.Goto(ctx, 0); err != nil {
+		t.Fatalf("down to 0: %v", err)
+	}
+	if v, _, _ := m.Version(ctx); v != 0 {
+		t.Fatalf("expected version 0 got %d", v)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("up again: %v", err)
+	}
+}
+
+func newMock(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock new: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	m, err := New(sqlx.NewDb(db, "sqlmock"))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	// keep the test independent of how many migrations exist
+	m.migrations = []Migration{
+		{Version: 1, Name: "one", Up: "CREATE TABLE one", Down: "DROP TABLE one"},
+		{Version: 2, Name: "two", Up: "CREATE TABLE two", Down: "DROP TABLE two"},
+		{Version: 3, Name: "three", Up: "CREATE TABLE three", Down: "DROP TABLE three"},
+	}
+	return m, mock
+}
+
+func expectLock(mock sqlmock.Sqlmock, version int64, dirty bool) {
+	mock.ExpectExec(`SELECT pg_advisory_lock`).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version", "dirty"})
+	if version > 0 {
+		rows.AddRow(version, dirty)
+	}
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").WillReturnRows(rows)
+}
+
+func expectStep(mock sqlmock.Sqlmock, version int64, body string, result int64) {
+	mock.ExpectExec("DELETE FROM schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(version, true).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec(body).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+	if result > 0 {
+		mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(result, false).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func TestMigrator_UpAppliesPendingUnderLock(t *testing.T) {
+	m, mock := newMock(t)
+	expectLock(mock, 1, false)
+	expectStep(mock, 2, "CREATE TABLE two", 2)
+	expectStep(mock, 3, "CREATE TABLE three", 3)
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestMigrator_DownRollsBackSteps(t *testing.T) {
+	m, mock := newMock(t)
+	expectLock(mock, 3, false)
+	expectStep(mock, 3, "DROP TABLE three", 2)
+	expectStep(mock, 2, "DROP TABLE two", 1)
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := m.Down(context.Background(), 2); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+func TestMigrator_RefusesDirtySchema(t *testing.T) {
+	m, mock := newMock(t)
+	expectLock(mock, 2, true)
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := m.Up(context.Background()); !errors.Is(err, ErrDirty) {
+		t.Fatalf("expected ErrDirty got %v", err)
+	}
+	if err := m.Goto(context.Background(), 7); err == nil {
+		t.Fatalf("expected error for unknown version")
+	}
+}
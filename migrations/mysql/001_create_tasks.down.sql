@@ -0,0 +1,4 @@
+-- 001_create_tasks.down.sql (MySQL/MariaDB)
+-- Reverts 001_create_tasks.up.sql.
+
+DROP TABLE IF EXISTS tasks;
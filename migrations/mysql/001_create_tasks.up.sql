@@ -0,0 +1,16 @@
+-- 001_create_tasks.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../001_create_tasks.up.sql. UUIDs are generated by the
+-- application and stored as CHAR(36); updated_at is set by the application
+-- rather than a trigger. Timestamps are DATETIME(6) in UTC.
+
+CREATE TABLE IF NOT EXISTS tasks (
+  id CHAR(36) NOT NULL PRIMARY KEY,
+  title TEXT NOT NULL,
+  description TEXT,
+  assignee VARCHAR(255),
+  completed BOOLEAN NOT NULL DEFAULT FALSE,
+  due_date DATETIME(6),
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  INDEX idx_tasks_completed (completed)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
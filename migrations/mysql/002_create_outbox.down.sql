@@ -0,0 +1,4 @@
+-- 002_create_outbox.down.sql (MySQL/MariaDB)
+-- Reverts 002_create_outbox.up.sql.
+
+DROP TABLE IF EXISTS outbox;
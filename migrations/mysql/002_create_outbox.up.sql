@@ -0,0 +1,13 @@
+-- 002_create_outbox.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../002_create_outbox.up.sql. There are no partial
+-- indexes, so the relay's scan uses (published_at, id) instead.
+
+CREATE TABLE IF NOT EXISTS outbox (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  event_type VARCHAR(64) NOT NULL,
+  aggregate_id CHAR(36) NOT NULL,
+  payload JSON NOT NULL,
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  published_at DATETIME(6),
+  INDEX idx_outbox_unpublished (published_at, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,4 @@
+-- 003_create_incidents.down.sql (MySQL/MariaDB)
+-- Reverts 003_create_incidents.up.sql.
+
+DROP TABLE IF EXISTS incidents;
@@ -0,0 +1,12 @@
+-- 003_create_incidents.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../003_create_incidents.up.sql.
+
+CREATE TABLE IF NOT EXISTS incidents (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  title TEXT NOT NULL,
+  description TEXT NOT NULL,
+  severity VARCHAR(32) NOT NULL DEFAULT 'minor',
+  started_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  resolved_at DATETIME(6),
+  INDEX idx_incidents_started_at (started_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
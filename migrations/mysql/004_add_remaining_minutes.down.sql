@@ -0,0 +1,6 @@
+-- 004_add_remaining_minutes.down.sql (MySQL/MariaDB)
+-- Reverts 004_add_remaining_minutes.up.sql.
+
+ALTER TABLE tasks
+  DROP CONSTRAINT chk_tasks_remaining_minutes,
+  DROP COLUMN remaining_minutes;
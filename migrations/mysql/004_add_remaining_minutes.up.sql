@@ -0,0 +1,7 @@
+-- 004_add_remaining_minutes.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../004_add_remaining_minutes.up.sql. The CHECK is
+-- named so the down migration can drop it (enforced from MySQL 8.0.16).
+
+ALTER TABLE tasks
+  ADD COLUMN remaining_minutes INT,
+  ADD CONSTRAINT chk_tasks_remaining_minutes CHECK (remaining_minutes >= 0);
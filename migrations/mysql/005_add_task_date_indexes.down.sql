@@ -0,0 +1,6 @@
+-- 005_add_task_date_indexes.down.sql (MySQL/MariaDB)
+-- Reverts 005_add_task_date_indexes.up.sql.
+
+ALTER TABLE tasks
+  DROP INDEX idx_tasks_updated_at,
+  DROP INDEX idx_tasks_created_at;
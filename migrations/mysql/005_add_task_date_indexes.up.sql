@@ -0,0 +1,6 @@
+-- 005_add_task_date_indexes.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../005_add_task_date_indexes.up.sql.
+
+ALTER TABLE tasks
+  ADD INDEX idx_tasks_created_at (created_at),
+  ADD INDEX idx_tasks_updated_at (updated_at);
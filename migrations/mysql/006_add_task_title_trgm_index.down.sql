@@ -0,0 +1,4 @@
+-- 006_add_task_title_trgm_index.down.sql (MySQL/MariaDB)
+-- Reverts 006_add_task_title_trgm_index.up.sql (nothing to undo).
+
+SELECT 1;
@@ -0,0 +1,5 @@
+-- 008_add_task_position.down.sql (MySQL/MariaDB)
+-- Reverts 008_add_task_position.up.sql.
+
+ALTER TABLE tasks DROP INDEX idx_tasks_position;
+ALTER TABLE tasks DROP COLUMN position;
@@ -0,0 +1,10 @@
+-- 008_add_task_position.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../008_add_task_position.up.sql.
+
+ALTER TABLE tasks ADD COLUMN position DOUBLE NOT NULL DEFAULT 0;
+
+UPDATE tasks
+JOIN (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS n FROM tasks) AS ranked ON tasks.id = ranked.id
+SET tasks.position = ranked.n * 1024;
+
+ALTER TABLE tasks ADD INDEX idx_tasks_position (position);
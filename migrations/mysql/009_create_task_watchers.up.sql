@@ -0,0 +1,10 @@
+-- 009_create_task_watchers.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../009_create_task_watchers.up.sql.
+
+CREATE TABLE IF NOT EXISTS task_watchers (
+  task_id CHAR(36) NOT NULL,
+  watcher VARCHAR(255) NOT NULL,
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  PRIMARY KEY (task_id, watcher),
+  CONSTRAINT fk_task_watchers_task FOREIGN KEY (task_id) REFERENCES tasks (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,4 @@
+-- 010_create_views.down.sql (MySQL/MariaDB)
+-- Reverts 010_create_views.up.sql.
+
+DROP TABLE IF EXISTS views;
@@ -0,0 +1,10 @@
+-- 010_create_views.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../010_create_views.up.sql.
+
+CREATE TABLE IF NOT EXISTS views (
+  id CHAR(36) NOT NULL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  filter JSON NOT NULL,
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
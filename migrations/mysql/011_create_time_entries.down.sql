@@ -0,0 +1,5 @@
+-- 011_create_time_entries.down.sql (MySQL/MariaDB)
+-- Reverts 011_create_time_entries.up.sql.
+
+ALTER TABLE tasks DROP COLUMN time_spent_seconds;
+DROP TABLE IF EXISTS time_entries;
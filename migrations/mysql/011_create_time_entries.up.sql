@@ -0,0 +1,17 @@
+-- 011_create_time_entries.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../011_create_time_entries.up.sql.
+
+CREATE TABLE IF NOT EXISTS time_entries (
+  id CHAR(36) NOT NULL PRIMARY KEY,
+  task_id CHAR(36) NOT NULL,
+  assignee VARCHAR(255) NULL,
+  started_at DATETIME(6) NOT NULL,
+  ended_at DATETIME(6) NULL,
+  seconds BIGINT NOT NULL DEFAULT 0,
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  INDEX idx_time_entries_task_id (task_id),
+  INDEX idx_time_entries_started_at (started_at),
+  CONSTRAINT fk_time_entries_task FOREIGN KEY (task_id) REFERENCES tasks (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+ALTER TABLE tasks ADD COLUMN time_spent_seconds BIGINT NOT NULL DEFAULT 0;
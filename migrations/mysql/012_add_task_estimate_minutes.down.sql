@@ -0,0 +1,6 @@
+-- 012_add_task_estimate_minutes.down.sql (MySQL/MariaDB)
+-- Reverts 012_add_task_estimate_minutes.up.sql.
+
+ALTER TABLE tasks
+  DROP CONSTRAINT chk_tasks_estimate_minutes,
+  DROP COLUMN estimate_minutes;
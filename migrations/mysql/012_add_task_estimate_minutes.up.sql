@@ -0,0 +1,7 @@
+-- 012_add_task_estimate_minutes.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../012_add_task_estimate_minutes.up.sql. The CHECK is
+-- named so the down migration can drop it.
+
+ALTER TABLE tasks
+  ADD COLUMN estimate_minutes INT,
+  ADD CONSTRAINT chk_tasks_estimate_minutes CHECK (estimate_minutes >= 0);
@@ -0,0 +1,4 @@
+-- 013_add_outbox_aggregate_index.down.sql (MySQL/MariaDB)
+-- Reverts 013_add_outbox_aggregate_index.up.sql.
+
+ALTER TABLE outbox DROP INDEX idx_outbox_aggregate;
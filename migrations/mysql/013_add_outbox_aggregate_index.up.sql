@@ -0,0 +1,4 @@
+-- 013_add_outbox_aggregate_index.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../013_add_outbox_aggregate_index.up.sql.
+
+ALTER TABLE outbox ADD INDEX idx_outbox_aggregate (aggregate_id, id);
@@ -0,0 +1,4 @@
+-- 014_add_task_number.down.sql (MySQL/MariaDB)
+-- Reverts 014_add_task_number.up.sql (the index goes with the column).
+
+ALTER TABLE tasks DROP COLUMN number;
@@ -0,0 +1,8 @@
+-- 014_add_task_number.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../014_add_task_number.up.sql. tasks has no other
+-- AUTO_INCREMENT column, so number can be one; the unique index is the key
+-- AUTO_INCREMENT requires.
+
+ALTER TABLE tasks
+  ADD COLUMN number BIGINT NOT NULL AUTO_INCREMENT,
+  ADD UNIQUE INDEX idx_tasks_number (number);
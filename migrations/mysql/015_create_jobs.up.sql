@@ -0,0 +1,23 @@
+-- 015_create_jobs.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../015_create_jobs.up.sql.
+
+CREATE TABLE IF NOT EXISTS jobs (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  kind VARCHAR(64) NOT NULL,
+  payload JSON NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  run_at DATETIME(6) NOT NULL,
+  last_error TEXT NULL,
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  INDEX idx_jobs_run_at (run_at, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS dead_jobs (
+  id BIGINT NOT NULL PRIMARY KEY,
+  kind VARCHAR(64) NOT NULL,
+  payload JSON NOT NULL,
+  attempts INT NOT NULL,
+  last_error TEXT NULL,
+  created_at DATETIME(6) NOT NULL,
+  failed_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
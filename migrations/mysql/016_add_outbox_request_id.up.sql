@@ -0,0 +1,5 @@
+-- 016_add_outbox_request_id.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../016_add_outbox_request_id.up.sql. Request ids are
+-- at most 128 characters (see logging.Middleware).
+
+ALTER TABLE outbox ADD COLUMN request_id VARCHAR(128);
@@ -0,0 +1,9 @@
+-- 017_create_task_tombstones.up.sql (MySQL/MariaDB)
+-- MySQL counterpart of ../017_create_task_tombstones.up.sql.
+
+CREATE TABLE IF NOT EXISTS task_tombstones (
+  task_id CHAR(36) NOT NULL PRIMARY KEY,
+  deleted_at DATETIME(6) NOT NULL,
+  actor VARCHAR(255) NULL,
+  INDEX idx_task_tombstones_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,4 @@
+-- 018_outbox_created_at_insert_time.down.sql (MySQL/MariaDB)
+-- Reverts 018_outbox_created_at_insert_time.up.sql (nothing to undo).
+
+SELECT 1;
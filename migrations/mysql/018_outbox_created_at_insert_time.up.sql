@@ -0,0 +1,5 @@
+-- 018_outbox_created_at_insert_time.up.sql (MySQL/MariaDB)
+-- Placeholder keeping the version in step with ../018_outbox_created_at_insert_time.up.sql:
+-- CURRENT_TIMESTAMP(6) is already the start of the inserting statement.
+
+SELECT 1;
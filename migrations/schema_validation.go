@@ -1,50 +0,0 @@
-package migrations
-
-import "github.com/jmoiron/sqlx"
-
-// ensureSchema applies minimal, idempotent DDL needed by the application.
-// For production use, prefer a real migration tool.
-func EnsureSchema(db *sqlx.DB) error {
-	schema := `
-CREATE EXTENSION IF NOT EXISTS pgcrypto;
-
-CREATE TABLE IF NOT EXISTS tasks (
-  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-  title TEXT NOT NULL,
-  description TEXT,
-  assignee TEXT,
-  completed BOOLEAN NOT NULL DEFAULT FALSE,
-  due_date TIMESTAMPTZ,
-  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
-  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
-);
-
-CREATE INDEX IF NOT EXISTS idx_tasks_completed ON tasks (completed);
-
-CREATE OR REPLACE FUNCTION trg_set_updated_at()
-RETURNS TRIGGER AS $$
-BEGIN
-  IF (TG_OP = 'UPDATE') THEN
-    NEW.updated_at = now();
-    RETURN NEW;
-  END IF;
-  RETURN NEW;
-END;
-$$ LANGUAGE plpgsql;
-
-DO $$
-BEGIN
-  IF NOT EXISTS (
-    SELECT 1 FROM pg_trigger WHERE tgname = 'trg_tasks_set_updated_at'
-  ) THEN
-    CREATE TRIGGER trg_tasks_set_updated_at
-      BEFORE UPDATE ON tasks
-      FOR EACH ROW
-      EXECUTE FUNCTION trg_set_updated_at();
-  END IF;
-END;
-$$;
-`
-	_, err := db.Exec(schema)
-	return err
-}
@@ -0,0 +1,5 @@
+-- 001_create_tasks.down.sql (SQLite)
+-- Reverts 001_create_tasks.up.sql.
+
+DROP INDEX IF EXISTS idx_tasks_completed;
+DROP TABLE IF EXISTS tasks;
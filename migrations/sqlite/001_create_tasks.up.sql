@@ -0,0 +1,16 @@
+-- 001_create_tasks.up.sql (SQLite)
+-- SQLite counterpart of ../001_create_tasks.up.sql for local development and
+-- tests. UUIDs are generated by the application and stored as text.
+
+CREATE TABLE IF NOT EXISTS tasks (
+  id TEXT PRIMARY KEY,
+  title TEXT NOT NULL,
+  description TEXT,
+  assignee TEXT,
+  completed BOOLEAN NOT NULL DEFAULT FALSE,
+  due_date TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_completed ON tasks (completed);
@@ -0,0 +1,13 @@
+-- 003_create_incidents.up.sql (SQLite)
+-- SQLite counterpart of ../003_create_incidents.up.sql.
+
+CREATE TABLE IF NOT EXISTS incidents (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  title TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  severity TEXT NOT NULL DEFAULT 'minor',
+  started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  resolved_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_started_at ON incidents (started_at);
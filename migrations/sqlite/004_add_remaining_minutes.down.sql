@@ -0,0 +1,4 @@
+-- 004_add_remaining_minutes.down.sql (SQLite)
+-- Reverts 004_add_remaining_minutes.up.sql.
+
+ALTER TABLE tasks DROP COLUMN remaining_minutes;
@@ -0,0 +1,4 @@
+-- 004_add_remaining_minutes.up.sql (SQLite)
+-- SQLite counterpart of ../004_add_remaining_minutes.up.sql.
+
+ALTER TABLE tasks ADD COLUMN remaining_minutes INTEGER CHECK (remaining_minutes >= 0);
@@ -0,0 +1,5 @@
+-- 005_add_task_date_indexes.up.sql (SQLite)
+-- SQLite counterpart of ../005_add_task_date_indexes.up.sql.
+
+CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON tasks (created_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_updated_at ON tasks (updated_at);
@@ -0,0 +1,5 @@
+-- 006_add_task_title_trgm_index.up.sql (SQLite)
+-- Placeholder keeping the version in step with ../006_add_task_title_trgm_index.up.sql:
+-- there is no trigram index here, so the q filter scans the tasks table.
+
+SELECT 1;
@@ -0,0 +1,4 @@
+-- 007_add_task_completed_at.down.sql (SQLite)
+-- Reverts 007_add_task_completed_at.up.sql.
+
+ALTER TABLE tasks DROP COLUMN completed_at;
@@ -0,0 +1,5 @@
+-- 007_add_task_completed_at.up.sql (SQLite)
+-- SQLite counterpart of ../007_add_task_completed_at.up.sql.
+
+ALTER TABLE tasks ADD COLUMN completed_at TIMESTAMP;
+UPDATE tasks SET completed_at = updated_at WHERE completed AND completed_at IS NULL;
@@ -0,0 +1,10 @@
+-- 008_add_task_position.up.sql (SQLite)
+-- SQLite counterpart of ../008_add_task_position.up.sql.
+
+ALTER TABLE tasks ADD COLUMN position REAL NOT NULL DEFAULT 0;
+
+UPDATE tasks SET position = ranked.n * 1024
+FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS n FROM tasks) AS ranked
+WHERE tasks.id = ranked.id;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_position ON tasks (position);
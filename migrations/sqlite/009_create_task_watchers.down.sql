@@ -0,0 +1,4 @@
+-- 009_create_task_watchers.down.sql (SQLite)
+-- Reverts 009_create_task_watchers.up.sql.
+
+DROP TABLE IF EXISTS task_watchers;
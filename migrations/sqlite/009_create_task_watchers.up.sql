@@ -0,0 +1,10 @@
+-- 009_create_task_watchers.up.sql (SQLite)
+-- SQLite counterpart of ../009_create_task_watchers.up.sql. Foreign keys are
+-- not enforced by default, so the repository deletes the rows with the task.
+
+CREATE TABLE IF NOT EXISTS task_watchers (
+  task_id TEXT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+  watcher TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task_id, watcher)
+);
@@ -0,0 +1,10 @@
+-- 010_create_views.up.sql (SQLite)
+-- SQLite counterpart of ../010_create_views.up.sql; filter is JSON text.
+
+CREATE TABLE IF NOT EXISTS views (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  filter TEXT NOT NULL DEFAULT '{}',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,7 @@
+-- 011_create_time_entries.down.sql (SQLite)
+-- Reverts 011_create_time_entries.up.sql.
+
+ALTER TABLE tasks DROP COLUMN time_spent_seconds;
+DROP INDEX IF EXISTS idx_time_entries_started_at;
+DROP INDEX IF EXISTS idx_time_entries_task_id;
+DROP TABLE IF EXISTS time_entries;
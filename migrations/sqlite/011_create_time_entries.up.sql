@@ -0,0 +1,17 @@
+-- 011_create_time_entries.up.sql (SQLite)
+-- SQLite counterpart of ../011_create_time_entries.up.sql. Foreign keys are
+-- not enforced by default, so the repository deletes the rows with the task.
+
+CREATE TABLE IF NOT EXISTS time_entries (
+  id TEXT PRIMARY KEY,
+  task_id TEXT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+  assignee TEXT,
+  started_at TIMESTAMP NOT NULL,
+  ended_at TIMESTAMP,
+  seconds INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_time_entries_task_id ON time_entries (task_id);
+CREATE INDEX IF NOT EXISTS idx_time_entries_started_at ON time_entries (started_at);
+
+ALTER TABLE tasks ADD COLUMN time_spent_seconds INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- 012_add_task_estimate_minutes.down.sql (SQLite)
+-- Reverts 012_add_task_estimate_minutes.up.sql.
+
+ALTER TABLE tasks DROP COLUMN estimate_minutes;
@@ -0,0 +1,4 @@
+-- 012_add_task_estimate_minutes.up.sql (SQLite)
+-- SQLite counterpart of ../012_add_task_estimate_minutes.up.sql.
+
+ALTER TABLE tasks ADD COLUMN estimate_minutes INTEGER CHECK (estimate_minutes >= 0);
@@ -0,0 +1,4 @@
+-- 013_add_outbox_aggregate_index.up.sql (SQLite)
+-- SQLite counterpart of ../013_add_outbox_aggregate_index.up.sql.
+
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate ON outbox (aggregate_id, id);
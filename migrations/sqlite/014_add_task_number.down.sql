@@ -0,0 +1,7 @@
+-- 014_add_task_number.down.sql (SQLite)
+-- Reverts 014_add_task_number.up.sql.
+
+DROP TRIGGER IF EXISTS trg_tasks_number;
+DROP TABLE IF EXISTS task_number_seq;
+DROP INDEX IF EXISTS idx_tasks_number;
+ALTER TABLE tasks DROP COLUMN number;
@@ -0,0 +1,18 @@
+-- 014_add_task_number.up.sql (SQLite)
+-- SQLite counterpart of ../014_add_task_number.up.sql. There are no
+-- sequences: task_number_seq holds the last number handed out and a trigger
+-- numbers each inserted task. Existing tasks are numbered in rowid order.
+
+ALTER TABLE tasks ADD COLUMN number INTEGER;
+UPDATE tasks SET number = rowid;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_number ON tasks (number);
+
+CREATE TABLE IF NOT EXISTS task_number_seq (last INTEGER NOT NULL);
+INSERT INTO task_number_seq (last) SELECT COALESCE(MAX(number), 0) FROM tasks;
+
+CREATE TRIGGER IF NOT EXISTS trg_tasks_number AFTER INSERT ON tasks
+WHEN NEW.number IS NULL
+BEGIN
+  UPDATE task_number_seq SET last = last + 1;
+  UPDATE tasks SET number = (SELECT last FROM task_number_seq) WHERE id = NEW.id;
+END;
@@ -0,0 +1,4 @@
+-- 016_add_outbox_request_id.down.sql (SQLite)
+-- Reverts 016_add_outbox_request_id.up.sql.
+
+ALTER TABLE outbox DROP COLUMN request_id;
@@ -0,0 +1,4 @@
+-- 016_add_outbox_request_id.up.sql (SQLite)
+-- SQLite counterpart of ../016_add_outbox_request_id.up.sql.
+
+ALTER TABLE outbox ADD COLUMN request_id TEXT;
@@ -0,0 +1,9 @@
+-- 017_create_task_tombstones.up.sql (SQLite)
+-- SQLite counterpart of ../017_create_task_tombstones.up.sql.
+
+CREATE TABLE IF NOT EXISTS task_tombstones (
+  task_id TEXT PRIMARY KEY,
+  deleted_at TIMESTAMP NOT NULL,
+  actor TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_task_tombstones_deleted_at ON task_tombstones (deleted_at);
@@ -0,0 +1,6 @@
+-- 018_outbox_created_at_insert_time.up.sql (SQLite)
+-- Placeholder keeping the version in step with ../018_outbox_created_at_insert_time.up.sql:
+-- CURRENT_TIMESTAMP is already the time of the insert, and writers are
+-- serialized, so the change feed never holds events back here.
+
+SELECT 1;
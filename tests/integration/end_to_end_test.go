@@ -1,164 +1,206 @@
 package integration
 
 import (
-	"bytes"
-	"encoding/json"
-	"net/http"
-	"net/http/httptest"
-	"sync"
+	"context"
+	"errors"
+	"slices"
 	"testing"
+	"time"
 
-	"taskmanager/internal/handler"
-	"taskmanager/internal/model"
-	"taskmanager/internal/repositories"
-	"taskmanager/internal/service"
-
-	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
+	"taskmanager/client"
+	"taskmanager/internal/database"
 )
 
-type inMemoryRepo struct {
-	mu sync.Mutex
-	m  map[string]model.Task
-}
-
-func newInMemoryRepo() *inMemoryRepo { return &inMemoryRepo{m: make(map[string]model.Task)} }
-
-func (r *inMemoryRepo) Create(task *model.Task) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if task.ID == "" {
-		task.ID = "id-" + task.Title
-	}
-	r.m[task.ID] = *task
-	return nil
-}
-func (r *inMemoryRepo) GetByID(id string) (*model.Task, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	t, ok := r.m[id]
-	if !ok {
-		return nil, repositories.ErrNotFound
-	}
-	return &t, nil
-}
-func (r *inMemoryRepo) List(limit, offset int, completed *bool, assignee *string) ([]model.Task, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	out := make([]model.Task, 0, len(r.m))
-	for _, v := range r.m {
-		out = append(out, v)
-	}
-	return out, nil
-}
-func (r *inMemoryRepo) Update(task *model.Task) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if _, ok := r.m[task.ID]; !ok {
-		return repositories.ErrNotFound
-	}
-	r.m[task.ID] = *task
-	return nil
-}
-func (r *inMemoryRepo) Delete(id string) (bool, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if _, ok := r.m[id]; !ok {
-		return false, nil
-	}
-	delete(r.m, id)
-	return true, nil
-}
-func (r *inMemoryRepo) Count() (int, error) { r.mu.Lock(); defer r.mu.Unlock(); return len(r.m), nil }
-func (r *inMemoryRepo) CountFiltered(completed *bool, assignee *string) (int, error) {
-	return r.Count()
-}
-func (r *inMemoryRepo) SetCacheClient(_ *redis.Client) {}
-
-func TestHandlers_EndToEnd(t *testing.T) {
-	gin.SetMode(gin.TestMode)
-
-	repo := newInMemoryRepo()
-	svc := service.NewTaskService(repo)
-	h := handler.NewTaskHandler(svc)
-
-	r := gin.New()
-	r.POST("/tasks", h.CreateTask)
-	r.GET("/tasks", h.ListTasks)
-	r.GET("/tasks/:id", h.GetTask)
-	r.PUT("/tasks/:id", h.UpdateTask)
-	r.DELETE("/tasks/:id", h.DeleteTask)
-
-	ts := httptest.NewServer(r)
-	defer ts.Close()
+// TestStack_TaskLifecycle drives one task through the API, from creation to
+// deletion.
+func TestStack_TaskLifecycle(t *testing.T) {
+	s := newStack(t)
+	ctx := context.Background()
 
-	// Create
-	createBody := map[string]string{"title": "task1"}
-	cb, _ := json.Marshal(createBody)
-	res, err := http.Post(ts.URL+"/tasks", "application/json", bytes.NewReader(cb))
+	created, err := s.tasks.Create(ctx, client.CreateTask{Title: "task1", Assignee: client.Ptr("alice"), EstimateMinutes: client.Ptr[int64](60)})
 	if err != nil {
-		t.Fatalf("create req err: %v", err)
+		t.Fatalf("create: %v", err)
 	}
-	if res.StatusCode != http.StatusCreated {
-		t.Fatalf("expected 201 got %d", res.StatusCode)
+	if created.Key != "TM-1" || created.Position == 0 || created.CreatedAt.IsZero() {
+		t.Fatalf("expected the database to assign key, position and timestamps, got %+v", created)
 	}
-	var created model.Task
-	_ = json.NewDecoder(res.Body).Decode(&created)
-
-	// List
-	res, err = http.Get(ts.URL + "/tasks")
-	if err != nil {
-		t.Fatalf("list req err: %v", err)
+	// the key resolves like the id
+	if got, err := s.tasks.Get(ctx, created.Key); err != nil || got.ID != created.ID || *got.EstimateMinutes != 60 {
+		t.Fatalf("get by key: %+v %v", got, err)
 	}
-	if res.StatusCode != http.StatusOK {
-		t.Fatalf("expected 200 got %d", res.StatusCode)
+
+	updated, err := s.tasks.Update(ctx, created.ID, client.UpdateTask{Title: client.Ptr("task1-upd"), RemainingMinutes: client.Ptr[int64](20)})
+	if err != nil || updated.Title != "task1-upd" || *updated.RemainingMinutes != 20 || *updated.Assignee != "alice" {
+		t.Fatalf("update: %+v %v", updated, err)
 	}
-	var listResp struct {
-		Items []model.Task `json:"items"`
+	done, err := s.tasks.Complete(ctx, created.ID)
+	if err != nil || !done.Completed || done.CompletedAt == nil {
+		t.Fatalf("complete: %+v %v", done, err)
 	}
-	_ = json.NewDecoder(res.Body).Decode(&listResp)
-	if len(listResp.Items) == 0 {
-		t.Fatalf("expected items in list")
+	reopened, err := s.tasks.Reopen(ctx, created.ID)
+	if err != nil || reopened.Completed || reopened.CompletedAt != nil {
+		t.Fatalf("reopen: %+v %v", reopened, err)
 	}
 
-	// Get
-	res, err = http.Get(ts.URL + "/tasks/" + created.ID)
-	if err != nil {
-		t.Fatalf("get req err: %v", err)
+	if err := s.tasks.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("delete: %v", err)
 	}
-	if res.StatusCode != http.StatusOK {
-		t.Fatalf("expected 200 got %d", res.StatusCode)
-	}
-
-	// Update
-	updBody := map[string]string{"title": "task1-upd"}
-	ub, _ := json.Marshal(updBody)
-	req, _ := http.NewRequest(http.MethodPut, ts.URL+"/tasks/"+created.ID, bytes.NewReader(ub))
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		t.Fatalf("update req err: %v", err)
+	if _, err := s.tasks.Get(ctx, created.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("expected 404 after delete, got %v", err)
 	}
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("expected 200 got %d", resp.StatusCode)
+	if err := s.tasks.Delete(ctx, created.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("expected 404 deleting twice, got %v", err)
 	}
+}
 
-	// Delete
-	req, _ = http.NewRequest(http.MethodDelete, ts.URL+"/tasks/"+created.ID, nil)
-	resp, err = http.DefaultClient.Do(req)
-	if err != nil {
-		t.Fatalf("delete req err: %v", err)
-	}
-	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		t.Fatalf("expected 204 or 200 got %d", resp.StatusCode)
+// TestStack_ListFilters runs combinations of the list filters against the
+// SQL the repository builds for the database.
+func TestStack_ListFilters(t *testing.T) {
+	s := newStack(t)
+	ctx := context.Background()
+	day := 24 * time.Hour
+	now := time.Now().UTC().Truncate(time.Hour)
+	for _, c := range []struct {
+		title, assignee string
+		due             time.Duration // 0: none
+		completed       bool
+	}{
+		{"write docs", "alice", 2 * day, false},
+		{"review docs", "bob", -day, false},
+		{"fix login bug", "alice", 0, true},
+		{"deploy release", "", 7 * day, false},
+		{"Clean up Docs", "", 0, true},
+		{"plan sprint", "carol", day, false},
+	} {
+		ct := client.CreateTask{Title: c.title}
+		if c.assignee != "" {
+			ct.Assignee = client.Ptr(c.assignee)
+		}
+		if c.due != 0 {
+			ct.DueDate = client.Ptr(now.Add(c.due))
+		}
+		task, err := s.tasks.Create(ctx, ct)
+		if err != nil {
+			t.Fatalf("create %q: %v", c.title, err)
+		}
+		if c.completed {
+			if _, err := s.tasks.Complete(ctx, task.ID); err != nil {
+				t.Fatalf("complete %q: %v", c.title, err)
+			}
+		}
+	}
+
+	yes, no := client.Ptr(true), client.Ptr(false)
+	for _, tc := range []struct {
+		name string
+		opts client.ListOptions
+		want []string
+	}{
+		{"completed", client.ListOptions{Completed: yes, Sort: "title asc"}, []string{"Clean up Docs", "fix login bug"}},
+		{"assignee", client.ListOptions{Assignees: []string{"alice"}, Sort: "title asc"}, []string{"fix login bug", "write docs"}},
+		{"assignees or unassigned", client.ListOptions{Assignees: []string{"bob", "none"}, Sort: "title asc"}, []string{"Clean up Docs", "deploy release", "review docs"}},
+		{"open and assignee", client.ListOptions{Completed: no, Assignees: []string{"alice", "bob"}, Sort: "title asc"}, []string{"review docs", "write docs"}},
+		{"title is case-insensitive", client.ListOptions{Query: "DOCS", Sort: "title asc"}, []string{"Clean up Docs", "review docs", "write docs"}},
+		{"title and completed and unassigned", client.ListOptions{Query: "docs", Completed: yes, Assignees: []string{"none"}}, []string{"Clean up Docs"}},
+		{"overdue", client.ListOptions{Completed: no, DueBefore: now}, []string{"review docs"}},
+		{"due this week", client.ListOptions{DueAfter: now, DueBefore: now.Add(3 * day), Sort: "due_date asc"}, []string{"plan sprint", "write docs"}},
+		{"due date order", client.ListOptions{Completed: no, Sort: "due_date desc nulls last"}, []string{"deploy release", "write docs", "plan sprint", "review docs"}},
+		{"page", client.ListOptions{Sort: "title asc", Limit: 2, Offset: 2}, []string{"fix login bug", "plan sprint"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := s.tasks.List(ctx, tc.opts)
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			var titles []string
+			for _, task := range page.Items {
+				titles = append(titles, task.Title)
+			}
+			if !slices.Equal(titles, tc.want) {
+				t.Fatalf("got %q, want %q", titles, tc.want)
+			}
+		})
+	}
+
+	page, err := s.tasks.List(ctx, client.ListOptions{Completed: no, Limit: 1})
+	if err != nil || page.Total == nil || *page.Total != 4 {
+		t.Fatalf("expected the total of the filter, not the page: %+v %v", page, err)
 	}
+}
+
+// TestStack_CacheInvalidation checks that reads are served from Redis and
+// that every kind of write through the API drops the affected entries.
+func TestStack_CacheInvalidation(t *testing.T) {
+	s := newStack(t)
+	s.requireRedis(t)
+	ctx := context.Background()
 
-	// Confirm deleted
-	res, err = http.Get(ts.URL + "/tasks/" + created.ID)
+	task, err := s.tasks.Create(ctx, client.CreateTask{Title: "cached", Assignee: client.Ptr("alice")})
 	if err != nil {
-		t.Fatalf("get after delete err: %v", err)
-	}
-	if res.StatusCode != http.StatusNotFound {
-		t.Fatalf("expected 404 got %d", res.StatusCode)
+		t.Fatalf("create: %v", err)
+	}
+	open := client.ListOptions{Completed: client.Ptr(false)}
+	if _, err := s.tasks.List(ctx, open); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if _, err := s.tasks.Get(ctx, task.ID); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(s.keys(t, "tasks:list:*")) == 0 || len(s.keys(t, "tasks:id:"+task.ID)) != 1 {
+		t.Fatalf("expected the list and the task to be cached")
+	}
+
+	// a change behind the service's back is not seen while cached...
+	if _, err := s.db.ExecContext(ctx, database.For(s.db).Rebind("UPDATE tasks SET title = $1 WHERE id = $2"), "changed in the database", task.ID); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	page, err := s.tasks.List(ctx, open)
+	if err != nil || len(page.Items) != 1 || page.Items[0].Title != "cached" {
+		t.Fatalf("expected the cached page, got %+v %v", page, err)
+	}
+	if got, err := s.tasks.Get(ctx, task.ID); err != nil || got.Title != "cached" {
+		t.Fatalf("expected the cached task, got %+v %v", got, err)
+	}
+
+	// ...until a write through the API drops it
+	if _, err := s.tasks.Update(ctx, task.ID, client.UpdateTask{RemainingMinutes: client.Ptr[int64](30)}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if keys := s.keys(t, "tasks:list:*"); len(keys) != 0 {
+		t.Fatalf("expected the update to drop the cached lists, got %v", keys)
+	}
+	// the service reads the task back after the write, caching it afresh
+	if got, err := s.tasks.Get(ctx, task.ID); err != nil || got.Title != "changed in the database" || *got.RemainingMinutes != 30 {
+		t.Fatalf("expected the stored task, got %+v %v", got, err)
+	}
+
+	for _, write := range []struct {
+		name string
+		fn   func() error
+	}{
+		{"complete", func() error { _, err := s.tasks.Complete(ctx, task.ID); return err }},
+		{"reopen", func() error { _, err := s.tasks.Reopen(ctx, task.ID); return err }},
+		{"create", func() error {
+			_, err := s.tasks.Create(ctx, client.CreateTask{Title: "another"})
+			return err
+		}},
+		{"delete", func() error { return s.tasks.Delete(ctx, task.ID) }},
+	} {
+		if _, err := s.tasks.List(ctx, open); err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		if err := write.fn(); err != nil {
+			t.Fatalf("%s: %v", write.name, err)
+		}
+		if keys := s.keys(t, "tasks:list:*"); len(keys) != 0 {
+			t.Fatalf("expected %s to drop the cached lists, got %v", write.name, keys)
+		}
+	}
+	page, err = s.tasks.List(ctx, open)
+	if err != nil || len(page.Items) != 1 || page.Items[0].Title != "another" {
+		t.Fatalf("expected only the new task, got %+v %v", page, err)
+	}
+	if _, err := s.tasks.Get(ctx, task.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("expected the deleted task not to be served from the cache, got %v", err)
 	}
 }
@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"taskmanager/internal/database"
+	"taskmanager/internal/outbox"
+)
+
+// TestStack_FeedWaitsForEarlierCommits commits two events out of id order
+// and checks that the feed never hands out the later id while the earlier
+// one is still in flight. It needs PostgreSQL: SQLite serializes writers.
+func TestStack_FeedWaitsForEarlierCommits(t *testing.T) {
+	s := newStack(t)
+	if database.For(s.db).SQLite() {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+	ctx := context.Background()
+	lag := 500 * time.Millisecond
+	feed := outbox.NewFeed(s.db, 10*time.Millisecond)
+	feed.SetLag(lag)
+
+	first, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer first.Rollback()
+	second, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer second.Rollback()
+	const a, b = "00000000-0000-0000-0000-00000000000a", "00000000-0000-0000-0000-00000000000b"
+	if err := outbox.Insert(ctx, first, outbox.EventTaskCreated, a, map[string]string{"id": a}); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := outbox.Insert(ctx, second, outbox.EventTaskCreated, b, map[string]string{"id": b}); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := second.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	// only the later id is visible: it must not be handed out yet
+	if events, err := feed.Since(ctx, 0, 10); err != nil || len(events) != 0 {
+		t.Fatalf("expected nothing while the first write is in flight got %v err=%v", events, err)
+	}
+	if seq, err := feed.SeqAt(ctx, time.Now().Add(time.Hour)); err != nil || seq != 0 {
+		t.Fatalf("expected no checkpoint past the first write got %d err=%v", seq, err)
+	}
+
+	if err := first.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	time.Sleep(lag)
+	events, err := feed.Since(ctx, 0, 10)
+	if err != nil || len(events) != 2 || events[0].AggregateID != a || events[1].AggregateID != b {
+		t.Fatalf("expected both events in id order got %v err=%v", events, err)
+	}
+}
@@ -0,0 +1,40 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"taskmanager/internal/database"
+	"taskmanager/migrations"
+)
+
+// TestMySQLBackend runs the same checks as TestSQLiteBackend against a real
+// MySQL or MariaDB server. It needs an empty, disposable database, e.g.
+//
+//	docker run -d -p 3306:3306 -e MYSQL_ROOT_PASSWORD=pass -e MYSQL_DATABASE=taskmgr mysql:8
+//	MYSQL_TEST_DSN='root:pass@tcp(localhost:3306)/taskmgr' go test ./tests/integration -run MySQL
+func TestMySQLBackend(t *testing.T) {
+	dsn := os.Getenv("MYSQL_TEST_DSN")
+	if dsn == "" {
+		t.Skip("MYSQL_TEST_DSN not set")
+	}
+	db, err := database.Open(database.MySQL, dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	m, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	reset := func() {
+		if err := m.Goto(context.Background(), 0); err != nil {
+			t.Fatalf("migrate down: %v", err)
+		}
+	}
+	reset()
+	defer reset()
+	exerciseBackend(t, db)
+}
@@ -0,0 +1,392 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"taskmanager/internal/database"
+	"taskmanager/internal/jobs"
+	"taskmanager/internal/model"
+	"taskmanager/internal/outbox"
+	"taskmanager/internal/repositories"
+	"taskmanager/internal/service"
+	"taskmanager/migrations"
+)
+
+type collectingPublisher struct{ events []outbox.Event }
+
+func (p *collectingPublisher) Publish(_ context.Context, e outbox.Event) error {
+	p.events = append(p.events, e)
+	return nil
+}
+
+// TestSQLiteBackend runs the real repositories, service and outbox relay
+// against an in-memory SQLite database migrated with the embedded files.
+func TestSQLiteBackend(t *testing.T) {
+	db, err := database.Open(database.SQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	exerciseBackend(t, db)
+}
+
+// exerciseBackend migrates an empty database and runs every repository
+// against it.
+func exerciseBackend(t *testing.T, db *sqlx.DB) {
+	t.Helper()
+	ctx := context.Background()
+	m, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repo := repositories.NewTaskRepository(db)
+	svc := service.NewTaskService(repo)
+
+	alice := "alice"
+	a := &model.Task{Title: "write docs"}
+	a.SetAssignee(alice)
+	a.SetEstimateMinutes(60)
+	a.SetRemainingMinutes(30)
+	if _, err := svc.Create(ctx, a); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	b := &model.Task{Title: "ship it"}
+	b.SetDueDate(time.Now().Add(24 * time.Hour))
+	if _, err := svc.Create(ctx, b); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if a.Key() != "TM-1" || b.Key() != "TM-2" {
+		t.Fatalf("expected sequential keys got %q %q", a.Key(), b.Key())
+	}
+	if byNumber, err := repo.GetByNumber(ctx, b.Number); err != nil || byNumber.ID != b.ID {
+		t.Fatalf("expected TM-2 to find b got %+v err=%v", byNumber, err)
+	}
+
+	got, err := svc.GetByID(ctx, a.ID)
+	if err != nil || got.Title != "write docs" || got.EstimateMinutes.Int64 != 60 || got.RemainingMinutes.Int64 != 30 || got.CreatedAt.IsZero() || got.CompletedAt.Valid {
+		t.Fatalf("unexpected task %+v err=%v", got, err)
+	}
+
+	batch, missing, err := svc.BatchGet(ctx, []string{b.ID, "missing", a.ID})
+	if err != nil || len(batch) != 2 || batch[0].ID != b.ID || batch[1].ID != a.ID || len(missing) != 1 {
+		t.Fatalf("unexpected batch get %v missing=%v err=%v", batch, missing, err)
+	}
+
+	stats, err := svc.Stats(ctx, repositories.TaskFilter{})
+	if err != nil || stats.Count != 2 || stats.EstimateMinutes != 60 || stats.RemainingMinutes != 30 || len(stats.ByAssignee) != 2 ||
+		stats.ByAssignee[0].Assignee != nil || *stats.ByAssignee[1].Assignee != alice || stats.ByAssignee[1].RemainingMinutes != 30 {
+		t.Fatalf("unexpected stats %+v err=%v", stats, err)
+	}
+
+	// list reads the first page matching f with an exact total
+	list := func(f repositories.TaskFilter, fields []string) ([]model.Task, int, error) {
+		opts := service.ListOptions{Count: repositories.CountExact}
+		opts.Filter, opts.Limit, opts.Fields = f, 10, fields
+		return svc.List(ctx, opts)
+	}
+	items, total, err := list(repositories.TaskFilter{Assignee: repositories.AssigneeIs(&alice)}, nil)
+	if err != nil || total != 1 || len(items) != 1 || items[0].ID != a.ID {
+		t.Fatalf("unexpected filtered list total=%d items=%v err=%v", total, items, err)
+	}
+
+	if _, total, err := list(repositories.TaskFilter{Assignee: repositories.AssigneeFilter{Unassigned: true}}, nil); err != nil || total != 1 {
+		t.Fatalf("expected 1 unassigned task got %d err=%v", total, err)
+	}
+	if _, total, err := list(repositories.TaskFilter{Assignee: repositories.AssigneeFilter{Names: []string{"alice", "carol"}, Unassigned: true}}, nil); err != nil || total != 2 {
+		t.Fatalf("expected alice's and unassigned tasks (2) got %d err=%v", total, err)
+	}
+
+	hourAgo := time.Now().Add(-time.Hour)
+	if _, total, err := list(repositories.TaskFilter{Dates: repositories.DateRange{CreatedAfter: &hourAgo}}, nil); err != nil || total != 2 {
+		t.Fatalf("expected 2 tasks created in the last hour got %d err=%v", total, err)
+	}
+	if _, total, err := list(repositories.TaskFilter{Dates: repositories.DateRange{UpdatedBefore: &hourAgo}}, nil); err != nil || total != 0 {
+		t.Fatalf("expected no tasks updated before an hour ago got %d err=%v", total, err)
+	}
+
+	items, total, err = list(repositories.TaskFilter{Title: "DOC"}, nil)
+	if err != nil || total != 1 || len(items) != 1 || items[0].ID != a.ID {
+		t.Fatalf("expected the docs task for q=DOC got total=%d items=%v err=%v", total, items, err)
+	}
+	fields, _ := repositories.ParseFields("title,key")
+	items, _, err = list(repositories.TaskFilter{Title: "DOC"}, fields)
+	if err != nil || len(items) != 1 || items[0].Key() != a.Key() || items[0].Title != a.Title || items[0].Assignee.Valid {
+		t.Fatalf("expected only id, key and title got %+v err=%v", items, err)
+	}
+	if _, total, err := list(repositories.TaskFilter{Title: "%"}, nil); err != nil || total != 0 {
+		t.Fatalf("expected a literal %% to match nothing got %d err=%v", total, err)
+	}
+
+	done, err := svc.Complete(ctx, b.ID)
+	if err != nil || !done.Completed || !done.CompletedAt.Valid {
+		t.Fatalf("unexpected completed task %+v err=%v", done, err)
+	}
+	if again, err := svc.Complete(ctx, b.ID); err != nil || !again.CompletedAt.Time.Equal(done.CompletedAt.Time) {
+		t.Fatalf("expected completing twice to keep completed_at got %+v err=%v", again, err)
+	}
+	if reopened, err := svc.Reopen(ctx, b.ID); err != nil || reopened.Completed || reopened.CompletedAt.Valid {
+		t.Fatalf("unexpected reopened task %+v err=%v", reopened, err)
+	}
+	if _, err := svc.Complete(ctx, "00000000-0000-0000-0000-000000000000"); !errors.Is(err, repositories.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound got %v", err)
+	}
+
+	upd := &model.Task{ID: a.ID, Title: "write better docs"}
+	upd.SetRemainingMinutes(10)
+	if updated, err := svc.Update(ctx, upd); err != nil || updated.Title != "write better docs" || updated.RemainingMinutes.Int64 != 10 || updated.CompletedAt.Valid {
+		t.Fatalf("unexpected update %+v err=%v", updated, err)
+	}
+
+	ids, err := svc.Reassign(ctx, repositories.TaskFilter{Assignee: repositories.AssigneeIs(&alice)}, "bob")
+	if err != nil || len(ids) != 1 || ids[0] != a.ID {
+		t.Fatalf("unexpected reassign ids=%v err=%v", ids, err)
+	}
+	// the title and date filters narrow a reassignment like a list
+	ids, err = svc.Reassign(ctx, repositories.TaskFilter{Title: "SHIP", Dates: repositories.DateRange{DueAfter: &a.CreatedAt}}, "carol")
+	if err != nil || len(ids) != 1 || ids[0] != b.ID {
+		t.Fatalf("unexpected reassign by title ids=%v err=%v", ids, err)
+	}
+
+	if err := svc.Delete(ctx, b.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := svc.GetByID(ctx, b.ID); !errors.Is(err, repositories.ErrNotFound) {
+		t.Fatalf("expected not found got %v", err)
+	}
+
+	changes, err := outbox.NewFeed(db, time.Millisecond).Since(ctx, 0, 100)
+	if err != nil || len(changes) != 8 || changes[0].Type != outbox.EventTaskCreated || changes[2].Type != outbox.EventTaskCompleted || changes[3].Type != outbox.EventTaskReopened {
+		t.Fatalf("expected 8 changes got %v err=%v", changes, err)
+	}
+	if rest, _ := outbox.NewFeed(db, time.Millisecond).Since(ctx, changes[4].ID, 100); len(rest) != 3 {
+		t.Fatalf("expected 3 changes after the fifth got %v", rest)
+	}
+	activity, err := outbox.NewFeed(db, time.Millisecond).Activity(ctx, a.ID, 0, 100)
+	if err != nil || len(activity) < 2 || activity[0].Type != outbox.EventTaskReassigned || activity[len(activity)-1].Type != outbox.EventTaskCreated {
+		t.Fatalf("expected a's activity newest first got %v err=%v", activity, err)
+	}
+	if older, _ := outbox.NewFeed(db, time.Millisecond).Activity(ctx, "", changes[1].ID, 100); len(older) != 1 || older[0].ID != changes[0].ID {
+		t.Fatalf("expected one event before the second got %v", older)
+	}
+
+	pub := &collectingPublisher{}
+	n, err := outbox.NewRelay(db, pub, time.Second).RelayOnce(ctx)
+	if err != nil || n != 8 {
+		t.Fatalf("expected 8 relayed events got %d err=%v", n, err)
+	}
+	if n, _ := outbox.NewRelay(db, pub, time.Second).RelayOnce(ctx); n != 0 {
+		t.Fatalf("expected events to be marked published, relayed %d again", n)
+	}
+
+	// halving the gap after a over and over has to rebalance the positions
+	x, y := &model.Task{Title: "x"}, &model.Task{Title: "y"}
+	for _, task := range []*model.Task{x, y} {
+		if _, err := svc.Create(ctx, task); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	// b, the latest task, is gone but its number is not handed out again
+	if x.Key() != "TM-3" || y.Key() != "TM-4" {
+		t.Fatalf("expected keys after TM-2 got %q %q", x.Key(), y.Key())
+	}
+	pos := func(id string) float64 {
+		task, err := svc.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		return task.Position
+	}
+	if pa, px, py := pos(a.ID), pos(x.ID), pos(y.ID); !(pa < px && px < py) {
+		t.Fatalf("expected new tasks at the end got a=%v x=%v y=%v", pa, px, py)
+	}
+	if moved, err := svc.Move(ctx, y.ID, ""); err != nil || moved.Position >= pos(a.ID) {
+		t.Fatalf("expected y to move first got %+v err=%v", moved, err)
+	}
+	for i := 0; i < 60; i++ {
+		first, second := y, x
+		if i%2 == 1 {
+			first, second = x, y
+		}
+		if _, err := svc.Move(ctx, first.ID, a.ID); err != nil {
+			t.Fatalf("move %d: %v", i, err)
+		}
+		if pa, pf, ps := pos(a.ID), pos(first.ID), pos(second.ID); !(pa < pf && pf < ps) {
+			t.Fatalf("move %d: expected a < %s < %s got %v %v %v", i, first.Title, second.Title, pa, pf, ps)
+		}
+	}
+	if _, err := svc.Move(ctx, x.ID, "00000000-0000-0000-0000-000000000000"); !errors.Is(err, repositories.ErrMoveAnchorNotFound) {
+		t.Fatalf("expected ErrMoveAnchorNotFound got %v", err)
+	}
+
+	watchers := repositories.NewWatcherRepository(db)
+	if _, added, err := watchers.Add(ctx, x.ID, "carol"); err != nil || !added {
+		t.Fatalf("add watcher added=%v err=%v", added, err)
+	}
+	if w, added, err := watchers.Add(ctx, x.ID, "carol"); err != nil || added || w.Watcher != "carol" || w.CreatedAt.IsZero() {
+		t.Fatalf("expected the existing watcher got %+v added=%v err=%v", w, added, err)
+	}
+	if _, _, err := watchers.Add(ctx, x.ID, "alice"); err != nil {
+		t.Fatalf("add watcher: %v", err)
+	}
+	if list, err := watchers.List(ctx, x.ID); err != nil || len(list) != 2 || list[0].Watcher != "alice" {
+		t.Fatalf("unexpected watchers %v err=%v", list, err)
+	}
+	if removed, err := watchers.Remove(ctx, x.ID, "alice"); err != nil || !removed {
+		t.Fatalf("remove watcher removed=%v err=%v", removed, err)
+	}
+	if _, _, err := watchers.Add(ctx, b.ID, "carol"); !errors.Is(err, repositories.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a deleted task got %v", err)
+	}
+	if err := svc.Delete(ctx, x.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	var left int
+	if err := db.Get(&left, "SELECT count(1) FROM task_watchers"); err != nil || left != 0 {
+		t.Fatalf("expected the watchers to go with the task got %d err=%v", left, err)
+	}
+
+	timeEntries := repositories.NewTimeEntryRepository(db, repo)
+	if _, err := timeEntries.StartTimer(ctx, y.ID); err != nil {
+		t.Fatalf("start timer: %v", err)
+	}
+	if _, err := timeEntries.StartTimer(ctx, y.ID); !errors.Is(err, repositories.ErrTimerRunning) {
+		t.Fatalf("expected ErrTimerRunning got %v", err)
+	}
+	if e, err := timeEntries.StopTimer(ctx, y.ID); err != nil || e.EndedAt == nil {
+		t.Fatalf("stop timer %+v err=%v", e, err)
+	}
+	logged := time.Now().Add(-2 * time.Hour).UTC()
+	if _, err := timeEntries.AddEntry(ctx, y.ID, logged, logged.Add(time.Hour)); err != nil {
+		t.Fatalf("add time entry: %v", err)
+	}
+	if got, err := svc.GetByID(ctx, y.ID); err != nil || got.TimeSpentSeconds < 3600 {
+		t.Fatalf("expected the logged hour on the task got %+v err=%v", got, err)
+	}
+	if list, err := timeEntries.List(ctx, y.ID); err != nil || len(list) != 2 || list[1].Seconds != 3600 {
+		t.Fatalf("unexpected time entries %+v err=%v", list, err)
+	}
+	from, to := logged.Add(-time.Minute), logged.Add(time.Minute)
+	if report, err := timeEntries.Report(ctx, &from, &to); err != nil || len(report) != 1 || report[0].Seconds != 3600 || report[0].Entries != 1 {
+		t.Fatalf("unexpected time report %+v err=%v", report, err)
+	}
+	if err := svc.Delete(ctx, y.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := db.Get(&left, "SELECT count(1) FROM time_entries"); err != nil || left != 0 {
+		t.Fatalf("expected the time entries to go with the task got %d err=%v", left, err)
+	}
+
+	z := &model.Task{Title: "z"}
+	if _, err := svc.Create(ctx, z); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := svc.Complete(ctx, z.ID); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if _, _, err := watchers.Add(ctx, z.ID, "carol"); err != nil {
+		t.Fatalf("add watcher: %v", err)
+	}
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	if n, err := svc.PreviewDeleteCompleted(ctx, &past); err != nil || n != 0 {
+		t.Fatalf("expected nothing completed an hour ago got %d err=%v", n, err)
+	}
+	if n, err := svc.PreviewDeleteCompleted(ctx, &future); err != nil || n != 1 {
+		t.Fatalf("expected one completed task got %d err=%v", n, err)
+	}
+	if n, err := svc.DeleteCompleted(ctx, &future); err != nil || n != 1 {
+		t.Fatalf("expected one deleted task got %d err=%v", n, err)
+	}
+	if _, err := svc.GetByID(ctx, z.ID); !errors.Is(err, repositories.ErrNotFound) {
+		t.Fatalf("expected not found got %v", err)
+	}
+	if err := db.Get(&left, "SELECT count(1) FROM task_watchers"); err != nil || left != 0 {
+		t.Fatalf("expected the watchers to go with the task got %d err=%v", left, err)
+	}
+	if activity, err := outbox.NewFeed(db, time.Millisecond).Activity(ctx, z.ID, 0, 1); err != nil || len(activity) != 1 || activity[0].Type != outbox.EventTaskDeleted {
+		t.Fatalf("expected a task.deleted event got %v err=%v", activity, err)
+	}
+	tombstones := repositories.NewTombstoneRepository(db)
+	if got, err := tombstones.GetMany(ctx, []string{b.ID, z.ID, a.ID}); err != nil || len(got) != 2 || got[z.ID].DeletedAt.IsZero() {
+		t.Fatalf("expected tombstones for the deleted tasks got %v err=%v", got, err)
+	}
+	if n, err := tombstones.Purge(ctx, time.Now().Add(time.Minute)); err != nil || n < 2 {
+		t.Fatalf("expected the tombstones to be purged got %d err=%v", n, err)
+	}
+
+	views := repositories.NewViewRepository(db)
+	open := false
+	dueBefore := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	view := &model.View{Name: "open before 2030", Filter: model.ViewFilter{Completed: &open, Assignee: []string{"none"}, DueBefore: &dueBefore, Sort: "due_date asc"}}
+	if err := views.Create(ctx, view); err != nil {
+		t.Fatalf("create view: %v", err)
+	}
+	if got, err := views.Get(ctx, view.ID); err != nil || got.Name != view.Name || got.Filter.DueBefore == nil || !got.Filter.DueBefore.Equal(dueBefore) || got.Filter.Sort != "due_date asc" {
+		t.Fatalf("unexpected view %+v err=%v", got, err)
+	}
+	if list, err := views.List(ctx); err != nil || len(list) != 1 {
+		t.Fatalf("list views %v err=%v", list, err)
+	}
+	if err := views.Delete(ctx, view.ID); err != nil {
+		t.Fatalf("delete view: %v", err)
+	}
+	if _, err := views.Get(ctx, view.ID); !errors.Is(err, repositories.ErrViewNotFound) {
+		t.Fatalf("expected ErrViewNotFound got %v", err)
+	}
+
+	incidents := repositories.NewIncidentRepository(db)
+	inc := &model.Incident{Title: "redis outage"}
+	if err := incidents.Create(ctx, inc); err != nil || inc.ID == 0 {
+		t.Fatalf("create incident id=%d err=%v", inc.ID, err)
+	}
+	if resolved, err := incidents.Resolve(ctx, inc.ID, time.Now().UTC()); err != nil || resolved.ResolvedAt == nil {
+		t.Fatalf("resolve: %+v err=%v", resolved, err)
+	}
+	if list, err := incidents.ListSince(ctx, time.Now().Add(-time.Hour)); err != nil || len(list) != 1 {
+		t.Fatalf("list incidents %v err=%v", list, err)
+	}
+
+	worker := jobs.NewWorker(db, 1, time.Second)
+	var handled []string
+	worker.Handle("test.ok", jobs.RetryPolicy{}, func(_ context.Context, payload json.RawMessage) error {
+		handled = append(handled, string(payload))
+		return nil
+	})
+	worker.Handle("test.fail", jobs.RetryPolicy{MaxAttempts: 1}, func(context.Context, json.RawMessage) error {
+		return errors.New("always fails")
+	})
+	if err := jobs.Enqueue(ctx, db, "test.ok", map[string]int{"n": 1}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := jobs.Enqueue(ctx, db, "test.fail", nil); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := jobs.EnqueueAt(ctx, db, "test.ok", nil, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if ran, err := worker.RunOnce(ctx); err != nil || !ran {
+			t.Fatalf("run %d: ran=%v err=%v", i, ran, err)
+		}
+	}
+	if ran, err := worker.RunOnce(ctx); err != nil || ran {
+		t.Fatalf("expected the delayed job to wait, ran=%v err=%v", ran, err)
+	}
+	if len(handled) != 1 || handled[0] != `{"n":1}` {
+		t.Fatalf("unexpected handled payloads %v", handled)
+	}
+	queued, dead, err := jobs.Counts(ctx, db)
+	if err != nil || queued["test.ok"] != 1 || queued["test.fail"] != 0 || dead["test.fail"] != 1 {
+		t.Fatalf("unexpected counts queued=%v dead=%v err=%v", queued, dead, err)
+	}
+}